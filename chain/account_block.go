@@ -6,6 +6,7 @@ import (
 	"github.com/vitelabs/go-vite/chain/file_manager"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
+	"math/big"
 )
 
 func (c *chain) IsGenesisAccountBlock(hash types.Hash) bool {
@@ -13,6 +14,16 @@ func (c *chain) IsGenesisAccountBlock(hash types.Hash) bool {
 	return ok
 }
 
+// GetGenesisBalanceMap returns the balances of addr set by its genesis account block
+func (c *chain) GetGenesisBalanceMap(addr types.Address) map[types.TokenTypeId]*big.Int {
+	for _, vmBlock := range c.genesisAccountBlocks {
+		if vmBlock.AccountBlock.AccountAddress == addr {
+			return vmBlock.VmDb.GetUnsavedBalanceMap()
+		}
+	}
+	return nil
+}
+
 func (c *chain) IsAccountBlockExisted(hash types.Hash) (bool, error) {
 	// cache
 	if ok := c.cache.IsAccountBlockExisted(hash); ok {
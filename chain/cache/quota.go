@@ -19,6 +19,13 @@ func (cache *Cache) GetGlobalQuota() types.QuotaInfo {
 	return cache.quotaList.GetGlobalQuota()
 }
 
+func (cache *Cache) GetGlobalQuotaList(count int) []types.QuotaInfo {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	return cache.quotaList.GetGlobalQuotaList(count)
+}
+
 func (cache *Cache) ResetUnconfirmedQuotas(unconfirmedBlocks []*ledger.AccountBlock) {
 	cache.mu.Lock()
 	defer cache.mu.Unlock()
@@ -72,6 +72,39 @@ func (ql *quotaList) GetGlobalQuota() types.QuotaInfo {
 	return globalQuota
 }
 
+// GetGlobalQuotaList returns the global quota as of each of the latest count snapshot blocks, the earliest first.
+// It is limited by the snapshot blocks kept in the list.
+func (ql *quotaList) GetGlobalQuotaList(count int) []types.QuotaInfo {
+	// the back element holds the quota of unconfirmed blocks
+	snapshotQuotas := make([]types.QuotaInfo, 0, ql.list.Len())
+	for pointer := ql.list.Front(); pointer != nil && pointer != ql.list.Back(); pointer = pointer.Next() {
+		blockCount, quotaTotal, quotaUsedTotal := ql.aggregate(pointer.Value.(map[types.Address]*quotaInfo))
+		snapshotQuotas = append(snapshotQuotas, types.QuotaInfo{BlockCount: blockCount, QuotaTotal: quotaTotal, QuotaUsedTotal: quotaUsedTotal})
+	}
+
+	window := ql.usedAccumulateHeight - 1
+	if max := len(snapshotQuotas) - window + 1; count > max {
+		count = max
+	}
+	if count > len(snapshotQuotas) {
+		count = len(snapshotQuotas)
+	}
+	if count <= 0 {
+		return []types.QuotaInfo{}
+	}
+
+	globalQuotaList := make([]types.QuotaInfo, count)
+	for i := range globalQuotaList {
+		end := len(snapshotQuotas) - count + i
+		for j := end; j >= 0 && j > end-window; j-- {
+			globalQuotaList[i].BlockCount += snapshotQuotas[j].BlockCount
+			globalQuotaList[i].QuotaTotal += snapshotQuotas[j].QuotaTotal
+			globalQuotaList[i].QuotaUsedTotal += snapshotQuotas[j].QuotaUsedTotal
+		}
+	}
+	return globalQuotaList
+}
+
 func (ql *quotaList) GetQuotaUsedList(addr types.Address) []types.QuotaInfo {
 	usedList := make([]types.QuotaInfo, 0, ql.usedAccumulateHeight)
 
@@ -0,0 +1,53 @@
+package chain_cache
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestQuotaList_GetGlobalQuotaList(t *testing.T) {
+	ql := newQuotaList(nil)
+	// the global quota is accumulated over 2 snapshot blocks
+	ql.usedAccumulateHeight = 3
+	ql.moveNext(make(map[types.Address]*quotaInfo))
+	ql.status = initialized
+
+	addr1, addr2 := types.Address{1}, types.Address{2}
+	for _, used := range []uint64{10, 20, 30, 40} {
+		blocks := []*ledger.AccountBlock{
+			{AccountAddress: addr1, Quota: used, QuotaUsed: used},
+			{AccountAddress: addr2, Quota: 1, QuotaUsed: 1},
+		}
+		for _, block := range blocks {
+			ql.Add(block.AccountAddress, block.Quota, block.QuotaUsed)
+		}
+		ql.NewNext(blocks)
+	}
+	// unconfirmed blocks aren't in the list
+	ql.Add(addr1, 100, 100)
+
+	check := func(count int, expected ...uint64) {
+		list := ql.GetGlobalQuotaList(count)
+		if len(list) != len(expected) {
+			t.Fatalf("length of %v not match, %+v", count, list)
+		}
+		for i, quota := range list {
+			if quota.QuotaUsedTotal != expected[i] || quota.BlockCount != 4 {
+				t.Fatalf("quota %v of %v not match, %+v", i, count, list)
+			}
+		}
+	}
+	check(0)
+	check(-1)
+	check(1, 72)
+	check(2, 52, 72)
+	// the earliest snapshot block has no full window
+	check(10, 32, 52, 72)
+
+	globalQuota := ql.GetGlobalQuota()
+	if globalQuota.QuotaUsedTotal != 72 || globalQuota.BlockCount != 4 {
+		t.Fatalf("global quota not match the latest of the list, %+v", globalQuota)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/vitelabs/go-vite/common/fork"
 
 	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/chain/proof"
 
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
@@ -74,8 +75,11 @@ type chain struct {
 
 	plugins *chain_plugins.Plugins
 
+	stateProof *chain_proof.StateProof
+
 	status uint32
 
+	forks                *fork.Schedule
 	forkActiveCheckPoint fork.ForkPointItem
 	forkActiveCache      fork.ForkPointList
 }
@@ -88,10 +92,6 @@ func NewChain(dir string, chainCfg *config.Chain, genesisCfg *config.Genesis) *c
 		chainCfg = defaultConfig()
 	}
 
-	if !fork.IsInitForkPoint() {
-		fork.SetForkPoints(genesisCfg.ForkPoints)
-	}
-
 	c := &chain{
 		genesisCfg: genesisCfg,
 		dataDir:    dir,
@@ -101,10 +101,12 @@ func NewChain(dir string, chainCfg *config.Chain, genesisCfg *config.Genesis) *c
 
 		emitter:  emitter.New(10),
 		chainCfg: chainCfg,
+
+		forks: fork.NewSchedule(genesisCfg.ForkPoints),
 	}
 
 	// set leaf fork point
-	forkActiveCheckPoint := fork.GetLeafForkPoint()
+	forkActiveCheckPoint := c.forks.GetLeafForkPoint()
 	if forkActiveCheckPoint == nil {
 		panic("LeafFork is not existed")
 	}
@@ -112,15 +114,13 @@ func NewChain(dir string, chainCfg *config.Chain, genesisCfg *config.Genesis) *c
 	c.forkActiveCheckPoint = *forkActiveCheckPoint
 
 	// set active fork
-	if !fork.IsInitActiveChecker() {
-		fork.SetActiveChecker(c)
-	}
+	c.forks.SetActiveChecker(c)
 
 	c.em = newEventManager(c)
 	c.emitter.Use("*", emitter.Sync)
 
-	c.genesisAccountBlocks = chain_genesis.NewGenesisAccountBlocks(genesisCfg)
-	c.genesisSnapshotBlock = chain_genesis.NewGenesisSnapshotBlock(c.genesisAccountBlocks)
+	c.genesisAccountBlocks = chain_genesis.NewGenesisAccountBlocks(c.forks, genesisCfg)
+	c.genesisSnapshotBlock = chain_genesis.NewGenesisSnapshotBlock(c.forks, c.genesisAccountBlocks)
 
 	c.genesisAccountBlockHash = chain_genesis.VmBlocksToHashMap(c.genesisAccountBlocks)
 
@@ -169,6 +169,13 @@ func (c *chain) Init() error {
 		return err
 	}
 
+	// init state commitment
+	if c.chainCfg.StateProof {
+		if err := c.initStateProof(); err != nil {
+			return err
+		}
+	}
+
 	// reconstruct the plugins
 	/*	if c.chainCfg.OpenPlugins {
 			c.plugins.BuildPluginsDb(c.flusher)
@@ -236,12 +243,40 @@ func (c *chain) Destroy() error {
 	}
 	c.log.Info("Close syncCache", "method", "Close")
 
+	if err := c.metaDB.Close(); err != nil {
+		cErr := errors.New(fmt.Sprintf("c.metaDB.Close failed, error is %s", err))
+		c.log.Error(cErr.Error(), "method", "Close")
+		return cErr
+	}
+	c.log.Info("Close metaDB", "method", "Close")
+
+	if c.plugins != nil {
+		if err := c.plugins.Close(); err != nil {
+			cErr := errors.New(fmt.Sprintf("c.plugins.Close failed, error is %s", err))
+			c.log.Error(cErr.Error(), "method", "Close")
+			return cErr
+		}
+		c.log.Info("Close plugins", "method", "Close")
+	}
+
+	if c.stateProof != nil {
+		if err := c.stateProof.Close(); err != nil {
+			cErr := errors.New(fmt.Sprintf("c.stateProof.Close failed, error is %s", err))
+			c.log.Error(cErr.Error(), "method", "Close")
+			return cErr
+		}
+		c.log.Info("Close stateProof", "method", "Close")
+	}
+
 	c.flusher = nil
 	c.cache = nil
 	c.stateDB = nil
 	c.indexDB = nil
 	c.blockDB = nil
 	c.syncCache = nil
+	c.metaDB = nil
+	c.plugins = nil
+	c.stateProof = nil
 
 	c.log.Info("Complete destruction", "method", "Close")
 
@@ -252,6 +287,27 @@ func (c *chain) Plugins() *chain_plugins.Plugins {
 	return c.plugins
 }
 
+// StateProof returns the state commitment index, nil if it isn't open
+func (c *chain) StateProof() *chain_proof.StateProof {
+	return c.stateProof
+}
+
+func (c *chain) initStateProof() error {
+	var err error
+	if c.stateProof, err = chain_proof.NewStateProof(c.chainDir, c, c.stateDB); err != nil {
+		cErr := errors.New(fmt.Sprintf("chain_proof.NewStateProof failed. Error: %s", err))
+		c.log.Error(cErr.Error(), "method", "initStateProof")
+		return cErr
+	}
+	if err := c.stateProof.Init(); err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateProof.Init failed. Error: %s", err))
+		c.log.Error(cErr.Error(), "method", "initStateProof")
+		return cErr
+	}
+	c.Register(c.stateProof)
+	return nil
+}
+
 func (c *chain) NewDb(dirName string) (*leveldb.DB, error) {
 	absoluteDirName := path.Join(c.chainDir, dirName)
 	db, err := leveldb.OpenFile(absoluteDirName, nil)
@@ -282,7 +338,7 @@ func (c *chain) newDbAndRecover() error {
 	}
 
 	// new ledger db
-	if c.indexDB, err = chain_index.NewIndexDB(c.chainDir, c); err != nil {
+	if c.indexDB, err = chain_index.NewIndexDB(c.chainDir, c.chainCfg.Backend, c); err != nil {
 		c.log.Error(fmt.Sprintf("chain_index.NewIndexDB failed, error is %s, chainDir is %s", err, c.chainDir), "method", "newDbAndRecover")
 		return err
 	}
@@ -304,7 +360,7 @@ func (c *chain) newDbAndRecover() error {
 	// init plugins
 	if c.chainCfg.OpenPlugins {
 		var err error
-		if c.plugins, err = chain_plugins.NewPlugins(c.chainDir, c); err != nil {
+		if c.plugins, err = chain_plugins.NewPlugins(c.chainDir, c.chainCfg, c); err != nil {
 			cErr := errors.New(fmt.Sprintf("chain_plugins.NewPlugins failed. Error: %s", err))
 			c.log.Error(cErr.Error(), "method", "newDbAndRecover")
 			return cErr
@@ -369,7 +425,7 @@ func (c *chain) checkAndInitData() (byte, error) {
 }
 
 func (c *chain) checkForkPointsAndRollback() error {
-	forkPointList := fork.GetActiveForkPointList()
+	forkPointList := c.forks.GetActiveForkPointList()
 
 	// check
 	var rollbackForkPoint *fork.ForkPointItem
@@ -384,7 +440,7 @@ func (c *chain) checkForkPointsAndRollback() error {
 			continue
 		}
 
-		if sb.ComputeHash() == sb.Hash {
+		if sb.ComputeHash(c.forks) == sb.Hash {
 			break
 		}
 		rollbackForkPoint = forkPoint
@@ -479,6 +535,17 @@ func (c *chain) closeAndCleanData() error {
 		}
 	}
 
+	// close state commitment
+	if c.stateProof != nil {
+		if err = c.stateProof.Close(); err != nil {
+			cErr := errors.New(fmt.Sprintf("c.stateProof.Close failed. Error: %s", err))
+
+			c.log.Error(cErr.Error(), "method", "closeAndCleanData")
+			return err
+		}
+		c.stateProof = nil
+	}
+
 	// clean all data
 	if err = c.cleanAllData(); err != nil {
 		cErr := errors.New(fmt.Sprintf("c.cleanAllData failed. Error: %s", err))
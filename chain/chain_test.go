@@ -2,7 +2,6 @@ package chain
 
 import (
 	"encoding/json"
-	"github.com/vitelabs/go-vite/common/fork"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
@@ -164,6 +163,14 @@ var GenesisJson = `{
 }
 `
 
+// testForkPoints are the fork points of the chains created by NewChainInstance
+var testForkPoints = &config.ForkPoints{
+	SeedFork: &config.ForkPoint{
+		Version: 1,
+		Height:  10000000,
+	},
+}
+
 func NewChainInstance(dirName string, clear bool) (*chain, error) {
 	var dataDir string
 
@@ -179,6 +186,7 @@ func NewChainInstance(dirName string, clear bool) (*chain, error) {
 	genesisConfig := &config.Genesis{}
 
 	json.Unmarshal([]byte(GenesisJson), genesisConfig)
+	genesisConfig.ForkPoints = testForkPoints
 
 	chainInstance := NewChain(dataDir, &config.Chain{}, genesisConfig)
 
@@ -197,17 +205,6 @@ func Clear(c *chain) error {
 }
 
 func SetUp(accountNum, txCount, snapshotPerBlockNum int) (*chain, map[types.Address]*Account, []*ledger.SnapshotBlock) {
-	// set fork point
-
-	if len(fork.GetActiveForkPointList()) <= 0 {
-		fork.SetForkPoints(&config.ForkPoints{
-			SeedFork: &config.ForkPoint{
-				Version: 1,
-				Height:  10000000,
-			},
-		})
-	}
-
 	// test quota
 	quota.InitQuotaConfig(true, true)
 
@@ -556,12 +553,14 @@ func TestChainForkRollBack(t *testing.T) {
 
 	// height
 	height := uint64(30)
-	fork.SetForkPoints(&config.ForkPoints{
+	defaultForkPoints := testForkPoints
+	testForkPoints = &config.ForkPoints{
 		SeedFork: &config.ForkPoint{
 			Height:  height,
 			Version: 1,
 		},
-	})
+	}
+	defer func() { testForkPoints = defaultForkPoints }()
 
 	c, accountMap, _ = SetUp(10, 0, 0)
 
@@ -612,7 +611,7 @@ func TestChainForkRollBack(t *testing.T) {
 		Timestamp:       &timeNow,
 		SnapshotContent: createSnaoshotContent(),
 	}
-	sb.Hash = sb.ComputeHash()
+	sb.Hash = sb.ComputeHash(c.forks)
 	delaccountBlockList, err := c.InsertSnapshotBlock(sb)
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,103 @@
+package chain_db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/memdb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/opt"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/interfaces"
+)
+
+const (
+	// BackendLevelDB is the vendored leveldb fork, it is the default backend.
+	BackendLevelDB = "leveldb"
+	// BackendTiered is the experimental size-tiered LSM engine in common/db/tiered, its compaction doesn't stall
+	// writes. It is only built into gvite with the tiered build tag.
+	BackendTiered = "tiered"
+)
+
+// Backend is the disk key-value engine of a Store. Reads are overlaid with the mem db of the Store,
+// the entries of the mem db newer than seq are ignored.
+type Backend interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	Get2(key []byte, ro *opt.ReadOptions, mdb *memdb.DB, seq uint64) ([]byte, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) interfaces.StorageIterator
+	NewIterator2(slice *util.Range, ro *opt.ReadOptions, mdb *memdb.DB, seq uint64) interfaces.StorageIterator
+	// Write applies the batch atomically
+	Write(batch *leveldb.Batch, wo *opt.WriteOptions) error
+	CompactRange(r util.Range) error
+	// Status returns the engine statistics in json
+	Status() string
+	Close() error
+}
+
+// DetectBackend returns the backend of an existing db dir, or an empty string if the dir has no db.
+func DetectBackend(dir string) string {
+	if _, err := os.Stat(path.Join(dir, "CURRENT")); err == nil {
+		return BackendLevelDB
+	}
+	if _, err := os.Stat(path.Join(dir, tieredManifestFileName)); err == nil {
+		return BackendTiered
+	}
+	return ""
+}
+
+// OpenBackend opens the db dir with the backend, an existing db of another backend must be migrated first.
+func OpenBackend(dir string, backend string) (Backend, error) {
+	if backend == "" {
+		backend = BackendLevelDB
+	}
+	if existing := DetectBackend(dir); existing != "" && existing != backend {
+		return nil, fmt.Errorf("%s is a %s db but the backend is %s, run `gvite db migrate --backend %s` first",
+			dir, existing, backend, backend)
+	}
+
+	switch backend {
+	case BackendLevelDB:
+		db, err := leveldb.OpenFile(dir, nil)
+		if err != nil {
+			return nil, err
+		}
+		return NewLevelDBBackend(db), nil
+	case BackendTiered:
+		return openTieredBackend(dir)
+	default:
+		return nil, fmt.Errorf("unknown db backend %s", backend)
+	}
+}
+
+type levelDBBackend struct {
+	*leveldb.DB
+}
+
+// NewLevelDBBackend wraps an opened leveldb.
+func NewLevelDBBackend(db *leveldb.DB) Backend {
+	return &levelDBBackend{db}
+}
+
+func (b *levelDBBackend) NewIterator(slice *util.Range, ro *opt.ReadOptions) interfaces.StorageIterator {
+	return b.DB.NewIterator(slice, ro)
+}
+
+func (b *levelDBBackend) NewIterator2(slice *util.Range, ro *opt.ReadOptions, mdb *memdb.DB, seq uint64) interfaces.StorageIterator {
+	return b.DB.NewIterator2(slice, ro, mdb, seq)
+}
+
+func (b *levelDBBackend) Status() string {
+	s := &leveldb.DBStats{}
+	b.DB.Stats(s)
+	return marshalStatus(s)
+}
+
+func marshalStatus(s interface{}) string {
+	status, err := json.Marshal(s)
+	if err != nil {
+		return "Error:" + err.Error()
+	}
+	return string(status)
+}
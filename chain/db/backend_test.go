@@ -0,0 +1,158 @@
+package chain_db
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+)
+
+func testStoreFlush(t *testing.T, backend string) {
+	dir, err := ioutil.TempDir("", "store_backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewStoreWithBackend(path.Join(dir, "store"), "store", backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Backend() != backend {
+		t.Fatal("unexpected backend", store.Backend())
+	}
+	batch := store.NewBatch()
+	batch.Put([]byte("key1"), []byte("value1"))
+	batch.Put([]byte("key2"), []byte("value2"))
+	store.WriteDirectly(batch)
+
+	store.Prepare()
+	redoLog, _ := store.RedoLog()
+	if err := store.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	store.AfterCommit()
+
+	// the mem db overlays the disk
+	batch = store.NewBatch()
+	batch.Delete([]byte("key1"))
+	batch.Put([]byte("key3"), []byte("value3"))
+	store.WriteDirectly(batch)
+
+	if value, _ := store.Get([]byte("key1")); value != nil {
+		t.Fatal("key1 is deleted in mem db")
+	}
+	iter := store.NewIterator(&util.Range{Start: []byte("key"), Limit: []byte("kez")})
+	var keys []string
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	iter.Release()
+	if fmt.Sprint(keys) != "[key2 key3]" {
+		t.Fatal("unexpected keys", keys)
+	}
+
+	// patching the redo log again is harmless
+	if err := store.PatchRedoLog(redoLog); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewStoreWithBackend(path.Join(dir, "store"), "store", backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if value, _ := store.Get([]byte("key1")); string(value) != "value1" {
+		t.Fatal("committed key should survive reopen", string(value))
+	}
+	if ok, _ := store.Has([]byte("key3")); ok {
+		t.Fatal("uncommitted key should be lost")
+	}
+	if store.GetStatus()[1].Status == "" {
+		t.Fatal("backend status should not be empty")
+	}
+}
+
+func TestStore_Backends(t *testing.T) {
+	backends := []string{BackendLevelDB}
+	if tieredEnabled {
+		backends = append(backends, BackendTiered)
+	}
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			testStoreFlush(t, backend)
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	if !tieredEnabled {
+		t.Skip("the tiered backend is built with -tags tiered")
+	}
+	dir, err := ioutil.TempDir("", "store_migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dbDir := path.Join(dir, "index")
+
+	src, err := OpenBackend(dbDir, BackendLevelDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := new(leveldb.Batch)
+	for i := 0; i < 1000; i++ {
+		batch.Put([]byte(fmt.Sprintf("key%04d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	src.Write(batch, nil)
+	src.Close()
+
+	if _, err := OpenBackend(dbDir, BackendTiered); err == nil {
+		t.Fatal("opening a leveldb dir as tiered should fail")
+	}
+
+	keys, err := Migrate(dbDir, BackendTiered, nil)
+	if err != nil || keys != 1000 {
+		t.Fatal("migrate failed", keys, err)
+	}
+	if DetectBackend(dbDir) != BackendTiered || exists(dbDir+".old") || exists(dbDir+".migrating") {
+		t.Fatal("unexpected dirs after migration")
+	}
+	// nothing to do the second time
+	if keys, err := Migrate(dbDir, BackendTiered, nil); err != nil || keys != 0 {
+		t.Fatal("migrate again", keys, err)
+	}
+
+	dst, err := OpenBackend(dbDir, BackendTiered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	for i := 0; i < 1000; i++ {
+		value, err := dst.Get([]byte(fmt.Sprintf("key%04d", i)), nil)
+		if err != nil || string(value) != fmt.Sprintf("value%d", i) {
+			t.Fatal("unexpected value", i, string(value), err)
+		}
+	}
+}
+
+func TestOpenBackend_TieredNotBuilt(t *testing.T) {
+	if tieredEnabled {
+		t.Skip("the tiered backend is built in")
+	}
+	dir, err := ioutil.TempDir("", "store_backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := OpenBackend(path.Join(dir, "index"), BackendTiered); err == nil {
+		t.Fatal("the tiered backend should not open without the build tag")
+	}
+}
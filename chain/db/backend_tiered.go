@@ -0,0 +1,32 @@
+// +build tiered
+
+package chain_db
+
+import (
+	"github.com/vitelabs/go-vite/common/db/tiered"
+)
+
+const (
+	tieredEnabled          = true
+	tieredManifestFileName = tiered.ManifestFileName
+)
+
+func openTieredBackend(dir string) (Backend, error) {
+	db, err := tiered.Open(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &tieredBackend{db}, nil
+}
+
+type tieredBackend struct {
+	*tiered.DB
+}
+
+func (b *tieredBackend) Status() string {
+	s := &tiered.Stats{}
+	if err := b.DB.Stats(s); err != nil {
+		return "Error:" + err.Error()
+	}
+	return marshalStatus(s)
+}
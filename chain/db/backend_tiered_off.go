@@ -0,0 +1,17 @@
+// +build !tiered
+
+package chain_db
+
+import (
+	"fmt"
+)
+
+const (
+	tieredEnabled = false
+	// the same as tiered.ManifestFileName, a tiered db dir is detected without building the engine in
+	tieredManifestFileName = "TIERED_MANIFEST"
+)
+
+func openTieredBackend(dir string) (Backend, error) {
+	return nil, fmt.Errorf("the %s backend of %s is experimental, it needs gvite built with `-tags tiered`", BackendTiered, dir)
+}
@@ -0,0 +1,101 @@
+package chain_db
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+)
+
+const migrateBatchSize = 4 * 1024 * 1024
+
+func exists(dir string) bool {
+	_, err := os.Stat(dir)
+	return err == nil
+}
+
+// Migrate copies the db in dir to the backend, it returns the number of keys copied.
+// The db is written to dir.migrating first and then swapped with dir, an interrupted migration
+// is cleaned up by the next call.
+func Migrate(dir string, backend string, progress func(keys uint64)) (uint64, error) {
+	tmpDir, oldDir := dir+".migrating", dir+".old"
+
+	// recover from an interrupted swap
+	if !exists(dir) && exists(oldDir) {
+		if err := os.Rename(oldDir, dir); err != nil {
+			return 0, err
+		}
+	}
+	if exists(oldDir) {
+		if err := os.RemoveAll(oldDir); err != nil {
+			return 0, err
+		}
+	}
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return 0, err
+	}
+
+	from := DetectBackend(dir)
+	if from == "" || from == backend {
+		return 0, nil
+	}
+
+	src, err := OpenBackend(dir, from)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := OpenBackend(tmpDir, backend)
+	if err != nil {
+		src.Close()
+		return 0, err
+	}
+
+	keys, err := copyBackend(src, dst, progress)
+	if cErr := dst.Close(); err == nil {
+		err = cErr
+	}
+	src.Close()
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return 0, fmt.Errorf("migrate %s failed, %v", dir, err)
+	}
+
+	if err := os.Rename(dir, oldDir); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return 0, err
+	}
+	return keys, os.RemoveAll(oldDir)
+}
+
+func copyBackend(src, dst Backend, progress func(keys uint64)) (uint64, error) {
+	iter := src.NewIterator(nil, nil)
+	defer iter.Release()
+
+	keys := uint64(0)
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Put(iter.Key(), iter.Value())
+		keys++
+		if batch.Size() >= migrateBatchSize {
+			if err := dst.Write(batch, nil); err != nil {
+				return 0, err
+			}
+			batch.Reset()
+			if progress != nil {
+				progress(keys)
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+	if err := dst.Write(batch, nil); err != nil {
+		return 0, err
+	}
+	if progress != nil {
+		progress(keys)
+	}
+	return keys, nil
+}
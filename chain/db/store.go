@@ -3,7 +3,6 @@ package chain_db
 import (
 	"errors"
 
-	"encoding/json"
 	"github.com/vitelabs/go-vite/common/db"
 	"github.com/vitelabs/go-vite/common/db/xleveldb"
 	"github.com/vitelabs/go-vite/common/db/xleveldb/memdb"
@@ -27,24 +26,32 @@ type Store struct {
 
 	unconfirmedBatchs *UnconfirmedBatchs
 
-	dbDir string
-	db    *leveldb.DB
+	dbDir   string
+	backend string
+	db      Backend
 
 	afterRecoverFuncs []func()
 }
 
 func NewStore(dataDir string, name string) (*Store, error) {
-	diskStore, err := leveldb.OpenFile(dataDir, nil)
+	return NewStoreWithBackend(dataDir, name, BackendLevelDB)
+}
+
+func NewStoreWithBackend(dataDir string, name string, backend string) (*Store, error) {
+	if backend == "" {
+		backend = BackendLevelDB
+	}
+	diskStore, err := OpenBackend(dataDir, backend)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return NewStoreWithDb(dataDir, name, diskStore)
-
+	return NewStoreWithDb(dataDir, name, backend, diskStore)
 }
 
-func NewStoreWithDb(dataDir string, name string, diskStore *leveldb.DB) (*Store, error) {
+// NewStoreWithDb returns a store over an opened disk engine, backend is the name of the engine.
+func NewStoreWithDb(dataDir string, name string, backend string, diskStore Backend) (*Store, error) {
 	id, _ := types.BytesToHash(crypto.Hash256([]byte(name)))
 
 	store := &Store{
@@ -54,8 +61,9 @@ func NewStoreWithDb(dataDir string, name string, diskStore *leveldb.DB) (*Store,
 
 		unconfirmedBatchs: NewUnconfirmedBatchs(),
 
-		dbDir: dataDir,
-		db:    diskStore,
+		dbDir:   dataDir,
+		backend: backend,
+		db:      diskStore,
 	}
 
 	store.snapshotBatch = store.getNewBatch()
@@ -63,6 +71,11 @@ func NewStoreWithDb(dataDir string, name string, diskStore *leveldb.DB) (*Store,
 	return store, nil
 }
 
+// Backend returns the name of the disk engine.
+func (store *Store) Backend() string {
+	return store.backend
+}
+
 func (store *Store) CompactRange(r util.Range) error {
 	return store.db.CompactRange(r)
 }
@@ -155,27 +168,26 @@ func (store *Store) GetStatus() []interfaces.DBStatus {
 		size += store.snapshotBatch.Size()
 	}
 
-	s := &leveldb.DBStats{}
-	store.db.Stats(s)
-
-	status, err := json.Marshal(s)
-	if err != nil {
-		status = []byte("Error:" + err.Error())
-	}
-
 	return []interfaces.DBStatus{{
 		Name:   "mem",
 		Count:  uint64(count),
 		Size:   uint64(size),
 		Status: "",
 	}, {
-		Name:   "levelDB",
+		Name:   store.statusName(),
 		Count:  0,
 		Size:   0,
-		Status: string(status),
+		Status: store.db.Status(),
 	}}
 }
 
+func (store *Store) statusName() string {
+	if store.backend == BackendLevelDB {
+		return "levelDB"
+	}
+	return store.backend
+}
+
 func (store *Store) getSnapshotMemDb() (*memdb.DB, uint64) {
 	store.memDbMu.RLock()
 	mdb := store.memDb.GetDb()
@@ -62,6 +62,21 @@ type Flusher struct {
 
 	terminal      chan struct{}
 	flusherStatus int32
+
+	statusMu      sync.Mutex
+	lastFlushTime time.Time
+	lastErr       error
+	lastErrTime   time.Time
+	errCount      uint64
+}
+
+// Status is the result of the recent flushes
+type Status struct {
+	Aborted       bool
+	LastFlushTime time.Time
+	LastError     error
+	LastErrorTime time.Time
+	ErrorCount    uint64
 }
 
 func NewFlusher(storeList []Storage, flushMu *sync.RWMutex, chainDir string) (*Flusher, error) {
@@ -148,6 +163,36 @@ func (flusher *Flusher) Stop() {
 	flusher.wg.Wait()
 }
 
+// Status returns the time of the last successful flush and the last failure
+func (flusher *Flusher) Status() Status {
+	flusher.statusMu.Lock()
+	defer flusher.statusMu.Unlock()
+
+	return Status{
+		Aborted:       atomic.LoadInt32(&flusher.flusherStatus) == aborted,
+		LastFlushTime: flusher.lastFlushTime,
+		LastError:     flusher.lastErr,
+		LastErrorTime: flusher.lastErrTime,
+		ErrorCount:    flusher.errCount,
+	}
+}
+
+func (flusher *Flusher) setError(err error) {
+	flusher.statusMu.Lock()
+	defer flusher.statusMu.Unlock()
+
+	flusher.lastErr = err
+	flusher.lastErrTime = time.Now()
+	flusher.errCount++
+}
+
+func (flusher *Flusher) setFlushed() {
+	flusher.statusMu.Lock()
+	defer flusher.statusMu.Unlock()
+
+	flusher.lastFlushTime = time.Now()
+}
+
 // force to flush synchronously
 func (flusher *Flusher) Flush() {
 	flusher.flush()
@@ -209,6 +254,7 @@ func (flusher *Flusher) flush() {
 	//flusher.log.Info("start prepare")
 	if err := flusher.prepare(); err != nil {
 		flusher.log.Warn(fmt.Sprintf("flusher.prepare failed, error is %s", err), "method", "flush")
+		flusher.setError(err)
 		return
 	}
 	//flusher.log.Info("prepare finish")
@@ -216,6 +262,7 @@ func (flusher *Flusher) flush() {
 	// write redo log
 	//flusher.log.Info("start write redo log")
 	if err := flusher.writeRedoLog(); err != nil {
+		flusher.setError(err)
 		return
 	}
 	//flusher.log.Info("finish writing redo log")
@@ -243,6 +290,7 @@ func (flusher *Flusher) flush() {
 
 	// redo
 	if err != nil {
+		flusher.setError(err)
 		//flusher.log.Info("commit redo")
 		if err := flusher.commitRedo(); err != nil {
 			panic(err)
@@ -252,6 +300,10 @@ func (flusher *Flusher) flush() {
 
 	// clean redo log
 	flusher.cleanRedoLog()
+
+	if err == nil {
+		flusher.setFlushed()
+	}
 }
 
 func (flusher *Flusher) commitRedo() error {
@@ -436,6 +488,7 @@ func (flusher *Flusher) syncRedoLog() bool {
 	}
 
 	flusher.log.Error(fmt.Sprintf("sync failed. Error: %s", err.Error()), "method", "Flush")
+	flusher.setError(err)
 
 	// cancel prepare, lock write
 	flusher.mu.Lock()
@@ -10,6 +10,11 @@ import (
 	"sort"
 )
 
+// ForkSchedule returns the fork points of the chain.
+func (c *chain) ForkSchedule() *fork.Schedule {
+	return c.forks
+}
+
 func (c *chain) IsForkActive(point fork.ForkPointItem) bool {
 	if point.Height <= c.forkActiveCheckPoint.Height {
 		// For backward compatibility, auto active old fork point
@@ -26,7 +31,7 @@ func (c *chain) IsForkActive(point fork.ForkPointItem) bool {
 func (c *chain) initActiveFork() error {
 	c.forkActiveCache = make(fork.ForkPointList, 0)
 
-	forkPointList := fork.GetForkPointList()
+	forkPointList := c.forks.GetForkPointList()
 
 	latestSnapshotBlock := c.GetLatestSnapshotBlock()
 
@@ -46,7 +51,7 @@ func (c *chain) initActiveFork() error {
 }
 
 func (c *chain) addActiveForkPoint(snapshotBlock *ledger.SnapshotBlock) {
-	point := fork.GetForkPoint(snapshotBlock.Height + 1)
+	point := c.forks.GetForkPoint(snapshotBlock.Height + 1)
 	if point == nil {
 		return
 	}
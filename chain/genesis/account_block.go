@@ -7,19 +7,20 @@ import (
 	"math/big"
 	"sort"
 
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm_db"
 )
 
-func NewGenesisAccountBlocks(cfg *config.Genesis) []*vm_db.VmAccountBlock {
+func NewGenesisAccountBlocks(forks *fork.Schedule, cfg *config.Genesis) []*vm_db.VmAccountBlock {
 	list := make([]*vm_db.VmAccountBlock, 0)
 	addrSet := make(map[types.Address]interface{})
-	list, addrSet = newGenesisGovernanceContractBlocks(cfg, list, addrSet)
-	list, addrSet = newGenesisAssetContractBlocks(cfg, list, addrSet)
-	list, addrSet = newGenesisQuotaContractBlocks(cfg, list, addrSet)
-	list = newGenesisNormalAccountBlocks(cfg, list, addrSet)
+	list, addrSet = newGenesisGovernanceContractBlocks(forks, cfg, list, addrSet)
+	list, addrSet = newGenesisAssetContractBlocks(forks, cfg, list, addrSet)
+	list, addrSet = newGenesisQuotaContractBlocks(forks, cfg, list, addrSet)
+	list = newGenesisNormalAccountBlocks(forks, cfg, list, addrSet)
 	return list
 }
 
@@ -34,7 +35,7 @@ func updateAccountBalanceMap(cfg *config.Genesis, addr types.Address, vmdb vm_db
 	}
 }
 
-func newGenesisGovernanceContractBlocks(cfg *config.Genesis, list []*vm_db.VmAccountBlock, addrSet map[types.Address]interface{}) ([]*vm_db.VmAccountBlock, map[types.Address]interface{}) {
+func newGenesisGovernanceContractBlocks(forks *fork.Schedule, cfg *config.Genesis, list []*vm_db.VmAccountBlock, addrSet map[types.Address]interface{}) ([]*vm_db.VmAccountBlock, map[types.Address]interface{}) {
 	if cfg.GovernanceInfo != nil {
 		contractAddr := types.AddressGovernance
 		block := ledger.AccountBlock{
@@ -44,7 +45,7 @@ func newGenesisGovernanceContractBlocks(cfg *config.Genesis, list []*vm_db.VmAcc
 			Amount:         big.NewInt(0),
 			Fee:            big.NewInt(0),
 		}
-		vmdb := vm_db.NewGenesisVmDB(&contractAddr)
+		vmdb := vm_db.NewGenesisVmDB(forks, &contractAddr)
 		for gidStr, groupInfo := range cfg.GovernanceInfo.ConsensusGroupInfoMap {
 			gid, err := types.HexToGid(gidStr)
 			dealWithError(err)
@@ -149,7 +150,7 @@ func (a byTokenId) Less(i, j int) bool {
 	return a[i].tokenId.Hex() > a[j].tokenId.Hex()
 }
 
-func newGenesisAssetContractBlocks(cfg *config.Genesis, list []*vm_db.VmAccountBlock, addrSet map[types.Address]interface{}) ([]*vm_db.VmAccountBlock, map[types.Address]interface{}) {
+func newGenesisAssetContractBlocks(forks *fork.Schedule, cfg *config.Genesis, list []*vm_db.VmAccountBlock, addrSet map[types.Address]interface{}) ([]*vm_db.VmAccountBlock, map[types.Address]interface{}) {
 	if cfg.AssetInfo != nil {
 		nextIndexMap := make(map[string]uint16)
 		contractAddr := types.AddressAsset
@@ -160,7 +161,7 @@ func newGenesisAssetContractBlocks(cfg *config.Genesis, list []*vm_db.VmAccountB
 			Amount:         big.NewInt(0),
 			Fee:            big.NewInt(0),
 		}
-		vmdb := vm_db.NewGenesisVmDB(&contractAddr)
+		vmdb := vm_db.NewGenesisVmDB(forks, &contractAddr)
 		tokenList := make([]*tokenInfoForSort, 0, len(cfg.AssetInfo.TokenInfoMap))
 		for tokenIdStr, tokenInfo := range cfg.AssetInfo.TokenInfoMap {
 			tokenId, err := types.HexToTokenTypeId(tokenIdStr)
@@ -208,7 +209,7 @@ func newGenesisAssetContractBlocks(cfg *config.Genesis, list []*vm_db.VmAccountB
 	return list, addrSet
 }
 
-func newGenesisQuotaContractBlocks(cfg *config.Genesis, list []*vm_db.VmAccountBlock, addrSet map[types.Address]interface{}) ([]*vm_db.VmAccountBlock, map[types.Address]interface{}) {
+func newGenesisQuotaContractBlocks(forks *fork.Schedule, cfg *config.Genesis, list []*vm_db.VmAccountBlock, addrSet map[types.Address]interface{}) ([]*vm_db.VmAccountBlock, map[types.Address]interface{}) {
 	if cfg.QuotaInfo != nil {
 		contractAddr := types.AddressQuota
 		block := ledger.AccountBlock{
@@ -218,7 +219,7 @@ func newGenesisQuotaContractBlocks(cfg *config.Genesis, list []*vm_db.VmAccountB
 			Amount:         big.NewInt(0),
 			Fee:            big.NewInt(0),
 		}
-		vmdb := vm_db.NewGenesisVmDB(&contractAddr)
+		vmdb := vm_db.NewGenesisVmDB(forks, &contractAddr)
 		for stakeAddrStr, stakeInfoList := range cfg.QuotaInfo.StakeInfoMap {
 			stakeAddr, err := types.HexToAddress(stakeAddrStr)
 			dealWithError(err)
@@ -250,7 +251,7 @@ func newGenesisQuotaContractBlocks(cfg *config.Genesis, list []*vm_db.VmAccountB
 	return list, addrSet
 }
 
-func newGenesisNormalAccountBlocks(cfg *config.Genesis, list []*vm_db.VmAccountBlock, addrSet map[types.Address]interface{}) []*vm_db.VmAccountBlock {
+func newGenesisNormalAccountBlocks(forks *fork.Schedule, cfg *config.Genesis, list []*vm_db.VmAccountBlock, addrSet map[types.Address]interface{}) []*vm_db.VmAccountBlock {
 	for addrStr, balanceMap := range cfg.AccountBalanceMap {
 		addr, err := types.HexToAddress(addrStr)
 		dealWithError(err)
@@ -264,7 +265,7 @@ func newGenesisNormalAccountBlocks(cfg *config.Genesis, list []*vm_db.VmAccountB
 			Amount:         big.NewInt(0),
 			Fee:            big.NewInt(0),
 		}
-		vmdb := vm_db.NewGenesisVmDB(&addr)
+		vmdb := vm_db.NewGenesisVmDB(forks, &addr)
 		for tokenIdStr, balance := range balanceMap {
 			tokenId, err := types.HexToTokenTypeId(tokenIdStr)
 			dealWithError(err)
@@ -1,6 +1,7 @@
 package chain_genesis
 
 import (
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm_db"
 	"time"
@@ -21,7 +22,7 @@ func newGenesisSnapshotContent(accountBlocks []*vm_db.VmAccountBlock) ledger.Sna
 	return sc
 }
 
-func NewGenesisSnapshotBlock(accountBlocks []*vm_db.VmAccountBlock) *ledger.SnapshotBlock {
+func NewGenesisSnapshotBlock(forks *fork.Schedule, accountBlocks []*vm_db.VmAccountBlock) *ledger.SnapshotBlock {
 	// 2019/05/21 12:00:00 UTC/GMT +8
 	genesisTimestamp := time.Unix(1558411200, 0)
 
@@ -31,7 +32,7 @@ func NewGenesisSnapshotBlock(accountBlocks []*vm_db.VmAccountBlock) *ledger.Snap
 		SnapshotContent: newGenesisSnapshotContent(accountBlocks),
 	}
 
-	genesisSnapshotBlock.Hash = genesisSnapshotBlock.ComputeHash()
+	genesisSnapshotBlock.Hash = genesisSnapshotBlock.ComputeHash(forks)
 
 	return genesisSnapshotBlock
 }
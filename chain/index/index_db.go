@@ -30,9 +30,9 @@ type IndexDB struct {
 	chain Chain
 }
 
-func NewIndexDB(chainDir string, chain Chain) (*IndexDB, error) {
+func NewIndexDB(chainDir string, backend string, chain Chain) (*IndexDB, error) {
 
-	store, err := chain_db.NewStore(path.Join(chainDir, "index"), "indexDb")
+	store, err := chain_db.NewStoreWithBackend(path.Join(chainDir, "index"), "indexDb", backend)
 	if err != nil {
 		return nil, err
 	}
@@ -514,7 +514,7 @@ func createSnapshotBlock(chainInstance *chain, option createSbOption) *ledger.Sn
 		SnapshotContent: createSnapshotContent(chainInstance, option.SnapshotAll),
 		Seed:            option.Seed,
 	}
-	sb.Hash = sb.ComputeHash()
+	sb.Hash = sb.ComputeHash(chainInstance.forks)
 	return sb
 
 }
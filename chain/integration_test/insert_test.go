@@ -322,7 +322,7 @@ func createSnapshotBlock(chainInstance chain.Chain, snapshotAll bool) *ledger.Sn
 		Timestamp:       &sbNow,
 		SnapshotContent: createSnapshotContent(chainInstance),
 	}
-	sb.Hash = sb.ComputeHash()
+	sb.Hash = sb.ComputeHash(chainInstance.ForkSchedule())
 	return sb
 
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/vitelabs/go-vite/chain/flusher"
 	"github.com/vitelabs/go-vite/chain/index"
 	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/chain/proof"
 	"github.com/vitelabs/go-vite/chain/state"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/consensus/core"
@@ -231,6 +232,9 @@ type Chain interface {
 
 	GetGlobalQuota() types.QuotaInfo
 
+	// GetGlobalQuotaList returns the global quota as of each of the latest count snapshot blocks, the earliest first
+	GetGlobalQuotaList(count int) []types.QuotaInfo
+
 	GetQuotaUsedList(address types.Address) []types.QuotaInfo
 
 	GetStorageIterator(address types.Address, prefix []byte) (interfaces.StorageIterator, error)
@@ -300,6 +304,8 @@ type Chain interface {
 
 	Plugins() *chain_plugins.Plugins
 
+	StateProof() *chain_proof.StateProof
+
 	SetConsensus(cs Consensus)
 
 	DBs() (*chain_index.IndexDB, *chain_block.BlockDB, *chain_state.StateDB)
@@ -316,6 +322,9 @@ type Chain interface {
 
 	IsForkActive(point fork.ForkPointItem) bool
 
+	// ForkSchedule returns the fork points of the chain, they are set by the genesis config
+	ForkSchedule() *fork.Schedule
+
 	// ====== Check ======
 	CheckRedo() error
 
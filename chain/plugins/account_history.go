@@ -0,0 +1,446 @@
+package chain_plugins
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+const (
+	historyPositionSize  = 8 + 4
+	historyRecordSize    = 1 + types.HashSize + types.AddressSize + types.TokenTypeIdSize + 8 + 8
+	historyRecordKeySize = 1 + types.AddressSize + historyPositionSize
+)
+
+// historyScanFactor bounds the index entries examined by GetHistory to historyScanFactor times count,
+// so a selective filter doesn't walk the whole history of a busy account in one call
+const historyScanFactor = 10
+
+var maxHistoryPosition = HistoryPosition{^uint64(0), ^uint32(0)}
+
+// HistoryPosition is the position of a block in the history of an account, the send blocks of
+// a contract receive block follow it by Index
+type HistoryPosition struct {
+	Height uint64
+	Index  uint32 // 0 for the block, i+1 for the ith block of its SendBlockList
+}
+
+func (p HistoryPosition) Bytes() []byte {
+	buf := make([]byte, historyPositionSize)
+	binary.BigEndian.PutUint64(buf[:8], p.Height)
+	binary.BigEndian.PutUint32(buf[8:], p.Index)
+	return buf
+}
+
+func BytesToHistoryPosition(buf []byte) (HistoryPosition, error) {
+	if len(buf) != historyPositionSize {
+		return HistoryPosition{}, errors.New(fmt.Sprintf("invalid history position length %d", len(buf)))
+	}
+	return HistoryPosition{binary.BigEndian.Uint64(buf[:8]), binary.BigEndian.Uint32(buf[8:])}, nil
+}
+
+// AccountHistoryRecord is a block of an account confirmed by a snapshot block
+type AccountHistoryRecord struct {
+	Address   types.Address
+	Position  HistoryPosition
+	BlockType byte
+	BlockHash types.Hash
+	// to address of a send block, or the address of the send block of a receive block
+	Counterparty types.Address
+	// token of a send block, or token of the send block of a receive block
+	TokenId types.TokenTypeId
+
+	SnapshotHeight uint64
+	Timestamp      int64
+}
+
+func (r *AccountHistoryRecord) IsSend() bool {
+	return ledger.IsSendBlock(r.BlockType)
+}
+
+func (r *AccountHistoryRecord) serialize() []byte {
+	buf := make([]byte, 0, historyRecordSize)
+	buf = append(buf, r.BlockType)
+	buf = append(buf, r.BlockHash.Bytes()...)
+	buf = append(buf, r.Counterparty.Bytes()...)
+	buf = append(buf, r.TokenId.Bytes()...)
+	buf = append(buf, chain_utils.Uint64ToBytes(r.SnapshotHeight)...)
+	buf = append(buf, chain_utils.Uint64ToBytes(uint64(r.Timestamp))...)
+	return buf
+}
+
+func (r *AccountHistoryRecord) deserialize(key, buf []byte) error {
+	if len(key) != historyRecordKeySize || len(buf) != historyRecordSize {
+		return errors.New("invalid account history record")
+	}
+	r.Address, _ = types.BytesToAddress(key[1 : 1+types.AddressSize])
+	r.Position, _ = BytesToHistoryPosition(key[1+types.AddressSize:])
+
+	r.BlockType = buf[0]
+	buf = buf[1:]
+	r.BlockHash, _ = types.BytesToHash(buf[:types.HashSize])
+	buf = buf[types.HashSize:]
+	r.Counterparty, _ = types.BytesToAddress(buf[:types.AddressSize])
+	buf = buf[types.AddressSize:]
+	r.TokenId, _ = types.BytesToTokenTypeId(buf[:types.TokenTypeIdSize])
+	buf = buf[types.TokenTypeIdSize:]
+	r.SnapshotHeight = chain_utils.BytesToUint64(buf[:8])
+	r.Timestamp = int64(chain_utils.BytesToUint64(buf[8:16]))
+	return nil
+}
+
+func (r *AccountHistoryRecord) indexKeys() [][]byte {
+	return [][]byte{
+		createAccountHistoryByTimeKey(r.Address, r.Timestamp, r.Position),
+		createAccountHistoryByCounterpartyKey(r.Address, r.Counterparty, r.Position),
+		createAccountHistoryByTokenKey(r.Address, r.TokenId, r.Position),
+	}
+}
+
+// AccountHistoryFilter selects the history records of an account, a nil field matches all
+type AccountHistoryFilter struct {
+	Counterparty *types.Address
+	TokenId      *types.TokenTypeId
+	// unix seconds of the confirming snapshot block, both inclusive
+	StartTime *int64
+	EndTime   *int64
+	// only send blocks if true, only receive blocks if false
+	IsSend *bool
+}
+
+func (f *AccountHistoryFilter) match(record *AccountHistoryRecord) bool {
+	return (f.Counterparty == nil || *f.Counterparty == record.Counterparty) &&
+		(f.TokenId == nil || *f.TokenId == record.TokenId) &&
+		(f.IsSend == nil || *f.IsSend == record.IsSend())
+}
+
+// AccountHistory indexes the blocks of accounts confirmed by snapshot blocks by counterparty, by token and
+// by the time of the confirming snapshot block. Genesis blocks are not indexed.
+type AccountHistory struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+func newAccountHistory(store *chain_db.Store, chain Chain) Plugin {
+	return &AccountHistory{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (ah *AccountHistory) SetStore(store *chain_db.Store) {
+	ah.store = store
+}
+
+func (ah *AccountHistory) InsertAccountBlock(*leveldb.Batch, *ledger.AccountBlock) error {
+	return nil
+}
+
+func (ah *AccountHistory) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	if snapshotBlock == nil {
+		return nil
+	}
+	sendBlocks := make(map[types.Hash]*ledger.AccountBlock)
+	for _, block := range confirmedBlocks {
+		if block.IsSendBlock() {
+			sendBlocks[block.Hash] = block
+		}
+		for _, sendBlock := range block.SendBlockList {
+			sendBlocks[sendBlock.Hash] = sendBlock
+		}
+	}
+
+	for _, block := range confirmedBlocks {
+		if block.BlockType == ledger.BlockTypeGenesisReceive {
+			continue
+		}
+		record := &AccountHistoryRecord{
+			Address:        block.AccountAddress,
+			Position:       HistoryPosition{Height: block.Height},
+			BlockType:      block.BlockType,
+			BlockHash:      block.Hash,
+			SnapshotHeight: snapshotBlock.Height,
+			Timestamp:      snapshotBlock.Timestamp.Unix(),
+		}
+		if block.IsSendBlock() {
+			record.Counterparty, record.TokenId = block.ToAddress, block.TokenId
+		} else {
+			sendBlock, ok := sendBlocks[block.FromBlockHash]
+			if !ok {
+				var err error
+				if sendBlock, err = ah.chain.GetAccountBlockByHash(block.FromBlockHash); err != nil {
+					return err
+				}
+				if sendBlock == nil {
+					return errors.New(fmt.Sprintf("send block %s of receive block %s is nil", block.FromBlockHash, block.Hash))
+				}
+			}
+			record.Counterparty, record.TokenId = sendBlock.AccountAddress, sendBlock.TokenId
+		}
+		ah.writeRecord(batch, record)
+
+		for i, sendBlock := range block.SendBlockList {
+			ah.writeRecord(batch, &AccountHistoryRecord{
+				Address:        block.AccountAddress,
+				Position:       HistoryPosition{block.Height, uint32(i + 1)},
+				BlockType:      sendBlock.BlockType,
+				BlockHash:      sendBlock.Hash,
+				Counterparty:   sendBlock.ToAddress,
+				TokenId:        sendBlock.TokenId,
+				SnapshotHeight: snapshotBlock.Height,
+				Timestamp:      snapshotBlock.Timestamp.Unix(),
+			})
+		}
+	}
+	return nil
+}
+
+func (ah *AccountHistory) DeleteAccountBlocks(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (ah *AccountHistory) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock == nil {
+			continue
+		}
+		for _, block := range chunk.AccountBlocks {
+			for i := 0; i <= len(block.SendBlockList); i++ {
+				key := createAccountHistoryKey(block.AccountAddress, HistoryPosition{block.Height, uint32(i)})
+				value, err := ah.store.Get(key)
+				if err != nil {
+					return err
+				}
+				if len(value) == 0 {
+					continue
+				}
+				record := &AccountHistoryRecord{}
+				if err := record.deserialize(key, value); err != nil {
+					return err
+				}
+				batch.Delete(key)
+				for _, indexKey := range record.indexKeys() {
+					batch.Delete(indexKey)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (ah *AccountHistory) RemoveNewUnconfirmed(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+// GetHistory returns at most count records of addr matching filter after the cursor, the latest first
+// or the earliest first if asc, and the cursor of the next page, which is nil if all records are scanned.
+// At most historyScanFactor*count records are examined, the page may be short or empty with a next cursor
+// once the limit is hit.
+func (ah *AccountHistory) GetHistory(addr types.Address, filter *AccountHistoryFilter, cursor *HistoryPosition, count int, asc bool) ([]*AccountHistoryRecord, *HistoryPosition, error) {
+	if filter == nil {
+		filter = &AccountHistoryFilter{}
+	}
+	lower, upper, ok, err := ah.timeRange(addr, filter.StartTime, filter.EndTime)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	if cursor != nil {
+		if asc {
+			if *cursor == maxHistoryPosition {
+				return nil, nil, nil
+			}
+			if lower.less(cursor.next()) {
+				lower = cursor.next()
+			}
+		} else {
+			if *cursor == (HistoryPosition{}) {
+				return nil, nil, nil
+			}
+			if cursor.prev().less(upper) {
+				upper = cursor.prev()
+			}
+		}
+	}
+	if upper.less(lower) {
+		return nil, nil, nil
+	}
+
+	// iterate the most selective index
+	var prefix []byte
+	switch {
+	case filter.Counterparty != nil:
+		prefix = createAccountHistoryByCounterpartyPrefixKey(addr, *filter.Counterparty)
+	case filter.TokenId != nil:
+		prefix = createAccountHistoryByTokenPrefixKey(addr, *filter.TokenId)
+	default:
+		prefix = createAccountHistoryPrefixKey(addr)
+	}
+	iter := ah.store.NewIterator(&util.Range{
+		Start: append(append([]byte{}, prefix...), lower.Bytes()...),
+		// the zero byte makes upper inclusive
+		Limit: append(append(append([]byte{}, prefix...), upper.Bytes()...), 0),
+	})
+	defer iter.Release()
+
+	records := make([]*AccountHistoryRecord, 0, count)
+	var next *HistoryPosition
+	var scanned HistoryPosition
+	maxScanned := historyScanFactor * count
+	// Next of a new iterator moves to the first key
+	advance := iter.Prev
+	if asc {
+		advance = iter.Next
+		ok = iter.Next()
+	} else {
+		ok = iter.Last()
+	}
+	for ; ok; ok = advance() {
+		position, err := BytesToHistoryPosition(iter.Key()[len(prefix):])
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(records) >= count {
+			next = &records[len(records)-1].Position
+			break
+		}
+		if maxScanned <= 0 {
+			next = &scanned
+			break
+		}
+		maxScanned--
+		scanned = position
+		key := createAccountHistoryKey(addr, position)
+		value := iter.Value()
+		if filter.Counterparty != nil || filter.TokenId != nil {
+			if value, err = ah.store.Get(key); err != nil {
+				return nil, nil, err
+			}
+		}
+		record := &AccountHistoryRecord{}
+		if err := record.deserialize(key, value); err != nil {
+			return nil, nil, err
+		}
+		if filter.match(record) {
+			records = append(records, record)
+		}
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, nil, err
+	}
+	return records, next, nil
+}
+
+// timeRange returns the positions of the first and the last records of addr confirmed between startTime and endTime
+func (ah *AccountHistory) timeRange(addr types.Address, startTime, endTime *int64) (HistoryPosition, HistoryPosition, bool, error) {
+	lower, upper := HistoryPosition{}, maxHistoryPosition
+	if startTime == nil && endTime == nil {
+		return lower, upper, true, nil
+	}
+	prefix := createAccountHistoryByTimePrefixKey(addr)
+	r := util.BytesPrefix(prefix)
+	if startTime != nil {
+		r.Start = append(append([]byte{}, prefix...), chain_utils.Uint64ToBytes(uint64(*startTime))...)
+	}
+	if endTime != nil {
+		r.Limit = append(append([]byte{}, prefix...), chain_utils.Uint64ToBytes(uint64(*endTime)+1)...)
+	}
+	iter := ah.store.NewIterator(r)
+	defer iter.Release()
+
+	if !iter.Next() {
+		if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+			return lower, upper, false, err
+		}
+		return lower, upper, false, nil
+	}
+	var err error
+	if lower, err = BytesToHistoryPosition(iter.Key()[len(prefix)+8:]); err != nil {
+		return lower, upper, false, err
+	}
+	iter.Last()
+	if upper, err = BytesToHistoryPosition(iter.Key()[len(prefix)+8:]); err != nil {
+		return lower, upper, false, err
+	}
+	return lower, upper, true, nil
+}
+
+func (p HistoryPosition) less(other HistoryPosition) bool {
+	return p.Height < other.Height || (p.Height == other.Height && p.Index < other.Index)
+}
+
+func (p HistoryPosition) next() HistoryPosition {
+	if p.Index == ^uint32(0) {
+		return HistoryPosition{p.Height + 1, 0}
+	}
+	return HistoryPosition{p.Height, p.Index + 1}
+}
+
+func (p HistoryPosition) prev() HistoryPosition {
+	if p.Index == 0 {
+		return HistoryPosition{p.Height - 1, ^uint32(0)}
+	}
+	return HistoryPosition{p.Height, p.Index - 1}
+}
+
+func (ah *AccountHistory) writeRecord(batch *leveldb.Batch, record *AccountHistoryRecord) {
+	batch.Put(createAccountHistoryKey(record.Address, record.Position), record.serialize())
+	for _, key := range record.indexKeys() {
+		batch.Put(key, nil)
+	}
+}
+
+func createAccountHistoryPrefixKey(addr types.Address) []byte {
+	key := make([]byte, 0, historyRecordKeySize)
+	key = append(key, AccountHistoryKeyPrefix)
+	key = append(key, addr.Bytes()...)
+	return key
+}
+
+func createAccountHistoryKey(addr types.Address, position HistoryPosition) []byte {
+	return append(createAccountHistoryPrefixKey(addr), position.Bytes()...)
+}
+
+func createAccountHistoryByTimePrefixKey(addr types.Address) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+8+historyPositionSize)
+	key = append(key, AccountHistoryByTimeKeyPrefix)
+	key = append(key, addr.Bytes()...)
+	return key
+}
+
+func createAccountHistoryByTimeKey(addr types.Address, timestamp int64, position HistoryPosition) []byte {
+	key := createAccountHistoryByTimePrefixKey(addr)
+	key = append(key, chain_utils.Uint64ToBytes(uint64(timestamp))...)
+	key = append(key, position.Bytes()...)
+	return key
+}
+
+func createAccountHistoryByCounterpartyPrefixKey(addr, counterparty types.Address) []byte {
+	key := make([]byte, 0, 1+2*types.AddressSize+historyPositionSize)
+	key = append(key, AccountHistoryByCounterpartyKeyPrefix)
+	key = append(key, addr.Bytes()...)
+	key = append(key, counterparty.Bytes()...)
+	return key
+}
+
+func createAccountHistoryByCounterpartyKey(addr, counterparty types.Address, position HistoryPosition) []byte {
+	return append(createAccountHistoryByCounterpartyPrefixKey(addr, counterparty), position.Bytes()...)
+}
+
+func createAccountHistoryByTokenPrefixKey(addr types.Address, tokenId types.TokenTypeId) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+types.TokenTypeIdSize+historyPositionSize)
+	key = append(key, AccountHistoryByTokenKeyPrefix)
+	key = append(key, addr.Bytes()...)
+	key = append(key, tokenId.Bytes()...)
+	return key
+}
+
+func createAccountHistoryByTokenKey(addr types.Address, tokenId types.TokenTypeId, position HistoryPosition) []byte {
+	return append(createAccountHistoryByTokenPrefixKey(addr, tokenId), position.Bytes()...)
+}
@@ -0,0 +1,101 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestAccountHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "account_history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := chain_db.NewStore(dir, "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ah := newAccountHistory(store, nil).(*AccountHistory)
+
+	chunks := make(map[uint64]*ledger.SnapshotChunk)
+	insert := func(height uint64, blocks ...[]*ledger.AccountBlock) {
+		now := time.Unix(int64(height), 0)
+		chunk := &ledger.SnapshotChunk{SnapshotBlock: &ledger.SnapshotBlock{Height: height, Timestamp: &now}}
+		for _, list := range blocks {
+			chunk.AccountBlocks = append(chunk.AccountBlocks, list...)
+		}
+		chunks[height] = chunk
+		batch := store.NewBatch()
+		if err := ah.InsertSnapshotBlock(batch, chunk.SnapshotBlock, chunk.AccountBlocks); err != nil {
+			t.Fatal(err)
+		}
+		store.WriteDirectly(batch)
+	}
+	exchange := types.Address{5}
+	query := func(filter *AccountHistoryFilter, cursor *HistoryPosition, count int, asc bool, heights ...uint64) *HistoryPosition {
+		records, next, err := ah.GetHistory(exchange, filter, cursor, count, asc)
+		if err != nil || len(records) != len(heights) {
+			t.Fatalf("records not match, %+v, %v", records, err)
+		}
+		for i, record := range records {
+			if record.Position.Height != heights[i] {
+				t.Fatalf("record %v not match, %+v", i, record)
+			}
+		}
+		return next
+	}
+
+	tb := &transferBuilder{}
+	insert(10, tb.transfer(holder1, exchange, 100, nil, 0), tb.transfer(holder2, exchange, 50, nil, 0))
+	insert(20, tb.transfer(holder1, exchange, 30, nil, 0), tb.transfer(exchange, holder1, 10, nil, 0))
+	otherToken := tb.transfer(holder1, exchange, 5, nil, 0)
+	otherToken[0].TokenId = ledger.ViteTokenId
+	insert(30, otherToken)
+
+	// deposits of the token from holder1 between 5 and 25
+	isSend := false
+	start, end := int64(5), int64(25)
+	deposits := &AccountHistoryFilter{Counterparty: &holder1, TokenId: &testToken, StartTime: &start, EndTime: &end, IsSend: &isSend}
+	if next := query(deposits, nil, 10, false, 3, 1); next != nil {
+		t.Fatalf("unexpected next cursor %+v", next)
+	}
+	query(&AccountHistoryFilter{Counterparty: &holder1}, nil, 10, false, 5, 4, 3, 1)
+
+	// pages of all records
+	next := query(nil, nil, 2, false, 5, 4)
+	next = query(nil, next, 2, false, 3, 2)
+	if next = query(nil, next, 2, false, 1); next != nil {
+		t.Fatalf("unexpected next cursor %+v", next)
+	}
+	next = query(&AccountHistoryFilter{TokenId: &testToken}, nil, 3, true, 1, 2, 3)
+	query(&AccountHistoryFilter{TokenId: &testToken}, next, 3, true, 4)
+
+	batch := store.NewBatch()
+	if err := ah.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{chunks[20], chunks[30]}); err != nil {
+		t.Fatal(err)
+	}
+	store.WriteDirectly(batch)
+	query(nil, nil, 10, false, 2, 1)
+	query(&AccountHistoryFilter{StartTime: &end}, nil, 10, false)
+
+	// a selective filter examines at most 10 times count records a call
+	for height := uint64(40); height < 52; height++ {
+		insert(height, tb.transfer(holder1, exchange, 1, nil, 0))
+	}
+	isSend = true
+	sends := &AccountHistoryFilter{IsSend: &isSend}
+	next = query(sends, nil, 1, true)
+	if next == nil || *next != (HistoryPosition{Height: 13}) {
+		t.Fatalf("next cursor of the scan limit not match, %+v", next)
+	}
+	if next = query(sends, next, 1, true); next != nil {
+		t.Fatalf("unexpected next cursor %+v", next)
+	}
+}
@@ -6,6 +6,24 @@ const (
 	OnRoadInfoKeyPrefix = byte(1)
 
 	DiffTokenHash = byte(2)
+
+	VoteHistoryKeyPrefix        = byte(3)
+	VoteHistoryByVoterKeyPrefix = byte(4)
+	VoteHistoryBySBPKeyPrefix   = byte(5)
+
+	TokenHolderBalanceKeyPrefix = byte(6)
+	TokenHolderRankKeyPrefix    = byte(7)
+	TokenHolderCountKeyPrefix   = byte(8)
+	TokenSupplyHistoryKeyPrefix = byte(9)
+	TokenHolderUndoLogKeyPrefix = byte(10)
+
+	AccountHistoryKeyPrefix               = byte(11)
+	AccountHistoryByTimeKeyPrefix         = byte(12)
+	AccountHistoryByCounterpartyKeyPrefix = byte(13)
+	AccountHistoryByTokenKeyPrefix        = byte(14)
+
+	IndexValidFromKeyPrefix = byte(15)
+	IndexDirtyKeyPrefix     = byte(16)
 )
 
 func CreateOnRoadInfoKey(addr *types.Address, tId *types.TokenTypeId) []byte {
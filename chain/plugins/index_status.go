@@ -0,0 +1,85 @@
+package chain_plugins
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// The optional indexes are enabled by their own config flags. The height of the first snapshot block an optional
+// index is built with is recorded as the height the index is valid from, an index enabled on the store of a synced
+// ledger is incomplete until the plugin data is rebuilt from the genesis snapshot block. An index which finds itself
+// inconsistent with the ledger is marked dirty, it stays dirty until the plugin data is rebuilt.
+
+func createIndexValidFromKey(name string) []byte {
+	return append([]byte{IndexValidFromKeyPrefix}, name...)
+}
+
+func createIndexDirtyKey(name string) []byte {
+	return append([]byte{IndexDirtyKeyPrefix}, name...)
+}
+
+// markIndexDirty records the index of the plugin is inconsistent with the ledger
+func markIndexDirty(batch *leveldb.Batch, name string) {
+	batch.Put(createIndexDirtyKey(name), []byte{1})
+}
+
+// markIndexes records the snapshot block as the first one of the optional indexes which have none
+func (p *Plugins) markIndexes(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock) {
+	if snapshotBlock == nil {
+		return
+	}
+	p.indexMu.Lock()
+	defer p.indexMu.Unlock()
+	for _, name := range p.optional {
+		if !p.indexed[name] {
+			batch.Put(createIndexValidFromKey(name), chain_utils.Uint64ToBytes(snapshotBlock.Height))
+			p.indexed[name] = true
+		}
+	}
+}
+
+// loadIndexes reads which optional indexes have recorded their first snapshot block
+func (p *Plugins) loadIndexes() error {
+	p.indexMu.Lock()
+	defer p.indexMu.Unlock()
+	p.indexed = make(map[string]bool, len(p.optional))
+	for _, name := range p.optional {
+		ok, err := p.store.Has(createIndexValidFromKey(name))
+		if err != nil {
+			return err
+		}
+		p.indexed[name] = ok
+	}
+	return nil
+}
+
+// CheckIndex returns an error if the index of the plugin is incomplete, which is built from a snapshot block after
+// the genesis one or being rebuilt, or if the index is dirty
+func (p *Plugins) CheckIndex(name string) error {
+	if atomic.LoadUint32(&p.writeStatus) == stop {
+		return errors.New(fmt.Sprintf("index incomplete, the plugin data of %s is being rebuilt", name))
+	}
+	dirty, err := p.store.Has(createIndexDirtyKey(name))
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return errors.New(fmt.Sprintf("index dirty, %s is inconsistent with the ledger, rebuild the plugin data", name))
+	}
+	value, err := p.store.Get(createIndexValidFromKey(name))
+	if err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		return errors.New(fmt.Sprintf("index incomplete, %s has no snapshot block indexed, rebuild the plugin data", name))
+	}
+	if validFrom := chain_utils.BytesToUint64(value); validFrom > 1 {
+		return errors.New(fmt.Sprintf("index incomplete, %s is built from snapshot block %d, rebuild the plugin data", name, validFrom))
+	}
+	return nil
+}
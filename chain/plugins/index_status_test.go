@@ -0,0 +1,70 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestPlugins_CheckIndex(t *testing.T) {
+	newPlugins := func(name string) *Plugins {
+		dir, err := ioutil.TempDir("", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewPlugins(dir, &config.Chain{VoteHistoryPlugin: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	insert := func(p *Plugins, height uint64) {
+		now := time.Unix(int64(height), 0)
+		chunks := []*ledger.SnapshotChunk{{SnapshotBlock: &ledger.SnapshotBlock{Height: height, Timestamp: &now}}}
+		if err := p.PrepareInsertSnapshotBlocks(chunks); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkIndex := func(p *Plugins, want string) {
+		err := p.CheckIndex("voteHistory")
+		if want == "" && err != nil {
+			t.Fatal(err)
+		}
+		if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+
+	// indexed from the genesis snapshot block
+	p := newPlugins("index_genesis")
+	defer os.RemoveAll(path.Dir(p.dataDir))
+	defer p.Close()
+	checkIndex(p, "no snapshot block indexed")
+	insert(p, 1)
+	checkIndex(p, "")
+	p.StopWrite()
+	checkIndex(p, "being rebuilt")
+	p.StartWrite()
+	checkIndex(p, "")
+	batch := p.store.NewBatch()
+	markIndexDirty(batch, "voteHistory")
+	p.store.WriteDirectly(batch)
+	checkIndex(p, "index dirty")
+
+	// enabled on the store of a synced ledger
+	p2 := newPlugins("index_synced")
+	defer os.RemoveAll(path.Dir(p2.dataDir))
+	defer p2.Close()
+	insert(p2, 5)
+	if err := p2.loadIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	insert(p2, 6)
+	checkIndex(p2, "built from snapshot block 5")
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/vitelabs/go-vite/common/db/xleveldb"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
+	"math/big"
 )
 
 type Chain interface {
@@ -18,6 +19,7 @@ type Chain interface {
 
 	IsAccountBlockExisted(hash types.Hash) (bool, error)
 	IsGenesisAccountBlock(hash types.Hash) bool
+	GetGenesisBalanceMap(addr types.Address) map[types.TokenTypeId]*big.Int
 
 	GetAllUnconfirmedBlocks() []*ledger.AccountBlock
 
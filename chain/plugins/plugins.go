@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vm_db"
@@ -28,16 +29,21 @@ type Plugins struct {
 	store   *chain_db.Store
 	plugins map[string]Plugin
 
+	// names of the enabled optional plugins
+	optional []string
+	indexed  map[string]bool
+	indexMu  sync.Mutex
+
 	writeStatus uint32
 	mu          sync.RWMutex
 }
 
-func NewPlugins(chainDir string, chain Chain) (*Plugins, error) {
+func NewPlugins(chainDir string, chainCfg *config.Chain, chain Chain) (*Plugins, error) {
 	var err error
 
 	dataDir := path.Join(chainDir, "plugins")
 
-	store, err := chain_db.NewStore(dataDir, "plugins")
+	store, err := chain_db.NewStoreWithBackend(dataDir, "plugins", chainCfg.Backend)
 	if err != nil {
 		return nil, err
 	}
@@ -47,14 +53,34 @@ func NewPlugins(chainDir string, chain Chain) (*Plugins, error) {
 		"onRoadInfo":  newOnRoadInfo(store, chain),
 	}
 
-	return &Plugins{
+	// the optional plugins cost more to index, they are enabled by their own flags
+	var optional []string
+	if chainCfg.VoteHistoryPlugin {
+		plugins["voteHistory"] = newVoteHistory(store, chain)
+		optional = append(optional, "voteHistory")
+	}
+	if chainCfg.TokenHolderPlugin {
+		plugins["tokenHolder"] = newTokenHolder(store, chain)
+		optional = append(optional, "tokenHolder")
+	}
+	if chainCfg.AccountHistoryPlugin {
+		plugins["accountHistory"] = newAccountHistory(store, chain)
+		optional = append(optional, "accountHistory")
+	}
+
+	p := &Plugins{
 		dataDir:     dataDir,
 		chain:       chain,
 		store:       store,
 		plugins:     plugins,
+		optional:    optional,
 		writeStatus: start,
 		log:         log15.New("module", "chain_plugins"),
-	}, nil
+	}
+	if err := p.loadIndexes(); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
 func (p *Plugins) StopWrite() {
@@ -87,7 +113,7 @@ func (p *Plugins) RebuildData() error {
 	os.RemoveAll(p.dataDir)
 
 	// set new store
-	store, err := chain_db.NewStore(p.dataDir, "plugins")
+	store, err := chain_db.NewStoreWithBackend(p.dataDir, "plugins", p.store.Backend())
 	if err != nil {
 		return err
 	}
@@ -97,6 +123,9 @@ func (p *Plugins) RebuildData() error {
 	for _, plugin := range p.plugins {
 		plugin.SetStore(store)
 	}
+	if err := p.loadIndexes(); err != nil {
+		return err
+	}
 
 	// replace flusher store
 	flusher := p.chain.Flusher()
@@ -155,6 +184,7 @@ func (p *Plugins) RebuildData() error {
 					return pErr
 				}
 			}
+			p.markIndexes(batch, chunk.SnapshotBlock)
 
 			p.store.WriteSnapshot(batch, chunk.AccountBlocks)
 
@@ -221,6 +251,7 @@ func (p *Plugins) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) er
 				return err
 			}
 		}
+		p.markIndexes(batch, chunk.SnapshotBlock)
 		p.store.WriteSnapshot(batch, chunk.AccountBlocks)
 
 	}
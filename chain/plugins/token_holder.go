@@ -0,0 +1,634 @@
+package chain_plugins
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+var thLog = log15.New("plugin", "token_holder")
+
+const (
+	TokenSupplyGenesis = byte(0)
+	TokenSupplyIssue   = byte(1)
+	TokenSupplyReIssue = byte(2)
+	TokenSupplyBurn    = byte(3)
+)
+
+const (
+	tokenSupplyRecordFixedSize = types.TokenTypeIdSize + 1 + types.AddressSize + 8 + 8 + 8 + types.HashSize + types.HashSize
+	tokenSupplyKeySize         = 1 + types.TokenTypeIdSize + 8 + 8
+	balanceRankSize            = 32
+
+	// maxTokenHolderCount is the max number of holders or supply records returned by a query
+	maxTokenHolderCount = 1000
+	// undo logs are kept for the latest snapshot blocks, as the redo logs of the state
+	tokenHolderUndoLogRetainHeight = 1200
+)
+
+// TokenSupplyRecord is a change of the total supply of a token by genesis, issue, reissue or burn
+type TokenSupplyRecord struct {
+	TokenId types.TokenTypeId
+	Kind    byte
+	Amount  *big.Int
+	// total supply after the change
+	TotalSupply *big.Int
+	// owner of an issued token, beneficiary of a reissue or burner, empty for genesis
+	Address types.Address
+
+	SnapshotHeight   uint64
+	Timestamp        int64
+	ReceiveHeight    uint64 // height of the receive block of asset contract, 0 for genesis
+	SendBlockHash    types.Hash
+	ReceiveBlockHash types.Hash
+}
+
+func (r *TokenSupplyRecord) serialize() []byte {
+	amount, totalSupply := r.Amount.Bytes(), r.TotalSupply.Bytes()
+	buf := make([]byte, 0, tokenSupplyRecordFixedSize+2+len(amount)+len(totalSupply))
+	buf = append(buf, r.TokenId.Bytes()...)
+	buf = append(buf, r.Kind)
+	buf = append(buf, r.Address.Bytes()...)
+	buf = append(buf, chain_utils.Uint64ToBytes(r.SnapshotHeight)...)
+	buf = append(buf, chain_utils.Uint64ToBytes(uint64(r.Timestamp))...)
+	buf = append(buf, chain_utils.Uint64ToBytes(r.ReceiveHeight)...)
+	buf = append(buf, r.SendBlockHash.Bytes()...)
+	buf = append(buf, r.ReceiveBlockHash.Bytes()...)
+	buf = append(buf, byte(len(amount)))
+	buf = append(buf, amount...)
+	buf = append(buf, byte(len(totalSupply)))
+	buf = append(buf, totalSupply...)
+	return buf
+}
+
+func (r *TokenSupplyRecord) deserialize(buf []byte) error {
+	if len(buf) < tokenSupplyRecordFixedSize+2 {
+		return errors.New("token supply record is too short")
+	}
+	r.TokenId, _ = types.BytesToTokenTypeId(buf[:types.TokenTypeIdSize])
+	buf = buf[types.TokenTypeIdSize:]
+	r.Kind = buf[0]
+	r.Address, _ = types.BytesToAddress(buf[1 : 1+types.AddressSize])
+	buf = buf[1+types.AddressSize:]
+	r.SnapshotHeight = chain_utils.BytesToUint64(buf[:8])
+	r.Timestamp = int64(chain_utils.BytesToUint64(buf[8:16]))
+	r.ReceiveHeight = chain_utils.BytesToUint64(buf[16:24])
+	buf = buf[24:]
+	r.SendBlockHash, _ = types.BytesToHash(buf[:types.HashSize])
+	r.ReceiveBlockHash, _ = types.BytesToHash(buf[types.HashSize : 2*types.HashSize])
+	buf = buf[2*types.HashSize:]
+
+	amount, buf, err := readShortBytes(buf)
+	if err != nil {
+		return err
+	}
+	totalSupply, _, err := readShortBytes(buf)
+	if err != nil {
+		return err
+	}
+	r.Amount, r.TotalSupply = new(big.Int).SetBytes(amount), new(big.Int).SetBytes(totalSupply)
+	return nil
+}
+
+func readShortBytes(buf []byte) ([]byte, []byte, error) {
+	if len(buf) < 1 || len(buf) < 1+int(buf[0]) {
+		return nil, nil, errors.New("token holder data is too short")
+	}
+	return buf[1 : 1+buf[0]], buf[1+buf[0]:], nil
+}
+
+// HolderBalance is the balance of a token holder
+type HolderBalance struct {
+	Address types.Address
+	Balance *big.Int
+}
+
+type holderKey struct {
+	tokenId types.TokenTypeId
+	addr    types.Address
+}
+
+// tokenHolderUndoLog keeps the balances before a snapshot block and the supply records of it for rollback
+type tokenHolderUndoLog struct {
+	tokenIds   []types.TokenTypeId
+	balances   []*HolderBalance
+	supplyKeys [][]byte
+}
+
+func (l *tokenHolderUndoLog) serialize() []byte {
+	buf := chain_utils.Uint64ToBytes(uint64(len(l.balances)))
+	for i, balance := range l.balances {
+		buf = append(buf, l.tokenIds[i].Bytes()...)
+		buf = append(buf, balance.Address.Bytes()...)
+		buf = append(buf, byte(len(balance.Balance.Bytes())))
+		buf = append(buf, balance.Balance.Bytes()...)
+	}
+	for _, key := range l.supplyKeys {
+		buf = append(buf, key...)
+	}
+	return buf
+}
+
+func (l *tokenHolderUndoLog) deserialize(buf []byte) error {
+	if len(buf) < 8 {
+		return errors.New("token holder undo log is too short")
+	}
+	count := chain_utils.BytesToUint64(buf[:8])
+	buf = buf[8:]
+	for i := uint64(0); i < count; i++ {
+		if len(buf) < types.TokenTypeIdSize+types.AddressSize {
+			return errors.New("token holder undo log is too short")
+		}
+		tokenId, _ := types.BytesToTokenTypeId(buf[:types.TokenTypeIdSize])
+		addr, _ := types.BytesToAddress(buf[types.TokenTypeIdSize : types.TokenTypeIdSize+types.AddressSize])
+		balance, rest, err := readShortBytes(buf[types.TokenTypeIdSize+types.AddressSize:])
+		if err != nil {
+			return err
+		}
+		l.tokenIds = append(l.tokenIds, tokenId)
+		l.balances = append(l.balances, &HolderBalance{addr, new(big.Int).SetBytes(balance)})
+		buf = rest
+	}
+	if len(buf)%tokenSupplyKeySize != 0 {
+		return errors.New("token holder undo log has invalid supply keys")
+	}
+	for len(buf) > 0 {
+		l.supplyKeys = append(l.supplyKeys, buf[:tokenSupplyKeySize])
+		buf = buf[tokenSupplyKeySize:]
+	}
+	return nil
+}
+
+// TokenHolder indexes the balances of token holders ordered by balance and the supply changes of tokens.
+// Balances are derived from the account blocks confirmed by snapshot blocks:
+//   - a send block pays its amount and fee, except rewards and minted tokens sent by builtin contracts
+//   - a receive block gets the amount of its send block, except the tokens burnt by asset contract
+//   - a failed contract receive block keeps nothing, its refunds are paid with the amount and fee it returns
+//
+// Fees are burnt without a supply record, so supply records follow the total supply of asset contract.
+// Rolling back the snapshot blocks older than tokenHolderUndoLogRetainHeight marks the index dirty until it is rebuilt.
+type TokenHolder struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+func newTokenHolder(store *chain_db.Store, chain Chain) Plugin {
+	return &TokenHolder{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (th *TokenHolder) SetStore(store *chain_db.Store) {
+	th.store = store
+}
+
+func (th *TokenHolder) InsertAccountBlock(*leveldb.Batch, *ledger.AccountBlock) error {
+	return nil
+}
+
+func (th *TokenHolder) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	if snapshotBlock == nil {
+		return nil
+	}
+	deltas, records, err := th.parseBlocks(snapshotBlock, confirmedBlocks)
+	if err != nil {
+		return err
+	}
+
+	undoLog := &tokenHolderUndoLog{}
+	counts := make(map[types.TokenTypeId]int64)
+	for key, delta := range deltas {
+		prev, err := th.getBalance(key.tokenId, key.addr)
+		if err != nil {
+			return err
+		}
+		balance := new(big.Int).Add(prev, delta)
+		if balance.Sign() < 0 {
+			// the index missed a credit of the holder, keep it out of the ranks and fail the queries until it is rebuilt
+			thLog.Error(fmt.Sprintf("negative balance %s of holder %s, token %s, prev %s", balance, key.addr, key.tokenId, prev),
+				"method", "InsertSnapshotBlock", "height", snapshotBlock.Height)
+			markIndexDirty(batch, "tokenHolder")
+			balance.SetInt64(0)
+		}
+		if balance.Cmp(prev) == 0 {
+			continue
+		}
+		undoLog.tokenIds = append(undoLog.tokenIds, key.tokenId)
+		undoLog.balances = append(undoLog.balances, &HolderBalance{key.addr, prev})
+		th.writeBalance(batch, key.tokenId, key.addr, prev, balance, counts)
+	}
+	if err := th.writeCounts(batch, counts); err != nil {
+		return err
+	}
+
+	// total supplies changed in this snapshot block, they are not readable from the store yet
+	totalSupplies := make(map[types.TokenTypeId]*big.Int)
+	for _, record := range records {
+		totalSupply, ok := totalSupplies[record.TokenId]
+		if !ok {
+			if totalSupply, err = th.getTotalSupply(record.TokenId); err != nil {
+				return err
+			}
+		}
+		if record.Kind == TokenSupplyBurn {
+			totalSupply = new(big.Int).Sub(totalSupply, record.Amount)
+		} else {
+			totalSupply = new(big.Int).Add(totalSupply, record.Amount)
+		}
+		totalSupplies[record.TokenId] = totalSupply
+		record.TotalSupply = totalSupply
+
+		key := createTokenSupplyHistoryKey(record.TokenId, record.SnapshotHeight, record.ReceiveHeight)
+		batch.Put(key, record.serialize())
+		undoLog.supplyKeys = append(undoLog.supplyKeys, key)
+	}
+
+	// an undo log is kept for every snapshot block, a missing one means the rollback is too deep
+	batch.Put(createTokenHolderUndoLogKey(snapshotBlock.Height), undoLog.serialize())
+	if snapshotBlock.Height > tokenHolderUndoLogRetainHeight {
+		batch.Delete(createTokenHolderUndoLogKey(snapshotBlock.Height - tokenHolderUndoLogRetainHeight))
+	}
+	return nil
+}
+
+func (th *TokenHolder) DeleteAccountBlocks(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (th *TokenHolder) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	snapshotBlocks := make([]*ledger.SnapshotBlock, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil {
+			snapshotBlocks = append(snapshotBlocks, chunk.SnapshotBlock)
+		}
+	}
+	// undo the latest snapshot block first, so that a balance ends with the one before all of them
+	sort.Slice(snapshotBlocks, func(i, j int) bool {
+		return snapshotBlocks[i].Height > snapshotBlocks[j].Height
+	})
+
+	// balances already rolled back in the batch
+	balances := make(map[holderKey]*big.Int)
+	counts := make(map[types.TokenTypeId]int64)
+	for _, snapshotBlock := range snapshotBlocks {
+		key := createTokenHolderUndoLogKey(snapshotBlock.Height)
+		value, err := th.store.Get(key)
+		if err != nil {
+			return err
+		}
+		if len(value) == 0 {
+			thLog.Error("undo log is pruned, the index needs a rebuild", "method", "DeleteSnapshotBlocks", "height", snapshotBlock.Height)
+			markIndexDirty(batch, "tokenHolder")
+			continue
+		}
+		undoLog := &tokenHolderUndoLog{}
+		if err := undoLog.deserialize(value); err != nil {
+			return err
+		}
+		for i, prev := range undoLog.balances {
+			hk := holderKey{undoLog.tokenIds[i], prev.Address}
+			balance, ok := balances[hk]
+			if !ok {
+				if balance, err = th.getBalance(hk.tokenId, hk.addr); err != nil {
+					return err
+				}
+			}
+			th.writeBalance(batch, hk.tokenId, hk.addr, balance, prev.Balance, counts)
+			balances[hk] = prev.Balance
+		}
+		for _, supplyKey := range undoLog.supplyKeys {
+			batch.Delete(supplyKey)
+		}
+		batch.Delete(key)
+	}
+	return th.writeCounts(batch, counts)
+}
+
+func (th *TokenHolder) RemoveNewUnconfirmed(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+// GetHolders returns the holders of token ordered by balance, the largest first, and the total count of them
+func (th *TokenHolder) GetHolders(tokenId types.TokenTypeId, offset, count int) ([]*HolderBalance, int, error) {
+	if err := checkTokenHolderRange(offset, count); err != nil {
+		return nil, 0, err
+	}
+	total, err := th.getHolderCount(tokenId)
+	if err != nil {
+		return nil, 0, err
+	}
+	prefix := createTokenHolderRankPrefixKey(tokenId)
+	iter := th.store.NewIterator(util.BytesPrefix(prefix))
+	defer iter.Release()
+
+	holders := make([]*HolderBalance, 0, count)
+	index := 0
+	for ok := iter.Last(); ok && len(holders) < count; ok = iter.Prev() {
+		if index >= offset {
+			key := iter.Key()[len(prefix):]
+			if len(key) != balanceRankSize+types.AddressSize {
+				return nil, 0, errors.New(fmt.Sprintf("invalid token holder rank key %x", iter.Key()))
+			}
+			addr, _ := types.BytesToAddress(key[balanceRankSize:])
+			holders = append(holders, &HolderBalance{addr, new(big.Int).SetBytes(key[:balanceRankSize])})
+		}
+		index++
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, 0, err
+	}
+	return holders, int(total), nil
+}
+
+// GetHolderBalance returns the indexed balance of a holder of token
+func (th *TokenHolder) GetHolderBalance(tokenId types.TokenTypeId, addr types.Address) (*big.Int, error) {
+	return th.getBalance(tokenId, addr)
+}
+
+// GetSupplyHistory returns the supply changes of token, the latest first, and the total count of them
+func (th *TokenHolder) GetSupplyHistory(tokenId types.TokenTypeId, offset, count int) ([]*TokenSupplyRecord, int, error) {
+	if err := checkTokenHolderRange(offset, count); err != nil {
+		return nil, 0, err
+	}
+	iter := th.store.NewIterator(util.BytesPrefix(createTokenSupplyHistoryPrefixKey(tokenId)))
+	defer iter.Release()
+
+	records := make([]*TokenSupplyRecord, 0, count)
+	total := 0
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		if total >= offset && len(records) < count {
+			record := &TokenSupplyRecord{}
+			if err := record.deserialize(iter.Value()); err != nil {
+				return nil, 0, err
+			}
+			records = append(records, record)
+		}
+		total++
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+func checkTokenHolderRange(offset, count int) error {
+	if offset < 0 || count < 0 || count > maxTokenHolderCount {
+		return fmt.Errorf("invalid offset %d or count %d, count can't be more than %d", offset, count, maxTokenHolderCount)
+	}
+	return nil
+}
+
+// parseBlocks returns the balance changes and the supply changes of the confirmed blocks of a snapshot block
+func (th *TokenHolder) parseBlocks(snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) (map[holderKey]*big.Int, []*TokenSupplyRecord, error) {
+	sendBlocks := make(map[types.Hash]*ledger.AccountBlock)
+	for _, block := range confirmedBlocks {
+		if block.IsSendBlock() {
+			sendBlocks[block.Hash] = block
+		}
+		for _, sendBlock := range block.SendBlockList {
+			sendBlocks[sendBlock.Hash] = sendBlock
+		}
+	}
+
+	deltas := make(map[holderKey]*big.Int)
+	add := func(addr types.Address, tokenId types.TokenTypeId, amount *big.Int) {
+		if amount == nil || amount.Sign() == 0 {
+			return
+		}
+		key := holderKey{tokenId, addr}
+		if delta, ok := deltas[key]; ok {
+			delta.Add(delta, amount)
+		} else {
+			deltas[key] = new(big.Int).Set(amount)
+		}
+	}
+	sub := func(addr types.Address, tokenId types.TokenTypeId, amount *big.Int) {
+		if amount != nil && amount.Sign() > 0 {
+			add(addr, tokenId, new(big.Int).Neg(amount))
+		}
+	}
+
+	records := make([]*TokenSupplyRecord, 0)
+	newRecord := func(tokenId types.TokenTypeId, kind byte, amount *big.Int, addr types.Address, receiveBlock, sendBlock *ledger.AccountBlock) {
+		records = append(records, &TokenSupplyRecord{
+			TokenId:          tokenId,
+			Kind:             kind,
+			Amount:           new(big.Int).Set(amount),
+			Address:          addr,
+			SnapshotHeight:   snapshotBlock.Height,
+			Timestamp:        snapshotBlock.Timestamp.Unix(),
+			ReceiveHeight:    receiveBlock.Height,
+			SendBlockHash:    sendBlock.Hash,
+			ReceiveBlockHash: receiveBlock.Hash,
+		})
+	}
+
+	genesisSupplies := make(map[types.TokenTypeId]*big.Int)
+	for _, block := range confirmedBlocks {
+		if block.BlockType == ledger.BlockTypeGenesisReceive {
+			for tokenId, balance := range th.chain.GetGenesisBalanceMap(block.AccountAddress) {
+				add(block.AccountAddress, tokenId, balance)
+				if supply, ok := genesisSupplies[tokenId]; ok {
+					supply.Add(supply, balance)
+				} else {
+					genesisSupplies[tokenId] = new(big.Int).Set(balance)
+				}
+			}
+			continue
+		}
+
+		if block.IsSendBlock() {
+			sub(block.AccountAddress, block.TokenId, block.Amount)
+			sub(block.AccountAddress, ledger.ViteTokenId, block.Fee)
+			continue
+		}
+
+		sendBlock, ok := sendBlocks[block.FromBlockHash]
+		if !ok {
+			var err error
+			if sendBlock, err = th.chain.GetAccountBlockByHash(block.FromBlockHash); err != nil {
+				return nil, nil, err
+			}
+			if sendBlock == nil {
+				return nil, nil, errors.New(fmt.Sprintf("send block %s of receive block %s is nil", block.FromBlockHash, block.Hash))
+			}
+		}
+		if isReceiveFailed(block) {
+			continue
+		}
+
+		var assetMethod string
+		if block.AccountAddress == types.AddressAsset {
+			if method, err := abi.ABIAsset.MethodById(sendBlock.Data); err == nil {
+				assetMethod = method.Name
+			}
+		}
+		if assetMethod == abi.MethodNameBurn {
+			newRecord(sendBlock.TokenId, TokenSupplyBurn, sendBlock.Amount, sendBlock.AccountAddress, block, sendBlock)
+		} else {
+			add(block.AccountAddress, sendBlock.TokenId, sendBlock.Amount)
+		}
+
+		for _, contractSendBlock := range block.SendBlockList {
+			if contractSendBlock.BlockType != ledger.BlockTypeSendReward {
+				sub(contractSendBlock.AccountAddress, contractSendBlock.TokenId, contractSendBlock.Amount)
+				sub(contractSendBlock.AccountAddress, ledger.ViteTokenId, contractSendBlock.Fee)
+				continue
+			}
+			if block.AccountAddress != types.AddressAsset {
+				continue
+			}
+			kind := TokenSupplyReIssue
+			if assetMethod == abi.MethodNameIssue || assetMethod == abi.MethodNameIssueV2 {
+				kind = TokenSupplyIssue
+			}
+			newRecord(contractSendBlock.TokenId, kind, contractSendBlock.Amount, contractSendBlock.ToAddress, block, sendBlock)
+		}
+	}
+
+	for tokenId, supply := range genesisSupplies {
+		records = append(records, &TokenSupplyRecord{
+			TokenId:        tokenId,
+			Kind:           TokenSupplyGenesis,
+			Amount:         supply,
+			SnapshotHeight: snapshotBlock.Height,
+			Timestamp:      snapshotBlock.Timestamp.Unix(),
+		})
+	}
+	return deltas, records, nil
+}
+
+// isReceiveFailed checks the result byte appended to the receipt hash in the data of a contract receive block,
+// a depth error keeps the amount received
+func isReceiveFailed(block *ledger.AccountBlock) bool {
+	return types.IsContractAddr(block.AccountAddress) && len(block.Data) == types.HashSize+1 && block.Data[types.HashSize] == 1
+}
+
+func (th *TokenHolder) getBalance(tokenId types.TokenTypeId, addr types.Address) (*big.Int, error) {
+	value, err := th.store.Get(createTokenHolderBalanceKey(tokenId, addr))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(value), nil
+}
+
+// getTotalSupply returns the total supply of the latest supply record of token
+func (th *TokenHolder) getTotalSupply(tokenId types.TokenTypeId) (*big.Int, error) {
+	iter := th.store.NewIterator(util.BytesPrefix(createTokenSupplyHistoryPrefixKey(tokenId)))
+	defer iter.Release()
+
+	if !iter.Last() {
+		if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+			return nil, err
+		}
+		return big.NewInt(0), nil
+	}
+	record := &TokenSupplyRecord{}
+	if err := record.deserialize(iter.Value()); err != nil {
+		return nil, err
+	}
+	return record.TotalSupply, nil
+}
+
+func (th *TokenHolder) getHolderCount(tokenId types.TokenTypeId) (uint64, error) {
+	value, err := th.store.Get(createTokenHolderCountKey(tokenId))
+	if err != nil || len(value) == 0 {
+		return 0, err
+	}
+	return chain_utils.BytesToUint64(value), nil
+}
+
+// writeBalance replaces the balance prev of a holder with balance and counts the holders added or removed
+func (th *TokenHolder) writeBalance(batch *leveldb.Batch, tokenId types.TokenTypeId, addr types.Address, prev, balance *big.Int, counts map[types.TokenTypeId]int64) {
+	if prev.Sign() > 0 {
+		batch.Delete(createTokenHolderRankKey(tokenId, prev, addr))
+		counts[tokenId]--
+	}
+	if balance.Sign() > 0 {
+		batch.Put(createTokenHolderBalanceKey(tokenId, addr), balance.Bytes())
+		batch.Put(createTokenHolderRankKey(tokenId, balance, addr), nil)
+		counts[tokenId]++
+	} else {
+		batch.Delete(createTokenHolderBalanceKey(tokenId, addr))
+	}
+}
+
+func (th *TokenHolder) writeCounts(batch *leveldb.Batch, counts map[types.TokenTypeId]int64) error {
+	for tokenId, delta := range counts {
+		if delta == 0 {
+			continue
+		}
+		count, err := th.getHolderCount(tokenId)
+		if err != nil {
+			return err
+		}
+		if int64(count)+delta <= 0 {
+			batch.Delete(createTokenHolderCountKey(tokenId))
+		} else {
+			batch.Put(createTokenHolderCountKey(tokenId), chain_utils.Uint64ToBytes(uint64(int64(count)+delta)))
+		}
+	}
+	return nil
+}
+
+func createTokenHolderBalanceKey(tokenId types.TokenTypeId, addr types.Address) []byte {
+	key := make([]byte, 0, 1+types.TokenTypeIdSize+types.AddressSize)
+	key = append(key, TokenHolderBalanceKeyPrefix)
+	key = append(key, tokenId.Bytes()...)
+	key = append(key, addr.Bytes()...)
+	return key
+}
+
+func createTokenHolderRankPrefixKey(tokenId types.TokenTypeId) []byte {
+	key := make([]byte, 0, 1+types.TokenTypeIdSize+balanceRankSize+types.AddressSize)
+	key = append(key, TokenHolderRankKeyPrefix)
+	key = append(key, tokenId.Bytes()...)
+	return key
+}
+
+func createTokenHolderRankKey(tokenId types.TokenTypeId, balance *big.Int, addr types.Address) []byte {
+	key := createTokenHolderRankPrefixKey(tokenId)
+	key = append(key, helper.LeftPadBytes(balance.Bytes(), balanceRankSize)...)
+	key = append(key, addr.Bytes()...)
+	return key
+}
+
+func createTokenHolderCountKey(tokenId types.TokenTypeId) []byte {
+	key := make([]byte, 0, 1+types.TokenTypeIdSize)
+	key = append(key, TokenHolderCountKeyPrefix)
+	key = append(key, tokenId.Bytes()...)
+	return key
+}
+
+func createTokenSupplyHistoryPrefixKey(tokenId types.TokenTypeId) []byte {
+	key := make([]byte, 0, tokenSupplyKeySize)
+	key = append(key, TokenSupplyHistoryKeyPrefix)
+	key = append(key, tokenId.Bytes()...)
+	return key
+}
+
+func createTokenSupplyHistoryKey(tokenId types.TokenTypeId, snapshotHeight, receiveHeight uint64) []byte {
+	key := createTokenSupplyHistoryPrefixKey(tokenId)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	key = append(key, chain_utils.Uint64ToBytes(receiveHeight)...)
+	return key
+}
+
+func createTokenHolderUndoLogKey(snapshotHeight uint64) []byte {
+	key := make([]byte, 0, 1+8)
+	key = append(key, TokenHolderUndoLogKeyPrefix)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	return key
+}
@@ -0,0 +1,213 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+var (
+	holder1   = types.Address{1}
+	holder2   = types.Address{2}
+	testToken = types.TokenTypeId{1}
+)
+
+type transferBuilder struct {
+	height uint64
+}
+
+// transfer returns a send block and the receive block of it with the sendBlockList of the receiver
+func (tb *transferBuilder) transfer(from, to types.Address, amount int64, data []byte, receiveResult byte, sendBlockList ...*ledger.AccountBlock) []*ledger.AccountBlock {
+	tb.height++
+	sendBlock := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: from,
+		ToAddress:      to,
+		Height:         tb.height,
+		Amount:         big.NewInt(amount),
+		TokenId:        testToken,
+		Fee:            big.NewInt(0),
+		Data:           data,
+	}
+	sendBlock.Hash = sendBlock.ComputeHash()
+	receiveBlock := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: to,
+		Height:         tb.height,
+		FromBlockHash:  sendBlock.Hash,
+		SendBlockList:  sendBlockList,
+	}
+	if types.IsContractAddr(to) {
+		receiveBlock.Data = append(types.Hash{}.Bytes(), receiveResult)
+	}
+	receiveBlock.Hash = receiveBlock.ComputeHash()
+	return []*ledger.AccountBlock{sendBlock, receiveBlock}
+}
+
+func (tb *transferBuilder) contractSend(blockType byte, from, to types.Address, amount int64) *ledger.AccountBlock {
+	tb.height++
+	block := &ledger.AccountBlock{
+		BlockType:      blockType,
+		AccountAddress: from,
+		ToAddress:      to,
+		Height:         tb.height,
+		Amount:         big.NewInt(amount),
+		TokenId:        testToken,
+		Fee:            big.NewInt(0),
+	}
+	block.Hash = block.ComputeHash()
+	return block
+}
+
+func receive(sendBlock *ledger.AccountBlock) []*ledger.AccountBlock {
+	block := &ledger.AccountBlock{BlockType: ledger.BlockTypeReceive, AccountAddress: sendBlock.ToAddress, FromBlockHash: sendBlock.Hash}
+	block.Hash = block.ComputeHash()
+	return []*ledger.AccountBlock{block}
+}
+
+func TestTokenHolder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token_holder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := chain_db.NewStore(dir, "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	th := newTokenHolder(store, nil).(*TokenHolder)
+
+	chunks := make(map[uint64]*ledger.SnapshotChunk)
+	insert := func(height uint64, blocks ...[]*ledger.AccountBlock) {
+		now := time.Unix(int64(height), 0)
+		chunk := &ledger.SnapshotChunk{SnapshotBlock: &ledger.SnapshotBlock{Height: height, Timestamp: &now}}
+		for _, list := range blocks {
+			chunk.AccountBlocks = append(chunk.AccountBlocks, list...)
+		}
+		chunks[height] = chunk
+		batch := store.NewBatch()
+		if err := th.InsertSnapshotBlock(batch, chunk.SnapshotBlock, chunk.AccountBlocks); err != nil {
+			t.Fatal(err)
+		}
+		store.WriteDirectly(batch)
+	}
+	checkHolders := func(expected ...int64) {
+		holders, total, err := th.GetHolders(testToken, 0, 10)
+		if err != nil || total != len(expected)/2 || len(holders) != len(expected)/2 {
+			t.Fatalf("holders not match, total %v, holders %+v, err %v", total, holders, err)
+		}
+		for i, holder := range holders {
+			if holder.Address != (types.Address{byte(expected[2*i])}) || holder.Balance.Int64() != expected[2*i+1] {
+				t.Fatalf("holder %v not match, %+v", i, holder)
+			}
+		}
+	}
+	checkSupply := func(expected ...int64) {
+		records, total, err := th.GetSupplyHistory(testToken, 0, 10)
+		if err != nil || total != len(expected) {
+			t.Fatalf("supply history not match, total %v, err %v", total, err)
+		}
+		for i, record := range records {
+			if record.TotalSupply.Int64() != expected[i] {
+				t.Fatalf("supply record %v not match, %+v", i, record)
+			}
+		}
+	}
+
+	contract := types.Address{3}
+	contract[types.AddressSize-1] = types.ContractAddrByte
+	tb := &transferBuilder{}
+	burnData, _ := abi.ABIAsset.PackMethod(abi.MethodNameBurn)
+
+	// holder1 issues 1000 tokens
+	mint := tb.contractSend(ledger.BlockTypeSendReward, types.AddressAsset, holder1, 1000)
+	insert(10, tb.transfer(holder1, types.AddressAsset, 0, abi.ABIAsset.Methods[abi.MethodNameIssueV2].Id(), 0, mint), receive(mint))
+	checkHolders(1, 1000)
+	checkSupply(1000)
+
+	// holder1 sends 300 tokens to holder2 and burns 100 tokens
+	insert(11, tb.transfer(holder1, holder2, 300, nil, 0), tb.transfer(holder1, types.AddressAsset, 100, burnData, 0))
+	checkHolders(1, 600, 2, 300)
+	checkSupply(900, 1000)
+
+	// the contract refunds holder2, holder1 sends all to holder2
+	refund := tb.contractSend(ledger.BlockTypeSendRefund, contract, holder2, 300)
+	insert(12, tb.transfer(holder2, contract, 300, nil, 1, refund), receive(refund),
+		tb.transfer(holder1, holder2, 600, nil, 0))
+	checkHolders(2, 900)
+
+	if balance, err := th.GetHolderBalance(testToken, holder1); err != nil || balance.Sign() != 0 {
+		t.Fatalf("holder1 balance not match, %v, %v", balance, err)
+	}
+
+	batch := store.NewBatch()
+	if err := th.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{chunks[11], chunks[12]}); err != nil {
+		t.Fatal(err)
+	}
+	store.WriteDirectly(batch)
+	checkHolders(1, 1000)
+	checkSupply(1000)
+
+	if _, _, err := th.GetHolders(testToken, 0, maxTokenHolderCount+1); err == nil {
+		t.Fatal("expected count limit error")
+	}
+
+	// undo logs beyond the retained heights are pruned
+	if value, _ := store.Get(createTokenHolderUndoLogKey(10)); len(value) == 0 {
+		t.Fatal("undo log of snapshot block 10 should be kept")
+	}
+	insert(10 + tokenHolderUndoLogRetainHeight)
+	if value, _ := store.Get(createTokenHolderUndoLogKey(10)); len(value) != 0 {
+		t.Fatal("undo log of snapshot block 10 should be pruned")
+	}
+
+	// rolling back a snapshot block without undo log marks the index dirty
+	if dirty, _ := store.Has(createIndexDirtyKey("tokenHolder")); dirty {
+		t.Fatal("index should not be dirty")
+	}
+	batch = store.NewBatch()
+	if err := th.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{chunks[10]}); err != nil {
+		t.Fatal(err)
+	}
+	store.WriteDirectly(batch)
+	if dirty, _ := store.Has(createIndexDirtyKey("tokenHolder")); !dirty {
+		t.Fatal("index should be dirty after a rollback beyond the undo logs")
+	}
+}
+
+func TestTokenHolder_NegativeBalance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token_holder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := chain_db.NewStore(dir, "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	th := newTokenHolder(store, nil).(*TokenHolder)
+
+	// holder1 sends tokens it never received
+	now := time.Unix(10, 0)
+	batch := store.NewBatch()
+	if err := th.InsertSnapshotBlock(batch, &ledger.SnapshotBlock{Height: 10, Timestamp: &now},
+		(&transferBuilder{}).transfer(holder1, holder2, 100, nil, 0)); err != nil {
+		t.Fatal(err)
+	}
+	store.WriteDirectly(batch)
+	if balance, err := th.GetHolderBalance(testToken, holder1); err != nil || balance.Sign() != 0 {
+		t.Fatalf("holder1 balance not match, %v, %v", balance, err)
+	}
+	if dirty, _ := store.Has(createIndexDirtyKey("tokenHolder")); !dirty {
+		t.Fatal("index should be dirty after a negative balance")
+	}
+}
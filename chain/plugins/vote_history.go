@@ -0,0 +1,354 @@
+package chain_plugins
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+const voteRecordFixedSize = types.AddressSize + types.GidSize + 8 + 8 + 8 + types.HashSize + types.HashSize
+
+// maxVoteHistoryCount is the max number of vote records returned by a query
+const maxVoteHistoryCount = 1000
+
+// VoteRecord is a vote or a vote cancellation received by the governance contract
+type VoteRecord struct {
+	Voter types.Address
+	Gid   types.Gid
+	// voted sbp, empty if the vote is cancelled
+	SbpName string
+	// sbp voted before, empty if the voter didn't vote or the vote is not indexed
+	PrevSbpName string
+
+	SnapshotHeight   uint64
+	Timestamp        int64
+	ReceiveHeight    uint64 // height of the receive block of governance contract
+	SendBlockHash    types.Hash
+	ReceiveBlockHash types.Hash
+}
+
+func (r *VoteRecord) serialize() []byte {
+	buf := make([]byte, 0, voteRecordFixedSize+2+len(r.SbpName)+len(r.PrevSbpName))
+	buf = append(buf, r.Voter.Bytes()...)
+	buf = append(buf, r.Gid.Bytes()...)
+	buf = append(buf, chain_utils.Uint64ToBytes(r.SnapshotHeight)...)
+	buf = append(buf, chain_utils.Uint64ToBytes(uint64(r.Timestamp))...)
+	buf = append(buf, chain_utils.Uint64ToBytes(r.ReceiveHeight)...)
+	buf = append(buf, r.SendBlockHash.Bytes()...)
+	buf = append(buf, r.ReceiveBlockHash.Bytes()...)
+	buf = append(buf, byte(len(r.SbpName)))
+	buf = append(buf, r.SbpName...)
+	buf = append(buf, byte(len(r.PrevSbpName)))
+	buf = append(buf, r.PrevSbpName...)
+	return buf
+}
+
+func (r *VoteRecord) deserialize(buf []byte) error {
+	if len(buf) < voteRecordFixedSize+2 {
+		return errors.New("vote record is too short")
+	}
+	r.Voter, _ = types.BytesToAddress(buf[:types.AddressSize])
+	buf = buf[types.AddressSize:]
+	r.Gid, _ = types.BytesToGid(buf[:types.GidSize])
+	buf = buf[types.GidSize:]
+	r.SnapshotHeight = chain_utils.BytesToUint64(buf[:8])
+	r.Timestamp = int64(chain_utils.BytesToUint64(buf[8:16]))
+	r.ReceiveHeight = chain_utils.BytesToUint64(buf[16:24])
+	buf = buf[24:]
+	r.SendBlockHash, _ = types.BytesToHash(buf[:types.HashSize])
+	r.ReceiveBlockHash, _ = types.BytesToHash(buf[types.HashSize : 2*types.HashSize])
+	buf = buf[2*types.HashSize:]
+
+	var err error
+	if r.SbpName, buf, err = readShortString(buf); err != nil {
+		return err
+	}
+	r.PrevSbpName, _, err = readShortString(buf)
+	return err
+}
+
+func readShortString(buf []byte) (string, []byte, error) {
+	if len(buf) < 1 || len(buf) < 1+int(buf[0]) {
+		return "", nil, errors.New("vote record is too short")
+	}
+	return string(buf[1 : 1+buf[0]]), buf[1+buf[0]:], nil
+}
+
+// VoteHistory indexes the vote changes of the governance contract by voter and by sbp. Only the votes
+// confirmed by snapshot blocks are indexed, the votes in genesis state have no history.
+type VoteHistory struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+func newVoteHistory(store *chain_db.Store, chain Chain) Plugin {
+	return &VoteHistory{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (vh *VoteHistory) SetStore(store *chain_db.Store) {
+	vh.store = store
+}
+
+func (vh *VoteHistory) InsertAccountBlock(*leveldb.Batch, *ledger.AccountBlock) error {
+	return nil
+}
+
+func (vh *VoteHistory) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	if snapshotBlock == nil {
+		return nil
+	}
+	sendBlocks := make(map[types.Hash]*ledger.AccountBlock)
+	for _, block := range confirmedBlocks {
+		if block.IsSendBlock() && block.ToAddress == types.AddressGovernance {
+			sendBlocks[block.Hash] = block
+		}
+	}
+
+	// votes of the voters in this snapshot block, they are not readable from the store yet
+	latestVotes := make(map[string]string)
+	for _, block := range confirmedBlocks {
+		if block.AccountAddress != types.AddressGovernance || !block.IsReceiveBlock() || !isReceiveSucceeded(block) {
+			continue
+		}
+		sendBlock, ok := sendBlocks[block.FromBlockHash]
+		if !ok {
+			var err error
+			if sendBlock, err = vh.chain.GetAccountBlockByHash(block.FromBlockHash); err != nil {
+				return err
+			}
+			if sendBlock == nil {
+				return errors.New(fmt.Sprintf("send block %s of governance receive block %s is nil", block.FromBlockHash, block.Hash))
+			}
+		}
+		record := parseVoteRecord(sendBlock)
+		if record == nil {
+			continue
+		}
+		record.SnapshotHeight = snapshotBlock.Height
+		record.Timestamp = snapshotBlock.Timestamp.Unix()
+		record.ReceiveHeight = block.Height
+		record.SendBlockHash = sendBlock.Hash
+		record.ReceiveBlockHash = block.Hash
+
+		voterKey := string(createVoteHistoryByVoterPrefixKey(record.Voter, record.Gid))
+		if prevSbpName, ok := latestVotes[voterKey]; ok {
+			record.PrevSbpName = prevSbpName
+		} else {
+			prev, err := vh.latestVote(record.Voter, record.Gid)
+			if err != nil {
+				return err
+			}
+			if prev != nil {
+				record.PrevSbpName = prev.SbpName
+			}
+		}
+		latestVotes[voterKey] = record.SbpName
+		vh.writeRecord(batch, record)
+	}
+	return nil
+}
+
+func (vh *VoteHistory) DeleteAccountBlocks(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (vh *VoteHistory) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	for _, chunk := range chunks {
+		for _, block := range chunk.AccountBlocks {
+			if block.AccountAddress != types.AddressGovernance || !block.IsReceiveBlock() {
+				continue
+			}
+			key := createVoteHistoryKey(block.Height)
+			value, err := vh.store.Get(key)
+			if err != nil {
+				return err
+			}
+			if len(value) == 0 {
+				continue
+			}
+			record := &VoteRecord{}
+			if err := record.deserialize(value); err != nil {
+				return err
+			}
+			batch.Delete(key)
+			for _, indexKey := range record.indexKeys() {
+				batch.Delete(indexKey)
+			}
+		}
+	}
+	return nil
+}
+
+func (vh *VoteHistory) RemoveNewUnconfirmed(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+// GetVoteHistoryByVoter returns the vote changes of voter confirmed at or before snapshot block
+// of snapshotHeight, the latest first, and the total count of them
+func (vh *VoteHistory) GetVoteHistoryByVoter(voter types.Address, gid types.Gid, snapshotHeight uint64, offset, count int) ([]*VoteRecord, int, error) {
+	return vh.getRecords(createVoteHistoryByVoterPrefixKey(voter, gid), snapshotHeight, offset, count)
+}
+
+// GetVoteHistoryBySBP returns the votes for and the votes leaving the sbp confirmed at or
+// before snapshot block of snapshotHeight, the latest first, and the total count of them
+func (vh *VoteHistory) GetVoteHistoryBySBP(name string, gid types.Gid, snapshotHeight uint64, offset, count int) ([]*VoteRecord, int, error) {
+	return vh.getRecords(createVoteHistoryBySBPPrefixKey(name, gid), snapshotHeight, offset, count)
+}
+
+// GetLatestVote returns the latest vote change of voter confirmed at or before snapshot block of snapshotHeight
+func (vh *VoteHistory) GetLatestVote(voter types.Address, gid types.Gid, snapshotHeight uint64) (*VoteRecord, error) {
+	return vh.getLatestRecord(createVoteHistoryByVoterPrefixKey(voter, gid), snapshotHeight)
+}
+
+func (vh *VoteHistory) latestVote(voter types.Address, gid types.Gid) (*VoteRecord, error) {
+	return vh.getLatestRecord(createVoteHistoryByVoterPrefixKey(voter, gid), ^uint64(0)-1)
+}
+
+// getLatestRecord reads the last record of prefix at or before snapshotHeight without counting the others
+func (vh *VoteHistory) getLatestRecord(prefix []byte, snapshotHeight uint64) (*VoteRecord, error) {
+	limit := append(append([]byte{}, prefix...), chain_utils.Uint64ToBytes(snapshotHeight+1)...)
+	iter := vh.store.NewIterator(&util.Range{Start: prefix, Limit: limit})
+	defer iter.Release()
+
+	if !iter.Last() {
+		if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+			return nil, err
+		}
+		return nil, nil
+	}
+	record := &VoteRecord{}
+	if err := record.deserialize(iter.Value()); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (vh *VoteHistory) getRecords(prefix []byte, snapshotHeight uint64, offset, count int) ([]*VoteRecord, int, error) {
+	if offset < 0 || count < 0 || count > maxVoteHistoryCount {
+		return nil, 0, fmt.Errorf("invalid offset %d or count %d, count can't be more than %d", offset, count, maxVoteHistoryCount)
+	}
+	limit := append(append([]byte{}, prefix...), chain_utils.Uint64ToBytes(snapshotHeight+1)...)
+	iter := vh.store.NewIterator(&util.Range{Start: prefix, Limit: limit})
+	defer iter.Release()
+
+	records := make([]*VoteRecord, 0, count)
+	total := 0
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		if total >= offset && len(records) < count {
+			record := &VoteRecord{}
+			if err := record.deserialize(iter.Value()); err != nil {
+				return nil, 0, err
+			}
+			records = append(records, record)
+		}
+		total++
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+func (vh *VoteHistory) writeRecord(batch *leveldb.Batch, record *VoteRecord) {
+	value := record.serialize()
+	batch.Put(createVoteHistoryKey(record.ReceiveHeight), value)
+	for _, key := range record.indexKeys() {
+		batch.Put(key, value)
+	}
+}
+
+func (r *VoteRecord) indexKeys() [][]byte {
+	keys := [][]byte{createVoteHistoryByVoterKey(r.Voter, r.Gid, r.SnapshotHeight, r.ReceiveHeight)}
+	if len(r.SbpName) > 0 {
+		keys = append(keys, createVoteHistoryBySBPKey(r.SbpName, r.Gid, r.SnapshotHeight, r.ReceiveHeight))
+	}
+	if len(r.PrevSbpName) > 0 && r.PrevSbpName != r.SbpName {
+		keys = append(keys, createVoteHistoryBySBPKey(r.PrevSbpName, r.Gid, r.SnapshotHeight, r.ReceiveHeight))
+	}
+	return keys
+}
+
+// parseVoteRecord returns the vote of a send block to governance contract, nil if it is not a vote
+func parseVoteRecord(sendBlock *ledger.AccountBlock) *VoteRecord {
+	method, err := abi.ABIGovernance.MethodById(sendBlock.Data)
+	if err != nil {
+		return nil
+	}
+	record := &VoteRecord{Voter: sendBlock.AccountAddress, Gid: types.SNAPSHOT_GID}
+	switch method.Name {
+	case abi.MethodNameVote:
+		param := new(abi.ParamVote)
+		if err := abi.ABIGovernance.UnpackMethod(param, method.Name, sendBlock.Data); err != nil {
+			return nil
+		}
+		record.Gid, record.SbpName = param.Gid, param.SbpName
+	case abi.MethodNameVoteV3:
+		param := new(abi.ParamVote)
+		if err := abi.ABIGovernance.UnpackMethod(param, method.Name, sendBlock.Data); err != nil {
+			return nil
+		}
+		record.SbpName = param.SbpName
+	case abi.MethodNameCancelVote:
+		gid := new(types.Gid)
+		if err := abi.ABIGovernance.UnpackMethod(gid, method.Name, sendBlock.Data); err != nil {
+			return nil
+		}
+		record.Gid = *gid
+	case abi.MethodNameCancelVoteV3:
+	default:
+		return nil
+	}
+	return record
+}
+
+// isReceiveSucceeded checks the result byte appended to the receipt hash in the data of a contract receive block
+func isReceiveSucceeded(block *ledger.AccountBlock) bool {
+	return len(block.Data) != types.HashSize+1 || block.Data[types.HashSize] == 0
+}
+
+func createVoteHistoryKey(receiveHeight uint64) []byte {
+	key := make([]byte, 0, 1+8)
+	key = append(key, VoteHistoryKeyPrefix)
+	key = append(key, chain_utils.Uint64ToBytes(receiveHeight)...)
+	return key
+}
+
+func createVoteHistoryByVoterPrefixKey(voter types.Address, gid types.Gid) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+types.GidSize+16)
+	key = append(key, VoteHistoryByVoterKeyPrefix)
+	key = append(key, voter.Bytes()...)
+	key = append(key, gid.Bytes()...)
+	return key
+}
+
+func createVoteHistoryByVoterKey(voter types.Address, gid types.Gid, snapshotHeight, receiveHeight uint64) []byte {
+	key := createVoteHistoryByVoterPrefixKey(voter, gid)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	key = append(key, chain_utils.Uint64ToBytes(receiveHeight)...)
+	return key
+}
+
+func createVoteHistoryBySBPPrefixKey(name string, gid types.Gid) []byte {
+	registrationKey := abi.GetRegistrationInfoKey(name, gid)
+	key := make([]byte, 0, 1+len(registrationKey)+16)
+	key = append(key, VoteHistoryBySBPKeyPrefix)
+	key = append(key, registrationKey...)
+	return key
+}
+
+func createVoteHistoryBySBPKey(name string, gid types.Gid, snapshotHeight, receiveHeight uint64) []byte {
+	key := createVoteHistoryBySBPPrefixKey(name, gid)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	key = append(key, chain_utils.Uint64ToBytes(receiveHeight)...)
+	return key
+}
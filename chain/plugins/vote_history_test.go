@@ -0,0 +1,103 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+var (
+	voter1 = types.Address{1}
+	voter2 = types.Address{2}
+)
+
+func newVoteBlocks(t *testing.T, voter types.Address, height uint64, method string, params ...interface{}) []*ledger.AccountBlock {
+	data, err := abi.ABIGovernance.PackMethod(method, params...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendBlock := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: voter,
+		ToAddress:      types.AddressGovernance,
+		Data:           data,
+		Hash:           types.DataHash(append(voter.Bytes(), data...)),
+	}
+	receiveBlock := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: types.AddressGovernance,
+		Height:         height,
+		FromBlockHash:  sendBlock.Hash,
+		Data:           append(types.Hash{}.Bytes(), 0),
+		Hash:           types.DataHash(sendBlock.Hash.Bytes()),
+	}
+	return []*ledger.AccountBlock{sendBlock, receiveBlock}
+}
+
+func TestVoteHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vote_history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := chain_db.NewStore(dir, "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	vh := newVoteHistory(store, nil).(*VoteHistory)
+
+	insert := func(height uint64, blocks []*ledger.AccountBlock) {
+		now := time.Unix(int64(height), 0)
+		batch := store.NewBatch()
+		if err := vh.InsertSnapshotBlock(batch, &ledger.SnapshotBlock{Height: height, Timestamp: &now}, blocks); err != nil {
+			t.Fatal(err)
+		}
+		store.WriteDirectly(batch)
+	}
+	// voter1 votes for s1 and switches to s2 in the same snapshot block, voter2 votes for s1 and cancels
+	insert(10, append(newVoteBlocks(t, voter1, 1, abi.MethodNameVoteV3, "s1"),
+		newVoteBlocks(t, voter2, 2, abi.MethodNameVote, types.SNAPSHOT_GID, "s1")...))
+	insert(11, append(newVoteBlocks(t, voter1, 3, abi.MethodNameVoteV3, "s2"),
+		newVoteBlocks(t, voter2, 4, abi.MethodNameCancelVoteV3)...))
+
+	records, total, err := vh.GetVoteHistoryByVoter(voter1, types.SNAPSHOT_GID, 11, 0, 10)
+	if err != nil || total != 2 || records[0].SbpName != "s2" || records[0].PrevSbpName != "s1" || records[1].SbpName != "s1" {
+		t.Fatalf("voter history not match, total %v, records %+v, err %v", total, records, err)
+	}
+	records, total, err = vh.GetVoteHistoryBySBP("s1", types.SNAPSHOT_GID, 11, 0, 10)
+	if err != nil || total != 4 {
+		t.Fatalf("sbp history not match, total %v, err %v", total, err)
+	}
+	if records[0].Voter != voter2 || records[0].SbpName != "" || records[0].PrevSbpName != "s1" || records[0].Timestamp != 11 {
+		t.Fatalf("latest sbp record not match, %+v", records[0])
+	}
+	if _, total, _ = vh.GetVoteHistoryBySBP("s1", types.SNAPSHOT_GID, 10, 0, 10); total != 2 {
+		t.Fatalf("sbp history at snapshot 10 not match, total %v", total)
+	}
+	if records, total, _ = vh.GetVoteHistoryBySBP("s1", types.SNAPSHOT_GID, 11, 3, 10); total != 4 || len(records) != 1 || records[0].ReceiveHeight != 1 {
+		t.Fatalf("sbp history page not match, total %v, records %+v", total, records)
+	}
+
+	batch := store.NewBatch()
+	if err := vh.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{{AccountBlocks: append(newVoteBlocks(t, voter1, 3, abi.MethodNameVoteV3, "s2"),
+		newVoteBlocks(t, voter2, 4, abi.MethodNameCancelVoteV3)...)}}); err != nil {
+		t.Fatal(err)
+	}
+	store.WriteDirectly(batch)
+	if _, total, _ = vh.GetVoteHistoryBySBP("s1", types.SNAPSHOT_GID, 11, 0, 10); total != 2 {
+		t.Fatalf("sbp history after rollback not match, total %v", total)
+	}
+	if record, _ := vh.GetLatestVote(voter1, types.SNAPSHOT_GID, 11); record == nil || record.SbpName != "s1" {
+		t.Fatalf("latest vote after rollback not match, %+v", record)
+	}
+	if _, _, err := vh.GetVoteHistoryByVoter(voter1, types.SNAPSHOT_GID, 11, 0, maxVoteHistoryCount+1); err == nil {
+		t.Fatal("expected count limit error")
+	}
+}
@@ -0,0 +1,62 @@
+package chain_proof
+
+import (
+	"encoding/binary"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+)
+
+// leaf kinds of the state commitment
+const (
+	balanceLeaf = byte(1)
+	storageLeaf = byte(2)
+	codeLeaf    = byte(3)
+)
+
+// proof db
+const (
+	nodeKeyPrefix   = byte(1)
+	valueKeyPrefix  = byte(2)
+	rootKeyPrefix   = byte(3)
+	latestKeyPrefix = byte(4)
+)
+
+// BalanceKey is the leaf key of the balance of tokenId of addr, the value is the big-endian balance.
+func BalanceKey(addr types.Address, tokenId types.TokenTypeId) types.Hash {
+	return leafKey(balanceLeaf, addr.Bytes(), tokenId.Bytes())
+}
+
+// StorageKey is the leaf key of a storage key of the contract addr, the value is the storage value.
+func StorageKey(addr types.Address, key []byte) types.Hash {
+	return leafKey(storageLeaf, addr.Bytes(), key)
+}
+
+// CodeKey is the leaf key of the code of the contract addr, the value is the code.
+func CodeKey(addr types.Address) types.Hash {
+	return leafKey(codeLeaf, addr.Bytes())
+}
+
+func leafKey(kind byte, data ...[]byte) types.Hash {
+	h, _ := types.BytesToHash(crypto.Hash256(append([][]byte{{kind}}, data...)...))
+	return h
+}
+
+func createNodeKey(h types.Hash) []byte {
+	return append([]byte{nodeKeyPrefix}, h.Bytes()...)
+}
+
+func createValueKey(h types.Hash) []byte {
+	return append([]byte{valueKeyPrefix}, h.Bytes()...)
+}
+
+func createRootKey(height uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = rootKeyPrefix
+	binary.BigEndian.PutUint64(key[1:], height)
+	return key
+}
+
+func createLatestKey() []byte {
+	return []byte{latestKeyPrefix}
+}
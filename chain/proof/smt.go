@@ -0,0 +1,331 @@
+package chain_proof
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+)
+
+/*
+The state commitment is a compact sparse merkle tree over 256-bit keys.
+
+A leaf is stored at the highest position of the path of its key where it is alone in its subtree,
+an internal node always has at least two leaves below it, and an empty subtree hashes to the zero hash.
+So the tree of a key set is unique, whatever the order of updates is.
+
+	leaf hash     = hash(0x00 | key | hash(value))
+	internal hash = hash(0x01 | left | right)
+
+Nodes are addressed by their hash and shared by the roots, a node is deleted by the gc of StateProof
+only when it's unreachable from all the roots kept.
+*/
+
+const (
+	leafNode     = byte(0)
+	internalNode = byte(1)
+
+	nodeSize = 1 + 2*types.HashSize
+)
+
+var emptyHash = types.Hash{}
+
+type node struct {
+	kind byte
+	// key and value hash of a leaf, left and right children of an internal node
+	a, b types.Hash
+}
+
+func (n *node) hash() types.Hash {
+	h, _ := types.BytesToHash(crypto.Hash256(n.serialize()))
+	return h
+}
+
+func (n *node) serialize() []byte {
+	buf := make([]byte, 0, nodeSize)
+	buf = append(buf, n.kind)
+	buf = append(buf, n.a.Bytes()...)
+	return append(buf, n.b.Bytes()...)
+}
+
+func deserializeNode(buf []byte) (*node, error) {
+	if len(buf) != nodeSize || (buf[0] != leafNode && buf[0] != internalNode) {
+		return nil, errors.New("invalid merkle node")
+	}
+	n := &node{kind: buf[0]}
+	copy(n.a[:], buf[1:1+types.HashSize])
+	copy(n.b[:], buf[1+types.HashSize:])
+	return n, nil
+}
+
+func leafHash(key, valueHash types.Hash) types.Hash {
+	return (&node{kind: leafNode, a: key, b: valueHash}).hash()
+}
+
+func internalHash(left, right types.Hash) types.Hash {
+	return (&node{kind: internalNode, a: left, b: right}).hash()
+}
+
+func hashValue(value []byte) types.Hash {
+	h, _ := types.BytesToHash(crypto.Hash256(value))
+	return h
+}
+
+// bit returns the bit of key at depth, depth 0 is the most significant bit
+func bit(key types.Hash, depth int) byte {
+	return (key[depth/8] >> (7 - uint(depth%8))) & 1
+}
+
+// Leaf is the leaf met on the path of an absent key
+type Leaf struct {
+	Key       types.Hash
+	ValueHash types.Hash
+}
+
+// Proof proves the value of a key, or its absence, against a root.
+// Siblings are the sibling hashes on the path of the key from the root down,
+// the path ends at the leaf of the key, at an empty subtree or at Leaf of another key.
+type Proof struct {
+	Siblings []types.Hash
+	Leaf     *Leaf
+}
+
+// Verify checks that key has value under root, a nil value means key is absent.
+func (p *Proof) Verify(root types.Hash, key types.Hash, value []byte) error {
+	depth := len(p.Siblings)
+	if depth > 8*types.HashSize {
+		return errors.New("too many siblings")
+	}
+
+	var cur types.Hash
+	if value != nil {
+		if p.Leaf != nil {
+			return errors.New("proof of an existing key has no other leaf")
+		}
+		cur = leafHash(key, hashValue(value))
+	} else if p.Leaf != nil {
+		if p.Leaf.Key == key {
+			return errors.New("the leaf of an absent key can't have the same key")
+		}
+		for d := 0; d < depth; d++ {
+			if bit(p.Leaf.Key, d) != bit(key, d) {
+				return errors.New("the leaf isn't on the path of the key")
+			}
+		}
+		cur = leafHash(p.Leaf.Key, p.Leaf.ValueHash)
+	}
+
+	for d := depth - 1; d >= 0; d-- {
+		if bit(key, d) == 0 {
+			cur = internalHash(cur, p.Siblings[d])
+		} else {
+			cur = internalHash(p.Siblings[d], cur)
+		}
+	}
+	if cur != root {
+		return fmt.Errorf("root mismatch, expected %s, got %s", root, cur)
+	}
+	return nil
+}
+
+// tree reads nodes from db and keeps the nodes of the updates in memory until commit
+type tree struct {
+	db      *leveldb.DB
+	pending map[types.Hash][]byte
+	values  map[types.Hash][]byte
+}
+
+func newTree(db *leveldb.DB) *tree {
+	return &tree{
+		db:      db,
+		pending: make(map[types.Hash][]byte),
+		values:  make(map[types.Hash][]byte),
+	}
+}
+
+func (t *tree) getNode(h types.Hash) (*node, error) {
+	if buf, ok := t.pending[h]; ok {
+		return deserializeNode(buf)
+	}
+	buf, err := t.db.Get(createNodeKey(h), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, fmt.Errorf("merkle node %s is missing", h)
+		}
+		return nil, err
+	}
+	return deserializeNode(buf)
+}
+
+func (t *tree) putNode(n *node) types.Hash {
+	h := n.hash()
+	t.pending[h] = n.serialize()
+	return h
+}
+
+func (t *tree) putLeaf(key types.Hash, value []byte) types.Hash {
+	valueHash := hashValue(value)
+	t.values[valueHash] = value
+	return t.putNode(&node{kind: leafNode, a: key, b: valueHash})
+}
+
+// update sets the value of key in the subtree of root at depth and returns the new root of the subtree,
+// an empty value deletes the key.
+func (t *tree) update(root types.Hash, depth int, key types.Hash, value []byte) (types.Hash, error) {
+	if root == emptyHash {
+		if len(value) == 0 {
+			return emptyHash, nil
+		}
+		return t.putLeaf(key, value), nil
+	}
+
+	n, err := t.getNode(root)
+	if err != nil {
+		return emptyHash, err
+	}
+
+	if n.kind == leafNode {
+		if n.a == key {
+			if len(value) == 0 {
+				return emptyHash, nil
+			}
+			return t.putLeaf(key, value), nil
+		}
+		if len(value) == 0 {
+			return root, nil
+		}
+		return t.split(depth, root, n.a, t.putLeaf(key, value), key)
+	}
+
+	left, right := n.a, n.b
+	if bit(key, depth) == 0 {
+		if left, err = t.update(left, depth+1, key, value); err != nil {
+			return emptyHash, err
+		}
+	} else {
+		if right, err = t.update(right, depth+1, key, value); err != nil {
+			return emptyHash, err
+		}
+	}
+	return t.join(left, right)
+}
+
+// split builds the subtree at depth of two leaves of different keys
+func (t *tree) split(depth int, leaf1 types.Hash, key1 types.Hash, leaf2 types.Hash, key2 types.Hash) (types.Hash, error) {
+	if depth >= 8*types.HashSize {
+		return emptyHash, errors.New("keys collide")
+	}
+	b1, b2 := bit(key1, depth), bit(key2, depth)
+	if b1 != b2 {
+		if b1 == 0 {
+			return t.putNode(&node{kind: internalNode, a: leaf1, b: leaf2}), nil
+		}
+		return t.putNode(&node{kind: internalNode, a: leaf2, b: leaf1}), nil
+	}
+	child, err := t.split(depth+1, leaf1, key1, leaf2, key2)
+	if err != nil {
+		return emptyHash, err
+	}
+	if b1 == 0 {
+		return t.putNode(&node{kind: internalNode, a: child, b: emptyHash}), nil
+	}
+	return t.putNode(&node{kind: internalNode, a: emptyHash, b: child}), nil
+}
+
+// join builds an internal node, a lone leaf moves up in place of the node
+func (t *tree) join(left, right types.Hash) (types.Hash, error) {
+	if left == emptyHash && right == emptyHash {
+		return emptyHash, nil
+	}
+	if left == emptyHash || right == emptyHash {
+		child := left
+		if child == emptyHash {
+			child = right
+		}
+		n, err := t.getNode(child)
+		if err != nil {
+			return emptyHash, err
+		}
+		if n.kind == leafNode {
+			return child, nil
+		}
+	}
+	return t.putNode(&node{kind: internalNode, a: left, b: right}), nil
+}
+
+// prove returns the proof of key under root and the value of key, nil if key is absent
+func (t *tree) prove(root types.Hash, key types.Hash) (*Proof, []byte, error) {
+	proof := &Proof{}
+	cur := root
+	for depth := 0; cur != emptyHash; depth++ {
+		n, err := t.getNode(cur)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n.kind == leafNode {
+			if n.a != key {
+				proof.Leaf = &Leaf{Key: n.a, ValueHash: n.b}
+				return proof, nil, nil
+			}
+			value, err := t.getValue(n.b)
+			if err != nil {
+				return nil, nil, err
+			}
+			return proof, value, nil
+		}
+		if bit(key, depth) == 0 {
+			proof.Siblings = append(proof.Siblings, n.b)
+			cur = n.a
+		} else {
+			proof.Siblings = append(proof.Siblings, n.a)
+			cur = n.b
+		}
+	}
+	return proof, nil, nil
+}
+
+func (t *tree) getValue(valueHash types.Hash) ([]byte, error) {
+	if value, ok := t.values[valueHash]; ok {
+		return value, nil
+	}
+	value, err := t.db.Get(createValueKey(valueHash), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, fmt.Errorf("merkle value %s is missing", valueHash)
+		}
+		return nil, err
+	}
+	if !bytes.Equal(crypto.Hash256(value), valueHash.Bytes()) {
+		return nil, fmt.Errorf("merkle value %s is corrupted", valueHash)
+	}
+	return value, nil
+}
+
+// commit moves the pending nodes and values to batch
+func (t *tree) commit(batch *leveldb.Batch) {
+	for h, buf := range t.pending {
+		batch.Put(createNodeKey(h), buf)
+	}
+	for h, value := range t.values {
+		batch.Put(createValueKey(h), value)
+	}
+	t.pending = make(map[types.Hash][]byte)
+	t.values = make(map[types.Hash][]byte)
+}
+
+// hashes calls fn with the hash of every pending node and value
+func (t *tree) hashes(fn func(h types.Hash)) {
+	for h := range t.pending {
+		fn(h)
+	}
+	for h := range t.values {
+		fn(h)
+	}
+}
+
+func (t *tree) pendingSize() int {
+	return len(t.pending)
+}
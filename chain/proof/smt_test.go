@@ -0,0 +1,143 @@
+package chain_proof
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain/state"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/storage"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+func newMemDB(t *testing.T) *leveldb.DB {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.NilError(t, err)
+	return db
+}
+
+func testKeys(n int) map[types.Hash][]byte {
+	kvs := make(map[types.Hash][]byte, n)
+	for i := 0; i < n; i++ {
+		kvs[leafKey(storageLeaf, []byte(fmt.Sprintf("key%d", i)))] = []byte(fmt.Sprintf("value%d", i))
+	}
+	return kvs
+}
+
+// build updates a tree with kvs in the order of keys and commits it
+func build(t *testing.T, db *leveldb.DB, root types.Hash, keys []types.Hash, kvs map[types.Hash][]byte) types.Hash {
+	tr := newTree(db)
+	var err error
+	for _, key := range keys {
+		root, err = tr.update(root, 0, key, kvs[key])
+		assert.NilError(t, err)
+	}
+	batch := new(leveldb.Batch)
+	tr.commit(batch)
+	assert.NilError(t, db.Write(batch, nil))
+	return root
+}
+
+func TestTree_Canonical(t *testing.T) {
+	kvs := testKeys(200)
+	keys := make([]types.Hash, 0, len(kvs))
+	for key := range kvs {
+		keys = append(keys, key)
+	}
+
+	db := newMemDB(t)
+	defer db.Close()
+	root := build(t, db, emptyHash, keys, kvs)
+	assert.Assert(t, root != emptyHash)
+
+	// the root doesn't depend on the order of updates
+	rand.New(rand.NewSource(1)).Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	assert.Equal(t, root, build(t, db, emptyHash, keys, kvs))
+
+	// deleting the added keys restores the root
+	added := testKeys(250)
+	addedKeys := make([]types.Hash, 0, 50)
+	for key := range added {
+		if _, ok := kvs[key]; !ok {
+			addedKeys = append(addedKeys, key)
+		}
+	}
+	root2 := build(t, db, root, addedKeys, added)
+	assert.Assert(t, root2 != root)
+	assert.Equal(t, root, build(t, db, root2, addedKeys, map[types.Hash][]byte{}))
+
+	// deleting all keys empties the tree
+	assert.Equal(t, emptyHash, build(t, db, root, keys, map[types.Hash][]byte{}))
+}
+
+func TestTree_Prove(t *testing.T) {
+	kvs := testKeys(100)
+	keys := make([]types.Hash, 0, len(kvs))
+	for key := range kvs {
+		keys = append(keys, key)
+	}
+
+	db := newMemDB(t)
+	defer db.Close()
+	root := build(t, db, emptyHash, keys, kvs)
+	tr := newTree(db)
+
+	for key, value := range kvs {
+		proof, got, err := tr.prove(root, key)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, value, got)
+		assert.NilError(t, proof.Verify(root, key, value))
+		assert.Assert(t, proof.Verify(root, key, []byte("other")) != nil)
+		assert.Assert(t, proof.Verify(root, key, nil) != nil)
+	}
+
+	for key := range testKeys(150) {
+		if _, ok := kvs[key]; ok {
+			continue
+		}
+		proof, got, err := tr.prove(root, key)
+		assert.NilError(t, err)
+		assert.Assert(t, got == nil)
+		assert.NilError(t, proof.Verify(root, key, nil))
+		assert.Assert(t, proof.Verify(root, key, []byte("value")) != nil)
+
+		if len(proof.Siblings) > 0 {
+			proof.Siblings[0][0] ^= 1
+			assert.Assert(t, proof.Verify(root, key, nil) != nil)
+		}
+	}
+
+	// the empty tree
+	proof, got, err := tr.prove(emptyHash, keys[0])
+	assert.NilError(t, err)
+	assert.Assert(t, got == nil)
+	assert.NilError(t, proof.Verify(emptyHash, keys[0], nil))
+}
+
+func TestLogChanges(t *testing.T) {
+	addr := types.AddressGovernance
+	snapshotLog := chain_state.SnapshotLog{
+		addr: {
+			{
+				Storage:    [][2][]byte{{[]byte("k1"), []byte("v1")}, {[]byte("k2"), []byte("v2")}},
+				BalanceMap: map[types.TokenTypeId]*big.Int{ledger.ViteTokenId: big.NewInt(10)},
+				Code:       []byte("code"),
+			},
+			{
+				Storage:    [][2][]byte{{[]byte("k1"), nil}},
+				BalanceMap: map[types.TokenTypeId]*big.Int{ledger.ViteTokenId: big.NewInt(0)},
+			},
+		},
+	}
+
+	changes := logChanges(snapshotLog)
+	assert.Equal(t, 4, len(changes))
+	assert.Equal(t, 0, len(changes[StorageKey(addr, []byte("k1"))]))
+	assert.DeepEqual(t, []byte("v2"), changes[StorageKey(addr, []byte("k2"))])
+	assert.Equal(t, 0, len(changes[BalanceKey(addr, ledger.ViteTokenId)]))
+	assert.DeepEqual(t, []byte("code"), changes[CodeKey(addr)])
+}
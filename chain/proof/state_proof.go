@@ -0,0 +1,753 @@
+package chain_proof
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"path"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/state"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	// flush the pending nodes of a bootstrap every flushSize nodes
+	flushSize = 100000
+	// roots of the latest retainRoots snapshot blocks are kept, like the redo logs of the state db
+	retainRoots = 1200
+	// nodes and values unreachable from the kept roots are collected every gcInterval snapshot blocks
+	gcInterval = 10000
+)
+
+// ErrNotReady is returned by proofs while the commitment is built in the background
+var ErrNotReady = errors.New("state commitment is being built, not ready")
+
+var errStopped = errors.New("state proof is closed")
+
+type Chain interface {
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSnapshotHeightByHash(hash types.Hash) (uint64, error)
+	GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error)
+	GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error)
+	GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error)
+}
+
+type StateDB interface {
+	Store() *chain_db.Store
+	Redo() chain_state.RedoInterface
+}
+
+// KeyProof is the value of a leaf key at a snapshot block and its proof against the state root of the block
+type KeyProof struct {
+	SnapshotHash   types.Hash
+	SnapshotHeight uint64
+	Root           types.Hash
+	Key            types.Hash
+	Value          []byte // nil if the key is absent
+	Proof          *Proof
+}
+
+// StateProof is a local index of the state commitment of every snapshot block, it isn't part of consensus.
+// The commitment of a snapshot block is the commitment of the previous block updated by the redo log of the block.
+// When the redo logs are missing, e.g. the index is enabled on an existing ledger, the commitment is built
+// in the background from the history of balances and storage at the latest snapshot block, and older blocks
+// have no commitment. Only the roots of the latest retainRoots snapshot blocks are kept, the nodes no longer
+// reachable from them are collected in the background.
+type StateProof struct {
+	chain Chain
+	state StateDB
+	db    *leveldb.DB
+
+	// latest indexed snapshot height, 0 means the index is empty and rebuilt by the next snapshot block
+	latest uint64
+	// building is true while the commitment is built in the background
+	building bool
+	// collecting is true while a gc runs, written holds the nodes and values written since it started
+	collecting bool
+	written    map[types.Hash]struct{}
+	sinceGc    uint64
+	mu         sync.RWMutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	log log15.Logger
+}
+
+func NewStateProof(chainDir string, chain Chain, state StateDB) (*StateProof, error) {
+	db, err := leveldb.OpenFile(path.Join(chainDir, "state_proof"), nil)
+	if err != nil {
+		return nil, err
+	}
+	return newStateProof(chain, state, db), nil
+}
+
+func newStateProof(chain Chain, state StateDB, db *leveldb.DB) *StateProof {
+	return &StateProof{
+		chain: chain,
+		state: state,
+		db:    db,
+		stop:  make(chan struct{}),
+		log:   log15.New("module", "chain_proof"),
+	}
+}
+
+// Init catches up with the latest snapshot block, or starts to build the commitment of it in the background.
+func (sp *StateProof) Init() error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	value, err := sp.db.Get(createLatestKey(), nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	if len(value) == 8 {
+		sp.latest = binary.BigEndian.Uint64(value)
+	}
+
+	latestBlock := sp.chain.GetLatestSnapshotBlock()
+	if latestBlock == nil {
+		return errors.New("latest snapshot block is nil")
+	}
+
+	if sp.latest > latestBlock.Height {
+		if err := sp.truncate(latestBlock.Height); err != nil {
+			return err
+		}
+	}
+	if sp.latest > 0 {
+		// the ledger may be replaced or rolled back while the index was closed
+		block, err := sp.chain.GetSnapshotHeaderByHeight(sp.latest)
+		if err != nil {
+			return err
+		}
+		_, hash, err := sp.getRoot(sp.latest)
+		if err != nil {
+			return err
+		}
+		if block == nil || hash == nil || *hash != block.Hash {
+			sp.log.Warn(fmt.Sprintf("state commitment of %d differs from the ledger, rebuild it", sp.latest))
+			sp.latest = 0
+		}
+	}
+
+	for sp.latest > 0 && sp.latest < latestBlock.Height {
+		block, err := sp.chain.GetSnapshotHeaderByHeight(sp.latest + 1)
+		if err != nil {
+			return err
+		}
+		if ok, err := sp.insert(block); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+	}
+	if sp.latest != latestBlock.Height {
+		return sp.startBootstrap(latestBlock)
+	}
+	return nil
+}
+
+// Close stops the background bootstrap and gc, and closes the db of the index.
+func (sp *StateProof) Close() error {
+	close(sp.stop)
+	sp.wg.Wait()
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	return sp.db.Close()
+}
+
+// Latest returns the latest indexed snapshot height.
+func (sp *StateProof) Latest() uint64 {
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+
+	return sp.latest
+}
+
+// Root returns the state root of the snapshot block.
+func (sp *StateProof) Root(snapshotHash types.Hash) (types.Hash, uint64, error) {
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+
+	return sp.root(snapshotHash)
+}
+
+// GetProof returns the value of the leaf key at the snapshot block and its proof.
+func (sp *StateProof) GetProof(snapshotHash types.Hash, key types.Hash) (*KeyProof, error) {
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+
+	root, height, err := sp.root(snapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	proof, value, err := newTree(sp.db).prove(root, key)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyProof{
+		SnapshotHash:   snapshotHash,
+		SnapshotHeight: height,
+		Root:           root,
+		Key:            key,
+		Value:          value,
+		Proof:          proof,
+	}, nil
+}
+
+func (sp *StateProof) root(snapshotHash types.Hash) (types.Hash, uint64, error) {
+	if sp.building {
+		return emptyHash, 0, ErrNotReady
+	}
+	height, err := sp.chain.GetSnapshotHeightByHash(snapshotHash)
+	if err != nil {
+		return emptyHash, 0, err
+	}
+	if height == 0 {
+		return emptyHash, 0, fmt.Errorf("snapshot block %s doesn't exist", snapshotHash)
+	}
+	root, hash, err := sp.getRoot(height)
+	if err != nil {
+		return emptyHash, 0, err
+	}
+	if hash == nil || *hash != snapshotHash || height > sp.latest {
+		return emptyHash, 0, fmt.Errorf("state commitment of snapshot block %s is not indexed", snapshotHash)
+	}
+	return root, height, nil
+}
+
+func (sp *StateProof) getRoot(height uint64) (types.Hash, *types.Hash, error) {
+	value, err := sp.db.Get(createRootKey(height), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return emptyHash, nil, nil
+		}
+		return emptyHash, nil, err
+	}
+	if len(value) != 2*types.HashSize {
+		return emptyHash, nil, fmt.Errorf("invalid state root of %d", height)
+	}
+	root, _ := types.BytesToHash(value[:types.HashSize])
+	hash, _ := types.BytesToHash(value[types.HashSize:])
+	return root, &hash, nil
+}
+
+func (sp *StateProof) putRoot(batch *leveldb.Batch, block *ledger.SnapshotBlock, root types.Hash) {
+	batch.Put(createRootKey(block.Height), append(root.Bytes(), block.Hash.Bytes()...))
+
+	latest := make([]byte, 8)
+	binary.BigEndian.PutUint64(latest, block.Height)
+	batch.Put(createLatestKey(), latest)
+}
+
+// insert applies the redo log of block to the commitment of the previous block,
+// it returns false if the redo log or the root of the previous block is missing.
+func (sp *StateProof) insert(block *ledger.SnapshotBlock) (bool, error) {
+	snapshotLog, ok, err := sp.state.Redo().QueryLog(block.Height)
+	if err != nil || !ok {
+		return false, err
+	}
+	root, hash, err := sp.getRoot(block.Height - 1)
+	if err != nil || hash == nil {
+		return false, err
+	}
+
+	t := newTree(sp.db)
+	for key, value := range logChanges(snapshotLog) {
+		if root, err = t.update(root, 0, key, value); err != nil {
+			return false, err
+		}
+	}
+
+	batch := new(leveldb.Batch)
+	sp.putRoot(batch, block, root)
+	if block.Height > retainRoots {
+		batch.Delete(createRootKey(block.Height - retainRoots))
+	}
+	if err := sp.commit(t, batch); err != nil {
+		return false, err
+	}
+	sp.latest = block.Height
+
+	if sp.sinceGc++; sp.sinceGc >= gcInterval && !sp.collecting {
+		sp.startGc()
+	}
+	return true, nil
+}
+
+// commit writes the pending nodes and values of t with batch, a running gc keeps them. mu is held.
+func (sp *StateProof) commit(t *tree, batch *leveldb.Batch) error {
+	if sp.collecting {
+		t.hashes(func(h types.Hash) {
+			sp.written[h] = struct{}{}
+		})
+	}
+	t.commit(batch)
+	return sp.db.Write(batch, nil)
+}
+
+// logChanges merges the redo log of a snapshot block to the final values of leaf keys, empty values are deletions
+func logChanges(snapshotLog chain_state.SnapshotLog) map[types.Hash][]byte {
+	changes := make(map[types.Hash][]byte)
+	for addr, logItems := range snapshotLog {
+		for _, item := range logItems {
+			for _, kv := range item.Storage {
+				changes[StorageKey(addr, kv[0])] = kv[1]
+			}
+			for tokenId, balance := range item.BalanceMap {
+				changes[BalanceKey(addr, tokenId)] = balance.Bytes()
+			}
+			if len(item.Code) > 0 {
+				changes[CodeKey(addr)] = item.Code
+			}
+		}
+	}
+	return changes
+}
+
+// startBootstrap starts to build the commitment of block in the background, proofs are not ready and
+// snapshot blocks aren't indexed until it's done. mu is held.
+func (sp *StateProof) startBootstrap(block *ledger.SnapshotBlock) error {
+	if sp.building {
+		return nil
+	}
+	if err := sp.truncate(0); err != nil {
+		return err
+	}
+	sp.building = true
+	sp.wg.Add(1)
+	go func() {
+		defer sp.wg.Done()
+
+		root, t, err := sp.bootstrap(block)
+
+		sp.mu.Lock()
+		defer sp.mu.Unlock()
+		sp.building = false
+		if err == nil {
+			err = sp.finishBootstrap(block, root, t)
+		}
+		if err != nil && err != errStopped {
+			sp.log.Error(fmt.Sprintf("build state commitment of %d failed. Error: %s", block.Height, err), "method", "bootstrap")
+		}
+	}()
+	return nil
+}
+
+// finishBootstrap saves the root of block and catches up with the snapshot blocks inserted meanwhile. mu is held.
+func (sp *StateProof) finishBootstrap(block *ledger.SnapshotBlock, root types.Hash, t *tree) error {
+	// block may be rolled back meanwhile, the next snapshot block starts a new bootstrap
+	current, err := sp.chain.GetSnapshotHeaderByHeight(block.Height)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.Hash != block.Hash {
+		sp.log.Warn(fmt.Sprintf("snapshot block %d %s is rolled back while its state commitment is built", block.Height, block.Hash))
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	sp.putRoot(batch, block, root)
+	if err := sp.commit(t, batch); err != nil {
+		return err
+	}
+	sp.latest = block.Height
+	sp.log.Info(fmt.Sprintf("state commitment of %d is %s", block.Height, root))
+
+	latestBlock := sp.chain.GetLatestSnapshotBlock()
+	for latestBlock != nil && sp.latest < latestBlock.Height {
+		next, err := sp.chain.GetSnapshotHeaderByHeight(sp.latest + 1)
+		if err != nil || next == nil {
+			return err
+		}
+		if ok, err := sp.insert(next); err != nil || !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// bootstrap builds the commitment of block from the history of balances, storage and code in the state db,
+// it returns the root and the tree holding the nodes not flushed yet. History at or below the height of block
+// doesn't change, so mu isn't held but to flush nodes.
+func (sp *StateProof) bootstrap(block *ledger.SnapshotBlock) (types.Hash, *tree, error) {
+	sp.log.Info(fmt.Sprintf("build state commitment of %d %s", block.Height, block.Hash))
+
+	store := sp.state.Store()
+	t := newTree(sp.db)
+	root := emptyHash
+	set := func(key types.Hash, value []byte) error {
+		select {
+		case <-sp.stop:
+			return errStopped
+		default:
+		}
+		var err error
+		if root, err = t.update(root, 0, key, value); err != nil {
+			return err
+		}
+		if t.pendingSize() >= flushSize {
+			sp.mu.Lock()
+			defer sp.mu.Unlock()
+			return sp.commit(t, new(leveldb.Batch))
+		}
+		return nil
+	}
+
+	// key: prefix, address, token id, snapshot height
+	if err := iterateHistory(store, chain_utils.BalanceHistoryKeyPrefix, block.Height, func(key, value []byte) error {
+		addr, err := types.BytesToAddress(key[1 : 1+types.AddressSize])
+		if err != nil {
+			return err
+		}
+		tokenId, err := types.BytesToTokenTypeId(key[1+types.AddressSize : 1+types.AddressSize+types.TokenTypeIdSize])
+		if err != nil {
+			return err
+		}
+		return set(BalanceKey(addr, tokenId), new(big.Int).SetBytes(value).Bytes())
+	}); err != nil {
+		return emptyHash, nil, err
+	}
+
+	// key: prefix, address, right padded storage key, length of storage key, snapshot height
+	if err := iterateHistory(store, chain_utils.StorageHistoryKeyPrefix, block.Height, func(key, value []byte) error {
+		addr, err := types.BytesToAddress(key[1 : 1+types.AddressSize])
+		if err != nil {
+			return err
+		}
+		keyLen := int(key[1+types.AddressSize+types.HashSize])
+		if keyLen > types.HashSize {
+			return fmt.Errorf("invalid storage history key %x", key)
+		}
+		storageKey := key[1+types.AddressSize : 1+types.AddressSize+keyLen]
+		return set(StorageKey(addr, storageKey), value)
+	}); err != nil {
+		return emptyHash, nil, err
+	}
+
+	// code is never changed, it is in the commitment if the contract is created before block
+	iter := store.NewIterator(util.BytesPrefix([]byte{chain_utils.CodeKeyPrefix}))
+	defer iter.Release()
+	for iter.Next() {
+		addr, err := types.BytesToAddress(iter.Key()[1:])
+		if err != nil {
+			return emptyHash, nil, err
+		}
+		confirmed, err := sp.createdBefore(addr, block.Height)
+		if err != nil {
+			return emptyHash, nil, err
+		}
+		if confirmed {
+			if err := set(CodeKey(addr), append([]byte{}, iter.Value()...)); err != nil {
+				return emptyHash, nil, err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return emptyHash, nil, err
+	}
+	return root, t, nil
+}
+
+// startGc collects in the background the nodes and values unreachable from the roots kept. mu is held,
+// so the snapshot of the db has every node written before and written has every node written after it.
+func (sp *StateProof) startGc() {
+	snapshot, err := sp.db.GetSnapshot()
+	if err != nil {
+		sp.log.Error(fmt.Sprintf("get snapshot of state commitment failed. Error: %s", err), "method", "gc")
+		return
+	}
+	sp.collecting = true
+	sp.written = make(map[types.Hash]struct{})
+	sp.sinceGc = 0
+	latest := sp.latest
+
+	sp.wg.Add(1)
+	go func() {
+		defer sp.wg.Done()
+		defer snapshot.Release()
+
+		deleted, err := sp.gc(snapshot, latest)
+
+		sp.mu.Lock()
+		sp.collecting = false
+		sp.written = nil
+		sp.mu.Unlock()
+		if err != nil && err != errStopped {
+			sp.log.Error(fmt.Sprintf("gc of state commitment failed. Error: %s", err), "method", "gc")
+			return
+		}
+		sp.log.Info(fmt.Sprintf("gc of state commitment deleted %d nodes and values", deleted))
+	}()
+}
+
+// gc marks the nodes and values reachable from the roots of the latest retainRoots snapshot blocks in snapshot,
+// then deletes the others but those written after snapshot. The marks are kept in memory, so a gc costs about
+// the size of a single state commitment.
+func (sp *StateProof) gc(snapshot *leveldb.Snapshot, latest uint64) (int, error) {
+	marked := make(map[types.Hash]struct{})
+	var expired [][]byte
+
+	iter := snapshot.NewIterator(util.BytesPrefix([]byte{rootKeyPrefix}), nil)
+	for iter.Next() {
+		if binary.BigEndian.Uint64(iter.Key()[1:])+retainRoots <= latest {
+			expired = append(expired, append([]byte{}, iter.Key()...))
+			continue
+		}
+		root, _ := types.BytesToHash(iter.Value()[:types.HashSize])
+		if err := sp.mark(snapshot, root, marked); err != nil {
+			iter.Release()
+			return 0, err
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	sweep := func(keys [][]byte) error {
+		sp.mu.Lock()
+		defer sp.mu.Unlock()
+
+		batch := new(leveldb.Batch)
+		for _, key := range keys {
+			if key[0] != rootKeyPrefix {
+				h, _ := types.BytesToHash(key[1:])
+				if _, ok := sp.written[h]; ok {
+					continue
+				}
+			}
+			batch.Delete(key)
+			deleted++
+		}
+		return sp.db.Write(batch, nil)
+	}
+	if err := sweep(expired); err != nil {
+		return 0, err
+	}
+
+	for _, prefix := range []byte{nodeKeyPrefix, valueKeyPrefix} {
+		var keys [][]byte
+		iter := snapshot.NewIterator(util.BytesPrefix([]byte{prefix}), nil)
+		for iter.Next() {
+			h, _ := types.BytesToHash(iter.Key()[1:])
+			if _, ok := marked[h]; ok {
+				continue
+			}
+			if keys = append(keys, append([]byte{}, iter.Key()...)); len(keys) >= flushSize {
+				if err := sweep(keys); err != nil {
+					iter.Release()
+					return deleted, err
+				}
+				keys = keys[:0]
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return deleted, err
+		}
+		if err := sweep(keys); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// mark adds the nodes and values of the tree of root in snapshot to marked, marked subtrees are skipped
+func (sp *StateProof) mark(snapshot *leveldb.Snapshot, root types.Hash, marked map[types.Hash]struct{}) error {
+	stack := []types.Hash{root}
+	for len(stack) > 0 {
+		select {
+		case <-sp.stop:
+			return errStopped
+		default:
+		}
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := marked[h]; ok || h == emptyHash {
+			continue
+		}
+		marked[h] = struct{}{}
+
+		buf, err := snapshot.Get(createNodeKey(h), nil)
+		if err != nil {
+			return err
+		}
+		n, err := deserializeNode(buf)
+		if err != nil {
+			return err
+		}
+		if n.kind == leafNode {
+			marked[n.b] = struct{}{}
+		} else {
+			stack = append(stack, n.a, n.b)
+		}
+	}
+	return nil
+}
+
+func (sp *StateProof) createdBefore(addr types.Address, height uint64) (bool, error) {
+	first, err := sp.chain.GetAccountBlockByHeight(addr, 1)
+	if err != nil || first == nil {
+		return false, err
+	}
+	confirm, err := sp.chain.GetConfirmSnapshotHeaderByAbHash(first.Hash)
+	if err != nil || confirm == nil {
+		return false, err
+	}
+	return confirm.Height <= height, nil
+}
+
+// iterateHistory calls fn with the latest value at or before height of every history key prefixed by prefix,
+// history keys end with the big-endian snapshot height. Empty values are skipped.
+func iterateHistory(store *chain_db.Store, prefix byte, height uint64, fn func(key, value []byte) error) error {
+	iter := store.NewIterator(util.BytesPrefix([]byte{prefix}))
+	defer iter.Release()
+
+	var lastKey, lastValue []byte
+	emit := func() error {
+		if lastKey == nil || len(lastValue) == 0 {
+			return nil
+		}
+		return fn(lastKey, lastValue)
+	}
+
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) < 9 {
+			continue
+		}
+		group := key[:len(key)-8]
+		if lastKey != nil && string(group) != string(lastKey[:len(lastKey)-8]) {
+			if err := emit(); err != nil {
+				return err
+			}
+			lastKey, lastValue = nil, nil
+		}
+		if binary.BigEndian.Uint64(key[len(key)-8:]) <= height {
+			lastKey = append([]byte{}, key...)
+			lastValue = append([]byte{}, iter.Value()...)
+		}
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	return emit()
+}
+
+// truncate deletes the roots after height, nodes are kept since they may be shared by the remaining roots
+func (sp *StateProof) truncate(height uint64) error {
+	batch := new(leveldb.Batch)
+	iter := sp.db.NewIterator(&util.Range{Start: createRootKey(height + 1), Limit: []byte{rootKeyPrefix + 1}}, nil)
+	for iter.Next() {
+		batch.Delete(append([]byte{}, iter.Key()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	_, hash, err := sp.getRoot(height)
+	if err != nil {
+		return err
+	}
+	if hash == nil {
+		height = 0
+	}
+	latest := make([]byte, 8)
+	binary.BigEndian.PutUint64(latest, height)
+	batch.Put(createLatestKey(), latest)
+	if err := sp.db.Write(batch, nil); err != nil {
+		return err
+	}
+	sp.latest = height
+	return nil
+}
+
+func (sp *StateProof) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (sp *StateProof) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (sp *StateProof) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (sp *StateProof) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	// the bootstrap catches up with the blocks when it's done
+	if sp.building {
+		return nil
+	}
+	for _, chunk := range chunks {
+		block := chunk.SnapshotBlock
+		if block == nil || block.Height <= sp.latest {
+			continue
+		}
+		ok := false
+		if sp.latest > 0 && block.Height == sp.latest+1 {
+			var err error
+			if ok, err = sp.insert(block); err != nil {
+				sp.log.Error(fmt.Sprintf("insert state commitment of %d failed, rebuild it. Error: %s", block.Height, err), "method", "InsertSnapshotBlocks")
+			}
+		}
+		if !ok {
+			if err := sp.startBootstrap(block); err != nil {
+				sp.log.Error(fmt.Sprintf("build state commitment of %d failed. Error: %s", block.Height, err), "method", "InsertSnapshotBlocks")
+				sp.latest = 0
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func (sp *StateProof) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (sp *StateProof) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (sp *StateProof) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (sp *StateProof) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	var lowest uint64
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil && (lowest == 0 || chunk.SnapshotBlock.Height < lowest) {
+			lowest = chunk.SnapshotBlock.Height
+		}
+	}
+	if lowest == 0 || lowest > sp.latest {
+		return nil
+	}
+	if err := sp.truncate(lowest - 1); err != nil {
+		sp.log.Error(fmt.Sprintf("delete state commitment after %d failed. Error: %s", lowest-1, err), "method", "DeleteSnapshotBlocks")
+		sp.latest = 0
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package chain_proof
+
+import (
+	"math/big"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/state"
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+type mockChain struct {
+	mu     sync.RWMutex
+	blocks []*ledger.SnapshotBlock // blocks[i] is the block of height i+1
+}
+
+func (c *mockChain) append(salt byte) *ledger.SnapshotBlock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	height := uint64(len(c.blocks) + 1)
+	block := &ledger.SnapshotBlock{Height: height, Hash: types.DataHash([]byte{byte(height), salt})}
+	c.blocks = append(c.blocks, block)
+	return block
+}
+
+func (c *mockChain) rollback(height uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blocks = c.blocks[:height]
+}
+
+func (c *mockChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *mockChain) GetSnapshotHeightByHash(hash types.Hash) (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, block := range c.blocks {
+		if block.Hash == hash {
+			return block.Height, nil
+		}
+	}
+	return 0, nil
+}
+
+func (c *mockChain) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if height == 0 || height > uint64(len(c.blocks)) {
+		return nil, nil
+	}
+	return c.blocks[height-1], nil
+}
+
+func (c *mockChain) GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *mockChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	return nil, nil
+}
+
+type mockRedo struct {
+	chain_state.RedoInterface
+	mu   sync.Mutex
+	logs map[uint64]chain_state.SnapshotLog
+}
+
+func (r *mockRedo) QueryLog(snapshotHeight uint64) (chain_state.SnapshotLog, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	log, ok := r.logs[snapshotHeight]
+	return log, ok, nil
+}
+
+type mockState struct {
+	store *chain_db.Store
+	redo  *mockRedo
+}
+
+func (s *mockState) Store() *chain_db.Store {
+	return s.store
+}
+
+func (s *mockState) Redo() chain_state.RedoInterface {
+	return s.redo
+}
+
+type stateProofEnv struct {
+	chain *mockChain
+	state *mockState
+	addr  types.Address
+}
+
+// setBalance writes the balance of the snapshot block of height to the history and to its redo log
+func (env *stateProofEnv) setBalance(height uint64, balance int64, withRedo bool) {
+	batch := new(leveldb.Batch)
+	batch.Put(chain_utils.CreateHistoryBalanceKey(env.addr, ledger.ViteTokenId, height), big.NewInt(balance).Bytes())
+	env.state.store.WriteDirectly(batch)
+	if withRedo {
+		env.state.redo.mu.Lock()
+		env.state.redo.logs[height] = chain_state.SnapshotLog{
+			env.addr: {{BalanceMap: map[types.TokenTypeId]*big.Int{ledger.ViteTokenId: big.NewInt(balance)}}},
+		}
+		env.state.redo.mu.Unlock()
+	}
+}
+
+func newStateProofEnv(t *testing.T, dir string) *stateProofEnv {
+	store, err := chain_db.NewStore(dir, "test_state_proof")
+	assert.NilError(t, err)
+	return &stateProofEnv{
+		chain: &mockChain{},
+		state: &mockState{store: store, redo: &mockRedo{logs: make(map[uint64]chain_state.SnapshotLog)}},
+		addr:  types.AddressGovernance,
+	}
+}
+
+func waitReady(t *testing.T, sp *StateProof) {
+	for i := 0; i < 500; i++ {
+		sp.mu.RLock()
+		building := sp.building
+		sp.mu.RUnlock()
+		if !building {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("bootstrap isn't done")
+}
+
+func checkBalance(t *testing.T, sp *StateProof, env *stateProofEnv, block *ledger.SnapshotBlock, balance int64) {
+	key := BalanceKey(env.addr, ledger.ViteTokenId)
+	p, err := sp.GetProof(block.Hash, key)
+	assert.NilError(t, err)
+	assert.Equal(t, block.Height, p.SnapshotHeight)
+	assert.DeepEqual(t, big.NewInt(balance).Bytes(), p.Value)
+	assert.NilError(t, p.Proof.Verify(p.Root, key, p.Value))
+}
+
+func countNodes(db *leveldb.DB) int {
+	iter := db.NewIterator(util.BytesPrefix([]byte{nodeKeyPrefix}), nil)
+	defer iter.Release()
+	count := 0
+	for iter.Next() {
+		count++
+	}
+	return count
+}
+
+func TestStateProof(t *testing.T) {
+	dir := path.Join(test_tools.DefaultDataDir(), "test_state_proof")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	env := newStateProofEnv(t, dir)
+	defer env.state.store.Close()
+	for height := uint64(1); height <= 3; height++ {
+		env.chain.append(0)
+		env.setBalance(height, int64(height*10), false)
+	}
+	block3, _ := env.chain.GetSnapshotHeaderByHeight(3)
+
+	db := newMemDB(t)
+	sp := newStateProof(env.chain, env.state, db)
+
+	// the index is empty, the commitment of the latest block is built in the background as Init does
+	sp.mu.Lock()
+	assert.NilError(t, sp.startBootstrap(block3))
+	assert.Assert(t, sp.building)
+	_, _, err := sp.root(block3.Hash)
+	assert.Equal(t, ErrNotReady, err)
+	sp.mu.Unlock()
+	waitReady(t, sp)
+	assert.Equal(t, uint64(3), sp.Latest())
+	checkBalance(t, sp, env, block3, 30)
+
+	// the redo log of a block is applied to the commitment of the previous one
+	block4 := env.chain.append(0)
+	env.setBalance(4, 40, true)
+	assert.NilError(t, sp.InsertSnapshotBlocks([]*ledger.SnapshotChunk{{SnapshotBlock: block4}}))
+	assert.Equal(t, uint64(4), sp.Latest())
+	checkBalance(t, sp, env, block3, 30)
+	checkBalance(t, sp, env, block4, 40)
+
+	// a block without redo log is bootstrapped
+	block5 := env.chain.append(0)
+	env.setBalance(5, 50, false)
+	assert.NilError(t, sp.InsertSnapshotBlocks([]*ledger.SnapshotChunk{{SnapshotBlock: block5}}))
+	waitReady(t, sp)
+	assert.Equal(t, uint64(5), sp.Latest())
+	checkBalance(t, sp, env, block5, 50)
+	_, err = sp.GetProof(block4.Hash, BalanceKey(env.addr, ledger.ViteTokenId))
+	assert.Assert(t, err != nil)
+
+	// deleting a snapshot block truncates the roots after the previous block
+	block6 := env.chain.append(0)
+	env.setBalance(6, 60, true)
+	assert.NilError(t, sp.InsertSnapshotBlocks([]*ledger.SnapshotChunk{{SnapshotBlock: block6}}))
+	assert.Equal(t, uint64(6), sp.Latest())
+	nodes := countNodes(db)
+	assert.NilError(t, sp.DeleteSnapshotBlocks([]*ledger.SnapshotChunk{{SnapshotBlock: block6}}))
+	env.chain.rollback(5)
+	assert.Equal(t, uint64(5), sp.Latest())
+	_, _, err = sp.Root(block6.Hash)
+	assert.Assert(t, err != nil)
+	checkBalance(t, sp, env, block5, 50)
+
+	// the gc deletes the nodes of the deleted root and keeps the others
+	sp.mu.Lock()
+	sp.startGc()
+	sp.mu.Unlock()
+	sp.wg.Wait()
+	assert.Assert(t, countNodes(db) < nodes)
+	checkBalance(t, sp, env, block5, 50)
+
+	// Init catches up with the redo logs of the blocks inserted while the index is closed
+	block6 = env.chain.append(1)
+	env.setBalance(6, 61, true)
+	sp = newStateProof(env.chain, env.state, db)
+	assert.NilError(t, sp.Init())
+	assert.Assert(t, !sp.building)
+	assert.Equal(t, uint64(6), sp.Latest())
+	checkBalance(t, sp, env, block6, 61)
+	assert.NilError(t, sp.Close())
+}
@@ -136,6 +136,10 @@ func (c *chain) GetGlobalQuota() types.QuotaInfo {
 	return c.cache.GetGlobalQuota()
 }
 
+func (c *chain) GetGlobalQuotaList(count int) []types.QuotaInfo {
+	return c.cache.GetGlobalQuotaList(count)
+}
+
 func (c *chain) GetQuotaUsedList(address types.Address) []types.QuotaInfo {
 	//return c.cache.GetQuotaUsedList(&address)
 	return c.cache.GetQuotaUsedList(address)
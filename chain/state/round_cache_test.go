@@ -11,7 +11,9 @@ import (
 	"github.com/vitelabs/go-vite/common/db/xleveldb/errors"
 	"github.com/vitelabs/go-vite/common/db/xleveldb/memdb"
 	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config/gen"
 	"github.com/vitelabs/go-vite/consensus/core"
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/ledger"
@@ -71,7 +73,7 @@ func (mockData *MockData) Add(appendAddrList []types.Address, snapshotCount int)
 			Height:    h,
 			Timestamp: &currentTime,
 		}
-		snapshotHeader.Hash = snapshotHeader.ComputeHash()
+		snapshotHeader.Hash = snapshotHeader.ComputeHash(fork.NewSchedule(config_gen.MakeGenesisConfig("").ForkPoints))
 
 		newMockSnapshot := MockSnapshot{
 			SnapshotHeader: snapshotHeader,
@@ -3,7 +3,6 @@ package chain
 import (
 	"fmt"
 	"github.com/pkg/errors"
-	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm/quota"
@@ -48,7 +47,7 @@ func (c *chain) filterUnconfirmedBlocks(snapshotBlock *ledger.SnapshotBlock, che
 		return nil
 	}
 	// check is active fork point
-	if fork.IsActiveForkPoint(snapshotBlock.Height) {
+	if c.forks.IsActiveForkPoint(snapshotBlock.Height) {
 		return blocks
 	}
 
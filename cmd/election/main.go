@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/pool/lock"
+	"github.com/vitelabs/go-vite/vm/quota"
+)
+
+func newChain(dirName string, genesis string) (chain.Chain, error) {
+	quota.InitQuotaConfig(false, true)
+	genesisConfig := &config.Genesis{}
+	if err := json.Unmarshal([]byte(genesis), genesisConfig); err != nil {
+		return nil, err
+	}
+
+	chainInstance := chain.NewChain(dirName, &config.Chain{}, genesisConfig)
+
+	if err := chainInstance.Init(); err != nil {
+		return nil, err
+	}
+	if err := chainInstance.Start(); err != nil {
+		return nil, err
+	}
+	return chainInstance, nil
+}
+
+var (
+	dataDir     = flag.String("dir", "devdata", "ledger dir")
+	genesisFile = flag.String("genesis", "genesis.json", "genesis file")
+	index       = flag.Int64("index", -1, "consensus index, the next period if negative")
+	votes       = flag.String("votes", "", "hypothetical votes, e.g. SBP1=1000000000000000000000,SBP2=0")
+)
+
+func parseOverrides(str string) (map[string]*big.Int, error) {
+	result := make(map[string]*big.Int)
+	if len(str) == 0 {
+		return result, nil
+	}
+	for _, item := range strings.Split(str, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid vote override %s", item)
+		}
+		amount, ok := new(big.Int).SetString(strings.TrimSpace(kv[1]), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid votes %s", kv[1])
+		}
+		result[strings.TrimSpace(kv[0])] = amount
+	}
+	return result, nil
+}
+
+func printVotes(votes []*core.Vote) {
+	for k, v := range votes {
+		fmt.Printf("%d\t%s\t%s\t%s\t%+v\n", k, v.Name, v.Addr, v.Balance, v.Type)
+	}
+}
+
+func main() {
+	flag.Parse()
+	overrides, err := parseOverrides(*votes)
+	if err != nil {
+		panic(err)
+	}
+	bytes, err := ioutil.ReadFile(*genesisFile)
+	if err != nil {
+		panic(err)
+	}
+	c, err := newChain(*dataDir, string(bytes))
+	if err != nil {
+		panic(err)
+	}
+
+	cs := consensus.NewConsensus(c, &lock.EasyImpl{})
+	if err := cs.Init(); err != nil {
+		panic(err)
+	}
+
+	i := uint64(*index)
+	if *index < 0 {
+		i = cs.SBPReader().GetPeriodTimeIndex().Time2Index(time.Now()) + 1
+	}
+
+	result, err := cs.API().SimulateElection(i, overrides)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("index:%d\tproof:%d-%s\tdetermined:%t\tline:%s\n", result.Index, result.ProofHeight, result.ProofHash, result.Determined, result.Line)
+	fmt.Println("-----------------ranking")
+	printVotes(result.Ranking)
+	fmt.Println("-----------------producers")
+	printVotes(result.Producers)
+}
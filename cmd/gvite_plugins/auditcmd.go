@@ -0,0 +1,43 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	auditCommand = cli.Command{
+		Action:    utils.MigrateFlags(auditAction),
+		Name:      "audit",
+		Usage:     "audit --from=2 --to=500000",
+		ArgsUsage: "--from=2 --to=500000 [--checkpoint=file] [--restart]",
+		Flags:     append(auditFlags, configFlags...),
+		Category:  "AUDIT COMMANDS",
+		Description: `
+Replay the account blocks through vm against the historical state,
+and report the first block whose result differs from the stored one.
+The progress is saved to the checkpoint file and resumed on the next run.
+`,
+	}
+)
+
+func auditAction(ctx *cli.Context) error {
+	// Create and start the node based on the CLI flags
+	nodeManager, err := nodemanager.NewAuditNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,47 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:     "db",
+		Usage:    "Ledger database commands",
+		Category: "DATABASE COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(migrateDBAction),
+				Name:      "migrate",
+				Usage:     "migrate --backend=tiered",
+				ArgsUsage: "--backend=tiered",
+				Flags:     append(dbFlags, configFlags...),
+				Description: `
+Convert the index, state and plugin stores of the ledger to another key-value backend.
+The node must be stopped, set LedgerBackend in the config file to the same backend afterwards.
+The tiered backend is experimental, it needs gvite built with -tags tiered, e.g. make gvite-tiered.
+`,
+			},
+		},
+	}
+)
+
+func migrateDBAction(ctx *cli.Context) error {
+	nodeManager, err := nodemanager.NewMigrateDBNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,309 @@
+package gvite_plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	"github.com/vitelabs/go-vite/chain/genesis"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/config/gen"
+	"github.com/vitelabs/go-vite/ledger"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	genesisCommand = cli.Command{
+		Name:     "genesis",
+		Usage:    "Genesis file commands",
+		Category: "GENESIS COMMANDS",
+		Description: `
+Build the genesis file of a private network step by step, every step keeps the balances,
+the total supplies and the contract infos consistent.
+Amounts are in token units, e.g. --amount=1.5 is 1.5 VITE.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(genesisNewAction),
+				Name:      "new",
+				Usage:     "Create a genesis file without SBPs and balances",
+				ArgsUsage: "<genesis.json> --owner=vite_... [--nodes=n] [--force]",
+				Flags:     []cli.Flag{utils.GenesisOwnerFlag, utils.GenesisNodesFlag, utils.GenesisForceFlag},
+			},
+			{
+				Action:    utils.MigrateFlags(genesisAddAccountAction),
+				Name:      "add-account",
+				Usage:     "Mint balance to an account or stake VITE for its quota",
+				ArgsUsage: "<genesis.json> --address=vite_... [--token=tti_...] [--amount=x] [--stake=x]",
+				Flags:     []cli.Flag{utils.GenesisAddressFlag, utils.GenesisTokenFlag, utils.GenesisAmountFlag, utils.GenesisStakeFlag},
+			},
+			{
+				Action:    utils.MigrateFlags(genesisAddSBPAction),
+				Name:      "add-sbp",
+				Usage:     "Register an SBP of the snapshot consensus group",
+				ArgsUsage: "<genesis.json> --name=name --producer=vite_... [--stakeaddr=vite_...]",
+				Flags:     []cli.Flag{utils.GenesisNameFlag, utils.GenesisProducerFlag, utils.GenesisStakeAddressFlag},
+			},
+			{
+				Action:    utils.MigrateFlags(genesisAddTokenAction),
+				Name:      "add-token",
+				Usage:     "Issue a token and mint its total supply to the owner",
+				ArgsUsage: "<genesis.json> --name=name --symbol=SYMBOL --owner=vite_... --decimals=n --supply=x [--reissuable --maxsupply=x [--ownerburnonly]]",
+				Flags: []cli.Flag{utils.GenesisNameFlag, utils.GenesisSymbolFlag, utils.GenesisOwnerFlag, utils.GenesisDecimalsFlag,
+					utils.GenesisSupplyFlag, utils.GenesisMaxSupplyFlag, utils.GenesisReIssuableFlag, utils.GenesisOwnerBurnOnlyFlag},
+			},
+			{
+				Action:    utils.MigrateFlags(genesisValidateAction),
+				Name:      "validate",
+				Usage:     "Check that a node can start with the genesis file",
+				ArgsUsage: "<genesis.json>",
+			},
+			{
+				Action:    utils.MigrateFlags(genesisHashAction),
+				Name:      "hash",
+				Usage:     "Print the genesis snapshot block hash",
+				ArgsUsage: "<genesis.json>",
+			},
+		},
+	}
+)
+
+func genesisNewAction(ctx *cli.Context) error {
+	file, err := genesisFileArg(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(file); err == nil && !ctx.Bool(utils.GenesisForceFlag.Name) {
+		return fmt.Errorf("%s exists, use --force to overwrite it", file)
+	}
+	owner, err := addressFlag(ctx, utils.GenesisOwnerFlag.Name)
+	if err != nil {
+		return err
+	}
+	nodes := ctx.Uint(utils.GenesisNodesFlag.Name)
+	if nodes > 255 {
+		return errors.New("--nodes must be less than 256")
+	}
+	return writeGenesis(file, config_gen.NewGenesisConfig(owner, uint8(nodes)))
+}
+
+func genesisAddAccountAction(ctx *cli.Context) error {
+	return editGenesis(ctx, func(g *config.Genesis) error {
+		addr, err := addressFlag(ctx, utils.GenesisAddressFlag.Name)
+		if err != nil {
+			return err
+		}
+		tokenId := ledger.ViteTokenId
+		if s := ctx.String(utils.GenesisTokenFlag.Name); s != "" {
+			if tokenId, err = types.HexToTokenTypeId(s); err != nil {
+				return fmt.Errorf("invalid --%s: %v", utils.GenesisTokenFlag.Name, err)
+			}
+		}
+		amountStr, stakeStr := ctx.String(utils.GenesisAmountFlag.Name), ctx.String(utils.GenesisStakeFlag.Name)
+		if amountStr == "" && stakeStr == "" {
+			return fmt.Errorf("--%s or --%s is required", utils.GenesisAmountFlag.Name, utils.GenesisStakeFlag.Name)
+		}
+
+		if amountStr != "" {
+			amount, err := tokenAmount(g, tokenId, amountStr)
+			if err != nil {
+				return err
+			}
+			if err := config_gen.AddGenesisAccount(g, addr, tokenId, amount); err != nil {
+				return err
+			}
+		}
+		if stakeStr != "" {
+			stake, err := tokenAmount(g, ledger.ViteTokenId, stakeStr)
+			if err != nil {
+				return err
+			}
+			if err := config_gen.AddGenesisStake(g, addr, addr, stake); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func genesisAddSBPAction(ctx *cli.Context) error {
+	return editGenesis(ctx, func(g *config.Genesis) error {
+		producer, err := addressFlag(ctx, utils.GenesisProducerFlag.Name)
+		if err != nil {
+			return err
+		}
+		stakeAddr := producer
+		if ctx.String(utils.GenesisStakeAddressFlag.Name) != "" {
+			if stakeAddr, err = addressFlag(ctx, utils.GenesisStakeAddressFlag.Name); err != nil {
+				return err
+			}
+		}
+		return config_gen.AddGenesisSBP(g, ctx.String(utils.GenesisNameFlag.Name), producer, stakeAddr)
+	})
+}
+
+func genesisAddTokenAction(ctx *cli.Context) error {
+	return editGenesis(ctx, func(g *config.Genesis) error {
+		owner, err := addressFlag(ctx, utils.GenesisOwnerFlag.Name)
+		if err != nil {
+			return err
+		}
+		decimals := ctx.Uint(utils.GenesisDecimalsFlag.Name)
+		if decimals > 255 {
+			return errors.New("--decimals must be less than 256")
+		}
+		info := config.TokenInfo{
+			TokenName:       ctx.String(utils.GenesisNameFlag.Name),
+			TokenSymbol:     ctx.String(utils.GenesisSymbolFlag.Name),
+			Decimals:        uint8(decimals),
+			Owner:           owner,
+			IsReIssuable:    ctx.Bool(utils.GenesisReIssuableFlag.Name),
+			IsOwnerBurnOnly: ctx.Bool(utils.GenesisOwnerBurnOnlyFlag.Name),
+		}
+		if info.TotalSupply, err = parseTokenAmount(ctx.String(utils.GenesisSupplyFlag.Name), info.Decimals); err != nil {
+			return fmt.Errorf("invalid --%s: %v", utils.GenesisSupplyFlag.Name, err)
+		}
+		if s := ctx.String(utils.GenesisMaxSupplyFlag.Name); s != "" {
+			if info.MaxSupply, err = parseTokenAmount(s, info.Decimals); err != nil {
+				return fmt.Errorf("invalid --%s: %v", utils.GenesisMaxSupplyFlag.Name, err)
+			}
+		}
+		tokenId, err := config_gen.AddGenesisToken(g, info)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("token id: %s\n", tokenId)
+		return nil
+	})
+}
+
+func genesisValidateAction(ctx *cli.Context) error {
+	file, err := genesisFileArg(ctx)
+	if err != nil {
+		return err
+	}
+	g, err := config_gen.ReadGenesisConfig(file)
+	if err != nil {
+		return err
+	}
+	for _, w := range config_gen.GenesisConfigWarnings(g) {
+		fmt.Printf("warning: %s\n", w)
+	}
+	if err := config_gen.CheckGenesisConfig(g); err != nil {
+		return err
+	}
+	block, err := genesisSnapshotBlock(g)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s is valid, genesis snapshot block hash: %s\n", file, block.Hash)
+	return nil
+}
+
+func genesisHashAction(ctx *cli.Context) error {
+	file, err := genesisFileArg(ctx)
+	if err != nil {
+		return err
+	}
+	g, err := config_gen.ReadGenesisConfig(file)
+	if err != nil {
+		return err
+	}
+	if err := config_gen.CheckGenesisConfig(g); err != nil {
+		return err
+	}
+	block, err := genesisSnapshotBlock(g)
+	if err != nil {
+		return err
+	}
+	fmt.Println(block.Hash)
+	return nil
+}
+
+// editGenesis reads the genesis file of the first argument, applies fn and writes it back if fn succeeds
+func editGenesis(ctx *cli.Context, fn func(g *config.Genesis) error) error {
+	file, err := genesisFileArg(ctx)
+	if err != nil {
+		return err
+	}
+	g, err := config_gen.ReadGenesisConfig(file)
+	if err != nil {
+		return err
+	}
+	if err := fn(g); err != nil {
+		return err
+	}
+	return writeGenesis(file, g)
+}
+
+func writeGenesis(file string, g *config.Genesis) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// genesisSnapshotBlock builds the genesis blocks the way the chain does, building panics on invalid contract infos
+func genesisSnapshotBlock(g *config.Genesis) (block *ledger.SnapshotBlock, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("build genesis blocks failed: %v", r)
+		}
+	}()
+	forks := fork.NewSchedule(config_gen.GenesisForkPoints(g))
+	accountBlocks := chain_genesis.NewGenesisAccountBlocks(forks, g)
+	return chain_genesis.NewGenesisSnapshotBlock(forks, accountBlocks), nil
+}
+
+func genesisFileArg(ctx *cli.Context) (string, error) {
+	if ctx.NArg() != 1 {
+		return "", errors.New("the genesis file is required")
+	}
+	return ctx.Args().First(), nil
+}
+
+func addressFlag(ctx *cli.Context, name string) (types.Address, error) {
+	s := ctx.String(name)
+	if s == "" {
+		return types.Address{}, fmt.Errorf("--%s is required", name)
+	}
+	addr, err := types.HexToAddress(s)
+	if err != nil {
+		return types.Address{}, fmt.Errorf("invalid --%s: %v", name, err)
+	}
+	return addr, nil
+}
+
+func tokenAmount(g *config.Genesis, tokenId types.TokenTypeId, s string) (*big.Int, error) {
+	if g.AssetInfo == nil || g.AssetInfo.TokenInfoMap[tokenId.String()] == nil {
+		return nil, fmt.Errorf("token %s doesn't exist", tokenId)
+	}
+	amount, err := parseTokenAmount(s, g.AssetInfo.TokenInfoMap[tokenId.String()].Decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %s: %v", s, err)
+	}
+	return amount, nil
+}
+
+// parseTokenAmount converts an amount in token units to the smallest unit
+func parseTokenAmount(s string, decimals uint8) (*big.Int, error) {
+	if s == "" {
+		return big.NewInt(0), nil
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() < 0 {
+		return nil, errors.New("not a non-negative number")
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("more than %d decimals", decimals)
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
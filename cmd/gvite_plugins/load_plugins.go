@@ -38,6 +38,8 @@ var (
 	//p2p
 	p2pFlags = []cli.Flag{
 		utils.DevNetFlag,
+		utils.DevFlag,
+		utils.DevPeriodFlag,
 		utils.TestNetFlag,
 		utils.MainNetFlag,
 		utils.IdentityFlag,
@@ -115,6 +117,10 @@ var (
 		utils.InfluxDBUsernameFlag,
 		utils.InfluxDBPasswordFlag,
 		utils.InfluxDBHostTagFlag,
+		utils.PrometheusEnableFlag,
+		utils.PrometheusEndpointFlag,
+		utils.HealthEnableFlag,
+		utils.HealthEndpointFlag,
 	}
 
 	// Ledger
@@ -127,6 +133,19 @@ var (
 	exportFlags = []cli.Flag{
 		utils.ExportSbHeightFlags,
 	}
+
+	// DB
+	dbFlags = []cli.Flag{
+		utils.DBBackendFlag,
+	}
+
+	// Audit
+	auditFlags = []cli.Flag{
+		utils.AuditFromFlag,
+		utils.AuditToFlag,
+		utils.AuditCheckpointFlag,
+		utils.AuditRestartFlag,
+	}
 )
 
 func init() {
@@ -155,13 +174,17 @@ func init() {
 		exportCommand,
 		pluginDataCommand,
 		checkChainCommand,
+		auditCommand,
+		dbCommand,
+		genesisCommand,
+		rewardCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
 	//Import: Please add the New Flags here
 	app.Flags = utils.MergeFlags(configFlags, generalFlags, p2pFlags,
 		ipcFlags, httpFlags, wsFlags, consoleFlags, producerFlags, logFlags,
-		vmFlags, netFlags, statFlags, metricsFlags, ledgerFlags, exportFlags)
+		vmFlags, netFlags, statFlags, metricsFlags, ledgerFlags, exportFlags, dbFlags, auditFlags)
 
 	app.Before = beforeAction
 	app.Action = action
@@ -0,0 +1,125 @@
+package gvite_plugins
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	rewardCommand = cli.Command{
+		Action:    utils.MigrateFlags(rewardLedgerAction),
+		Name:      "reward",
+		Usage:     "Export the reward ledger of an SBP and its voters (connect to node)",
+		ArgsUsage: "[endpoint] --sbp=name --startcycle=n [--endcycle=n] [--shareratio=100] [--format=csv|json] [--output=file]",
+		Flags: []cli.Flag{utils.DataDirFlag, utils.RewardSBPFlag, utils.RewardStartCycleFlag, utils.RewardEndCycleFlag,
+			utils.RewardShareRatioFlag, utils.RewardFormatFlag, utils.RewardOutputFlag},
+		Category: "REWARD COMMANDS",
+		Description: `
+Export the block reward, the vote reward and the produced blocks of an SBP in each cycle, with the
+share of the total reward split to its voters in proportion to their votes in the last snapshot block
+before the cycle starts. Amounts are in the smallest unit of VITE.
+The endpoint is the same as "gvite attach", default is the IPC endpoint in the data dir.
+`,
+	}
+)
+
+var rewardLedgerCSVHeader = []string{"cycle", "startTime", "endTime", "sbpName", "blockReward", "voteReward", "totalReward",
+	"expectedBlockNum", "producedBlockNum", "voteSnapshotHeight", "totalVotes", "voterReward", "voter", "votes", "reward"}
+
+func rewardLedgerAction(ctx *cli.Context) error {
+	if ctx.String(utils.RewardSBPFlag.Name) == "" || !ctx.IsSet(utils.RewardStartCycleFlag.Name) {
+		return fmt.Errorf("--%s and --%s are required", utils.RewardSBPFlag.Name, utils.RewardStartCycleFlag.Name)
+	}
+	format := ctx.String(utils.RewardFormatFlag.Name)
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("invalid --%s %s", utils.RewardFormatFlag.Name, format)
+	}
+	shareRatio := ctx.Uint(utils.RewardShareRatioFlag.Name)
+	if shareRatio > 100 {
+		return fmt.Errorf("--%s must be no more than 100", utils.RewardShareRatioFlag.Name)
+	}
+	ratio := uint8(shareRatio)
+	param := api.SBPRewardLedgerParam{
+		Name:       ctx.String(utils.RewardSBPFlag.Name),
+		StartCycle: strconv.FormatUint(ctx.Uint64(utils.RewardStartCycleFlag.Name), 10),
+		ShareRatio: &ratio,
+	}
+	if ctx.IsSet(utils.RewardEndCycleFlag.Name) {
+		param.EndCycle = strconv.FormatUint(ctx.Uint64(utils.RewardEndCycleFlag.Name), 10)
+	}
+
+	dataDir := makeDataDir(ctx)
+	endpoint := ctx.Args().First()
+	if endpoint == "" {
+		endpoint = defaultAttachEndpoint(dataDir)
+	}
+	client, err := dialRPC(dataDir, endpoint)
+	if err != nil {
+		return fmt.Errorf("unable to attach to remote gvite: %v", err)
+	}
+	defer client.Close()
+	ledger := &api.SBPRewardLedger{}
+	if err := client.Call(ledger, "contract_getSBPRewardLedger", param); err != nil {
+		return err
+	}
+
+	file := ctx.String(utils.RewardOutputFlag.Name)
+	if file == "" {
+		return writeRewardLedger(os.Stdout, format, ledger)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := writeRewardLedger(f, format, ledger); err != nil {
+		f.Close()
+		return err
+	}
+	// a failed close may lose buffered data of the file
+	return f.Close()
+}
+
+func writeRewardLedger(out io.Writer, format string, ledger *api.SBPRewardLedger) error {
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(ledger)
+	}
+	return writeRewardLedgerCSV(out, ledger)
+}
+
+// writeRewardLedgerCSV writes a row for each voter of each cycle, or a row without the voter columns
+// if a cycle has no voters
+func writeRewardLedgerCSV(out io.Writer, ledger *api.SBPRewardLedger) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(rewardLedgerCSVHeader); err != nil {
+		return err
+	}
+	for _, cycle := range ledger.CycleList {
+		row := []string{cycle.Cycle, strconv.FormatInt(cycle.StartTime, 10), strconv.FormatInt(cycle.EndTime, 10), ledger.Name,
+			cycle.BlockReward, cycle.VoteReward, cycle.TotalReward, cycle.ExpectedBlockNum, cycle.ProducedBlockNum,
+			cycle.VoteSnapshotHeight, cycle.TotalVotes, cycle.VoterReward}
+		if len(cycle.VoterRewardList) == 0 {
+			if err := w.Write(append(row, "", "", "")); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, voter := range cycle.VoterRewardList {
+			voterRow := append(append([]string{}, row...), voter.Address.String(), voter.Votes, voter.Reward)
+			if err := w.Write(voterRow); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
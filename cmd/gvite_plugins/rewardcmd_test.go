@@ -0,0 +1,47 @@
+package gvite_plugins
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+func TestWriteRewardLedgerCSV(t *testing.T) {
+	voter1, voter2 := types.Address{1}, types.Address{2}
+	ledger := &api.SBPRewardLedger{
+		Name: "s1",
+		CycleList: []*api.SBPRewardLedgerCycle{
+			{Cycle: "10", StartTime: 100, EndTime: 200, BlockReward: "1", VoteReward: "2", TotalReward: "3",
+				ExpectedBlockNum: "4", ProducedBlockNum: "5", VoteSnapshotHeight: "6", TotalVotes: "7", VoterReward: "3",
+				VoterRewardList: []*api.VoterReward{{Address: voter1, Votes: "5", Reward: "2"}, {Address: voter2, Votes: "2", Reward: "1"}}},
+			{Cycle: "11", StartTime: 200, EndTime: 300, BlockReward: "0", VoteReward: "0", TotalReward: "0",
+				ExpectedBlockNum: "4", ProducedBlockNum: "0", VoteSnapshotHeight: "8", TotalVotes: "0", VoterReward: "0"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := writeRewardLedgerCSV(&buf, ledger); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		rewardLedgerCSVHeader,
+		{"10", "100", "200", "s1", "1", "2", "3", "4", "5", "6", "7", "3", voter1.String(), "5", "2"},
+		{"10", "100", "200", "s1", "1", "2", "3", "4", "5", "6", "7", "3", voter2.String(), "2", "1"},
+		{"11", "200", "300", "s1", "0", "0", "0", "4", "0", "8", "0", "0", "", "", ""},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("rows not match, %v", records)
+	}
+	for i, record := range records {
+		if strings.Join(record, ",") != strings.Join(expected[i], ",") {
+			t.Fatalf("row %d not match, %v", i, record)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package nodemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/verifier/audit"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const defaultAuditCheckpoint = "audit_checkpoint.json"
+
+type AuditNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+	log  log15.Logger
+}
+
+func NewAuditNodeManager(ctx *cli.Context, maker NodeMaker) (*AuditNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// single mode
+	node.Config().Single = true
+	node.ViteConfig().Net.Single = true
+
+	// no miner
+	node.Config().MinerEnabled = false
+	node.ViteConfig().Producer.Producer = false
+
+	// no ledger gc
+	ledgerGc := false
+	node.Config().LedgerGc = &ledgerGc
+	node.ViteConfig().Chain.LedgerGc = ledgerGc
+
+	return &AuditNodeManager{
+		ctx:  ctx,
+		node: node,
+		log:  log15.New("module", "auditCMD"),
+	}, nil
+}
+
+func (nodeManager *AuditNodeManager) checkpointFile() string {
+	if nodeManager.ctx.GlobalIsSet(utils.AuditCheckpointFlag.Name) {
+		return nodeManager.ctx.GlobalString(utils.AuditCheckpointFlag.Name)
+	}
+	return filepath.Join(nodeManager.node.ViteConfig().DataDir, defaultAuditCheckpoint)
+}
+
+func (nodeManager *AuditNodeManager) Start() error {
+	ctx := nodeManager.ctx
+	if err := StartNode(nodeManager.node); err != nil {
+		return err
+	}
+
+	c := nodeManager.node.Vite().Chain()
+	from := uint64(2)
+	if ctx.GlobalIsSet(utils.AuditFromFlag.Name) {
+		from = ctx.GlobalUint64(utils.AuditFromFlag.Name)
+	}
+	to := c.GetLatestSnapshotBlock().Height
+	if ctx.GlobalIsSet(utils.AuditToFlag.Name) {
+		to = ctx.GlobalUint64(utils.AuditToFlag.Name)
+	}
+
+	checkpoint := nodeManager.checkpointFile()
+	if ctx.GlobalBool(utils.AuditRestartFlag.Name) {
+		if err := os.Remove(checkpoint); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	auditor := audit.NewAuditor(c, nodeManager.node.Vite().Consensus(), checkpoint)
+	auditor.Progress = func(cp *audit.Checkpoint) {
+		fmt.Printf("audited to snapshot height %d, %d account blocks\n", cp.Next-1, cp.Blocks)
+	}
+
+	fmt.Printf("start audit from %d to %d, checkpoint %s\n", from, to, checkpoint)
+	d, err := auditor.Run(from, to)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		fmt.Println("audit success.")
+		return nil
+	}
+
+	nodeManager.log.Error(d.String(), "audit", "divergence")
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("divergence found: %s\n%s\n", d, data)
+	return fmt.Errorf("divergence at snapshot height %d", d.SnapshotHeight)
+}
+
+func (nodeManager *AuditNodeManager) Stop() error {
+
+	StopNode(nodeManager.node)
+
+	return nil
+}
+
+func (nodeManager *AuditNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
@@ -173,6 +173,14 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.Single = ctx.GlobalBool(utils.SingleFlag.Name)
 	}
 
+	//Dev
+	if ctx.GlobalIsSet(utils.DevFlag.Name) {
+		cfg.Dev = ctx.GlobalBool(utils.DevFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.DevPeriodFlag.Name) {
+		cfg.DevPeriod = ctx.GlobalInt(utils.DevPeriodFlag.Name)
+	}
+
 	//metrics
 	if ctx.GlobalIsSet(utils.MetricsEnabledFlag.Name) {
 		mBool := ctx.GlobalBool(utils.MetricsEnabledFlag.Name)
@@ -197,6 +205,20 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if tag := ctx.GlobalString(utils.InfluxDBHostTagFlag.Name); len(tag) > 0 {
 		cfg.InfluxDBHostTag = &tag
 	}
+	if ctx.GlobalIsSet(utils.PrometheusEnableFlag.Name) {
+		pBool := ctx.GlobalBool(utils.PrometheusEnableFlag.Name)
+		cfg.PrometheusEnable = &pBool
+	}
+	if endpoint := ctx.GlobalString(utils.PrometheusEndpointFlag.Name); len(endpoint) > 0 {
+		cfg.PrometheusEndpoint = &endpoint
+	}
+	if ctx.GlobalIsSet(utils.HealthEnableFlag.Name) {
+		hBool := ctx.GlobalBool(utils.HealthEnableFlag.Name)
+		cfg.HealthEnable = &hBool
+	}
+	if endpoint := ctx.GlobalString(utils.HealthEndpointFlag.Name); len(endpoint) > 0 {
+		cfg.HealthEndpoint = &endpoint
+	}
 }
 
 func overrideNodeConfigs(ctx *cli.Context, cfg *node.Config) {
@@ -213,6 +235,11 @@ func overrideNodeConfigs(ctx *cli.Context, cfg *node.Config) {
 		cfg.LogLevel = "info"
 	}
 
+	if cfg.Dev {
+		cfg.SetupDevMode()
+		return
+	}
+
 	if ctx.GlobalBool(utils.MainNetFlag.Name) || cfg.NetID == 1 {
 		cfg.NetSelect = "main"
 		if cfg.NetID != 1 {
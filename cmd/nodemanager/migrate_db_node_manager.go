@@ -0,0 +1,86 @@
+package nodemanager
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/cmd/utils/flock"
+	"github.com/vitelabs/go-vite/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// the stores of the chain which can be migrated, the other dbs are always leveldb
+var migratedStores = []string{"index", "state", "state_redo", "plugins"}
+
+type MigrateDBNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+}
+
+func NewMigrateDBNodeManager(ctx *cli.Context, maker NodeMaker) (*MigrateDBNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigrateDBNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+func (nodeManager *MigrateDBNodeManager) getBackend() string {
+	if nodeManager.ctx.GlobalIsSet(utils.DBBackendFlag.Name) {
+		return nodeManager.ctx.GlobalString(utils.DBBackendFlag.Name)
+	}
+	if backend := nodeManager.node.ViteConfig().Chain.Backend; backend != "" {
+		return backend
+	}
+	return chain_db.BackendLevelDB
+}
+
+func (nodeManager *MigrateDBNodeManager) Start() error {
+	dataDir := nodeManager.node.ViteConfig().DataDir
+	backend := nodeManager.getBackend()
+	if backend != chain_db.BackendLevelDB && backend != chain_db.BackendTiered {
+		return fmt.Errorf("unknown db backend %s", backend)
+	}
+
+	// the node must not be running
+	release, _, err := flock.New(filepath.Join(dataDir, "LOCK"))
+	if err != nil {
+		return fmt.Errorf("lock %s failed, stop the node first: %v", dataDir, err)
+	}
+	defer release.Release()
+
+	chainDir := filepath.Join(dataDir, "ledger")
+	fmt.Printf("Migrate the ledger in %s to %s, don't shut down.\n", chainDir, backend)
+	for _, name := range migratedStores {
+		dir := filepath.Join(chainDir, name)
+		from := chain_db.DetectBackend(dir)
+		if from == "" || from == backend {
+			fmt.Printf("%s: nothing to migrate\n", name)
+			continue
+		}
+		keys, err := chain_db.Migrate(dir, backend, func(keys uint64) {
+			fmt.Printf("\r%s: %d keys copied", name, keys)
+		})
+		if err != nil {
+			fmt.Println()
+			return err
+		}
+		fmt.Printf("\r%s: %d keys migrated from %s to %s\n", name, keys, from, backend)
+	}
+	fmt.Printf("Migrate success, set \"LedgerBackend\": %q in the config file before starting the node.\n", backend)
+	return nil
+}
+
+func (nodeManager *MigrateDBNodeManager) Stop() error {
+	return nil
+}
+
+func (nodeManager *MigrateDBNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
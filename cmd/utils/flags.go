@@ -35,6 +35,14 @@ var (
 		Name:  "devnet",
 		Usage: "Rinkeby network: pre-configured proof-of-authority dev network",
 	}
+	DevFlag = cli.BoolFlag{
+		Name:  "dev",
+		Usage: "Instant-seal single node development chain with prefunded accounts",
+	}
+	DevPeriodFlag = cli.IntFlag{
+		Name:  "dev.period",
+		Usage: "Seal a snapshot block every period(seconds) in dev mode even if there is nothing to snapshot, 0 = never",
+	}
 
 	MainNetFlag = cli.BoolFlag{
 		Name:  "mainnet",
@@ -190,6 +198,124 @@ var (
 		Usage: "The snapshot block height",
 	}
 
+	// DB
+	DBBackendFlag = cli.StringFlag{
+		Name:  "backend",
+		Usage: "The key-value backend of the ledger, leveldb or tiered(experimental, needs gvite built with -tags tiered)",
+	}
+
+	// Audit
+	AuditFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "The first snapshot block height to audit",
+	}
+	AuditToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "The last snapshot block height to audit, default is the latest one",
+	}
+	AuditCheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "The checkpoint file of the audit progress, default is audit_checkpoint.json in the data dir",
+	}
+	AuditRestartFlag = cli.BoolFlag{
+		Name:  "restart",
+		Usage: "Ignore the checkpoint and audit from the beginning",
+	}
+
+	// Genesis
+	GenesisOwnerFlag = cli.StringFlag{
+		Name:  "owner",
+		Usage: "The owner of the genesis or the token",
+	}
+	GenesisNodesFlag = cli.UintFlag{
+		Name:  "nodes",
+		Usage: "The node count of the consensus groups, grows with the registered SBPs",
+	}
+	GenesisForceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "Overwrite the existing genesis file",
+	}
+	GenesisAddressFlag = cli.StringFlag{
+		Name:  "address",
+		Usage: "The account address",
+	}
+	GenesisTokenFlag = cli.StringFlag{
+		Name:  "token",
+		Usage: "The token id of the balance, default is VITE",
+	}
+	GenesisAmountFlag = cli.StringFlag{
+		Name:  "amount",
+		Usage: "The balance in token units, e.g. 1.5",
+	}
+	GenesisStakeFlag = cli.StringFlag{
+		Name:  "stake",
+		Usage: "The VITE staked for the quota of the account in token units",
+	}
+	GenesisNameFlag = cli.StringFlag{
+		Name:  "name",
+		Usage: "The name of the SBP or the token",
+	}
+	GenesisProducerFlag = cli.StringFlag{
+		Name:  "producer",
+		Usage: "The block producing address of the SBP",
+	}
+	GenesisStakeAddressFlag = cli.StringFlag{
+		Name:  "stakeaddr",
+		Usage: "The address staking for the SBP and voting for it, default is the block producing address",
+	}
+	GenesisSymbolFlag = cli.StringFlag{
+		Name:  "symbol",
+		Usage: "The symbol of the token",
+	}
+	GenesisDecimalsFlag = cli.UintFlag{
+		Name:  "decimals",
+		Usage: "The decimals of the token",
+	}
+	GenesisSupplyFlag = cli.StringFlag{
+		Name:  "supply",
+		Usage: "The total supply of the token in token units, minted to the owner",
+	}
+	GenesisMaxSupplyFlag = cli.StringFlag{
+		Name:  "maxsupply",
+		Usage: "The max supply of a reissuable token in token units",
+	}
+	GenesisReIssuableFlag = cli.BoolFlag{
+		Name:  "reissuable",
+		Usage: "The token is reissuable",
+	}
+	GenesisOwnerBurnOnlyFlag = cli.BoolFlag{
+		Name:  "ownerburnonly",
+		Usage: "Only the owner can burn the reissuable token",
+	}
+
+	// Reward
+	RewardSBPFlag = cli.StringFlag{
+		Name:  "sbp",
+		Usage: "The name of the SBP",
+	}
+	RewardStartCycleFlag = cli.Uint64Flag{
+		Name:  "startcycle",
+		Usage: "The first cycle of the reward ledger",
+	}
+	RewardEndCycleFlag = cli.Uint64Flag{
+		Name:  "endcycle",
+		Usage: "The last cycle of the reward ledger, default is the start cycle",
+	}
+	RewardShareRatioFlag = cli.UintFlag{
+		Name:  "shareratio",
+		Usage: "The percent of the total reward split to voters",
+		Value: 100,
+	}
+	RewardFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "The output format, csv or json",
+		Value: "csv",
+	}
+	RewardOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "The output file, default is stdout",
+	}
+
 	//Net
 	SingleFlag = cli.BoolFlag{
 		Name:  "single",
@@ -249,6 +375,22 @@ var (
 		Usage: "InfluxDB `host` tag attached to all measurements",
 		Value: "localhost",
 	}
+	PrometheusEnableFlag = cli.BoolFlag{
+		Name:  "metrics.prometheus",
+		Usage: "Enable metrics collection and serve them to Prometheus at /metrics",
+	}
+	PrometheusEndpointFlag = cli.StringFlag{
+		Name:  "metrics.prometheus.endpoint",
+		Usage: "`host:port` of the Prometheus metrics endpoint (default: localhost:48133)",
+	}
+	HealthEnableFlag = cli.BoolFlag{
+		Name:  "health",
+		Usage: "Serve the node health at /health and the readiness at /ready",
+	}
+	HealthEndpointFlag = cli.StringFlag{
+		Name:  "health.endpoint",
+		Usage: "`host:port` of the health endpoints (default: localhost:48133)",
+	}
 )
 
 // This allows the use of the existing configuration functionality.
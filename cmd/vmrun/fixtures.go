@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/vmtest"
+)
+
+// runFixtures runs the fixtures in path, a directory or a json file, and prints the result of every case.
+// Interpreter fixtures (vm/test/interpreter_test) and block fixtures (vm/test/run_test) are told apart
+// per file, block fixtures have a block type. It returns false if any case fails.
+func runFixtures(path string, filter string, trace bool) bool {
+	files, err := fixtureFiles(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+
+	var tracer vm.Tracer
+	if trace {
+		tracer = func(step *vm.TraceStep) {
+			t := toTraceJSON(step)
+			fmt.Printf("  pc=%d op=%s cost=%d quotaLeft=%d stack=%v\n", t.Pc, t.Op, t.Cost, t.QuotaLeft, t.Stack)
+		}
+	}
+
+	passed, failed := 0, 0
+	for _, file := range files {
+		cases := make(map[string]json.RawMessage)
+		data, err := ioutil.ReadFile(file)
+		if err == nil {
+			err = json.Unmarshal(data, &cases)
+		}
+		if err != nil {
+			fmt.Printf("FAIL %s: decode failed, %v\n", filepath.Base(file), err)
+			failed++
+			continue
+		}
+		runCase := func(data json.RawMessage) error {
+			testCase := new(vmtest.TestCase)
+			if err := json.Unmarshal(data, testCase); err != nil {
+				return fmt.Errorf("decode failed, %v", err)
+			}
+			return vmtest.RunTestCase(testCase, tracer)
+		}
+		if isBlockFixture(cases) {
+			runCase = func(data json.RawMessage) error {
+				testCase := new(vmtest.VMRunTestCase)
+				if err := json.Unmarshal(data, testCase); err != nil {
+					return fmt.Errorf("decode failed, %v", err)
+				}
+				return vmtest.RunVMRunTestCase(testCase)
+			}
+		}
+
+		names := make([]string, 0, len(cases))
+		for name := range cases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fullName := filepath.Base(file) + ":" + name
+			if filter != "" && !strings.Contains(fullName, filter) {
+				continue
+			}
+			if err := runCase(cases[name]); err != nil {
+				fmt.Printf("FAIL %s: %v\n", fullName, err)
+				failed++
+			} else {
+				fmt.Printf("ok   %s\n", fullName)
+				passed++
+			}
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed\n", passed, failed)
+	return failed == 0
+}
+
+// isBlockFixture returns true if any case of a fixture file has a block type, json keys are case insensitive
+func isBlockFixture(cases map[string]json.RawMessage) bool {
+	for _, data := range cases {
+		fields := make(map[string]json.RawMessage)
+		if json.Unmarshal(data, &fields) != nil {
+			continue
+		}
+		for k := range fields {
+			if strings.EqualFold(k, "blockType") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func fixtureFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	return filepath.Glob(filepath.Join(path, "*.json"))
+}
@@ -0,0 +1,358 @@
+// vmrun executes contract bytecode through the vm interpreter without a chain.
+//
+//	vmrun -code 6001600055 -trace
+//	vmrun -code @contract.hex -data <calldata> -prestate prestate.json -fork EarthFork
+//	vmrun -fixtures vm/test/interpreter_test
+//	vmrun -fixtures vm/test/run_test
+//	vmrun -disasm -code @contract.hex -abi contract.abi
+//
+// The prestate file holds the state of the contract and the snapshot block:
+//
+//	{
+//	  "snapshotHeight": 100,
+//	  "snapshotTime": 1546272100,
+//	  "seed": 1,
+//	  "balances": {"tti_5649544520544f4b454e6e40": "1000000000000000000"},
+//	  "storage": {"0000000000000000000000000000000000000000000000000000000000000000": "01"}
+//	}
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	config_gen "github.com/vitelabs/go-vite/config/gen"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/abi"
+	"github.com/vitelabs/go-vite/vm/vmtest"
+)
+
+var (
+	codeFlag     = flag.String("code", "", "hex encoded code, or @file to read it from a file")
+	dataFlag     = flag.String("data", "", "hex encoded calldata")
+	callerFlag   = flag.String("caller", "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a", "address of the caller")
+	addressFlag  = flag.String("address", "vite_470328ad08903a431953bfdcaf7760c084233c475e5726a35c", "address of the contract")
+	valueFlag    = flag.String("value", "0", "amount transferred to the contract with the call")
+	tokenFlag    = flag.String("token", ledger.ViteTokenId.String(), "token id of the value")
+	quotaFlag    = flag.Uint64("quota", 1000000, "quota of the execution")
+	prestateFlag = flag.String("prestate", "", "prestate json file of the contract and the snapshot block")
+	genesisFlag  = flag.String("genesis", "", "genesis file of the fork points, the mainnet ones if empty")
+	forkFlag     = flag.String("fork", "", "run at the height of a fork point like EarthFork, overrides the prestate")
+	heightFlag   = flag.Uint64("height", 0, "snapshot height, overrides the prestate and -fork")
+	traceFlag    = flag.Bool("trace", false, "print the opcode trace, of the code or the interpreter fixtures")
+	fixturesFlag = flag.String("fixtures", "", "run the interpreter or the block fixtures of a directory or a file as a conformance suite")
+	runFlag      = flag.String("run", "", "run only the fixtures whose file:case name contains it")
+	debugFlag    = flag.Bool("debug", false, "print the vm logs")
+	disasmFlag   = flag.Bool("disasm", false, "print the annotated assembly of the code instead of running it")
+	abiFlag      = flag.String("abi", "", "abi json file to match the function selectors of -disasm")
+)
+
+func main() {
+	flag.Parse()
+	vm.InitVMConfig(false, false, false, false, common.HomeDir())
+	if !*debugFlag {
+		log15.Root().SetHandler(log15.DiscardHandler())
+	}
+
+	if *fixturesFlag != "" {
+		if !runFixtures(*fixturesFlag, *runFlag, *traceFlag) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *disasmFlag {
+		if err := disassemble(*codeFlag, *abiFlag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	cfg, db, err := makeRunConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	var trace []*traceJSON
+	if *traceFlag {
+		cfg.Tracer = func(step *vm.TraceStep) {
+			trace = append(trace, toTraceJSON(step))
+		}
+	}
+	result := vm.RunCode(cfg, db)
+
+	out := toResultJSON(cfg, result, db)
+	out.Trace = trace
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if result.Err != nil {
+		os.Exit(1)
+	}
+}
+
+type prestate struct {
+	SnapshotHeight uint64            `json:"snapshotHeight"`
+	SnapshotTime   int64             `json:"snapshotTime"`
+	Seed           uint64            `json:"seed"`
+	Balances       map[string]string `json:"balances"` // token id to decimal amount
+	Storage        map[string]string `json:"storage"`  // hex key to hex value
+}
+
+// makeRunConfig returns the environment of the code and the database holding the prestate of the contract
+func makeRunConfig() (*vm.RunConfig, *vmtest.MemoryDatabase, error) {
+	cfg := &vm.RunConfig{
+		Quota:        *quotaFlag,
+		SnapshotTime: time.Now(),
+	}
+
+	var genesisConfig *config.Genesis
+	var err error
+	if *genesisFlag != "" {
+		if genesisConfig, err = config_gen.ReadGenesisConfig(*genesisFlag); err != nil {
+			return nil, nil, err
+		}
+	}
+	cfg.Forks = fork.NewSchedule(config_gen.GenesisForkPoints(genesisConfig))
+	// runs with the latest interpreter by default
+	cfg.SnapshotHeight = cfg.Forks.GetLastForkPoint().Height
+
+	if cfg.Code, err = readHex(*codeFlag); err != nil {
+		return nil, nil, fmt.Errorf("invalid code: %v", err)
+	}
+	if len(cfg.Code) == 0 {
+		return nil, nil, errors.New("code is empty")
+	}
+	if cfg.Data, err = readHex(*dataFlag); err != nil {
+		return nil, nil, fmt.Errorf("invalid data: %v", err)
+	}
+	if cfg.Caller, err = types.HexToAddress(*callerFlag); err != nil {
+		return nil, nil, fmt.Errorf("invalid caller: %v", err)
+	}
+	if cfg.Address, err = types.HexToAddress(*addressFlag); err != nil {
+		return nil, nil, fmt.Errorf("invalid address: %v", err)
+	}
+	if cfg.TokenId, err = types.HexToTokenTypeId(*tokenFlag); err != nil {
+		return nil, nil, fmt.Errorf("invalid token: %v", err)
+	}
+	var ok bool
+	if cfg.Amount, ok = new(big.Int).SetString(*valueFlag, 10); !ok || cfg.Amount.Sign() < 0 {
+		return nil, nil, fmt.Errorf("invalid value %s", *valueFlag)
+	}
+
+	state := new(prestate)
+	if *prestateFlag != "" {
+		if state, err = loadPrestate(*prestateFlag, cfg); err != nil {
+			return nil, nil, fmt.Errorf("invalid prestate: %v", err)
+		}
+	}
+
+	if *forkFlag != "" {
+		point, ok := cfg.Forks.GetForkPointMap()[*forkFlag]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown fork %s", *forkFlag)
+		}
+		cfg.SnapshotHeight = point.Height
+	}
+	if *heightFlag > 0 {
+		cfg.SnapshotHeight = *heightFlag
+	}
+
+	db := vmtest.NewMemoryDatabase(cfg.Address, vm.RunSnapshotBlock(cfg), cfg.Forks)
+	if err := state.apply(db); err != nil {
+		return nil, nil, fmt.Errorf("invalid prestate: %v", err)
+	}
+	return cfg, db, nil
+}
+
+func loadPrestate(file string, cfg *vm.RunConfig) (*prestate, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	state := new(prestate)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+
+	if state.SnapshotHeight > 0 {
+		cfg.SnapshotHeight = state.SnapshotHeight
+	}
+	if state.SnapshotTime > 0 {
+		cfg.SnapshotTime = time.Unix(state.SnapshotTime, 0)
+	}
+	cfg.Seed = state.Seed
+	return state, nil
+}
+
+// apply sets the balances and the storage of the prestate to db
+func (state *prestate) apply(db *vmtest.MemoryDatabase) error {
+	for tti, amount := range state.Balances {
+		tokenId, err := types.HexToTokenTypeId(tti)
+		if err != nil {
+			return err
+		}
+		balance, ok := new(big.Int).SetString(amount, 10)
+		if !ok || balance.Sign() < 0 {
+			return fmt.Errorf("invalid balance %s of %s", amount, tti)
+		}
+		db.SetBalance(&tokenId, balance)
+	}
+	for k, v := range state.Storage {
+		key, err := hex.DecodeString(k)
+		if err != nil {
+			return fmt.Errorf("invalid storage key %s", k)
+		}
+		value, err := hex.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("invalid storage value %s", v)
+		}
+		db.SetStorage(hex.EncodeToString(key), value)
+	}
+	return nil
+}
+
+func disassemble(codeArg string, abiFile string) error {
+	code, err := readHex(codeArg)
+	if err != nil {
+		return fmt.Errorf("invalid code: %v", err)
+	}
+	var contractAbi *abi.ABIContract
+	if abiFile != "" {
+		file, err := os.Open(abiFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		abiContract, err := abi.JSONToABIContract(file)
+		if err != nil {
+			return fmt.Errorf("invalid abi: %v", err)
+		}
+		contractAbi = &abiContract
+	}
+	fmt.Print(vm.Disassemble(code, contractAbi).String())
+	return nil
+}
+
+// readHex decodes s or the content of the file s is prefixed with @
+func readHex(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "@") {
+		data, err := ioutil.ReadFile(s[1:])
+		if err != nil {
+			return nil, err
+		}
+		s = string(data)
+	}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	return hex.DecodeString(s)
+}
+
+type logJSON struct {
+	Topics []string `json:"topics"`
+	Data   string   `json:"data"`
+}
+
+type sendBlockJSON struct {
+	BlockType byte   `json:"blockType"`
+	ToAddress string `json:"toAddress"`
+	Amount    string `json:"amount"`
+	TokenId   string `json:"tokenId"`
+	Data      string `json:"data"`
+}
+
+type traceJSON struct {
+	Pc         uint64   `json:"pc"`
+	Op         string   `json:"op"`
+	Cost       uint64   `json:"cost"`
+	QuotaLeft  uint64   `json:"quotaLeft"`
+	Stack      []string `json:"stack"`
+	MemorySize int      `json:"memorySize"`
+}
+
+type resultJSON struct {
+	SnapshotHeight uint64            `json:"snapshotHeight"`
+	ReturnData     string            `json:"returnData"`
+	Error          string            `json:"error,omitempty"`
+	QuotaUsed      uint64            `json:"quotaUsed"`
+	QuotaLeft      uint64            `json:"quotaLeft"`
+	Logs           []logJSON         `json:"logs"`
+	LogHash        *types.Hash       `json:"logHash"`
+	SendBlocks     []sendBlockJSON   `json:"sendBlocks"`
+	Balances       map[string]string `json:"balances"`
+	Storage        map[string]string `json:"storage"`
+	Trace          []*traceJSON      `json:"trace,omitempty"`
+}
+
+func toResultJSON(cfg *vm.RunConfig, result *vm.RunResult, db *vmtest.MemoryDatabase) *resultJSON {
+	balances, storage := db.Balances(), db.Storage()
+	out := &resultJSON{
+		SnapshotHeight: cfg.SnapshotHeight,
+		ReturnData:     hex.EncodeToString(result.ReturnData),
+		QuotaUsed:      result.QuotaUsed,
+		QuotaLeft:      result.QuotaLeft,
+		Logs:           make([]logJSON, 0, len(result.Logs)),
+		LogHash:        result.LogHash,
+		SendBlocks:     make([]sendBlockJSON, 0, len(result.SendBlocks)),
+		Balances:       make(map[string]string, len(balances)),
+		Storage:        make(map[string]string, len(storage)),
+	}
+	if result.Err != nil {
+		out.Error = result.Err.Error()
+	}
+	for _, l := range result.Logs {
+		topics := make([]string, len(l.Topics))
+		for i, t := range l.Topics {
+			topics[i] = t.String()
+		}
+		out.Logs = append(out.Logs, logJSON{Topics: topics, Data: hex.EncodeToString(l.Data)})
+	}
+	for _, b := range result.SendBlocks {
+		out.SendBlocks = append(out.SendBlocks, sendBlockJSON{
+			BlockType: b.BlockType,
+			ToAddress: b.ToAddress.String(),
+			Amount:    b.Amount.String(),
+			TokenId:   b.TokenId.String(),
+			Data:      hex.EncodeToString(b.Data),
+		})
+	}
+	for tokenId, balance := range balances {
+		out.Balances[tokenId.String()] = balance.String()
+	}
+	for k, v := range storage {
+		out.Storage[k] = hex.EncodeToString(v)
+	}
+	return out
+}
+
+func toTraceJSON(step *vm.TraceStep) *traceJSON {
+	stack := make([]string, len(step.Stack))
+	for i, v := range step.Stack {
+		stack[i] = "0x" + v.Text(16)
+	}
+	return &traceJSON{
+		Pc:         step.Pc,
+		Op:         step.Op,
+		Cost:       step.Cost,
+		QuotaLeft:  step.QuotaLeft,
+		Stack:      stack,
+		MemorySize: step.MemorySize,
+	}
+}
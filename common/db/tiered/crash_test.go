@@ -0,0 +1,158 @@
+package tiered
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/opt"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+)
+
+const crashDirEnv = "TIERED_CRASH_DIR"
+
+func crashKey(i int) []byte {
+	return []byte(fmt.Sprintf("crash%08d", i))
+}
+
+// countCrashKeys returns the number of the contiguous crash keys from 0, and fails on a hole after them.
+func countCrashKeys(t testing.TB, db *DB) int {
+	iter := db.NewIterator(util.BytesPrefix([]byte("crash")), nil)
+	defer iter.Release()
+	n := 0
+	for iter.Next() {
+		if string(iter.Key()) != string(crashKey(n)) || string(iter.Value()) != strconv.Itoa(n) {
+			t.Fatalf("crash key %d missing, got %s=%s", n, iter.Key(), iter.Value())
+		}
+		n++
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// TestDB_CrashWriter is run by TestDB_Crash in a child process, it writes batches and prints the number of each
+// batch acknowledged until it is killed.
+func TestDB_CrashWriter(t *testing.T) {
+	dir := os.Getenv(crashDirEnv)
+	if dir == "" {
+		t.Skip("run by TestDB_Crash")
+	}
+	db, err := Open(dir, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := countCrashKeys(t, db); ; i++ {
+		// a batch adds the next key and moves the last key to it, a recovered db has both or neither
+		batch := new(leveldb.Batch)
+		batch.Put(crashKey(i), []byte(strconv.Itoa(i)))
+		batch.Put([]byte("last"), []byte(strconv.Itoa(i)))
+		if i%7 == 0 {
+			// keep the old tables busy with compactions
+			batch.Put([]byte(fmt.Sprintf("filler%03d", i%500)), make([]byte, 200))
+		}
+		if err := db.Write(batch, &opt.WriteOptions{Sync: i%2 == 0}); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Println(i)
+	}
+}
+
+// TestDB_Crash kills a writing process at random points, during flushes and compactions, and checks that every
+// acknowledged batch is recovered and no batch is partially recovered.
+func TestDB_Crash(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping crash test in short mode")
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	r := rand.New(rand.NewSource(4))
+	acked := -1
+	for round := 0; round < 8; round++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDB_CrashWriter$")
+		cmd.Env = append(os.Environ(), crashDirEnv+"="+dir)
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		target := acked + 50 + r.Intn(300)
+		scanner := bufio.NewScanner(stdout)
+		for acked < target && scanner.Scan() {
+			if i, err := strconv.Atoi(scanner.Text()); err == nil {
+				acked = i
+			}
+		}
+		cmd.Process.Kill()
+		ioutil.ReadAll(stdout)
+		cmd.Wait()
+		if acked < target {
+			t.Fatalf("round %d: writer stopped at %d before %d", round, acked, target)
+		}
+
+		db, err := Open(dir, testOptions)
+		if err != nil {
+			t.Fatalf("round %d: reopen after crash failed, %v", round, err)
+		}
+		n := countCrashKeys(t, db)
+		last, err := db.Get([]byte("last"), nil)
+		db.Close()
+		if n <= acked {
+			t.Fatalf("round %d: %d batches acknowledged, %d recovered", round, acked+1, n)
+		}
+		if err != nil || string(last) != strconv.Itoa(n-1) {
+			t.Fatalf("round %d: last key %s doesn't match %d recovered keys, err %v", round, last, n, err)
+		}
+		acked = n - 1
+	}
+}
+
+// TestDB_CrashLeftovers reopens a db with the files left by a crash in the middle of a flush or compaction.
+func TestDB_CrashLeftovers(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := make(map[string]string)
+	randomWrites(t, db, model, rand.New(rand.NewSource(5)), 100)
+	nextFileNum := db.nextFileNum
+	db.Close()
+
+	// a table not in the manifest yet and a manifest not renamed yet
+	orphan := tableFile(dir, nextFileNum+10)
+	if err := ioutil.WriteFile(orphan, []byte("partial table"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ManifestFileName+".tmp"), []byte("{\"tables\":"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if db, err = Open(dir, testOptions); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Fatal("orphan table should be removed", err)
+	}
+	if db.nextFileNum <= nextFileNum+10 {
+		t.Fatal("file numbers should skip the orphan table", db.nextFileNum)
+	}
+	checkModel(t, db, model)
+	randomWrites(t, db, model, rand.New(rand.NewSource(6)), 100)
+	checkModel(t, db, model)
+}
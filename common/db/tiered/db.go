@@ -0,0 +1,781 @@
+// Package tiered is a log-structured key-value engine with size-tiered compaction.
+//
+// Writes go to a wal file and a mem table, full mem tables are flushed to immutable tables
+// by a background goroutine, and adjacent tables of the same tier are merged by another one.
+// Compaction never holds the write path, writes only wait when the flushing falls behind by
+// Options.MaxImmutable mem tables.
+package tiered
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/memdb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/opt"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/interfaces"
+)
+
+var ErrClosed = errors.New("tiered: closed")
+
+// TierStats is the tables of a tier.
+type TierStats struct {
+	Tier    int    `json:"tier"`
+	Tables  int    `json:"tables"`
+	Size    uint64 `json:"size"`
+	Entries uint64 `json:"entries"`
+}
+
+// Stats is the status of a DB.
+type Stats struct {
+	MemTableSize   int           `json:"memTableSize"`
+	Immutables     int           `json:"immutables"`
+	Tiers          []TierStats   `json:"tiers"`
+	Flushes        uint64        `json:"flushes"`
+	Compactions    uint64        `json:"compactions"`
+	CompactionTime time.Duration `json:"compactionTime"`
+	WriteStalls    uint64        `json:"writeStalls"`
+	WriteStallTime time.Duration `json:"writeStallTime"`
+}
+
+type DB struct {
+	dir   string
+	o     *Options
+	cache *lru.Cache
+
+	// protects mem, imm, tables, logNum, err and closed
+	mu     sync.RWMutex
+	cond   *sync.Cond
+	mem    *memTable
+	imm    []*memTable // the newest first
+	tables []*table    // the newest first
+	logNum uint64
+	err    error
+	closed bool
+
+	seq         uint64
+	nextFileNum uint64
+
+	writeMu   sync.Mutex
+	wal       *walWriter
+	compactMu sync.Mutex
+
+	flushC   chan struct{}
+	compactC chan struct{}
+	closeC   chan struct{}
+	wg       sync.WaitGroup
+
+	flushes        uint64
+	compactions    uint64
+	compactionTime int64
+	writeStalls    uint64
+	writeStallTime int64
+}
+
+// Open opens or creates the db in dir, the unflushed wal files are recovered to a table.
+func Open(dir string, o *Options) (*DB, error) {
+	o = o.withDefaults()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	m, err := loadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := lru.New(o.BlockCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{
+		dir:         dir,
+		o:           o,
+		cache:       cache,
+		logNum:      m.LogNum,
+		nextFileNum: m.NextFileNum,
+		flushC:      make(chan struct{}, 1),
+		compactC:    make(chan struct{}, 1),
+		closeC:      make(chan struct{}),
+	}
+	db.cond = sync.NewCond(&db.mu)
+
+	if err := db.recover(m); err != nil {
+		for _, t := range db.tables {
+			t.decref()
+		}
+		return nil, err
+	}
+
+	if err := db.newWal(); err != nil {
+		for _, t := range db.tables {
+			t.decref()
+		}
+		return nil, err
+	}
+
+	db.wg.Add(2)
+	go db.flushLoop()
+	go db.compactLoop()
+	db.triggerCompaction()
+	return db, nil
+}
+
+func (db *DB) recover(m *manifest) error {
+	live := make(map[uint64]bool, len(m.Tables))
+	for _, meta := range m.Tables {
+		t, err := openTable(tableFile(db.dir, meta.Num), meta.Num, meta.Tier, db.cache, db.o)
+		if err != nil {
+			return err
+		}
+		db.tables = append(db.tables, t)
+		live[meta.Num] = true
+	}
+
+	files, err := ioutil.ReadDir(db.dir)
+	if err != nil {
+		return err
+	}
+	var logs []uint64
+	for _, f := range files {
+		name := f.Name()
+		ext := ""
+		if strings.HasSuffix(name, ".sst") || strings.HasSuffix(name, ".log") {
+			ext = name[len(name)-4:]
+		}
+		num, err := strconv.ParseUint(strings.TrimSuffix(name, ext), 10, 64)
+		if ext == "" || err != nil {
+			continue
+		}
+		if num >= db.nextFileNum {
+			db.nextFileNum = num + 1
+		}
+		switch {
+		case ext == ".sst" && !live[num]:
+			// left by an interrupted flush or compaction
+			os.Remove(tableFile(db.dir, num))
+		case ext == ".log" && num < db.logNum:
+			os.Remove(walFile(db.dir, num))
+		case ext == ".log":
+			logs = append(logs, num)
+		}
+	}
+	if len(logs) == 0 {
+		// a new db is marked by the manifest
+		if _, err := os.Stat(filepath.Join(db.dir, ManifestFileName)); os.IsNotExist(err) {
+			return db.saveManifest(db.tables)
+		}
+		return nil
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i] < logs[j] })
+
+	mem := newMemTable(logs[len(logs)-1])
+	r := &memReplay{mdb: mem.mdb}
+	batch := new(leveldb.Batch)
+	for _, num := range logs {
+		// a torn record is a batch not completely written, it is dropped
+		if _, err := readWal(walFile(db.dir, num), func(data []byte) error {
+			if err := batch.Load(data); err != nil {
+				return err
+			}
+			return batch.Replay(r)
+		}); err != nil {
+			return err
+		}
+	}
+	if !mem.empty() {
+		t, err := db.writeMemTable(mem)
+		if err != nil {
+			return err
+		}
+		db.tables = append([]*table{t}, db.tables...)
+	}
+	db.logNum = logs[len(logs)-1] + 1
+	if err := db.saveManifest(db.tables); err != nil {
+		return err
+	}
+	for _, num := range logs {
+		os.Remove(walFile(db.dir, num))
+	}
+	return nil
+}
+
+func (db *DB) allocFileNum() uint64 {
+	return atomic.AddUint64(&db.nextFileNum, 1) - 1
+}
+
+// newWal switches the mem table to a new wal file, the current one is frozen.
+func (db *DB) newWal() error {
+	num := db.allocFileNum()
+	wal, err := createWal(walFile(db.dir, num), num)
+	if err != nil {
+		return err
+	}
+	if db.wal != nil {
+		if err := db.wal.close(); err != nil {
+			wal.close()
+			return err
+		}
+	}
+	db.wal = wal
+
+	db.mu.Lock()
+	if db.mem != nil {
+		db.imm = append([]*memTable{db.mem}, db.imm...)
+	}
+	db.mem = newMemTable(num)
+	db.mu.Unlock()
+	return nil
+}
+
+// saveManifest saves the tables with the current log number and file number.
+func (db *DB) saveManifest(tables []*table) error {
+	m := &manifest{
+		NextFileNum: atomic.LoadUint64(&db.nextFileNum),
+		LogNum:      db.logNum,
+		Tables:      make([]tableMeta, len(tables)),
+	}
+	for i, t := range tables {
+		m.Tables[i] = tableMeta{Num: t.num, Tier: t.tier}
+	}
+	return saveManifest(db.dir, m)
+}
+
+func (db *DB) ok() error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.closed {
+		return ErrClosed
+	}
+	return db.err
+}
+
+func (db *DB) setErr(err error) {
+	db.mu.Lock()
+	if db.err == nil {
+		db.err = err
+	}
+	db.cond.Broadcast()
+	db.mu.Unlock()
+}
+
+// makeRoomForWrite freezes the mem table if it's full, and waits if too many mem tables are not flushed.
+func (db *DB) makeRoomForWrite() error {
+	db.mu.Lock()
+	if db.mem.mdb.Size() < db.o.MemTableSize {
+		db.mu.Unlock()
+		return nil
+	}
+	if len(db.imm) >= db.o.MaxImmutable {
+		start := time.Now()
+		atomic.AddUint64(&db.writeStalls, 1)
+		for len(db.imm) >= db.o.MaxImmutable && db.err == nil && !db.closed {
+			db.cond.Wait()
+		}
+		atomic.AddInt64(&db.writeStallTime, int64(time.Since(start)))
+	}
+	err := db.err
+	if db.closed {
+		err = ErrClosed
+	}
+	db.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := db.newWal(); err != nil {
+		return err
+	}
+	db.triggerFlush()
+	return nil
+}
+
+// Write applies the batch atomically, the batch is synced to disk if wo.Sync is set.
+func (db *DB) Write(batch *leveldb.Batch, wo *opt.WriteOptions) error {
+	if batch == nil || batch.Len() == 0 {
+		return db.ok()
+	}
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	if err := db.ok(); err != nil {
+		return err
+	}
+	if err := db.makeRoomForWrite(); err != nil {
+		return err
+	}
+	if err := db.wal.append(batch.Dump(), wo != nil && wo.Sync); err != nil {
+		db.setErr(err)
+		return err
+	}
+
+	db.mu.RLock()
+	mem := db.mem
+	db.mu.RUnlock()
+
+	r := &memReplay{mdb: mem.mdb, seq: atomic.LoadUint64(&db.seq)}
+	if err := batch.Replay(r); err != nil {
+		return err
+	}
+	// the batch becomes visible at once
+	atomic.StoreUint64(&db.seq, r.seq)
+	return nil
+}
+
+type view struct {
+	mems   []*memTable
+	tables []*table
+	seq    uint64
+}
+
+func (db *DB) acquireView() *view {
+	db.mu.RLock()
+	v := &view{
+		mems:   append([]*memTable{db.mem}, db.imm...),
+		tables: append([]*table(nil), db.tables...),
+		seq:    atomic.LoadUint64(&db.seq),
+	}
+	for _, t := range v.tables {
+		t.incref()
+	}
+	db.mu.RUnlock()
+	return v
+}
+
+func (v *view) release() {
+	for _, t := range v.tables {
+		t.decref()
+	}
+	v.tables = nil
+}
+
+// Get2 returns the value of the key in auxm overlaid on the db, entries in auxm newer than seq are ignored.
+// It returns leveldb.ErrNotFound if the key doesn't exist.
+func (db *DB) Get2(key []byte, ro *opt.ReadOptions, auxm *memdb.DB, seq uint64) ([]byte, error) {
+	if err := db.ok(); err != nil {
+		return nil, err
+	}
+	if auxm != nil {
+		if ok, mv, err := leveldb.MemGet(auxm, leveldb.MakeInternalKey(nil, key, seq, leveldb.KeyTypeSeek), icmp); ok {
+			return append([]byte{}, mv...), err
+		}
+	}
+
+	v := db.acquireView()
+	defer v.release()
+
+	ikey := leveldb.MakeInternalKey(nil, key, v.seq, leveldb.KeyTypeSeek)
+	for _, m := range v.mems {
+		if ok, mv, err := leveldb.MemGet(m.mdb, ikey, icmp); ok {
+			return append([]byte{}, mv...), err
+		}
+	}
+	for _, t := range v.tables {
+		value, deleted, found, err := t.get(key)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			if deleted {
+				return nil, leveldb.ErrNotFound
+			}
+			return append([]byte{}, value...), nil
+		}
+	}
+	return nil, leveldb.ErrNotFound
+}
+
+// Get returns the value of the key, or leveldb.ErrNotFound.
+func (db *DB) Get(key []byte, ro *opt.ReadOptions) ([]byte, error) {
+	return db.Get2(key, ro, nil, 0)
+}
+
+// NewIterator2 returns an iterator of auxm overlaid on the db, entries in auxm newer than seq are ignored.
+// The iterator reads a consistent view of the db and must be released after use.
+func (db *DB) NewIterator2(slice *util.Range, ro *opt.ReadOptions, auxm *memdb.DB, seq uint64) interfaces.StorageIterator {
+	if err := db.ok(); err != nil {
+		return &errIterator{err: err}
+	}
+	v := db.acquireView()
+
+	srcs := make([]source, 0, len(v.mems)+len(v.tables)+1)
+	if auxm != nil {
+		srcs = append(srcs, newMemSource(auxm, seq))
+	}
+	for _, m := range v.mems {
+		srcs = append(srcs, newMemSource(m.mdb, v.seq))
+	}
+	for _, t := range v.tables {
+		srcs = append(srcs, newTableSource(t))
+	}
+	return newMergedIterator(srcs, slice, false, v.release)
+}
+
+// NewIterator returns an iterator of the db.
+func (db *DB) NewIterator(slice *util.Range, ro *opt.ReadOptions) interfaces.StorageIterator {
+	return db.NewIterator2(slice, ro, nil, 0)
+}
+
+func (db *DB) triggerFlush() {
+	select {
+	case db.flushC <- struct{}{}:
+	default:
+	}
+}
+
+func (db *DB) triggerCompaction() {
+	select {
+	case db.compactC <- struct{}{}:
+	default:
+	}
+}
+
+// buildTable writes a table by fill, it returns nil if nothing is added.
+func (db *DB) buildTable(tier int, fill func(tw *tableWriter) error) (*table, error) {
+	num := db.allocFileNum()
+	file := tableFile(db.dir, num)
+	tw, err := newTableWriter(file, db.o)
+	if err != nil {
+		return nil, err
+	}
+	if err := fill(tw); err != nil {
+		tw.abort()
+		return nil, err
+	}
+	if tw.entries == 0 {
+		tw.abort()
+		return nil, nil
+	}
+	if _, err := tw.finish(); err != nil {
+		os.Remove(file)
+		return nil, err
+	}
+	return openTable(file, num, tier, db.cache, db.o)
+}
+
+// writeMemTable writes the latest entry of each key in the mem table to a tier 0 table.
+func (db *DB) writeMemTable(m *memTable) (*table, error) {
+	return db.buildTable(0, func(tw *tableWriter) error {
+		it := m.mdb.NewIterator(nil)
+		defer it.Release()
+		var last []byte
+		for ok := it.First(); ok; ok = it.Next() {
+			ukey, _, kt, err := leveldb.ParseInternalKey(it.Key())
+			if err != nil {
+				return err
+			}
+			// entries of a key are ordered from the newest
+			if tw.entries > 0 && bytes.Equal(ukey, last) {
+				continue
+			}
+			last = append(last[:0], ukey...)
+			if err := tw.add(ukey, it.Value(), kt == leveldb.KeyTypeDel); err != nil {
+				return err
+			}
+		}
+		return it.Error()
+	})
+}
+
+func (db *DB) flushLoop() {
+	defer db.wg.Done()
+	for {
+		select {
+		case <-db.closeC:
+			return
+		case <-db.flushC:
+		}
+		for {
+			select {
+			case <-db.closeC:
+				return
+			default:
+			}
+			db.mu.RLock()
+			if len(db.imm) == 0 || db.err != nil {
+				db.mu.RUnlock()
+				break
+			}
+			m := db.imm[len(db.imm)-1]
+			db.mu.RUnlock()
+
+			if err := db.flush(m); err != nil {
+				db.setErr(err)
+				return
+			}
+		}
+	}
+}
+
+// flush writes the oldest frozen mem table to a table, and removes its wal file.
+func (db *DB) flush(m *memTable) error {
+	t, err := db.writeMemTable(m)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	tables := db.tables
+	if t != nil {
+		tables = append([]*table{t}, db.tables...)
+	}
+	db.logNum = m.walNum + 1
+	if err := db.saveManifest(tables); err != nil {
+		db.mu.Unlock()
+		if t != nil {
+			atomic.StoreInt32(&t.obsolete, 1)
+			t.decref()
+		}
+		return err
+	}
+	db.tables = tables
+	db.imm = db.imm[:len(db.imm)-1]
+	db.cond.Broadcast()
+	db.mu.Unlock()
+
+	os.Remove(walFile(db.dir, m.walNum))
+	atomic.AddUint64(&db.flushes, 1)
+	db.triggerCompaction()
+	return nil
+}
+
+func (db *DB) compactLoop() {
+	defer db.wg.Done()
+	for {
+		select {
+		case <-db.closeC:
+			return
+		case <-db.compactC:
+		}
+		for {
+			select {
+			case <-db.closeC:
+				return
+			default:
+			}
+			db.compactMu.Lock()
+			run, tier := db.pickCompaction()
+			if run == nil {
+				db.compactMu.Unlock()
+				break
+			}
+			err := db.compact(run, tier)
+			db.compactMu.Unlock()
+			if err != nil {
+				db.setErr(err)
+				return
+			}
+		}
+	}
+}
+
+// pickCompaction returns the first run of CompactionFanIn adjacent tables of the same tier.
+func (db *DB) pickCompaction() ([]*table, int) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.err != nil {
+		return nil, 0
+	}
+	for i := 0; i < len(db.tables); {
+		j := i + 1
+		for j < len(db.tables) && db.tables[j].tier == db.tables[i].tier {
+			j++
+		}
+		if j-i >= db.o.CompactionFanIn {
+			return append([]*table(nil), db.tables[i:j]...), db.tables[i].tier + 1
+		}
+		i = j
+	}
+	return nil, 0
+}
+
+// compact merges the adjacent tables into one table of the tier, the caller must hold compactMu.
+func (db *DB) compact(run []*table, tier int) error {
+	start := time.Now()
+
+	db.mu.RLock()
+	// tombstones can be dropped only if there is no older table
+	dropDeleted := run[len(run)-1] == db.tables[len(db.tables)-1]
+	db.mu.RUnlock()
+
+	srcs := make([]source, len(run))
+	for i, t := range run {
+		srcs[i] = newTableSource(t)
+	}
+	it := newMergedIterator(srcs, nil, true, nil)
+	t, err := db.buildTable(tier, func(tw *tableWriter) error {
+		for ok := it.First(); ok; ok = it.Next() {
+			if it.Deleted() && dropDeleted {
+				continue
+			}
+			if err := tw.add(it.Key(), it.Value(), it.Deleted()); err != nil {
+				return err
+			}
+		}
+		return it.Error()
+	})
+	it.Release()
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	pos := -1
+	for i, existing := range db.tables {
+		if existing == run[0] {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 || pos+len(run) > len(db.tables) || db.tables[pos+len(run)-1] != run[len(run)-1] {
+		db.mu.Unlock()
+		if t != nil {
+			atomic.StoreInt32(&t.obsolete, 1)
+			t.decref()
+		}
+		return errors.New("tiered: compacted tables changed")
+	}
+	tables := append([]*table(nil), db.tables[:pos]...)
+	if t != nil {
+		tables = append(tables, t)
+	}
+	tables = append(tables, db.tables[pos+len(run):]...)
+	if err := db.saveManifest(tables); err != nil {
+		db.mu.Unlock()
+		if t != nil {
+			atomic.StoreInt32(&t.obsolete, 1)
+			t.decref()
+		}
+		return err
+	}
+	db.tables = tables
+	db.mu.Unlock()
+
+	for _, old := range run {
+		atomic.StoreInt32(&old.obsolete, 1)
+		old.decref()
+	}
+	atomic.AddUint64(&db.compactions, 1)
+	atomic.AddInt64(&db.compactionTime, int64(time.Since(start)))
+	return nil
+}
+
+// CompactRange flushes the mem table and merges all tables into one, the range is ignored.
+func (db *DB) CompactRange(r util.Range) error {
+	db.writeMu.Lock()
+	err := db.ok()
+	if err == nil && !db.mem.empty() {
+		err = db.newWal()
+		db.triggerFlush()
+	}
+	db.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	for len(db.imm) > 0 && db.err == nil && !db.closed {
+		db.cond.Wait()
+	}
+	db.mu.Unlock()
+	if err := db.ok(); err != nil {
+		return err
+	}
+
+	db.compactMu.Lock()
+	defer db.compactMu.Unlock()
+	db.mu.RLock()
+	run := append([]*table(nil), db.tables...)
+	db.mu.RUnlock()
+	if len(run) <= 1 {
+		return nil
+	}
+	tier := 0
+	for _, t := range run {
+		if t.tier > tier {
+			tier = t.tier
+		}
+	}
+	return db.compact(run, tier)
+}
+
+// Stats fills the status of the db.
+func (db *DB) Stats(s *Stats) error {
+	if err := db.ok(); err != nil {
+		return err
+	}
+	db.mu.RLock()
+	s.MemTableSize = db.mem.mdb.Size()
+	s.Immutables = len(db.imm)
+	s.Tiers = nil
+	tiers := make(map[int]*TierStats)
+	for _, t := range db.tables {
+		ts, ok := tiers[t.tier]
+		if !ok {
+			ts = &TierStats{Tier: t.tier}
+			tiers[t.tier] = ts
+		}
+		ts.Tables++
+		ts.Size += t.size
+		ts.Entries += t.entries
+	}
+	db.mu.RUnlock()
+	for _, ts := range tiers {
+		s.Tiers = append(s.Tiers, *ts)
+	}
+	sort.Slice(s.Tiers, func(i, j int) bool { return s.Tiers[i].Tier < s.Tiers[j].Tier })
+
+	s.Flushes = atomic.LoadUint64(&db.flushes)
+	s.Compactions = atomic.LoadUint64(&db.compactions)
+	s.CompactionTime = time.Duration(atomic.LoadInt64(&db.compactionTime))
+	s.WriteStalls = atomic.LoadUint64(&db.writeStalls)
+	s.WriteStallTime = time.Duration(atomic.LoadInt64(&db.writeStallTime))
+	return nil
+}
+
+// Close stops the background goroutines and closes the files, the unflushed mem tables stay in their wal files.
+func (db *DB) Close() error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return ErrClosed
+	}
+	db.closed = true
+	db.cond.Broadcast()
+	db.mu.Unlock()
+
+	close(db.closeC)
+	db.wg.Wait()
+
+	err := db.wal.close()
+	for _, t := range db.tables {
+		t.decref()
+	}
+	return err
+}
+
+type errIterator struct {
+	err error
+}
+
+func (it *errIterator) Last() bool           { return false }
+func (it *errIterator) Prev() bool           { return false }
+func (it *errIterator) Seek(key []byte) bool { return false }
+func (it *errIterator) Next() bool           { return false }
+func (it *errIterator) Key() []byte          { return nil }
+func (it *errIterator) Value() []byte        { return nil }
+func (it *errIterator) Error() error         { return it.err }
+func (it *errIterator) Release()             {}
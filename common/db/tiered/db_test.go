@@ -0,0 +1,338 @@
+package tiered
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/comparer"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/memdb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/interfaces"
+)
+
+var testOptions = &Options{
+	MemTableSize:    4 * 1024,
+	MaxImmutable:    2,
+	BlockSize:       256,
+	CompactionFanIn: 3,
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tiered")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func randomWrites(t *testing.T, db *DB, model map[string]string, r *rand.Rand, batches int) {
+	for i := 0; i < batches; i++ {
+		batch := new(leveldb.Batch)
+		for j := 0; j < 20; j++ {
+			key := fmt.Sprintf("key%04d", r.Intn(1000))
+			if r.Intn(4) == 0 {
+				batch.Delete([]byte(key))
+				delete(model, key)
+			} else {
+				value := fmt.Sprintf("value%d-%d", i, j)
+				batch.Put([]byte(key), []byte(value))
+				model[key] = value
+			}
+		}
+		if err := db.Write(batch, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func sortedKeys(model map[string]string, start, limit string) []string {
+	var keys []string
+	for k := range model {
+		if (start == "" || k >= start) && (limit == "" || k < limit) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func checkModel(t *testing.T, db *DB, model map[string]string) {
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%04d", i)
+		value, err := db.Get([]byte(key), nil)
+		expected, ok := model[key]
+		if !ok {
+			if err != leveldb.ErrNotFound {
+				t.Fatalf("%s should not exist, value %s, err %v", key, value, err)
+			}
+			continue
+		}
+		if err != nil || string(value) != expected {
+			t.Fatalf("%s expected %s, got %s, err %v", key, expected, value, err)
+		}
+	}
+
+	checkIterator(t, db.NewIterator(nil, nil), model, "", "")
+	checkIterator(t, db.NewIterator(&util.Range{Start: []byte("key0100"), Limit: []byte("key0500")}, nil), model, "key0100", "key0500")
+}
+
+func checkIterator(t *testing.T, iter interfaces.StorageIterator, model map[string]string, start, limit string) {
+	defer iter.Release()
+	keys := sortedKeys(model, start, limit)
+
+	i := 0
+	for iter.Next() {
+		if i >= len(keys) || string(iter.Key()) != keys[i] || string(iter.Value()) != model[keys[i]] {
+			t.Fatalf("forward %d: got %s=%s", i, iter.Key(), iter.Value())
+		}
+		i++
+	}
+	if i != len(keys) {
+		t.Fatalf("forward got %d keys, expected %d", i, len(keys))
+	}
+
+	i = len(keys) - 1
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		if i < 0 || string(iter.Key()) != keys[i] {
+			t.Fatalf("backward %d: got %s", i, iter.Key())
+		}
+		i--
+	}
+	if i != -1 {
+		t.Fatalf("backward stopped at %d", i)
+	}
+
+	// change direction in the middle
+	if len(keys) > 3 {
+		if !iter.Seek([]byte(keys[2])) || string(iter.Key()) != keys[2] {
+			t.Fatal("seek failed")
+		}
+		if !iter.Prev() || string(iter.Key()) != keys[1] {
+			t.Fatal("prev after seek failed", string(iter.Key()))
+		}
+		if !iter.Next() || string(iter.Key()) != keys[2] {
+			t.Fatal("next after prev failed", string(iter.Key()))
+		}
+		if !iter.Next() || string(iter.Key()) != keys[3] {
+			t.Fatal("next failed", string(iter.Key()))
+		}
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDB(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := make(map[string]string)
+	r := rand.New(rand.NewSource(1))
+	randomWrites(t, db, model, r, 500)
+	checkModel(t, db, model)
+
+	if err := db.CompactRange(util.Range{}); err != nil {
+		t.Fatal(err)
+	}
+	stats := &Stats{}
+	db.Stats(stats)
+	if stats.Flushes == 0 || stats.Compactions == 0 || len(stats.Tiers) != 1 || stats.Tiers[0].Tables != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	checkModel(t, db, model)
+
+	// writes after the last flush are recovered from the wal
+	randomWrites(t, db, model, r, 3)
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if db, err = Open(dir, testOptions); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	checkModel(t, db, model)
+}
+
+func TestDB_IteratorSnapshot(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	model := make(map[string]string)
+	r := rand.New(rand.NewSource(2))
+	randomWrites(t, db, model, r, 100)
+
+	snapshot := make(map[string]string, len(model))
+	for k, v := range model {
+		snapshot[k] = v
+	}
+	iter := db.NewIterator(nil, nil)
+
+	// flushes and compactions while the iterator is alive
+	randomWrites(t, db, model, r, 300)
+	if err := db.CompactRange(util.Range{}); err != nil {
+		t.Fatal(err)
+	}
+	checkIterator(t, iter, snapshot, "", "")
+	checkModel(t, db, model)
+}
+
+func TestDB_TornWal(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := new(leveldb.Batch)
+	batch.Put([]byte("a"), []byte("1"))
+	db.Write(batch, nil)
+	batch = new(leveldb.Batch)
+	batch.Put([]byte("b"), []byte("2"))
+	batch.Put([]byte("c"), []byte("3"))
+	db.Write(batch, nil)
+	walNum := db.wal.num
+	db.Close()
+
+	// cut the last batch in half
+	file := walFile(dir, walNum)
+	stat, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(file, stat.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if value, err := db.Get([]byte("a"), nil); err != nil || string(value) != "1" {
+		t.Fatal("complete batch should be recovered", err)
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, err := db.Get([]byte(key), nil); err != leveldb.ErrNotFound {
+			t.Fatal("torn batch should be dropped", key, err)
+		}
+	}
+}
+
+func TestDB_CorruptedWal(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b"} {
+		batch := new(leveldb.Batch)
+		batch.Put([]byte(key), []byte("1"))
+		db.Write(batch, nil)
+	}
+	walNum := db.wal.num
+	db.Close()
+
+	// a checksum mismatch of the first record isn't a torn tail
+	file := walFile(dir, walNum)
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content[walHeaderSize] ^= 0xff
+	if err := ioutil.WriteFile(file, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if db, err = Open(dir, nil); err == nil {
+		db.Close()
+		t.Fatal("corrupted wal should fail to open")
+	}
+}
+
+func TestDB_Overlay(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	batch := new(leveldb.Batch)
+	for _, key := range []string{"a", "b", "c"} {
+		batch.Put([]byte(key), []byte("disk"))
+	}
+	db.Write(batch, nil)
+
+	// the overlay uses internal keys like common/db.MemDB
+	mdb := memdb.New(leveldb.NewIComparer(comparer.DefaultComparer), 0)
+	mdb.Put(leveldb.MakeInternalKey(nil, []byte("b"), 10, leveldb.KeyTypeDel), nil)
+	mdb.Put(leveldb.MakeInternalKey(nil, []byte("d"), 11, leveldb.KeyTypeVal), []byte("mem"))
+	mdb.Put(leveldb.MakeInternalKey(nil, []byte("a"), 20, leveldb.KeyTypeVal), []byte("newer"))
+
+	if value, err := db.Get2([]byte("a"), nil, mdb, 15); err != nil || string(value) != "disk" {
+		t.Fatal("entries newer than seq should be ignored", string(value), err)
+	}
+	if _, err := db.Get2([]byte("b"), nil, mdb, 15); err != leveldb.ErrNotFound {
+		t.Fatal("deleted in overlay", err)
+	}
+
+	iter := db.NewIterator2(nil, nil, mdb, 15)
+	defer iter.Release()
+	var result []string
+	for iter.Next() {
+		result = append(result, string(iter.Key())+"="+string(iter.Value()))
+	}
+	if !bytes.Equal([]byte(fmt.Sprint(result)), []byte("[a=disk c=disk d=mem]")) {
+		t.Fatal("unexpected overlay iteration", result)
+	}
+}
+
+func TestDB_WriteDuringCompaction(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	model := make(map[string]string)
+	r := rand.New(rand.NewSource(3))
+
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 5; i++ {
+			if err := db.CompactRange(util.Range{}); err != nil {
+				done <- err
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		done <- nil
+	}()
+	randomWrites(t, db, model, r, 300)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	checkModel(t, db, model)
+}
@@ -0,0 +1,127 @@
+package tiered
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+)
+
+// FuzzDB applies the operations encoded in the input to a db and a map, and compares them after each reopen
+// and at the end. An operation is 2 bytes, the kind and the key.
+func FuzzDB(f *testing.F) {
+	f.Add([]byte{0, 1, 0, 2, 1, 1, 2, 0, 3, 0})
+	f.Add([]byte{0, 5, 0, 6, 0, 7, 3, 0, 1, 6, 2, 0, 0, 6})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		if len(ops) > 4096 {
+			return
+		}
+		dir, err := ioutil.TempDir("", "tiered_fuzz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		options := &Options{MemTableSize: 512, MaxImmutable: 2, BlockSize: 64, CompactionFanIn: 2}
+		db, err := Open(dir, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			db.Close()
+		}()
+		model := make(map[string]string)
+		batch := new(leveldb.Batch)
+		for i := 0; i+1 < len(ops); i += 2 {
+			key := fmt.Sprintf("key%04d", int(ops[i+1])*3)
+			switch ops[i] % 5 {
+			case 0:
+				value := fmt.Sprintf("value%d", i)
+				batch.Put([]byte(key), []byte(value))
+				model[key] = value
+			case 1:
+				batch.Delete([]byte(key))
+				delete(model, key)
+			case 2:
+				if err := db.Write(batch, nil); err != nil {
+					t.Fatal(err)
+				}
+				batch.Reset()
+			case 3:
+				if err := db.Write(batch, nil); err != nil {
+					t.Fatal(err)
+				}
+				batch.Reset()
+				if err := db.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if db, err = Open(dir, options); err != nil {
+					t.Fatal(err)
+				}
+				checkModel(t, db, model)
+			case 4:
+				if err := db.Write(batch, nil); err != nil {
+					t.Fatal(err)
+				}
+				batch.Reset()
+				if err := db.CompactRange(util.Range{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := db.Write(batch, nil); err != nil {
+			t.Fatal(err)
+		}
+		checkModel(t, db, model)
+	})
+}
+
+// FuzzReadWal checks that a wal file of any content is either read or rejected, and only a torn tail is dropped.
+func FuzzReadWal(f *testing.F) {
+	dir, err := ioutil.TempDir("", "tiered_wal")
+	if err != nil {
+		f.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "000001.log")
+	wal, err := createWal(file, 1)
+	if err != nil {
+		f.Fatal(err)
+	}
+	wal.append([]byte("record1"), false)
+	wal.append([]byte("record2"), false)
+	wal.close()
+	valid, err := ioutil.ReadFile(file)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(valid)
+	f.Add(valid[:len(valid)-3])
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		file := filepath.Join(t.TempDir(), "000001.log")
+		if err := ioutil.WriteFile(file, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		read := 0
+		torn, err := readWal(file, func(data []byte) error {
+			read += walHeaderSize + len(data)
+			return nil
+		})
+		if err != nil {
+			return
+		}
+		if !torn && read != len(content) {
+			t.Fatalf("%d of %d bytes read without a torn tail", read, len(content))
+		}
+		if torn && read >= len(content) {
+			t.Fatalf("torn tail reported after reading all %d bytes", len(content))
+		}
+	})
+}
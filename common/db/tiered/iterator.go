@@ -0,0 +1,258 @@
+package tiered
+
+import (
+	"bytes"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+)
+
+// source is a sorted sequence of unique user keys, deleted keys are kept as tombstones.
+type source interface {
+	First() bool
+	Last() bool
+	Seek(key []byte) bool
+	Next() bool
+	Prev() bool
+	Valid() bool
+	Key() []byte
+	Value() []byte
+	Deleted() bool
+	Error() error
+	Release()
+}
+
+const (
+	dirReleased = iota - 1
+	dirSOI
+	dirEOI
+	dirBackward
+	dirForward
+)
+
+// mergedIterator merges sources in order of age, the first source is the newest one.
+// When several sources hold the same key the entry of the newest source wins.
+type mergedIterator struct {
+	srcs  []source
+	start []byte
+	limit []byte
+
+	keepDeleted bool
+	dir         int
+
+	key     []byte
+	value   []byte
+	deleted bool
+	skipKey []byte
+	err     error
+
+	releaser func()
+}
+
+func newMergedIterator(srcs []source, slice *util.Range, keepDeleted bool, releaser func()) *mergedIterator {
+	it := &mergedIterator{
+		srcs:        srcs,
+		keepDeleted: keepDeleted,
+		releaser:    releaser,
+	}
+	if slice != nil {
+		it.start = slice.Start
+		it.limit = slice.Limit
+	}
+	return it
+}
+
+// pick returns the index of the source with the smallest key if forward, or the largest key if backward.
+func (it *mergedIterator) pick(forward bool) int {
+	index := -1
+	for i, s := range it.srcs {
+		if !s.Valid() {
+			if err := s.Error(); err != nil && it.err == nil {
+				it.err = err
+			}
+			continue
+		}
+		if index < 0 {
+			index = i
+			continue
+		}
+		c := bytes.Compare(s.Key(), it.srcs[index].Key())
+		if (forward && c < 0) || (!forward && c > 0) {
+			index = i
+		}
+	}
+	return index
+}
+
+func (it *mergedIterator) settle(forward bool) bool {
+	for it.err == nil {
+		i := it.pick(forward)
+		if i < 0 || it.err != nil {
+			break
+		}
+		s := it.srcs[i]
+		k := s.Key()
+		if forward && it.limit != nil && bytes.Compare(k, it.limit) >= 0 {
+			break
+		}
+		if !forward && it.start != nil && bytes.Compare(k, it.start) < 0 {
+			break
+		}
+		if !s.Deleted() || it.keepDeleted {
+			if forward {
+				it.dir = dirForward
+			} else {
+				it.dir = dirBackward
+			}
+			it.key = append(it.key[:0], k...)
+			it.value = s.Value()
+			it.deleted = s.Deleted()
+			return true
+		}
+		it.skipKey = append(it.skipKey[:0], k...)
+		it.step(it.skipKey, forward)
+	}
+	if forward {
+		it.dir = dirEOI
+	} else {
+		it.dir = dirSOI
+	}
+	it.key, it.value = nil, nil
+	return false
+}
+
+// step moves the sources positioned at the key one step in the direction.
+func (it *mergedIterator) step(key []byte, forward bool) {
+	for _, s := range it.srcs {
+		if !s.Valid() || !bytes.Equal(s.Key(), key) {
+			continue
+		}
+		if forward {
+			s.Next()
+		} else {
+			s.Prev()
+		}
+	}
+}
+
+func (it *mergedIterator) seek(key []byte) bool {
+	for _, s := range it.srcs {
+		s.Seek(key)
+	}
+	return it.settle(true)
+}
+
+func (it *mergedIterator) First() bool {
+	if it.dir == dirReleased {
+		return false
+	}
+	if it.start != nil {
+		return it.seek(it.start)
+	}
+	for _, s := range it.srcs {
+		s.First()
+	}
+	return it.settle(true)
+}
+
+func (it *mergedIterator) Last() bool {
+	if it.dir == dirReleased {
+		return false
+	}
+	for _, s := range it.srcs {
+		if it.limit == nil {
+			s.Last()
+		} else if s.Seek(it.limit) {
+			s.Prev()
+		} else {
+			s.Last()
+		}
+	}
+	return it.settle(false)
+}
+
+func (it *mergedIterator) Seek(key []byte) bool {
+	if it.dir == dirReleased {
+		return false
+	}
+	if it.start != nil && bytes.Compare(key, it.start) < 0 {
+		key = it.start
+	}
+	return it.seek(key)
+}
+
+func (it *mergedIterator) Next() bool {
+	switch it.dir {
+	case dirSOI:
+		return it.First()
+	case dirEOI, dirReleased:
+		return false
+	case dirBackward:
+		// the sources are at the largest keys not greater than the current key,
+		// move them to the smallest keys not less than the current key.
+		for _, s := range it.srcs {
+			s.Seek(it.key)
+		}
+	}
+	it.step(it.key, true)
+	return it.settle(true)
+}
+
+func (it *mergedIterator) Prev() bool {
+	switch it.dir {
+	case dirEOI:
+		return it.Last()
+	case dirSOI, dirReleased:
+		return false
+	case dirForward:
+		// the sources are at the smallest keys not less than the current key,
+		// move them to the largest keys not greater than the current key.
+		for _, s := range it.srcs {
+			if !s.Seek(it.key) {
+				s.Last()
+			} else if bytes.Compare(s.Key(), it.key) > 0 {
+				s.Prev()
+			}
+		}
+	}
+	it.step(it.key, false)
+	return it.settle(false)
+}
+
+func (it *mergedIterator) Key() []byte {
+	if it.dir != dirForward && it.dir != dirBackward {
+		return nil
+	}
+	return it.key
+}
+
+func (it *mergedIterator) Value() []byte {
+	if it.dir != dirForward && it.dir != dirBackward {
+		return nil
+	}
+	return it.value
+}
+
+// Deleted reports whether the current key is a tombstone, only for iterators keeping deleted keys.
+func (it *mergedIterator) Deleted() bool {
+	return it.deleted
+}
+
+func (it *mergedIterator) Error() error {
+	return it.err
+}
+
+func (it *mergedIterator) Release() {
+	if it.dir == dirReleased {
+		return
+	}
+	it.dir = dirReleased
+	for _, s := range it.srcs {
+		s.Release()
+	}
+	it.srcs = nil
+	it.key, it.value = nil, nil
+	if it.releaser != nil {
+		it.releaser()
+		it.releaser = nil
+	}
+}
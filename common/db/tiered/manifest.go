@@ -0,0 +1,87 @@
+package tiered
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFileName is the name of the file listing the live tables, its existence marks a tiered db dir.
+const ManifestFileName = "TIERED_MANIFEST"
+
+type tableMeta struct {
+	Num  uint64 `json:"num"`
+	Tier int    `json:"tier"`
+}
+
+type manifest struct {
+	NextFileNum uint64 `json:"nextFileNum"`
+	// LogNum is the smallest number of the wal files not flushed yet
+	LogNum uint64 `json:"logNum"`
+	// Tables are ordered from the newest to the oldest
+	Tables []tableMeta `json:"tables"`
+}
+
+func tableFile(dir string, num uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%06d.sst", num))
+}
+
+func walFile(dir string, num uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%06d.log", num))
+}
+
+func loadManifest(dir string) (*manifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFileName))
+	if os.IsNotExist(err) {
+		return &manifest{NextFileNum: 1}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parse %s failed, %v", ManifestFileName, err)
+	}
+	return m, nil
+}
+
+// saveManifest replaces the manifest atomically.
+func saveManifest(dir string, m *manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, ManifestFileName+".tmp")
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, ManifestFileName)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// some platforms don't support syncing a directory
+	d.Sync()
+	return nil
+}
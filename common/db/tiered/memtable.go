@@ -0,0 +1,178 @@
+package tiered
+
+import (
+	"bytes"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/comparer"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/iterator"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/memdb"
+)
+
+var icmp = leveldb.NewIComparer(comparer.DefaultComparer)
+
+// memTable holds the writes of a wal file, keys are leveldb internal keys so that
+// readers only see the entries not newer than their sequence.
+type memTable struct {
+	mdb    *memdb.DB
+	walNum uint64
+}
+
+func newMemTable(walNum uint64) *memTable {
+	return &memTable{
+		mdb:    memdb.New(icmp, 0),
+		walNum: walNum,
+	}
+}
+
+func (m *memTable) empty() bool {
+	return m.mdb.Len() == 0
+}
+
+// memReplay puts the records of a batch into a mem table with increasing sequences.
+type memReplay struct {
+	mdb *memdb.DB
+	seq uint64
+	buf []byte
+}
+
+func (r *memReplay) Put(key, value []byte) {
+	r.seq++
+	r.buf = leveldb.MakeInternalKey(r.buf, key, r.seq, leveldb.KeyTypeVal)
+	r.mdb.Put(r.buf, value)
+}
+
+func (r *memReplay) Delete(key []byte) {
+	r.seq++
+	r.buf = leveldb.MakeInternalKey(r.buf, key, r.seq, leveldb.KeyTypeDel)
+	r.mdb.Put(r.buf, nil)
+}
+
+// memSource iterates the latest entry of each user key in a mem db whose sequence is not greater than seq.
+type memSource struct {
+	it  iterator.Iterator
+	seq uint64
+
+	key     []byte
+	value   []byte
+	deleted bool
+	valid   bool
+	seekBuf []byte
+	err     error
+}
+
+func newMemSource(mdb *memdb.DB, seq uint64) *memSource {
+	return &memSource{
+		it:  mdb.NewIterator(nil),
+		seq: seq,
+	}
+}
+
+func (m *memSource) parse() (ukey []byte, seq uint64, deleted bool, ok bool) {
+	ukey, seq, kt, err := leveldb.ParseInternalKey(m.it.Key())
+	if err != nil {
+		m.err = err
+		return nil, 0, false, false
+	}
+	return ukey, seq, kt == leveldb.KeyTypeDel, true
+}
+
+func (m *memSource) set(ukey []byte, deleted bool) {
+	m.key = append(m.key[:0], ukey...)
+	m.value = m.it.Value()
+	m.deleted = deleted
+	m.valid = true
+}
+
+// forward settles on the first visible entry at or after the position of the underlying iterator.
+func (m *memSource) forward(ok bool) bool {
+	for ; ok; ok = m.it.Next() {
+		ukey, seq, deleted, parsed := m.parse()
+		if !parsed {
+			break
+		}
+		if seq <= m.seq {
+			m.set(ukey, deleted)
+			return true
+		}
+	}
+	m.valid = false
+	return false
+}
+
+// backward settles on the visible entry of the user key at or before the position of the underlying iterator,
+// internal keys of the same user key are ordered by sequence in decreasing order.
+func (m *memSource) backward(ok bool) bool {
+	for ok {
+		ukey, seq, deleted, parsed := m.parse()
+		if !parsed {
+			break
+		}
+		if seq > m.seq {
+			ok = m.it.Prev()
+			continue
+		}
+		m.set(ukey, deleted)
+		for ok = m.it.Prev(); ok; ok = m.it.Prev() {
+			ukey, seq, deleted, parsed := m.parse()
+			if !parsed || seq > m.seq || !bytes.Equal(ukey, m.key) {
+				break
+			}
+			m.set(ukey, deleted)
+		}
+		return true
+	}
+	m.valid = false
+	return false
+}
+
+func (m *memSource) First() bool {
+	return m.forward(m.it.First())
+}
+
+func (m *memSource) Last() bool {
+	return m.backward(m.it.Last())
+}
+
+func (m *memSource) Seek(key []byte) bool {
+	m.seekBuf = leveldb.MakeInternalKey(m.seekBuf, key, m.seq, leveldb.KeyTypeSeek)
+	return m.forward(m.it.Seek(m.seekBuf))
+}
+
+func (m *memSource) Next() bool {
+	if !m.valid {
+		return false
+	}
+	// skip the older entries of the current user key
+	m.seekBuf = leveldb.MakeInternalKey(m.seekBuf, m.key, 0, leveldb.KeyTypeDel)
+	ok := m.it.Seek(m.seekBuf)
+	for ok {
+		ukey, _, _, parsed := m.parse()
+		if !parsed || !bytes.Equal(ukey, m.key) {
+			break
+		}
+		ok = m.it.Next()
+	}
+	return m.forward(ok)
+}
+
+func (m *memSource) Prev() bool {
+	if !m.valid {
+		return false
+	}
+	m.seekBuf = leveldb.MakeInternalKey(m.seekBuf, m.key, leveldb.KeyMaxSeq, leveldb.KeyTypeSeek)
+	var ok bool
+	if m.it.Seek(m.seekBuf) {
+		ok = m.it.Prev()
+	} else {
+		ok = m.it.Last()
+	}
+	return m.backward(ok)
+}
+
+func (m *memSource) Valid() bool   { return m.valid }
+func (m *memSource) Key() []byte   { return m.key }
+func (m *memSource) Value() []byte { return m.value }
+func (m *memSource) Deleted() bool { return m.deleted }
+func (m *memSource) Error() error  { return m.err }
+func (m *memSource) Release()      { m.it.Release() }
@@ -0,0 +1,52 @@
+package tiered
+
+const (
+	defaultMemTableSize    = 16 * 1024 * 1024
+	defaultMaxImmutable    = 4
+	defaultBlockSize       = 4 * 1024
+	defaultBloomBitsPerKey = 10
+	defaultCompactionFanIn = 4
+	defaultBlockCacheSize  = 8192
+)
+
+// Options are the tuning parameters of a DB, zero fields take the default values.
+type Options struct {
+	// MemTableSize is the size of the mem table before it is frozen and flushed to a table.
+	MemTableSize int
+	// MaxImmutable is the number of frozen mem tables waiting to be flushed before writes stall.
+	MaxImmutable int
+	// BlockSize is the approximate size of a table block.
+	BlockSize int
+	// BloomBitsPerKey is the number of bloom filter bits of each key in a table.
+	BloomBitsPerKey int
+	// CompactionFanIn is the number of adjacent tables of the same tier merged by a compaction.
+	CompactionFanIn int
+	// BlockCacheSize is the number of decoded blocks kept in memory.
+	BlockCacheSize int
+}
+
+func (o *Options) withDefaults() *Options {
+	result := Options{}
+	if o != nil {
+		result = *o
+	}
+	if result.MemTableSize <= 0 {
+		result.MemTableSize = defaultMemTableSize
+	}
+	if result.MaxImmutable <= 0 {
+		result.MaxImmutable = defaultMaxImmutable
+	}
+	if result.BlockSize <= 0 {
+		result.BlockSize = defaultBlockSize
+	}
+	if result.BloomBitsPerKey <= 0 {
+		result.BloomBitsPerKey = defaultBloomBitsPerKey
+	}
+	if result.CompactionFanIn < 2 {
+		result.CompactionFanIn = defaultCompactionFanIn
+	}
+	if result.BlockCacheSize <= 0 {
+		result.BlockCacheSize = defaultBlockCacheSize
+	}
+	return &result
+}
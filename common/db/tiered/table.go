@@ -0,0 +1,486 @@
+package tiered
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+	"sync/atomic"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/filter"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+)
+
+// Table file layout:
+//
+//	block*  entries of sorted unique keys, followed by the crc32 of the entries
+//	index   for each block: first key, offset and length
+//	filter  bloom filter of all keys
+//	footer  index offset, index length, filter offset, filter length, entries, crc32 of index and filter, magic
+//
+// An entry is uvarint(len(key)) uvarint(len(value)) flag key value, flag 1 means the key is deleted.
+const (
+	footerSize = 5*8 + 4 + 4
+	tableMagic = uint32(0x7469e2d1)
+
+	flagValue   = byte(0)
+	flagDeleted = byte(1)
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type blockHandle struct {
+	firstKey []byte
+	offset   uint64
+	length   uint64
+}
+
+type tableWriter struct {
+	f   *os.File
+	w   *bufio.Writer
+	off uint64
+
+	blockSize int
+	block     []byte
+	firstKey  []byte
+	lastKey   []byte
+
+	index   []blockHandle
+	filter  filter.FilterGenerator
+	entries uint64
+}
+
+func newTableWriter(file string, o *Options) (*tableWriter, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &tableWriter{
+		f:         f,
+		w:         bufio.NewWriterSize(f, 64*1024),
+		blockSize: o.BlockSize,
+		filter:    filter.NewBloomFilter(o.BloomBitsPerKey).NewGenerator(),
+	}, nil
+}
+
+// add appends an entry, keys must be added in increasing order.
+func (tw *tableWriter) add(key, value []byte, deleted bool) error {
+	if tw.entries > 0 && bytes.Compare(key, tw.lastKey) <= 0 {
+		return errors.Errorf("table keys out of order, %x after %x", key, tw.lastKey)
+	}
+	if len(tw.block) == 0 {
+		tw.firstKey = append(tw.firstKey[:0], key...)
+	}
+	var buf [2 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(key)))
+	n += binary.PutUvarint(buf[n:], uint64(len(value)))
+	tw.block = append(tw.block, buf[:n]...)
+	if deleted {
+		tw.block = append(tw.block, flagDeleted)
+	} else {
+		tw.block = append(tw.block, flagValue)
+	}
+	tw.block = append(tw.block, key...)
+	tw.block = append(tw.block, value...)
+
+	tw.filter.Add(key)
+	tw.lastKey = append(tw.lastKey[:0], key...)
+	tw.entries++
+
+	if len(tw.block) >= tw.blockSize {
+		return tw.finishBlock()
+	}
+	return nil
+}
+
+func (tw *tableWriter) write(data []byte) error {
+	n, err := tw.w.Write(data)
+	tw.off += uint64(n)
+	return err
+}
+
+func (tw *tableWriter) finishBlock() error {
+	if len(tw.block) == 0 {
+		return nil
+	}
+	var crc [4]byte
+	binary.LittleEndian.PutUint32(crc[:], crc32.Checksum(tw.block, crcTable))
+	tw.block = append(tw.block, crc[:]...)
+
+	tw.index = append(tw.index, blockHandle{
+		firstKey: append([]byte(nil), tw.firstKey...),
+		offset:   tw.off,
+		length:   uint64(len(tw.block)),
+	})
+	if err := tw.write(tw.block); err != nil {
+		return err
+	}
+	tw.block = tw.block[:0]
+	return nil
+}
+
+// finish writes the index, the filter and the footer, and syncs the file.
+func (tw *tableWriter) finish() (uint64, error) {
+	defer tw.f.Close()
+	if err := tw.finishBlock(); err != nil {
+		return 0, err
+	}
+
+	var index []byte
+	var buf [binary.MaxVarintLen64]byte
+	for _, h := range tw.index {
+		index = append(index, buf[:binary.PutUvarint(buf[:], uint64(len(h.firstKey)))]...)
+		index = append(index, h.firstKey...)
+		index = append(index, buf[:binary.PutUvarint(buf[:], h.offset)]...)
+		index = append(index, buf[:binary.PutUvarint(buf[:], h.length)]...)
+	}
+	filterBuf := &util.Buffer{}
+	tw.filter.Generate(filterBuf)
+	filterData := filterBuf.Bytes()
+
+	indexOffset := tw.off
+	if err := tw.write(index); err != nil {
+		return 0, err
+	}
+	filterOffset := tw.off
+	if err := tw.write(filterData); err != nil {
+		return 0, err
+	}
+
+	footer := make([]byte, footerSize)
+	binary.LittleEndian.PutUint64(footer[0:], indexOffset)
+	binary.LittleEndian.PutUint64(footer[8:], uint64(len(index)))
+	binary.LittleEndian.PutUint64(footer[16:], filterOffset)
+	binary.LittleEndian.PutUint64(footer[24:], uint64(len(filterData)))
+	binary.LittleEndian.PutUint64(footer[32:], tw.entries)
+	crc := crc32.Update(crc32.Checksum(index, crcTable), crcTable, filterData)
+	binary.LittleEndian.PutUint32(footer[40:], crc)
+	binary.LittleEndian.PutUint32(footer[44:], tableMagic)
+	if err := tw.write(footer); err != nil {
+		return 0, err
+	}
+
+	if err := tw.w.Flush(); err != nil {
+		return 0, err
+	}
+	if err := tw.f.Sync(); err != nil {
+		return 0, err
+	}
+	return tw.off, nil
+}
+
+// abort closes and removes an unfinished table.
+func (tw *tableWriter) abort() {
+	tw.f.Close()
+	os.Remove(tw.f.Name())
+}
+
+type block struct {
+	keys    [][]byte
+	values  [][]byte
+	deleted []bool
+}
+
+func decodeBlock(data []byte) (*block, error) {
+	if len(data) < 4 {
+		return nil, errors.New("block too short")
+	}
+	entries, crc := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(entries, crcTable) != crc {
+		return nil, errors.New("block checksum mismatch")
+	}
+	b := &block{}
+	for len(entries) > 0 {
+		keyLen, n := binary.Uvarint(entries)
+		if n <= 0 {
+			return nil, errors.New("corrupted block entry")
+		}
+		entries = entries[n:]
+		valueLen, n := binary.Uvarint(entries)
+		if n <= 0 || uint64(len(entries)-n) < 1+keyLen+valueLen {
+			return nil, errors.New("corrupted block entry")
+		}
+		entries = entries[n:]
+		flag := entries[0]
+		entries = entries[1:]
+		b.keys = append(b.keys, entries[:keyLen:keyLen])
+		b.values = append(b.values, entries[keyLen:keyLen+valueLen:keyLen+valueLen])
+		b.deleted = append(b.deleted, flag == flagDeleted)
+		entries = entries[keyLen+valueLen:]
+	}
+	if len(b.keys) == 0 {
+		return nil, errors.New("empty block")
+	}
+	return b, nil
+}
+
+type blockCacheKey struct {
+	num   uint64
+	block int
+}
+
+// table is an immutable sorted file, it is closed when it's unreferenced,
+// and removed as well if it has been compacted.
+type table struct {
+	num   uint64
+	tier  int
+	file  string
+	f     *os.File
+	size  uint64
+	cache *lru.Cache
+
+	index   []blockHandle
+	filter  []byte
+	bloom   filter.Filter
+	entries uint64
+
+	ref      int32
+	obsolete int32
+}
+
+func openTable(file string, num uint64, tier int, cache *lru.Cache, o *Options) (*table, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	t, err := loadTable(f, cache, o)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "open table %s", file)
+	}
+	t.num = num
+	t.tier = tier
+	t.file = file
+	t.ref = 1
+	return t, nil
+}
+
+func loadTable(f *os.File, cache *lru.Cache, o *Options) (*table, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(stat.Size())
+	if size < footerSize {
+		return nil, errors.New("table too short")
+	}
+	footer := make([]byte, footerSize)
+	if _, err := f.ReadAt(footer, int64(size-footerSize)); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(footer[44:]) != tableMagic {
+		return nil, errors.New("bad table magic")
+	}
+	indexOffset := binary.LittleEndian.Uint64(footer[0:])
+	indexLen := binary.LittleEndian.Uint64(footer[8:])
+	filterOffset := binary.LittleEndian.Uint64(footer[16:])
+	filterLen := binary.LittleEndian.Uint64(footer[24:])
+	if indexOffset+indexLen != filterOffset || filterOffset+filterLen != size-footerSize {
+		return nil, errors.New("bad table footer")
+	}
+
+	meta := make([]byte, indexLen+filterLen)
+	if _, err := f.ReadAt(meta, int64(indexOffset)); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(meta, crcTable) != binary.LittleEndian.Uint32(footer[40:]) {
+		return nil, errors.New("table meta checksum mismatch")
+	}
+
+	t := &table{
+		f:       f,
+		size:    size,
+		cache:   cache,
+		filter:  meta[indexLen:],
+		bloom:   filter.NewBloomFilter(o.BloomBitsPerKey),
+		entries: binary.LittleEndian.Uint64(footer[32:]),
+	}
+	index := meta[:indexLen]
+	for len(index) > 0 {
+		keyLen, n := binary.Uvarint(index)
+		if n <= 0 || uint64(len(index)-n) < keyLen {
+			return nil, errors.New("corrupted table index")
+		}
+		index = index[n:]
+		h := blockHandle{firstKey: index[:keyLen:keyLen]}
+		index = index[keyLen:]
+		if h.offset, n = binary.Uvarint(index); n <= 0 {
+			return nil, errors.New("corrupted table index")
+		}
+		index = index[n:]
+		if h.length, n = binary.Uvarint(index); n <= 0 {
+			return nil, errors.New("corrupted table index")
+		}
+		index = index[n:]
+		t.index = append(t.index, h)
+	}
+	return t, nil
+}
+
+func (t *table) incref() {
+	atomic.AddInt32(&t.ref, 1)
+}
+
+func (t *table) decref() {
+	if atomic.AddInt32(&t.ref, -1) == 0 {
+		t.f.Close()
+		if atomic.LoadInt32(&t.obsolete) == 1 {
+			os.Remove(t.file)
+		}
+	}
+}
+
+func (t *table) readBlock(i int) (*block, error) {
+	key := blockCacheKey{num: t.num, block: i}
+	if t.cache != nil {
+		if b, ok := t.cache.Get(key); ok {
+			return b.(*block), nil
+		}
+	}
+	h := t.index[i]
+	data := make([]byte, h.length)
+	if _, err := t.f.ReadAt(data, int64(h.offset)); err != nil {
+		return nil, errors.Wrapf(err, "read block %d of table %d", i, t.num)
+	}
+	b, err := decodeBlock(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "block %d of table %d", i, t.num)
+	}
+	if t.cache != nil {
+		t.cache.Add(key, b)
+	}
+	return b, nil
+}
+
+// findBlock returns the last block whose first key is not greater than the key, or 0.
+func (t *table) findBlock(key []byte) int {
+	i := sort.Search(len(t.index), func(i int) bool {
+		return bytes.Compare(t.index[i].firstKey, key) > 0
+	})
+	if i > 0 {
+		i--
+	}
+	return i
+}
+
+// get returns the entry of the key, found is false if the table doesn't contain the key.
+func (t *table) get(key []byte) (value []byte, deleted bool, found bool, err error) {
+	if len(t.index) == 0 || !t.bloom.Contains(t.filter, key) {
+		return nil, false, false, nil
+	}
+	b, err := t.readBlock(t.findBlock(key))
+	if err != nil {
+		return nil, false, false, err
+	}
+	pos := sort.Search(len(b.keys), func(i int) bool {
+		return bytes.Compare(b.keys[i], key) >= 0
+	})
+	if pos < len(b.keys) && bytes.Equal(b.keys[pos], key) {
+		return b.values[pos], b.deleted[pos], true, nil
+	}
+	return nil, false, false, nil
+}
+
+func (t *table) String() string {
+	return fmt.Sprintf("table %d(tier %d, %d bytes)", t.num, t.tier, t.size)
+}
+
+// tableSource iterates the entries of a table.
+type tableSource struct {
+	t   *table
+	bi  int
+	blk *block
+	pos int
+	err error
+}
+
+func newTableSource(t *table) *tableSource {
+	return &tableSource{t: t, blk: nil}
+}
+
+func (ts *tableSource) load(bi int) bool {
+	if bi < 0 || bi >= len(ts.t.index) {
+		ts.blk = nil
+		return false
+	}
+	b, err := ts.t.readBlock(bi)
+	if err != nil {
+		ts.err = err
+		ts.blk = nil
+		return false
+	}
+	ts.bi, ts.blk = bi, b
+	return true
+}
+
+func (ts *tableSource) First() bool {
+	if !ts.load(0) {
+		return false
+	}
+	ts.pos = 0
+	return true
+}
+
+func (ts *tableSource) Last() bool {
+	if !ts.load(len(ts.t.index) - 1) {
+		return false
+	}
+	ts.pos = len(ts.blk.keys) - 1
+	return true
+}
+
+func (ts *tableSource) Seek(key []byte) bool {
+	if len(ts.t.index) == 0 || !ts.load(ts.t.findBlock(key)) {
+		return false
+	}
+	ts.pos = sort.Search(len(ts.blk.keys), func(i int) bool {
+		return bytes.Compare(ts.blk.keys[i], key) >= 0
+	})
+	if ts.pos == len(ts.blk.keys) {
+		if !ts.load(ts.bi + 1) {
+			return false
+		}
+		ts.pos = 0
+	}
+	return true
+}
+
+func (ts *tableSource) Next() bool {
+	if ts.blk == nil {
+		return false
+	}
+	ts.pos++
+	if ts.pos == len(ts.blk.keys) {
+		if !ts.load(ts.bi + 1) {
+			return false
+		}
+		ts.pos = 0
+	}
+	return true
+}
+
+func (ts *tableSource) Prev() bool {
+	if ts.blk == nil {
+		return false
+	}
+	ts.pos--
+	if ts.pos < 0 {
+		if !ts.load(ts.bi - 1) {
+			return false
+		}
+		ts.pos = len(ts.blk.keys) - 1
+	}
+	return true
+}
+
+func (ts *tableSource) Valid() bool   { return ts.blk != nil }
+func (ts *tableSource) Key() []byte   { return ts.blk.keys[ts.pos] }
+func (ts *tableSource) Value() []byte { return ts.blk.values[ts.pos] }
+func (ts *tableSource) Deleted() bool { return ts.blk.deleted[ts.pos] }
+func (ts *tableSource) Error() error  { return ts.err }
+func (ts *tableSource) Release()      { ts.blk = nil }
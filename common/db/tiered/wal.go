@@ -0,0 +1,82 @@
+package tiered
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+)
+
+// A wal record is crc32(4) length(4) batch, a batch is written by one record so that
+// a torn record at the tail of the file drops the whole batch.
+const walHeaderSize = 8
+
+type walWriter struct {
+	num uint64
+	f   *os.File
+	buf []byte
+}
+
+func createWal(file string, num uint64) (*walWriter, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &walWriter{num: num, f: f}, nil
+}
+
+func (w *walWriter) append(data []byte, sync bool) error {
+	w.buf = append(w.buf[:0], make([]byte, walHeaderSize)...)
+	binary.LittleEndian.PutUint32(w.buf[0:], crc32.Checksum(data, crcTable))
+	binary.LittleEndian.PutUint32(w.buf[4:], uint32(len(data)))
+	w.buf = append(w.buf, data...)
+	if _, err := w.f.Write(w.buf); err != nil {
+		return err
+	}
+	if sync {
+		return w.f.Sync()
+	}
+	return nil
+}
+
+func (w *walWriter) close() error {
+	if err := w.f.Sync(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// readWal calls fn with each complete record of the wal file, and reports whether the file has a torn tail.
+// Only the last record can be torn by a crash, a checksum mismatch of a record followed by others is
+// a corruption and returns an error.
+func readWal(file string, fn func(data []byte) error) (torn bool, err error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	size := len(content)
+	for len(content) > 0 {
+		if len(content) < walHeaderSize {
+			return true, nil
+		}
+		crc := binary.LittleEndian.Uint32(content[0:])
+		length := binary.LittleEndian.Uint32(content[4:])
+		if uint64(len(content)-walHeaderSize) < uint64(length) {
+			return true, nil
+		}
+		data := content[walHeaderSize : walHeaderSize+length]
+		if crc32.Checksum(data, crcTable) != crc {
+			if len(content) > walHeaderSize+int(length) {
+				return false, fmt.Errorf("tiered: wal %s corrupted at offset %d, record checksum mismatch", file, size-len(content))
+			}
+			return true, nil
+		}
+		if err := fn(data); err != nil {
+			return false, err
+		}
+		content = content[walHeaderSize+length:]
+	}
+	return false, nil
+}
@@ -15,6 +15,9 @@ const (
 	DefaultWSHost   = "localhost" // Default host interface for the websocket RPC server
 	DefaultWSPort   = 31420       // Default TCP port for the websocket RPC server
 	DefaultP2PPort  = 8483
+
+	DefaultMetricsHost = "localhost" // Default host interface for the Prometheus metrics endpoint
+	DefaultMetricsPort = 48133       // Default TCP port for the Prometheus metrics endpoint
 )
 
 // DefaultDataDir is  $HOME/viteisbest/
@@ -8,8 +8,6 @@ import (
 	"sort"
 )
 
-var forkPoints config.ForkPoints
-
 type ForkPointItem struct {
 	config.ForkPoint
 	ForkName string
@@ -18,51 +16,57 @@ type ForkPointItem struct {
 type ForkPointList []*ForkPointItem
 type ForkPointMap map[string]*ForkPointItem
 
-var activeChecker ActiveChecker
-
-var forkPointList ForkPointList
-var forkPointMap ForkPointMap
-
 func (a ForkPointList) Len() int           { return len(a) }
 func (a ForkPointList) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ForkPointList) Less(i, j int) bool { return a[i].Height < a[j].Height }
 
-func IsInitForkPoint() bool {
-	return forkPointMap != nil
-}
-func IsInitActiveChecker() bool {
-	return activeChecker != nil
+// Schedule holds the fork points of a chain. Every chain instance carries its own schedule,
+// so that chains with different fork points can run in one process.
+type Schedule struct {
+	forkPoints    config.ForkPoints
+	forkPointList ForkPointList
+	forkPointMap  ForkPointMap
+	activeChecker ActiveChecker
 }
 
-func SetForkPoints(points *config.ForkPoints) {
-	if points != nil {
-		forkPoints = *points
-
-		t := reflect.TypeOf(forkPoints)
-		v := reflect.ValueOf(forkPoints)
-		forkPointMap = make(ForkPointMap)
-
-		for k := 0; k < t.NumField(); k++ {
-			forkPoint := v.Field(k).Interface().(*config.ForkPoint)
+// NewSchedule builds the schedule of the fork points, the checks of a fork missing in points panic.
+func NewSchedule(points *config.ForkPoints) *Schedule {
+	s := &Schedule{forkPointMap: make(ForkPointMap)}
+	if points == nil {
+		return s
+	}
+	s.forkPoints = *points
 
-			forkName := t.Field(k).Name
-			forkPointItem := &ForkPointItem{
-				ForkPoint: *forkPoint,
-				ForkName:  forkName,
-			}
+	t := reflect.TypeOf(s.forkPoints)
+	v := reflect.ValueOf(s.forkPoints)
+	for k := 0; k < t.NumField(); k++ {
+		forkPoint := v.Field(k).Interface().(*config.ForkPoint)
+		if forkPoint == nil {
+			continue
+		}
 
-			// set fork point list
-			forkPointList = append(forkPointList, forkPointItem)
-			// set fork point map
-			forkPointMap[forkName] = forkPointItem
+		forkName := t.Field(k).Name
+		forkPointItem := &ForkPointItem{
+			ForkPoint: *forkPoint,
+			ForkName:  forkName,
 		}
 
-		sort.Sort(forkPointList)
+		// set fork point list
+		s.forkPointList = append(s.forkPointList, forkPointItem)
+		// set fork point map
+		s.forkPointMap[forkName] = forkPointItem
 	}
+
+	sort.Sort(s.forkPointList)
+	return s
+}
+
+func (s *Schedule) IsInitActiveChecker() bool {
+	return s.activeChecker != nil
 }
 
-func SetActiveChecker(ac ActiveChecker) {
-	activeChecker = ac
+func (s *Schedule) SetActiveChecker(ac ActiveChecker) {
+	s.activeChecker = ac
 }
 
 func CheckForkPoints(points config.ForkPoints) error {
@@ -101,12 +105,12 @@ Contents:
   3. Verifier verifies seed count since seed fork.
   4. Vm interpreters add SEED opcode since seed fork.
 */
-func IsSeedFork(snapshotHeight uint64) bool {
-	seedForkPoint, ok := forkPointMap["SeedFork"]
+func (s *Schedule) IsSeedFork(snapshotHeight uint64) bool {
+	seedForkPoint, ok := s.forkPointMap["SeedFork"]
 	if !ok {
 		panic("check seed fork failed. SeedFork is not existed.")
 	}
-	return snapshotHeight >= seedForkPoint.Height && IsForkActive(*seedForkPoint)
+	return snapshotHeight >= seedForkPoint.Height && s.IsForkActive(*seedForkPoint)
 }
 
 /*
@@ -119,12 +123,12 @@ Features:
      and VM instructions.
   3. ViteX decentralized exchange support.
 */
-func IsDexFork(snapshotHeight uint64) bool {
-	dexForkPoint, ok := forkPointMap["DexFork"]
+func (s *Schedule) IsDexFork(snapshotHeight uint64) bool {
+	dexForkPoint, ok := s.forkPointMap["DexFork"]
 	if !ok {
 		panic("check dex fork failed. DexFork is not existed.")
 	}
-	return snapshotHeight >= dexForkPoint.Height && IsForkActive(*dexForkPoint)
+	return snapshotHeight >= dexForkPoint.Height && s.IsForkActive(*dexForkPoint)
 }
 
 /*
@@ -133,12 +137,12 @@ Vite pre-mainnet hard forks at snapshot block height 8013367.
 Dex fee hard fork is an emergency hard fork to solve one wrongly placed order which
 has caused ViteX failed to display user balances.
 */
-func IsDexFeeFork(snapshotHeight uint64) bool {
-	dexFeeForkPoint, ok := forkPointMap["DexFeeFork"]
+func (s *Schedule) IsDexFeeFork(snapshotHeight uint64) bool {
+	dexFeeForkPoint, ok := s.forkPointMap["DexFeeFork"]
 	if !ok {
 		panic("check dex fee fork failed. DexFeeFork is not existed.")
 	}
-	return snapshotHeight >= dexFeeForkPoint.Height && IsForkActive(*dexFeeForkPoint)
+	return snapshotHeight >= dexFeeForkPoint.Height && s.IsForkActive(*dexFeeForkPoint)
 }
 
 /*
@@ -149,40 +153,40 @@ Features:
   2. Super VIP membership. Stake and then enjoy zero trading fee!
      (Additional operator fee cannot be exempted)
 */
-func IsStemFork(snapshotHeight uint64) bool {
-	stemForkPoint, ok := forkPointMap["StemFork"]
+func (s *Schedule) IsStemFork(snapshotHeight uint64) bool {
+	stemForkPoint, ok := s.forkPointMap["StemFork"]
 	if !ok {
 		panic("check stem fork failed. StemFork is not existed.")
 	}
-	return snapshotHeight >= stemForkPoint.Height && IsForkActive(*stemForkPoint)
+	return snapshotHeight >= stemForkPoint.Height && s.IsForkActive(*stemForkPoint)
 }
 
-func IsLeafFork(snapshotHeight uint64) bool {
-	leafForkPoint, ok := forkPointMap["LeafFork"]
+func (s *Schedule) IsLeafFork(snapshotHeight uint64) bool {
+	leafForkPoint, ok := s.forkPointMap["LeafFork"]
 	if !ok {
 		panic("check leaf fork failed. LeafFork is not existed.")
 	}
-	return snapshotHeight >= leafForkPoint.Height && IsForkActive(*leafForkPoint)
+	return snapshotHeight >= leafForkPoint.Height && s.IsForkActive(*leafForkPoint)
 }
 
-func IsEarthFork(snapshotHeight uint64) bool {
-	earthForkPoint, ok := forkPointMap["EarthFork"]
+func (s *Schedule) IsEarthFork(snapshotHeight uint64) bool {
+	earthForkPoint, ok := s.forkPointMap["EarthFork"]
 	if !ok {
 		panic("check earth fork failed. EarthFork is not existed.")
 	}
-	return snapshotHeight >= earthForkPoint.Height && IsForkActive(*earthForkPoint)
+	return snapshotHeight >= earthForkPoint.Height && s.IsForkActive(*earthForkPoint)
 }
 
-func IsDexMiningFork(snapshotHeight uint64) bool {
-	dexMiningForkPoint, ok := forkPointMap["DexMiningFork"]
+func (s *Schedule) IsDexMiningFork(snapshotHeight uint64) bool {
+	dexMiningForkPoint, ok := s.forkPointMap["DexMiningFork"]
 	if !ok {
 		panic("check dex mining fork failed. DexMiningFork is not existed.")
 	}
-	return snapshotHeight >= dexMiningForkPoint.Height && IsForkActive(*dexMiningForkPoint)
+	return snapshotHeight >= dexMiningForkPoint.Height && s.IsForkActive(*dexMiningForkPoint)
 }
 
-func GetLeafForkPoint() *ForkPointItem {
-	leafForkPoint, ok := forkPointMap["LeafFork"]
+func (s *Schedule) GetLeafForkPoint() *ForkPointItem {
+	leafForkPoint, ok := s.forkPointMap["LeafFork"]
 	if !ok {
 		panic("check leaf fork failed. LeafFork is not existed.")
 	}
@@ -190,12 +194,12 @@ func GetLeafForkPoint() *ForkPointItem {
 	return leafForkPoint
 }
 
-func IsActiveForkPoint(snapshotHeight uint64) bool {
+func (s *Schedule) IsActiveForkPoint(snapshotHeight uint64) bool {
 	// assume that fork point list is sorted by height asc
-	for i := len(forkPointList) - 1; i >= 0; i-- {
-		forkPoint := forkPointList[i]
+	for i := len(s.forkPointList) - 1; i >= 0; i-- {
+		forkPoint := s.forkPointList[i]
 		if forkPoint.Height == snapshotHeight {
-			return IsForkActive(*forkPoint)
+			return s.IsForkActive(*forkPoint)
 		}
 
 		if forkPoint.Height < snapshotHeight {
@@ -206,10 +210,10 @@ func IsActiveForkPoint(snapshotHeight uint64) bool {
 	return false
 }
 
-func GetForkPoint(snapshotHeight uint64) *ForkPointItem {
+func (s *Schedule) GetForkPoint(snapshotHeight uint64) *ForkPointItem {
 	// assume that fork point list is sorted by height asc
-	for i := len(forkPointList) - 1; i >= 0; i-- {
-		forkPoint := forkPointList[i]
+	for i := len(s.forkPointList) - 1; i >= 0; i-- {
+		forkPoint := s.forkPointList[i]
 		if forkPoint.Height == snapshotHeight {
 			return forkPoint
 		}
@@ -222,22 +226,22 @@ func GetForkPoint(snapshotHeight uint64) *ForkPointItem {
 	return nil
 }
 
-func GetForkPoints() config.ForkPoints {
-	return forkPoints
+func (s *Schedule) GetForkPoints() config.ForkPoints {
+	return s.forkPoints
 }
 
-func GetForkPointList() ForkPointList {
-	return forkPointList
+func (s *Schedule) GetForkPointList() ForkPointList {
+	return s.forkPointList
 }
 
-func GetForkPointMap() ForkPointMap {
-	return forkPointMap
+func (s *Schedule) GetForkPointMap() ForkPointMap {
+	return s.forkPointMap
 }
 
-func GetActiveForkPointList() ForkPointList {
-	activeForkPointList := make(ForkPointList, 0, len(forkPointList))
-	for _, forkPoint := range forkPointList {
-		if IsForkActive(*forkPoint) {
+func (s *Schedule) GetActiveForkPointList() ForkPointList {
+	activeForkPointList := make(ForkPointList, 0, len(s.forkPointList))
+	for _, forkPoint := range s.forkPointList {
+		if s.IsForkActive(*forkPoint) {
 			activeForkPointList = append(activeForkPointList, forkPoint)
 		}
 	}
@@ -245,22 +249,22 @@ func GetActiveForkPointList() ForkPointList {
 	return activeForkPointList
 }
 
-func GetRecentActiveFork(blockHeight uint64) *ForkPointItem {
-	for i := len(forkPointList) - 1; i >= 0; i-- {
-		item := forkPointList[i]
-		if item.Height <= blockHeight && IsForkActive(*item) {
+func (s *Schedule) GetRecentActiveFork(blockHeight uint64) *ForkPointItem {
+	for i := len(s.forkPointList) - 1; i >= 0; i-- {
+		item := s.forkPointList[i]
+		if item.Height <= blockHeight && s.IsForkActive(*item) {
 			return item
 		}
 	}
 	return nil
 }
 
-func GetLastForkPoint() *ForkPointItem {
-	return forkPointList[forkPointList.Len()-1]
+func (s *Schedule) GetLastForkPoint() *ForkPointItem {
+	return s.forkPointList[s.forkPointList.Len()-1]
 }
 
-func IsForkActive(point ForkPointItem) bool {
+func (s *Schedule) IsForkActive(point ForkPointItem) bool {
 	// TODO suppose all point is active.
 	return true
-	//return activeChecker.IsForkActive(point)
+	//return s.activeChecker.IsForkActive(point)
 }
@@ -0,0 +1,42 @@
+package fork
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/config"
+)
+
+func TestSchedule_Independent(t *testing.T) {
+	s1 := NewSchedule(&config.ForkPoints{
+		SeedFork: &config.ForkPoint{Height: 100, Version: 1},
+		LeafFork: &config.ForkPoint{Height: 400, Version: 5},
+	})
+	s2 := NewSchedule(&config.ForkPoints{
+		SeedFork: &config.ForkPoint{Height: 10, Version: 1},
+		LeafFork: &config.ForkPoint{Height: 40, Version: 5},
+	})
+
+	assert.False(t, s1.IsSeedFork(50))
+	assert.True(t, s2.IsSeedFork(50))
+	assert.False(t, s1.IsLeafFork(100))
+	assert.True(t, s2.IsLeafFork(100))
+
+	assert.Equal(t, "SeedFork", s1.GetRecentActiveFork(100).ForkName)
+	assert.Equal(t, "LeafFork", s2.GetRecentActiveFork(100).ForkName)
+	assert.Nil(t, s1.GetRecentActiveFork(99))
+
+	assert.Equal(t, uint64(400), s1.GetLastForkPoint().Height)
+	assert.Equal(t, uint64(40), s2.GetLastForkPoint().Height)
+	assert.True(t, s2.IsActiveForkPoint(40))
+	assert.False(t, s2.IsActiveForkPoint(400))
+}
+
+func TestSchedule_MissingFork(t *testing.T) {
+	s := NewSchedule(&config.ForkPoints{
+		SeedFork: &config.ForkPoint{Height: 100, Version: 1},
+	})
+	assert.Len(t, s.GetForkPointList(), 1)
+	assert.Panics(t, func() { s.IsDexFork(100) })
+	assert.Panics(t, func() { NewSchedule(nil).IsSeedFork(100) })
+}
@@ -4,31 +4,41 @@ import (
 	"sync/atomic"
 )
 
+const (
+	LifecycleOrigin int32 = iota
+	LifecycleIniting
+	LifecycleInited
+	LifecycleStarting
+	LifecycleStarted
+	LifecycleStopping
+	LifecycleStopped
+)
+
 type LifecycleStatus struct {
 	Status int32 // 0:origin 1: initing 2:inited 3:starting 4:started 5:stopping 6:stopped
 }
 
 func (self *LifecycleStatus) PreInit() bool {
-	return atomic.CompareAndSwapInt32(&self.Status, 0, 1)
+	return atomic.CompareAndSwapInt32(&self.Status, LifecycleOrigin, LifecycleIniting)
 }
 func (self *LifecycleStatus) PostInit() bool {
-	return atomic.CompareAndSwapInt32(&self.Status, 1, 2)
+	return atomic.CompareAndSwapInt32(&self.Status, LifecycleIniting, LifecycleInited)
 }
 func (self *LifecycleStatus) PreStart() bool {
-	return atomic.CompareAndSwapInt32(&self.Status, 2, 3)
+	return atomic.CompareAndSwapInt32(&self.Status, LifecycleInited, LifecycleStarting)
 }
 func (self *LifecycleStatus) PostStart() bool {
-	return atomic.CompareAndSwapInt32(&self.Status, 3, 4)
+	return atomic.CompareAndSwapInt32(&self.Status, LifecycleStarting, LifecycleStarted)
 }
 func (self *LifecycleStatus) PreStop() bool {
-	return atomic.CompareAndSwapInt32(&self.Status, 4, 5)
+	return atomic.CompareAndSwapInt32(&self.Status, LifecycleStarted, LifecycleStopping)
 }
 func (self *LifecycleStatus) PostStop() bool {
-	return atomic.CompareAndSwapInt32(&self.Status, 5, 6)
+	return atomic.CompareAndSwapInt32(&self.Status, LifecycleStopping, LifecycleStopped)
 }
 
 func (self *LifecycleStatus) Stopped() bool {
-	return self.Status == 6 || self.Status == 5
+	return self.Status == LifecycleStopped || self.Status == LifecycleStopping
 }
 func (self *LifecycleStatus) GetStatus() int32 {
 	return self.Status
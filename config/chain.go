@@ -8,7 +8,14 @@ type Chain struct {
 	GenesisFile    string // genesis file path
 	LedgerGc       bool   // open or close ledger garbage collector
 	OpenPlugins    bool   // open or close chain plugins. eg, filter account blocks by token.
+	StateProof     bool   // open or close the state commitment index of snapshot blocks, it serves ledger_getProof
+
+	VoteHistoryPlugin    bool // index the votes of the governance contract, it requires OpenPlugins
+	TokenHolderPlugin    bool // index the balances of token holders and the supply changes of tokens, it requires OpenPlugins
+	AccountHistoryPlugin bool // index the transfers of accounts by time, counterparty and token, it requires OpenPlugins
 
 	VmLogWhiteList []types.Address // contract address white list which save VM logs
 	VmLogAll       bool            // save all VM logs, it will cost more disk space
+
+	Backend string // key-value engine of the index, state and plugin stores, "leveldb"(default) or the experimental "tiered"
 }
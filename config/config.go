@@ -14,9 +14,11 @@ type Config struct {
 	*Chain      `json:"Chain"`
 	*Vm         `json:"Vm"`
 	*Subscribe  `json:"Subscribe"`
+	*OnRoad     `json:"OnRoad"`
 	*Net        `json:"Net"`
 	*biz.Reward `json:"Reward"`
 	*Genesis    `json:"Genesis"`
+	*Health     `json:"Health"`
 
 	// global keys
 	DataDir string `json:"DataDir"`
@@ -0,0 +1,530 @@
+package config_gen
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+// same limits as the governance and asset contracts
+const (
+	sbpNameLengthMax     = 40
+	tokenNameLengthMax   = 40
+	tokenSymbolLengthMax = 10
+)
+
+var (
+	sbpNameRegexp     = regexp.MustCompile("^([0-9a-zA-Z_.]+[ ]?)*[0-9a-zA-Z_.]$")
+	tokenNameRegexp   = regexp.MustCompile("^([a-zA-Z_]+[ ]?)*[a-zA-Z_]$")
+	tokenSymbolRegexp = regexp.MustCompile("^[A-Z0-9]+$")
+)
+
+// NewGenesisConfig returns the genesis of a private network owned by owner, with the VITE token,
+// the snapshot and delegate consensus groups of nodeCount producers and all forks active soon after genesis.
+// It has no SBP and no balance yet, use AddGenesisSBP and AddGenesisAccount to complete it.
+func NewGenesisConfig(owner types.Address, nodeCount uint8) *config.Genesis {
+	viteTokenId := ledger.ViteTokenId
+	registerParam := config.RegisterConditionParam{
+		StakeAmount: new(big.Int).Set(devSBPStakeAmount),
+		StakeToken:  viteTokenId,
+		StakeHeight: 1,
+	}
+	group := func(checkLevel uint8) *config.ConsensusGroupInfo {
+		return &config.ConsensusGroupInfo{
+			NodeCount:              nodeCount,
+			Interval:               1,
+			PerCount:               3,
+			RandCount:              0,
+			RandRank:               1,
+			Repeat:                 1,
+			CheckLevel:             checkLevel,
+			CountingTokenId:        viteTokenId,
+			RegisterConditionId:    1,
+			RegisterConditionParam: registerParam,
+			VoteConditionId:        1,
+			Owner:                  owner,
+			StakeAmount:            big.NewInt(0),
+			ExpirationHeight:       1,
+		}
+	}
+
+	topics, data, err := abi.ABIAsset.PackEvent("mint", viteTokenId)
+	if err != nil {
+		panic(err)
+	}
+	return &config.Genesis{
+		GenesisAccountAddress: &owner,
+		ForkPoints:            makeDevForkPointsConfig(),
+		GovernanceInfo: &config.GovernanceContractInfo{
+			ConsensusGroupInfoMap: map[string]*config.ConsensusGroupInfo{
+				types.SNAPSHOT_GID.String(): group(0),
+				types.DELEGATE_GID.String(): group(1),
+			},
+			RegistrationInfoMap: make(map[string]map[string]*config.RegistrationInfo),
+			VoteStatusMap:       make(map[string]map[string]string),
+		},
+		AssetInfo: &config.AssetContractInfo{
+			TokenInfoMap: map[string]*config.TokenInfo{
+				viteTokenId.String(): {
+					TokenName:    "Vite Token",
+					TokenSymbol:  "VITE",
+					TotalSupply:  big.NewInt(0),
+					Decimals:     18,
+					Owner:        types.AddressAsset,
+					MaxSupply:    new(big.Int).Set(helper.Tt256m1),
+					IsReIssuable: true,
+				},
+			},
+			LogList: []*config.GenesisVmLog{{Data: hex.EncodeToString(data), Topics: topics}},
+		},
+		QuotaInfo: &config.QuotaContractInfo{
+			StakeInfoMap:       make(map[string][]*config.StakeInfo),
+			StakeBeneficialMap: make(map[string]*big.Int),
+		},
+		AccountBalanceMap: make(map[string]map[string]*big.Int),
+	}
+}
+
+// AddGenesisAccount mints amount of the token to addr, the total supply of the token grows by amount.
+func AddGenesisAccount(g *config.Genesis, addr types.Address, tokenId types.TokenTypeId, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("amount must be positive")
+	}
+	return mint(g, addr, tokenId, amount)
+}
+
+// AddGenesisStake stakes amount of VITE for the quota of beneficiary, the staked VITE is minted to the quota contract.
+func AddGenesisStake(g *config.Genesis, addr types.Address, beneficiary types.Address, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("stake amount must be positive")
+	}
+	if g.QuotaInfo == nil {
+		g.QuotaInfo = &config.QuotaContractInfo{}
+	}
+	if g.QuotaInfo.StakeInfoMap == nil {
+		g.QuotaInfo.StakeInfoMap = make(map[string][]*config.StakeInfo)
+	}
+	if g.QuotaInfo.StakeBeneficialMap == nil {
+		g.QuotaInfo.StakeBeneficialMap = make(map[string]*big.Int)
+	}
+	if err := mint(g, types.AddressQuota, ledger.ViteTokenId, amount); err != nil {
+		return err
+	}
+
+	g.QuotaInfo.StakeInfoMap[addr.String()] = append(g.QuotaInfo.StakeInfoMap[addr.String()], &config.StakeInfo{
+		Amount:           new(big.Int).Set(amount),
+		ExpirationHeight: 1,
+		Beneficiary:      &beneficiary,
+	})
+	beneficial := g.QuotaInfo.StakeBeneficialMap[beneficiary.String()]
+	if beneficial == nil {
+		beneficial = big.NewInt(0)
+	}
+	g.QuotaInfo.StakeBeneficialMap[beneficiary.String()] = beneficial.Add(beneficial, amount)
+	return nil
+}
+
+// AddGenesisSBP registers an SBP of the snapshot consensus group staked by stakeAddr, and stakeAddr votes for it
+// if it hasn't voted yet. The stake amount required by the group is minted to the governance contract,
+// and the node count of the consensus groups grows to the count of SBPs.
+func AddGenesisSBP(g *config.Genesis, name string, producer types.Address, stakeAddr types.Address) error {
+	if len(name) == 0 || len(name) > sbpNameLengthMax || !sbpNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid sbp name %q", name)
+	}
+	if g.GovernanceInfo == nil {
+		return errors.New("genesis has no governance info")
+	}
+	snapshotGid := types.SNAPSHOT_GID.String()
+	group, ok := g.GovernanceInfo.ConsensusGroupInfoMap[snapshotGid]
+	if !ok {
+		return errors.New("genesis has no snapshot consensus group")
+	}
+	if g.GovernanceInfo.RegistrationInfoMap == nil {
+		g.GovernanceInfo.RegistrationInfoMap = make(map[string]map[string]*config.RegistrationInfo)
+	}
+	registrations := g.GovernanceInfo.RegistrationInfoMap[snapshotGid]
+	if registrations == nil {
+		registrations = make(map[string]*config.RegistrationInfo)
+		g.GovernanceInfo.RegistrationInfoMap[snapshotGid] = registrations
+	}
+	if _, ok := registrations[name]; ok {
+		return fmt.Errorf("sbp %s is registered", name)
+	}
+	for other, info := range registrations {
+		if info.BlockProducingAddress != nil && *info.BlockProducingAddress == producer {
+			return fmt.Errorf("block producing address %s is used by sbp %s", producer, other)
+		}
+	}
+	// the node count of a consensus group is a uint8
+	if len(registrations) >= math.MaxUint8 {
+		return fmt.Errorf("at most %d sbps can be registered", math.MaxUint8)
+	}
+
+	param := group.RegisterConditionParam
+	if param.StakeAmount == nil {
+		return errors.New("register stake amount of the snapshot consensus group is nil")
+	}
+	if err := mint(g, types.AddressGovernance, param.StakeToken, param.StakeAmount); err != nil {
+		return err
+	}
+
+	registrations[name] = &config.RegistrationInfo{
+		BlockProducingAddress: &producer,
+		StakeAddress:          &stakeAddr,
+		Amount:                new(big.Int).Set(param.StakeAmount),
+		ExpirationHeight:      param.StakeHeight,
+		RewardTime:            1,
+		RevokeTime:            0,
+		HistoryAddressList:    []types.Address{producer},
+	}
+
+	if g.GovernanceInfo.VoteStatusMap == nil {
+		g.GovernanceInfo.VoteStatusMap = make(map[string]map[string]string)
+	}
+	votes := g.GovernanceInfo.VoteStatusMap[snapshotGid]
+	if votes == nil {
+		votes = make(map[string]string)
+		g.GovernanceInfo.VoteStatusMap[snapshotGid] = votes
+	}
+	if _, ok := votes[stakeAddr.String()]; !ok {
+		votes[stakeAddr.String()] = name
+	}
+
+	for _, info := range g.GovernanceInfo.ConsensusGroupInfoMap {
+		if int(info.NodeCount) < len(registrations) {
+			info.NodeCount = uint8(len(registrations))
+		}
+	}
+	return nil
+}
+
+// AddGenesisToken issues a token owned by owner, the total supply of info is minted to owner.
+// The token id is derived from the owner and the symbol, so the same genesis always gets the same ids.
+func AddGenesisToken(g *config.Genesis, info config.TokenInfo) (types.TokenTypeId, error) {
+	if len(info.TokenName) == 0 || len(info.TokenName) > tokenNameLengthMax || !tokenNameRegexp.MatchString(info.TokenName) {
+		return types.TokenTypeId{}, fmt.Errorf("invalid token name %q", info.TokenName)
+	}
+	if len(info.TokenSymbol) == 0 || len(info.TokenSymbol) > tokenSymbolLengthMax || !tokenSymbolRegexp.MatchString(info.TokenSymbol) {
+		return types.TokenTypeId{}, fmt.Errorf("invalid token symbol %q", info.TokenSymbol)
+	}
+	if info.TokenSymbol == "VITE" || info.TokenSymbol == "VCP" || info.TokenSymbol == "VX" {
+		return types.TokenTypeId{}, fmt.Errorf("token symbol %s is reserved", info.TokenSymbol)
+	}
+	if info.TotalSupply == nil {
+		info.TotalSupply = big.NewInt(0)
+	}
+	if info.MaxSupply == nil {
+		info.MaxSupply = big.NewInt(0)
+	}
+	if info.IsReIssuable {
+		if info.MaxSupply.Cmp(info.TotalSupply) < 0 || info.MaxSupply.Cmp(helper.Tt256m1) > 0 {
+			return types.TokenTypeId{}, errors.New("max supply must be between total supply and 2**256-1")
+		}
+	} else {
+		if info.TotalSupply.Sign() <= 0 {
+			return types.TokenTypeId{}, errors.New("total supply of a non-reissuable token must be positive")
+		}
+		if info.MaxSupply.Sign() > 0 || info.IsOwnerBurnOnly {
+			return types.TokenTypeId{}, errors.New("max supply and owner burn only are for reissuable tokens")
+		}
+	}
+	if g.AssetInfo == nil {
+		g.AssetInfo = &config.AssetContractInfo{}
+	}
+	if g.AssetInfo.TokenInfoMap == nil {
+		g.AssetInfo.TokenInfoMap = make(map[string]*config.TokenInfo)
+	}
+
+	var index uint16
+	for _, other := range g.AssetInfo.TokenInfoMap {
+		if other.TokenSymbol == info.TokenSymbol {
+			index++
+		}
+	}
+	tokenId := types.CreateTokenTypeId(info.Owner.Bytes(), []byte(info.TokenSymbol), helper.LeftPadBytes(big.NewInt(int64(index)).Bytes(), 2))
+	if _, ok := g.AssetInfo.TokenInfoMap[tokenId.String()]; ok {
+		return types.TokenTypeId{}, fmt.Errorf("token %s exists", tokenId)
+	}
+
+	topics, data, err := abi.ABIAsset.PackEvent("mint", tokenId)
+	if err != nil {
+		return types.TokenTypeId{}, err
+	}
+	supply := info.TotalSupply
+	info.TotalSupply = big.NewInt(0)
+	g.AssetInfo.TokenInfoMap[tokenId.String()] = &info
+	g.AssetInfo.LogList = append(g.AssetInfo.LogList, &config.GenesisVmLog{Data: hex.EncodeToString(data), Topics: topics})
+	if supply.Sign() > 0 {
+		if err := mint(g, info.Owner, tokenId, supply); err != nil {
+			return types.TokenTypeId{}, err
+		}
+	}
+	return tokenId, nil
+}
+
+func mint(g *config.Genesis, addr types.Address, tokenId types.TokenTypeId, amount *big.Int) error {
+	if g.AssetInfo == nil {
+		return errors.New("genesis has no asset info")
+	}
+	token, ok := g.AssetInfo.TokenInfoMap[tokenId.String()]
+	if !ok {
+		return fmt.Errorf("token %s doesn't exist", tokenId)
+	}
+	supply := new(big.Int).Set(amount)
+	if token.TotalSupply != nil {
+		supply.Add(supply, token.TotalSupply)
+	}
+	if token.IsReIssuable && token.MaxSupply != nil && supply.Cmp(token.MaxSupply) > 0 {
+		return fmt.Errorf("total supply of token %s exceeds the max supply", tokenId)
+	}
+
+	if g.AccountBalanceMap == nil {
+		g.AccountBalanceMap = make(map[string]map[string]*big.Int)
+	}
+	balances := g.AccountBalanceMap[addr.String()]
+	if balances == nil {
+		balances = make(map[string]*big.Int)
+		g.AccountBalanceMap[addr.String()] = balances
+	}
+	balance := balances[tokenId.String()]
+	if balance == nil {
+		balance = big.NewInt(0)
+	}
+	balances[tokenId.String()] = balance.Add(balance, amount)
+	token.TotalSupply = supply
+	return nil
+}
+
+// CheckGenesisConfig returns an error if the node can't start with the genesis.
+// Besides IsCompleteGenesisConfig and CheckForkPoints, it checks the references between the contract infos,
+// which would make the node panic while building the genesis blocks.
+func CheckGenesisConfig(g *config.Genesis) error {
+	if !config.IsCompleteGenesisConfig(g) {
+		return errors.New("genesis account info is not complete")
+	}
+	if g.ForkPoints != nil {
+		if err := fork.CheckForkPoints(*g.ForkPoints); err != nil {
+			return err
+		}
+	}
+
+	gov := g.GovernanceInfo
+	if _, ok := gov.ConsensusGroupInfoMap[types.SNAPSHOT_GID.String()]; !ok {
+		return errors.New("snapshot consensus group is missing")
+	}
+	for gidStr, info := range gov.ConsensusGroupInfoMap {
+		if _, err := types.HexToGid(gidStr); err != nil {
+			return fmt.Errorf("invalid gid %s: %v", gidStr, err)
+		}
+		if info.NodeCount == 0 || info.Interval <= 0 || info.PerCount <= 0 || info.Repeat == 0 {
+			return fmt.Errorf("consensus group %s: node count, interval, per count and repeat must be positive", gidStr)
+		}
+		if _, ok := g.AssetInfo.TokenInfoMap[info.CountingTokenId.String()]; !ok {
+			return fmt.Errorf("consensus group %s: counting token %s doesn't exist", gidStr, info.CountingTokenId)
+		}
+		if info.RegisterConditionId == 1 && info.RegisterConditionParam.StakeAmount == nil {
+			return fmt.Errorf("consensus group %s: register stake amount is nil", gidStr)
+		}
+		if info.StakeAmount == nil {
+			return fmt.Errorf("consensus group %s: stake amount is nil", gidStr)
+		}
+	}
+	for gidStr, registrations := range gov.RegistrationInfoMap {
+		if _, ok := gov.ConsensusGroupInfoMap[gidStr]; !ok {
+			return fmt.Errorf("registrations of unknown consensus group %s", gidStr)
+		}
+		for name, info := range registrations {
+			if info.BlockProducingAddress == nil || info.StakeAddress == nil || info.Amount == nil {
+				return fmt.Errorf("sbp %s: block producing address, stake address and amount are required", name)
+			}
+		}
+	}
+	for gidStr, votes := range gov.VoteStatusMap {
+		if _, err := types.HexToGid(gidStr); err != nil {
+			return fmt.Errorf("invalid gid %s: %v", gidStr, err)
+		}
+		for voter, name := range votes {
+			if _, err := types.HexToAddress(voter); err != nil {
+				return fmt.Errorf("invalid voter %s: %v", voter, err)
+			}
+			if _, ok := gov.RegistrationInfoMap[gidStr][name]; !ok {
+				return fmt.Errorf("%s votes for unregistered sbp %s", voter, name)
+			}
+		}
+	}
+	for gidStr, names := range gov.HisNameMap {
+		if _, err := types.HexToGid(gidStr); err != nil {
+			return fmt.Errorf("invalid gid %s: %v", gidStr, err)
+		}
+		for addr := range names {
+			if _, err := types.HexToAddress(addr); err != nil {
+				return fmt.Errorf("invalid block producing address %s: %v", addr, err)
+			}
+		}
+	}
+
+	for tokenIdStr, info := range g.AssetInfo.TokenInfoMap {
+		if _, err := types.HexToTokenTypeId(tokenIdStr); err != nil {
+			return fmt.Errorf("invalid token id %s: %v", tokenIdStr, err)
+		}
+		if info.TotalSupply == nil || info.MaxSupply == nil {
+			return fmt.Errorf("token %s: total supply and max supply are required", tokenIdStr)
+		}
+	}
+	for _, l := range g.AssetInfo.LogList {
+		if _, err := hex.DecodeString(l.Data); err != nil {
+			return fmt.Errorf("invalid vm log data %s: %v", l.Data, err)
+		}
+	}
+
+	if g.QuotaInfo != nil {
+		for addr, stakes := range g.QuotaInfo.StakeInfoMap {
+			if _, err := types.HexToAddress(addr); err != nil {
+				return fmt.Errorf("invalid stake address %s: %v", addr, err)
+			}
+			for _, stake := range stakes {
+				if stake.Amount == nil || stake.Beneficiary == nil {
+					return fmt.Errorf("stake of %s: amount and beneficiary are required", addr)
+				}
+			}
+		}
+		for addr, amount := range g.QuotaInfo.StakeBeneficialMap {
+			if _, err := types.HexToAddress(addr); err != nil {
+				return fmt.Errorf("invalid beneficiary %s: %v", addr, err)
+			}
+			if amount == nil {
+				return fmt.Errorf("stake beneficial amount of %s is nil", addr)
+			}
+		}
+	}
+
+	for addr, balances := range g.AccountBalanceMap {
+		if _, err := types.HexToAddress(addr); err != nil {
+			return fmt.Errorf("invalid account %s: %v", addr, err)
+		}
+		for tokenIdStr, balance := range balances {
+			if _, err := types.HexToTokenTypeId(tokenIdStr); err != nil {
+				return fmt.Errorf("invalid token id %s of account %s: %v", tokenIdStr, addr, err)
+			}
+			if balance == nil || balance.Sign() < 0 {
+				return fmt.Errorf("invalid balance of token %s of account %s", tokenIdStr, addr)
+			}
+		}
+	}
+	return nil
+}
+
+// GenesisConfigWarnings returns the accounting inconsistencies of the genesis, the node starts with them,
+// but the ledger disagrees with itself: total supplies differ from the sums of balances,
+// the contracts don't hold the staked VITE, or the beneficial amounts differ from the stakes.
+func GenesisConfigWarnings(g *config.Genesis) []string {
+	var warnings []string
+	if g.AssetInfo == nil {
+		return warnings
+	}
+
+	sums := make(map[string]*big.Int)
+	for _, balances := range g.AccountBalanceMap {
+		for tokenIdStr, balance := range balances {
+			if balance == nil {
+				continue
+			}
+			if sums[tokenIdStr] == nil {
+				sums[tokenIdStr] = big.NewInt(0)
+			}
+			sums[tokenIdStr].Add(sums[tokenIdStr], balance)
+		}
+	}
+	for tokenIdStr := range sums {
+		if _, ok := g.AssetInfo.TokenInfoMap[tokenIdStr]; !ok {
+			warnings = append(warnings, fmt.Sprintf("token %s of balances doesn't exist", tokenIdStr))
+		}
+	}
+	for tokenIdStr, info := range g.AssetInfo.TokenInfoMap {
+		sum := sums[tokenIdStr]
+		if sum == nil {
+			sum = big.NewInt(0)
+		}
+		if info.TotalSupply != nil && info.TotalSupply.Cmp(sum) != 0 {
+			warnings = append(warnings, fmt.Sprintf("total supply of token %s is %s, but the sum of balances is %s", tokenIdStr, info.TotalSupply, sum))
+		}
+	}
+
+	if gov := g.GovernanceInfo; gov != nil {
+		staked := make(map[string]*big.Int)
+		for gidStr, registrations := range gov.RegistrationInfoMap {
+			group, ok := gov.ConsensusGroupInfoMap[gidStr]
+			if !ok {
+				continue
+			}
+			tokenIdStr := group.RegisterConditionParam.StakeToken.String()
+			if staked[tokenIdStr] == nil {
+				staked[tokenIdStr] = big.NewInt(0)
+			}
+			for _, info := range registrations {
+				if info.Amount != nil && info.RevokeTime == 0 {
+					staked[tokenIdStr].Add(staked[tokenIdStr], info.Amount)
+				}
+			}
+		}
+		warnings = append(warnings, checkContractBalance(g, types.AddressGovernance, staked)...)
+	}
+
+	if g.QuotaInfo != nil {
+		staked := big.NewInt(0)
+		beneficial := make(map[string]*big.Int)
+		for _, stakes := range g.QuotaInfo.StakeInfoMap {
+			for _, stake := range stakes {
+				if stake.Amount == nil || stake.Beneficiary == nil {
+					continue
+				}
+				staked.Add(staked, stake.Amount)
+				b := stake.Beneficiary.String()
+				if beneficial[b] == nil {
+					beneficial[b] = big.NewInt(0)
+				}
+				beneficial[b].Add(beneficial[b], stake.Amount)
+			}
+		}
+		warnings = append(warnings, checkContractBalance(g, types.AddressQuota, map[string]*big.Int{ledger.ViteTokenId.String(): staked})...)
+
+		for addr, amount := range beneficial {
+			if got := g.QuotaInfo.StakeBeneficialMap[addr]; got == nil || got.Cmp(amount) != 0 {
+				warnings = append(warnings, fmt.Sprintf("stake beneficial amount of %s is %v, but the stakes are %s", addr, got, amount))
+			}
+		}
+		for addr, amount := range g.QuotaInfo.StakeBeneficialMap {
+			if _, ok := beneficial[addr]; !ok {
+				warnings = append(warnings, fmt.Sprintf("stake beneficial amount of %s is %v, but there is no stake", addr, amount))
+			}
+		}
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
+
+func checkContractBalance(g *config.Genesis, addr types.Address, expected map[string]*big.Int) []string {
+	var warnings []string
+	for tokenIdStr, amount := range expected {
+		if amount.Sign() == 0 {
+			continue
+		}
+		balance := g.AccountBalanceMap[addr.String()][tokenIdStr]
+		if balance == nil || balance.Cmp(amount) < 0 {
+			warnings = append(warnings, fmt.Sprintf("balance of token %s of %s is %v, less than the staked %s", tokenIdStr, addr, balance, amount))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,74 @@
+package config_gen
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+func TestGenesisBuilder(t *testing.T) {
+	owner, _ := types.HexToAddress("vite_60e292f0ac471c73d914aeff10bb25925e13b2a9fddb6e6122")
+	producers := make([]types.Address, 2)
+	producers[0], _ = types.HexToAddress("vite_9065ff0e14ebf983e090cde47d59fe77d7164b576a6d2d0eda")
+	producers[1], _ = types.HexToAddress("vite_995769283a01ba8d00258dbb5371c915df59c8657335bfb1b2")
+
+	g := NewGenesisConfig(owner, 0)
+	assert.Assert(t, CheckGenesisConfig(g) != nil)
+
+	assert.NilError(t, AddGenesisSBP(g, "s1", producers[0], producers[0]))
+	assert.NilError(t, AddGenesisSBP(g, "s2", producers[1], owner))
+	assert.ErrorContains(t, AddGenesisSBP(g, "s2", owner, owner), "registered")
+	assert.ErrorContains(t, AddGenesisSBP(g, "s3", producers[0], owner), "is used by")
+	assert.ErrorContains(t, AddGenesisSBP(g, "bad name!", owner, owner), "invalid sbp name")
+	assert.Equal(t, uint8(2), g.GovernanceInfo.ConsensusGroupInfoMap[types.SNAPSHOT_GID.String()].NodeCount)
+	assert.Equal(t, uint8(2), g.GovernanceInfo.ConsensusGroupInfoMap[types.DELEGATE_GID.String()].NodeCount)
+
+	assert.NilError(t, AddGenesisAccount(g, owner, ledger.ViteTokenId, big.NewInt(100)))
+	assert.NilError(t, AddGenesisStake(g, owner, owner, big.NewInt(10)))
+	assert.NilError(t, AddGenesisStake(g, producers[0], owner, big.NewInt(5)))
+
+	tokenId, err := AddGenesisToken(g, config.TokenInfo{
+		TokenName:   "Test Coin",
+		TokenSymbol: "TC",
+		TotalSupply: big.NewInt(1000),
+		Owner:       owner,
+	})
+	assert.NilError(t, err)
+	_, err = AddGenesisToken(g, config.TokenInfo{TokenName: "Vite", TokenSymbol: "VITE", TotalSupply: big.NewInt(1), Owner: owner})
+	assert.ErrorContains(t, err, "reserved")
+	assert.ErrorContains(t, AddGenesisAccount(g, owner, types.CreateTokenTypeId([]byte("none")), big.NewInt(1)), "doesn't exist")
+
+	assert.NilError(t, CheckGenesisConfig(g))
+	assert.Equal(t, 0, len(GenesisConfigWarnings(g)))
+	assert.Equal(t, "1000", g.AccountBalanceMap[owner.String()][tokenId.String()].String())
+	assert.Equal(t, "15", g.QuotaInfo.StakeBeneficialMap[owner.String()].String())
+	sbpStake := new(big.Int).Mul(devSBPStakeAmount, big.NewInt(2))
+	assert.Equal(t, sbpStake.String(), g.AccountBalanceMap[types.AddressGovernance.String()][ledger.ViteTokenId.String()].String())
+
+	g.AccountBalanceMap[owner.String()][ledger.ViteTokenId.String()] = big.NewInt(1)
+	assert.Equal(t, 1, len(GenesisConfigWarnings(g)))
+}
+
+func TestAddGenesisSBP_NodeCount(t *testing.T) {
+	owner, _ := types.HexToAddress("vite_60e292f0ac471c73d914aeff10bb25925e13b2a9fddb6e6122")
+	g := NewGenesisConfig(owner, 0)
+	for i := 0; i < 255; i++ {
+		producer := types.Address{byte(i), byte(i >> 8)}
+		assert.NilError(t, AddGenesisSBP(g, fmt.Sprintf("s%d", i), producer, owner))
+	}
+	assert.Equal(t, uint8(255), g.GovernanceInfo.ConsensusGroupInfoMap[types.SNAPSHOT_GID.String()].NodeCount)
+	assert.ErrorContains(t, AddGenesisSBP(g, "s255", types.Address{0xff, 0xff}, owner), "at most 255")
+}
+
+func TestGenesisConfigWarnings_Dev(t *testing.T) {
+	producer, _ := types.HexToAddress("vite_9065ff0e14ebf983e090cde47d59fe77d7164b576a6d2d0eda")
+	account, _ := types.HexToAddress("vite_60e292f0ac471c73d914aeff10bb25925e13b2a9fddb6e6122")
+	g := MakeDevGenesisConfig(producer, []types.Address{account})
+	assert.NilError(t, CheckGenesisConfig(g))
+	assert.DeepEqual(t, []string(nil), GenesisConfigWarnings(g))
+}
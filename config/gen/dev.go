@@ -0,0 +1,183 @@
+package config_gen
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+const DevSBPName = "dev"
+
+var (
+	devAccountBalance = new(big.Int).Mul(big.NewInt(1e8), big.NewInt(1e18))
+	devStakeAmount    = new(big.Int).Mul(big.NewInt(1e4), big.NewInt(1e18))
+	devSBPStakeAmount = new(big.Int).Mul(big.NewInt(1e6), big.NewInt(1e18))
+)
+
+// MakeDevGenesisConfig returns the genesis of an instant-seal development chain.
+// The producer is the only SBP, every account is prefunded with VITE and has quota by staking.
+func MakeDevGenesisConfig(producer types.Address, accounts []types.Address) *config.Genesis {
+	return makeSBPGenesisConfig([]string{DevSBPName}, []types.Address{producer}, accounts)
+}
+
+// MakeTestNetGenesisConfig returns the genesis of a local test network with all forks active soon after genesis.
+// Every producer is an SBP named by TestNetSBPName and votes for itself, producers and accounts are prefunded
+// like in MakeDevGenesisConfig.
+func MakeTestNetGenesisConfig(producers []types.Address, accounts []types.Address) *config.Genesis {
+	if len(producers) == 0 {
+		panic("test network needs at least one producer")
+	}
+	names := make([]string, len(producers))
+	for i := range producers {
+		names[i] = TestNetSBPName(i)
+	}
+	return makeSBPGenesisConfig(names, producers, accounts)
+}
+
+// TestNetSBPName returns the name of the i-th SBP of MakeTestNetGenesisConfig.
+func TestNetSBPName(i int) string {
+	return fmt.Sprintf("s%d", i+1)
+}
+
+func makeSBPGenesisConfig(names []string, producers []types.Address, accounts []types.Address) *config.Genesis {
+	viteTokenId := ledger.ViteTokenId
+	snapshotGid := types.SNAPSHOT_GID.String()
+	delegateGid := types.DELEGATE_GID.String()
+	owner := producers[0]
+	nodeCount := uint8(len(producers))
+
+	genesisConfig := &config.Genesis{
+		GenesisAccountAddress: &owner,
+		ForkPoints:            makeDevForkPointsConfig(),
+	}
+
+	registerParam := config.RegisterConditionParam{
+		StakeAmount: devSBPStakeAmount,
+		StakeToken:  viteTokenId,
+		StakeHeight: 1,
+	}
+	genesisConfig.GovernanceInfo = &config.GovernanceContractInfo{
+		ConsensusGroupInfoMap: map[string]*config.ConsensusGroupInfo{
+			snapshotGid: {
+				NodeCount:              nodeCount,
+				Interval:               1,
+				PerCount:               3,
+				RandCount:              0,
+				RandRank:               1,
+				Repeat:                 1,
+				CheckLevel:             0,
+				CountingTokenId:        viteTokenId,
+				RegisterConditionId:    1,
+				RegisterConditionParam: registerParam,
+				VoteConditionId:        1,
+				Owner:                  owner,
+				StakeAmount:            big.NewInt(0),
+				ExpirationHeight:       1,
+			},
+			delegateGid: {
+				NodeCount:              nodeCount,
+				Interval:               1,
+				PerCount:               3,
+				RandCount:              0,
+				RandRank:               1,
+				Repeat:                 1,
+				CheckLevel:             1,
+				CountingTokenId:        viteTokenId,
+				RegisterConditionId:    1,
+				RegisterConditionParam: registerParam,
+				VoteConditionId:        1,
+				Owner:                  owner,
+				StakeAmount:            big.NewInt(0),
+				ExpirationHeight:       1,
+			},
+		},
+		RegistrationInfoMap: map[string]map[string]*config.RegistrationInfo{
+			snapshotGid: make(map[string]*config.RegistrationInfo),
+		},
+		VoteStatusMap: map[string]map[string]string{
+			snapshotGid: make(map[string]string),
+		},
+	}
+	for i := range producers {
+		producer := producers[i]
+		genesisConfig.GovernanceInfo.RegistrationInfoMap[snapshotGid][names[i]] = &config.RegistrationInfo{
+			BlockProducingAddress: &producer,
+			StakeAddress:          &producer,
+			Amount:                devSBPStakeAmount,
+			ExpirationHeight:      1,
+			RewardTime:            1,
+			RevokeTime:            0,
+			HistoryAddressList:    []types.Address{producer},
+		}
+		genesisConfig.GovernanceInfo.VoteStatusMap[snapshotGid][producer.String()] = names[i]
+	}
+
+	topics, data, err := abi.ABIAsset.PackEvent("mint", viteTokenId)
+	if err != nil {
+		panic(err)
+	}
+	genesisConfig.AssetInfo = &config.AssetContractInfo{
+		TokenInfoMap: map[string]*config.TokenInfo{
+			viteTokenId.String(): {
+				TokenName:    "Vite Token",
+				TokenSymbol:  "VITE",
+				Decimals:     18,
+				Owner:        types.AddressAsset,
+				MaxSupply:    new(big.Int).Set(helper.Tt256m1),
+				IsReIssuable: true,
+			},
+		},
+		LogList: []*config.GenesisVmLog{{Data: hex.EncodeToString(data), Topics: topics}},
+	}
+
+	genesisConfig.QuotaInfo = &config.QuotaContractInfo{
+		StakeInfoMap:       make(map[string][]*config.StakeInfo),
+		StakeBeneficialMap: make(map[string]*big.Int),
+	}
+	genesisConfig.AccountBalanceMap = make(map[string]map[string]*big.Int)
+
+	totalSBPStake := new(big.Int).Mul(devSBPStakeAmount, big.NewInt(int64(len(producers))))
+	totalSupply := new(big.Int).Set(totalSBPStake)
+	totalStake := big.NewInt(0)
+	all := append(append([]types.Address{}, producers...), accounts...)
+	for _, addr := range all {
+		if _, ok := genesisConfig.AccountBalanceMap[addr.String()]; ok {
+			continue
+		}
+		beneficiary := addr
+		genesisConfig.AccountBalanceMap[addr.String()] = map[string]*big.Int{viteTokenId.String(): new(big.Int).Set(devAccountBalance)}
+		genesisConfig.QuotaInfo.StakeInfoMap[addr.String()] = []*config.StakeInfo{{
+			Amount:           new(big.Int).Set(devStakeAmount),
+			ExpirationHeight: 1,
+			Beneficiary:      &beneficiary,
+		}}
+		genesisConfig.QuotaInfo.StakeBeneficialMap[addr.String()] = new(big.Int).Set(devStakeAmount)
+		totalSupply.Add(totalSupply, devAccountBalance)
+		totalStake.Add(totalStake, devStakeAmount)
+	}
+	genesisConfig.AccountBalanceMap[types.AddressQuota.String()] = map[string]*big.Int{viteTokenId.String(): totalStake}
+	genesisConfig.AccountBalanceMap[types.AddressGovernance.String()] = map[string]*big.Int{viteTokenId.String(): totalSBPStake}
+	totalSupply.Add(totalSupply, totalStake)
+	genesisConfig.AssetInfo.TokenInfoMap[viteTokenId.String()].TotalSupply = totalSupply
+
+	return genesisConfig
+}
+
+// makeDevForkPointsConfig activates all forks in the first snapshot blocks after genesis.
+func makeDevForkPointsConfig() *config.ForkPoints {
+	return &config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 2, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 3, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 4, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 5, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 6, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 7, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 8, Version: 7},
+	}
+}
@@ -0,0 +1,68 @@
+package config_gen
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestMakeDevGenesisConfig(t *testing.T) {
+	producer, _, _ := types.CreateAddress()
+	account, _, _ := types.CreateAddress()
+	cfg := MakeDevGenesisConfig(producer, []types.Address{producer, account})
+	if !config.IsCompleteGenesisConfig(cfg) {
+		t.Fatalf("dev genesis config is not complete")
+	}
+	if err := fork.CheckForkPoints(*cfg.ForkPoints); err != nil {
+		t.Fatal(err)
+	}
+
+	total := big.NewInt(0)
+	for _, balances := range cfg.AccountBalanceMap {
+		total.Add(total, balances[ledger.ViteTokenId.String()])
+	}
+	if supply := cfg.AssetInfo.TokenInfoMap[ledger.ViteTokenId.String()].TotalSupply; supply.Cmp(total) != 0 {
+		t.Fatalf("total supply %s not equals to balances %s", supply, total)
+	}
+	if len(cfg.QuotaInfo.StakeInfoMap) != 2 {
+		t.Fatalf("stake info size %d", len(cfg.QuotaInfo.StakeInfoMap))
+	}
+}
+
+func TestMakeTestNetGenesisConfig(t *testing.T) {
+	var producers []types.Address
+	for i := 0; i < 3; i++ {
+		addr, _, _ := types.CreateAddress()
+		producers = append(producers, addr)
+	}
+	cfg := MakeTestNetGenesisConfig(producers, nil)
+	if !config.IsCompleteGenesisConfig(cfg) {
+		t.Fatalf("test network genesis config is not complete")
+	}
+
+	snapshotGid := types.SNAPSHOT_GID.String()
+	if n := cfg.GovernanceInfo.ConsensusGroupInfoMap[snapshotGid].NodeCount; n != 3 {
+		t.Fatalf("node count %d", n)
+	}
+	for i, producer := range producers {
+		info := cfg.GovernanceInfo.RegistrationInfoMap[snapshotGid][TestNetSBPName(i)]
+		if info == nil || *info.BlockProducingAddress != producer {
+			t.Fatalf("sbp %d is not registered", i)
+		}
+		if cfg.GovernanceInfo.VoteStatusMap[snapshotGid][producer.String()] != TestNetSBPName(i) {
+			t.Fatalf("sbp %d doesn't vote for itself", i)
+		}
+	}
+
+	total := big.NewInt(0)
+	for _, balances := range cfg.AccountBalanceMap {
+		total.Add(total, balances[ledger.ViteTokenId.String()])
+	}
+	if supply := cfg.AssetInfo.TokenInfoMap[ledger.ViteTokenId.String()].TotalSupply; supply.Cmp(total) != 0 {
+		t.Fatalf("total supply %s not equals to balances %s", supply, total)
+	}
+}
@@ -14,15 +14,10 @@ func MakeGenesisConfig(genesisFile string) *config.Genesis {
 	var genesisConfig *config.Genesis
 
 	if len(genesisFile) > 0 {
-		file, err := os.Open(genesisFile)
+		var err error
+		genesisConfig, err = ReadGenesisConfig(genesisFile)
 		if err != nil {
-			log.Crit(fmt.Sprintf("Failed to read genesis file: %v", err), "method", "readGenesis")
-		}
-		defer file.Close()
-
-		genesisConfig = new(config.Genesis)
-		if err := json.NewDecoder(file).Decode(genesisConfig); err != nil {
-			log.Crit(fmt.Sprintf("invalid genesis file: %v", err), "method", "readGenesis")
+			log.Crit(err.Error(), "method", "readGenesis")
 		}
 		if !config.IsCompleteGenesisConfig(genesisConfig) {
 			log.Crit(fmt.Sprintf("invalid genesis file, genesis account info is not complete"), "method", "readGenesis")
@@ -36,6 +31,26 @@ func MakeGenesisConfig(genesisFile string) *config.Genesis {
 	return genesisConfig
 }
 
+// ReadGenesisConfig decodes the genesis file as it is, fork points are not filled.
+func ReadGenesisConfig(genesisFile string) (*config.Genesis, error) {
+	file, err := os.Open(genesisFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis file: %v", err)
+	}
+	defer file.Close()
+
+	genesisConfig := new(config.Genesis)
+	if err := json.NewDecoder(file).Decode(genesisConfig); err != nil {
+		return nil, fmt.Errorf("invalid genesis file: %v", err)
+	}
+	return genesisConfig, nil
+}
+
+// GenesisForkPoints returns the fork points the node runs with the genesis, the ones of the mainnet if the genesis has none.
+func GenesisForkPoints(genesisConfig *config.Genesis) *config.ForkPoints {
+	return makeForkPointsConfig(genesisConfig)
+}
+
 func makeForkPointsConfig(genesisConfig *config.Genesis) *config.ForkPoints {
 	// checkForkPoints(genesisConfig.ForkPoints)
 	if genesisConfig != nil && genesisConfig.ForkPoints != nil {
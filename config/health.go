@@ -0,0 +1,21 @@
+package config
+
+// Health thresholds of the node health checks, a zero threshold disables its check
+type Health struct {
+	MaxHeightLag  uint64 `json:"MaxHeightLag"`  // max snapshot heights behind the highest peer
+	MaxBlockAge   uint64 `json:"MaxBlockAge"`   // max seconds since the latest snapshot block
+	MinPeerCount  uint64 `json:"MinPeerCount"`  // min connected peers, ignored by a single node
+	MinFreeDiskMB uint64 `json:"MinFreeDiskMB"` // min free space of the disk holding the data dir
+	FlushErrorTTL uint64 `json:"FlushErrorTTL"` // seconds a flusher error keeps the node unhealthy
+}
+
+// DefaultHealth returns the thresholds used when they are not configured
+func DefaultHealth() *Health {
+	return &Health{
+		MaxHeightLag:  30,
+		MaxBlockAge:   120,
+		MinPeerCount:  1,
+		MinFreeDiskMB: 1024,
+		FlushErrorTTL: 300,
+	}
+}
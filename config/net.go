@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	_net "net"
 	"os"
 	"path/filepath"
 
@@ -94,6 +95,16 @@ type Net struct {
 	WhiteBlockList     []string
 
 	MineKey ed25519.PrivateKey
+
+	// Transport creates the p2p and file sync connections, nil means TCP. It is only set in process,
+	// e.g. by test networks running many nodes over in-memory connections.
+	Transport Transport `json:"-"`
+}
+
+// Transport is the connection layer under p2p and file sync.
+type Transport interface {
+	Listen(addr string) (_net.Listener, error)
+	Dial(addr string) (_net.Conn, error)
 }
 
 func getPeerKey(filename string) (privateKey ed25519.PrivateKey, err error) {
@@ -0,0 +1,18 @@
+package config
+
+import "github.com/vitelabs/go-vite/common/types"
+
+// OnRoad config of contract receive scheduling
+type OnRoad struct {
+	// scheduling policies of contract workers, keyed by gid in hex
+	OnRoadPolicies map[string]*OnRoadPolicy
+}
+
+// OnRoadPolicy limits how a contract worker shares its receive capacity among contracts and callers
+type OnRoadPolicy struct {
+	ContractWeights        map[types.Address]uint64 // priority of a contract is its stake quota multiplied by the weight, default weight is 1
+	CallerCap              uint64                   // max receives of a caller by one contract per snapshot block, 0 means no limit
+	MaxReceivesPerSnapshot uint64                   // max receives of one contract per snapshot block, 0 means no limit
+	ReservedContracts      []types.Address          // contracts which can also be served by the reserved task processors
+	ReservedProcessors     int                      // number of task processors which only serve the reserved contracts
+}
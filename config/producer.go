@@ -4,6 +4,13 @@ type Producer struct {
 	Producer         bool   `json:"Producer"`
 	Coinbase         string `json:"Coinbase"`
 	EntropyStorePath string `json:"EntropyStorePath"`
+
+	// instant-seal development mode
+	Dev       bool `json:"Dev"`
+	DevPeriod int  `json:"DevPeriod"` // seconds, seal a snapshot block periodically even if there is nothing to snapshot, 0 means never
+
+	// Manual producer only seals when asked, used by in-process test networks driven by a fake clock
+	Manual bool `json:"-"`
 }
 
 //func MergeMinerConfig(cfg *Miner) *Miner {
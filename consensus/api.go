@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"math/big"
 	"time"
 
 	"github.com/vitelabs/go-vite/consensus/cdb"
@@ -31,3 +32,8 @@ func (api *APISnapshot) ReadSuccessRate(start, end uint64) ([]map[types.Address]
 	}
 	return result, nil
 }
+
+// SimulateElection re-run the SBP election for the index with hypothetical votes(SBP name -> votes).
+func (api *APISnapshot) SimulateElection(index uint64, overrides map[string]*big.Int) (*SimulationResult, error) {
+	return api.snapshot.simulateElection(index, overrides)
+}
@@ -60,6 +60,10 @@ func NewConsensusDB(db *leveldb.DB) *ConsensusDB {
 	}
 }
 
+func (self *ConsensusDB) Close() error {
+	return self.db.Close()
+}
+
 func (self *ConsensusDB) GetPointByHeight(prefix byte, height uint64) (*Point, error) {
 	key := CreatePointKey(prefix, height)
 	value, err := self.db.Get(key, nil)
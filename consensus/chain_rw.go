@@ -155,7 +155,7 @@ func (cRw *chainRw) Stop() error {
 	// todo register chain
 	close(cRw.started)
 	cRw.wg.Wait()
-	return nil
+	return cRw.dbCache.Close()
 }
 
 // VoteDetails is an extension for core.Vote
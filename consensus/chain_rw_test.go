@@ -7,6 +7,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config/gen"
 	"github.com/vitelabs/go-vite/ledger"
@@ -85,7 +86,7 @@ func TestChainRw_GetMemberInfo(t *testing.T) {
 
 	mch := NewMockChain(ctrl)
 	genesisBlock := &ledger.SnapshotBlock{Height: uint64(1), Timestamp: &simpleGenesis}
-	genesisBlock.ComputeHash()
+	genesisBlock.ComputeHash(fork.NewSchedule(config_gen.MakeGenesisConfig("").ForkPoints))
 	mch.EXPECT().GetLatestSnapshotBlock().Return(genesisBlock).AnyTimes()
 	mch.EXPECT().GetGenesisSnapshotBlock().Return(genesisBlock).AnyTimes()
 	mch.EXPECT().NewDb(gomock.Any()).Return(db, nil).MaxTimes(1)
@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"math/big"
 	"sync"
 	"time"
 
@@ -54,12 +55,14 @@ type Reader interface {
 	ReadByIndex(gid types.Gid, index uint64) ([]*Event, uint64, error)
 	VoteTimeToIndex(gid types.Gid, t2 time.Time) (uint64, error)
 	VoteIndexToTime(gid types.Gid, i uint64) (*time.Time, *time.Time, error)
+	ReadSchedule(gid types.Gid, sTime, eTime time.Time) ([]*PeriodSchedule, error)
 }
 
 // APIReader is just provided for RPC api
 type APIReader interface {
 	ReadVoteMap(t time.Time) ([]*VoteDetails, *ledger.HashHeight, error)
 	ReadSuccessRate(start, end uint64) ([]map[types.Address]*cdb.Content, error)
+	SimulateElection(index uint64, overrides map[string]*big.Int) (*SimulationResult, error)
 }
 
 // Life define the life cycle for consensus component
@@ -11,7 +11,9 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config/gen"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 )
@@ -79,7 +81,7 @@ func GenSnapshotBlock(height uint64, hexPubKey string, prevHash types.Hash, t ti
 		SeedHash:        nil,
 		SnapshotContent: nil,
 	}
-	block.Hash = block.ComputeHash()
+	block.Hash = block.ComputeHash(fork.NewSchedule(config_gen.MakeGenesisConfig("").ForkPoints))
 	return block
 }
 
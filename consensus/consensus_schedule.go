@@ -0,0 +1,76 @@
+package consensus
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// MaxSchedulePeriods is the max number of periods can be read by one ReadSchedule call.
+const MaxSchedulePeriods = 288
+
+// PeriodSchedule describes the planned producers of one period.
+// Plans is empty when the period is not determined, which means the proof block
+// of the period has not been produced yet.
+type PeriodSchedule struct {
+	Gid        types.Gid
+	Index      uint64
+	STime      time.Time
+	ETime      time.Time
+	VoteTime   time.Time
+	Determined bool
+	Plans      []*core.MemberPlan
+}
+
+func (cs *consensus) ReadSchedule(gid types.Gid, sTime, eTime time.Time) ([]*PeriodSchedule, error) {
+	// load from dpos wrapper
+	reader, err := cs.dposWrapper.getDposConsensus(gid)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.rw.rollbackLock.RLockRollback()
+	defer cs.rw.rollbackLock.RUnLockRollback()
+	return readSchedule(gid, reader, cs.rw.GetLatestSnapshotBlock(), sTime, eTime)
+}
+
+func readSchedule(gid types.Gid, reader DposReader, head *ledger.SnapshotBlock, sTime, eTime time.Time) ([]*PeriodSchedule, error) {
+	if head == nil {
+		return nil, errors.New("latest snapshot block is nil")
+	}
+	if eTime.Before(sTime) {
+		return nil, errors.Errorf("end time[%s] is before start time[%s]", eTime, sTime)
+	}
+	fromIndex := reader.Time2Index(sTime)
+	toIndex := reader.Time2Index(eTime)
+	if toIndex-fromIndex >= MaxSchedulePeriods {
+		return nil, errors.Errorf("too many periods[%d-%d], max is %d", fromIndex, toIndex, MaxSchedulePeriods)
+	}
+
+	var result []*PeriodSchedule
+	for i := fromIndex; i <= toIndex; i++ {
+		pSTime, pETime := reader.Index2Time(i)
+		schedule := &PeriodSchedule{
+			Gid:      gid,
+			Index:    i,
+			STime:    pSTime,
+			ETime:    pETime,
+			VoteTime: reader.GenProofTime(i),
+		}
+		result = append(result, schedule)
+		// the proof block is still in the future, the election result may change.
+		if head.Timestamp.Before(schedule.VoteTime) {
+			continue
+		}
+		eResult, err := reader.ElectionIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Determined = true
+		schedule.Plans = eResult.Plans
+	}
+	return result, nil
+}
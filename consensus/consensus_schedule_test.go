@@ -0,0 +1,44 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+func TestReadSchedule(t *testing.T) {
+	cs := newSimpleCs(log15.New("unittest", "schedule"))
+
+	// head is in period 2, the proof time of period 2 is the end of period 2
+	_, headTime := cs.Index2Time(1)
+	headTime = headTime.Add(time.Second)
+	head := &ledger.SnapshotBlock{Timestamp: &headTime}
+
+	sTime, _ := cs.Index2Time(0)
+	_, eTime := cs.Index2Time(3)
+	result, err := readSchedule(types.SNAPSHOT_GID, cs, head, sTime, eTime.Add(-time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(result))
+
+	for i, schedule := range result {
+		assert.Equal(t, uint64(i), schedule.Index)
+		if i < 2 {
+			assert.True(t, schedule.Determined)
+			assert.Equal(t, 6, len(schedule.Plans))
+			assert.Equal(t, schedule.STime, schedule.Plans[0].STime)
+		} else {
+			assert.False(t, schedule.Determined)
+			assert.Empty(t, schedule.Plans)
+		}
+	}
+
+	_, err = readSchedule(types.SNAPSHOT_GID, cs, head, eTime, sTime)
+	assert.Error(t, err)
+
+	_, err = readSchedule(types.SNAPSHOT_GID, cs, head, sTime, sTime.Add(time.Hour*24*365))
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package consensus
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// SimulationResult is the result of a snapshot election re-run with hypothetical votes.
+type SimulationResult struct {
+	Index       uint64
+	ProofHash   types.Hash
+	ProofHeight uint64
+	Determined  bool
+
+	// Ranking is the candidates sorted by votes, at most RandRank candidates.
+	Ranking []*core.Vote
+	// Line is the votes of the last candidate in top NodeCount, nil if candidates are not enough.
+	Line *big.Int
+	// Producers is the elected SBPs in shuffled order.
+	Producers []*core.Vote
+}
+
+// simulateElection re-runs the election for the index, votes of the SBP in overrides are replaced.
+func (snapshot *snapshotCs) simulateElection(index uint64, overrides map[string]*big.Int) (*SimulationResult, error) {
+	snapshot.rw.rollbackLock.RLockRollback()
+	defer snapshot.rw.rollbackLock.RUnLockRollback()
+
+	proofTime, _ := snapshot.genSnapshotProofTimeIndx(index)
+
+	proofBlock, err := snapshot.rw.GetSnapshotBeforeTime(proofTime)
+	if err != nil {
+		return nil, err
+	}
+	hashH := ledger.HashHeight{Hash: proofBlock.Hash, Height: proofBlock.Height}
+
+	votes, err := snapshot.rw.CalVotes(&snapshot.GroupInfo, hashH)
+	if err != nil {
+		return nil, err
+	}
+	var successRate map[types.Address]int32
+	_, proofIndex := snapshot.genSnapshotProofTimeIndx(snapshot.Time2Index(*proofBlock.Timestamp))
+	if proofIndex > 0 {
+		successRate, err = snapshot.rw.GetSuccessRateByHour(proofIndex)
+		if err != nil {
+			return nil, err
+		}
+	}
+	seed := core.NewSeedInfo(snapshot.rw.GetSeedsBeforeHashH(hashH.Hash))
+
+	result, err := simulateVotes(&snapshot.GroupInfo, snapshot.algo, votes, &hashH, successRate, seed, overrides)
+	if err != nil {
+		return nil, err
+	}
+	result.Index = index
+	result.Determined = !snapshot.rw.GetLatestSnapshotBlock().Timestamp.Before(proofTime)
+	return result, nil
+}
+
+func simulateVotes(info *core.GroupInfo, algo core.Algo, votes []*core.Vote, hashH *ledger.HashHeight,
+	successRate map[types.Address]int32, seed *core.SeedInfo, overrides map[string]*big.Int) (*SimulationResult, error) {
+	for name, balance := range overrides {
+		if balance == nil || balance.Sign() < 0 {
+			return nil, errors.Errorf("invalid votes for SBP[%s]", name)
+		}
+		found := false
+		for _, v := range votes {
+			if v.Name == name {
+				v.Balance = new(big.Int).Set(balance)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("SBP[%s] not exist", name)
+		}
+	}
+
+	ranking := make([]*core.Vote, len(votes))
+	copy(ranking, votes)
+	sort.Sort(core.ByBalance(ranking))
+	if len(ranking) > int(info.RandRank) {
+		ranking = ranking[:info.RandRank]
+	}
+
+	result := &SimulationResult{ProofHash: hashH.Hash, ProofHeight: hashH.Height, Ranking: ranking}
+	if len(ranking) >= int(info.NodeCount) && info.NodeCount > 0 {
+		result.Line = ranking[info.NodeCount-1].Balance
+	}
+
+	context := core.NewVoteAlgoContext(votes, hashH, successRate, seed)
+	// filter size of members
+	finalVotes := algo.FilterVotes(context)
+	// shuffle the members
+	result.Producers = algo.ShuffleVotes(finalVotes, hashH, seed)
+	return result, nil
+}
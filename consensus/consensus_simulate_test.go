@@ -0,0 +1,59 @@
+package consensus
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func genSimulateVotes(n int) []*core.Vote {
+	var votes []*core.Vote
+	for i := 0; i < n; i++ {
+		votes = append(votes, &core.Vote{Name: "s" + strconv.Itoa(i), Balance: big.NewInt(int64(100 + i))})
+	}
+	return votes
+}
+
+func TestSimulateVotes(t *testing.T) {
+	info := core.NewGroupInfo(time.Unix(1541640427, 0), types.ConsensusGroupInfo{
+		Gid:       types.SNAPSHOT_GID,
+		NodeCount: 5,
+		Interval:  1,
+		PerCount:  3,
+		RandCount: 0,
+		RandRank:  8,
+		Repeat:    1,
+	})
+	algo := core.NewAlgo(info)
+	hashH := &ledger.HashHeight{Height: 10}
+
+	result, err := simulateVotes(info, algo, genSimulateVotes(10), hashH, nil, core.NewSeedInfo(0), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(result.Ranking))
+	assert.Equal(t, "s9", result.Ranking[0].Name)
+	assert.Equal(t, big.NewInt(105), result.Line)
+	assert.Equal(t, 5, len(result.Producers))
+
+	// s0 gets the most votes and enters the producer set
+	overrides := map[string]*big.Int{"s0": big.NewInt(1000)}
+	result, err = simulateVotes(info, algo, genSimulateVotes(10), hashH, nil, core.NewSeedInfo(0), overrides)
+	assert.NoError(t, err)
+	assert.Equal(t, "s0", result.Ranking[0].Name)
+	assert.Equal(t, big.NewInt(106), result.Line)
+	found := false
+	for _, v := range result.Producers {
+		if v.Name == "s0" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+
+	_, err = simulateVotes(info, algo, genSimulateVotes(10), hashH, nil, core.NewSeedInfo(0), map[string]*big.Int{"unknown": big.NewInt(1)})
+	assert.Error(t, err)
+}
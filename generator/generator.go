@@ -3,7 +3,6 @@ package generator
 import (
 	"errors"
 	"fmt"
-	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/consensus/core"
 	"github.com/vitelabs/go-vite/ledger"
@@ -141,7 +140,7 @@ func (gen *Generator) generateBlock(block *ledger.AccountBlock, fromBlock *ledge
 		if err != nil {
 			return nil, fmt.Errorf("GetSnapshotBlockByContractMeta failed", "err", err)
 		}
-		if fork.IsSeedFork(latestSb.Height) {
+		if gen.GetVMDB().ForkSchedule().IsSeedFork(latestSb.Height) {
 			limitSeedSb, err := gen.chain.GetSeedConfirmedSnapshotBlock(block.AccountAddress, fromBlock.Hash)
 			if err != nil {
 				return nil, fmt.Errorf("GetSeedConfirmedSnapshotBlock failed", "err", err)
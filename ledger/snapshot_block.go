@@ -153,7 +153,7 @@ func ComputeSeedHash(seed uint64, prevHash types.Hash, timestamp *time.Time) typ
 	return hash
 }
 
-func (sb *SnapshotBlock) hashSourceLength() int {
+func (sb *SnapshotBlock) hashSourceLength(forks *fork.Schedule) int {
 	// 1 , 2, 3, 4, 5
 	size := types.HashSize + 8 + 8 + 8 + types.HashSize
 
@@ -161,12 +161,12 @@ func (sb *SnapshotBlock) hashSourceLength() int {
 	size += len(sb.SnapshotContent) * ScItemBytesLen
 
 	// forkName
-	forkPoint := fork.GetRecentActiveFork(sb.Height)
+	forkPoint := forks.GetRecentActiveFork(sb.Height)
 	if forkPoint != nil {
 		size += len(forkPoint.ForkName)
 	}
 	// Add version
-	if fork.IsLeafFork(sb.Height) {
+	if forks.IsLeafFork(sb.Height) {
 		// append version
 		size += 4
 	}
@@ -174,8 +174,9 @@ func (sb *SnapshotBlock) hashSourceLength() int {
 	return size
 }
 
-func (sb *SnapshotBlock) ComputeHash() types.Hash {
-	source := make([]byte, 0, sb.hashSourceLength())
+// ComputeHash computes the hash of the block, the hash source depends on the forks the block height is over.
+func (sb *SnapshotBlock) ComputeHash(forks *fork.Schedule) types.Hash {
+	source := make([]byte, 0, sb.hashSourceLength(forks))
 	// PrevHash
 	source = append(source, sb.PrevHash.Bytes()...)
 
@@ -209,14 +210,14 @@ func (sb *SnapshotBlock) ComputeHash() types.Hash {
 	}
 
 	// Add fork name
-	forkPoint := fork.GetRecentActiveFork(sb.Height)
+	forkPoint := forks.GetRecentActiveFork(sb.Height)
 
 	if forkPoint != nil {
 		source = append(source, []byte(forkPoint.ForkName)...)
 	}
 
 	// Add version
-	if fork.IsLeafFork(sb.Height) {
+	if forks.IsLeafFork(sb.Height) {
 		// append version
 		versionBytes := make([]byte, 4)
 		binary.BigEndian.PutUint32(versionBytes, sb.Version)
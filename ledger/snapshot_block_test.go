@@ -12,6 +12,7 @@ import (
 
 	"github.com/vitelabs/go-vite/crypto"
 	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"math"
 	"math/rand"
 	"testing"
 	"time"
@@ -32,6 +33,12 @@ func createSnapshotContent(count int) SnapshotContent {
 	return sc
 }
 
+// testForks has no fork active at the heights of the blocks in the tests
+var testForks = fork.NewSchedule(&config.ForkPoints{
+	SeedFork: &config.ForkPoint{Height: math.MaxUint64, Version: 1},
+	LeafFork: &config.ForkPoint{Height: math.MaxUint64, Version: 1},
+})
+
 func createSnapshotBlock(scCount int, sbheight uint64) *SnapshotBlock {
 	_, privateKey, _ := types.CreateAddress()
 	now := time.Now()
@@ -44,7 +51,7 @@ func createSnapshotBlock(scCount int, sbheight uint64) *SnapshotBlock {
 		Timestamp:       &now,
 		SnapshotContent: createSnapshotContent(scCount),
 	}
-	sb.Hash = sb.ComputeHash()
+	sb.Hash = sb.ComputeHash(testForks)
 	sb.Signature = ed25519.Sign(privateKey, sb.Hash.Bytes())
 	return sb
 
@@ -97,7 +104,7 @@ func BmSnapshotBlockHash(b *testing.B, scCount int) {
 	b.StartTimer()
 
 	for i := 0; i < b.N; i++ {
-		block.ComputeHash()
+		block.ComputeHash(testForks)
 	}
 }
 
@@ -218,14 +225,15 @@ func TestForkComputeHash(t *testing.T) {
 
 	snapshotBlock := createSnapshotBlock(1, 10000000000000)
 	hashold := snapshotBlock.Hash
-	fork.SetForkPoints(&config.ForkPoints{
+	forks := fork.NewSchedule(&config.ForkPoints{
 		SeedFork: &config.ForkPoint{
 			Height:  90,
 			Version: 1,
 		},
+		LeafFork: &config.ForkPoint{Height: math.MaxUint64, Version: 1},
 	})
 
-	hashnew := snapshotBlock.ComputeHash()
+	hashnew := snapshotBlock.ComputeHash(forks)
 
 	if hashold == hashnew {
 		t.Fatal(fmt.Sprintf("is not right, old: %+v,  new:  %+v", hashold, hashnew))
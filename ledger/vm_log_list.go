@@ -15,7 +15,7 @@ type VmLog struct {
 
 type VmLogList []*VmLog
 
-func (vll VmLogList) Hash(snapshotHeight uint64, address types.Address, prevHash types.Hash) *types.Hash {
+func (vll VmLogList) Hash(forks *fork.Schedule, snapshotHeight uint64, address types.Address, prevHash types.Hash) *types.Hash {
 	if len(vll) == 0 {
 		return nil
 	}
@@ -29,7 +29,7 @@ func (vll VmLogList) Hash(snapshotHeight uint64, address types.Address, prevHash
 		source = append(source, vmLog.Data...)
 	}
 
-	if fork.IsSeedFork(snapshotHeight) {
+	if forks.IsSeedFork(snapshotHeight) {
 		// append address bytes
 		source = append(source, address.Bytes()...)
 		source = append(source, prevHash.Bytes()...)
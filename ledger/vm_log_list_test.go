@@ -11,7 +11,7 @@ import (
 func TestVmLogList_Hash(t *testing.T) {
 	var vmLogList VmLogList
 
-	fork.SetForkPoints(&config.ForkPoints{
+	forks := fork.NewSchedule(&config.ForkPoints{
 		SeedFork: &config.ForkPoint{
 			Height:  90,
 			Version: 1,
@@ -41,9 +41,9 @@ func TestVmLogList_Hash(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	vmLogHash1 := vmLogList.Hash(1, address, prehash)
-	vmLogHash50 := vmLogList.Hash(50, address, prehash)
-	vmLogHash100 := vmLogList.Hash(100, address, prehash)
+	vmLogHash1 := vmLogList.Hash(forks, 1, address, prehash)
+	vmLogHash50 := vmLogList.Hash(forks, 50, address, prehash)
+	vmLogHash100 := vmLogList.Hash(forks, 100, address, prehash)
 
 	if *vmLogHash1 != *vmLogHash50 {
 		t.Fatal(fmt.Sprintf("vmloghash1 should be equal with vmloghash50 , %+v, %+v", vmLogHash1, vmLogHash50))
@@ -52,20 +52,20 @@ func TestVmLogList_Hash(t *testing.T) {
 	if *vmLogHash100 == *vmLogHash1 {
 		t.Fatal(fmt.Sprintf("vmloghash1 should not be equal with vmloghash100 , %+v, %+v", vmLogHash100, vmLogHash1))
 	}
-	fork.SetForkPoints(&config.ForkPoints{
+	forks = fork.NewSchedule(&config.ForkPoints{
 		SeedFork: &config.ForkPoint{
 			Height:  101,
 			Version: 1,
 		},
 	})
 
-	vmLogHash95 := vmLogList.Hash(95, address, prehash)
+	vmLogHash95 := vmLogList.Hash(forks, 95, address, prehash)
 
 	if *vmLogHash50 != *vmLogHash95 {
 		t.Fatal(fmt.Sprintf("vmloghash51 should be equal with vmloghash50 , %+v, %+v", vmLogHash95, vmLogHash50))
 	}
 
-	vmLogHash105 := vmLogList.Hash(105, address, prehash)
+	vmLogHash105 := vmLogList.Hash(forks, 105, address, prehash)
 	if *vmLogHash105 != *vmLogHash100 {
 		t.Fatal(fmt.Sprintf("vmloghash105 should be equal with vmLogHash100 , %+v, %+v", vmLogHash105, vmLogHash100))
 	}
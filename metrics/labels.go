@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"strings"
+)
+
+// LabeledName appends labels to the name of a metric, e.g. LabeledName("/rpc/duration", "method", "ledger_getProof")
+// is "/rpc/duration{method=ledger_getProof}". Exporters supporting labels split them from the name,
+// the others see the labels as a part of the name.
+func LabeledName(name string, kv ...string) string {
+	if len(kv) < 2 {
+		return name
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i := 0; i+1 < len(kv); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(kv[i])
+		b.WriteByte('=')
+		b.WriteString(strings.NewReplacer(",", "_", "}", "_", "=", "_").Replace(kv[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// SplitLabels splits a name built by LabeledName to the name and the labels.
+func SplitLabels(name string) (string, map[string]string) {
+	i := strings.IndexByte(name, '{')
+	if i < 0 || !strings.HasSuffix(name, "}") {
+		return name, nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(name[i+1:len(name)-1], ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 && kv[0] != "" {
+			labels[kv[0]] = kv[1]
+		}
+	}
+	return name[:i], labels
+}
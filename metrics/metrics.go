@@ -36,6 +36,9 @@ type Config struct {
 	IsEnable         bool
 	IsInfluxDBEnable bool
 	InfluxDBInfo     *InfluxDBConfig
+
+	IsPrometheusEnable bool
+	PrometheusEndpoint string // host:port of the /metrics endpoint
 }
 
 func InitMetrics(metricFlag, influxDBFlag bool) {
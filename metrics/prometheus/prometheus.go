@@ -0,0 +1,239 @@
+// Package prometheus exports metrics in the Prometheus text exposition format.
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metric types of the exposition format
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+	Summary = "summary"
+)
+
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99}
+
+// Sample is a value of a metric family, Name is a full Prometheus name, e.g. "vite_monitor_events_total".
+type Sample struct {
+	Name   string
+	Type   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Collector provides the samples of the values kept outside of a metrics.Registry.
+type Collector func() []Sample
+
+type family struct {
+	typ     string
+	samples []Sample
+}
+
+// Handler serves the metrics of r with names prefixed by namespace and the samples of collectors.
+func Handler(r metrics.Registry, namespace string, collectors ...Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		samples := Gather(r, namespace)
+		for _, c := range collectors {
+			samples = append(samples, c()...)
+		}
+		Write(w, samples)
+	})
+}
+
+// Gather converts the metrics of r to samples:
+// counters and meters are counters, gauges are gauges, histograms are summaries,
+// timers are summaries in seconds and resetting timers are gauges of the quantiles in seconds.
+func Gather(r metrics.Registry, namespace string) []Sample {
+	var samples []Sample
+	r.Each(func(registryName string, i interface{}) {
+		path, labels := metrics.SplitLabels(registryName)
+		name := Name(namespace, path)
+		if name == "" {
+			return
+		}
+
+		switch m := i.(type) {
+		case metrics.Counter:
+			samples = append(samples, Sample{Name: counterName(name), Type: Counter, Labels: labels, Value: float64(m.Count())})
+		case metrics.Gauge:
+			samples = append(samples, Sample{Name: name, Type: Gauge, Labels: labels, Value: float64(m.Value())})
+		case metrics.GaugeFloat64:
+			samples = append(samples, Sample{Name: name, Type: Gauge, Labels: labels, Value: m.Value()})
+		case metrics.Meter:
+			samples = append(samples, Sample{Name: counterName(name), Type: Counter, Labels: labels, Value: float64(m.Snapshot().Count())})
+		case metrics.Histogram:
+			s := m.Snapshot()
+			samples = append(samples, summary(name, labels, s.Percentiles(quantiles), float64(s.Sum()), s.Count(), 1)...)
+		case metrics.Timer:
+			s := m.Snapshot()
+			samples = append(samples, summary(name+"_seconds", labels, s.Percentiles(quantiles), float64(s.Sum()), s.Count(), 1e-9)...)
+		case metrics.ResettingTimer:
+			st, ok := m.(*metrics.StandardResettingTimer)
+			if !ok {
+				return
+			}
+			s := st.Peek()
+			if len(s.Values()) == 0 {
+				return
+			}
+			for i, v := range s.Percentiles(quantiles) {
+				samples = append(samples, Sample{
+					Name:   name + "_seconds",
+					Type:   Gauge,
+					Labels: withLabel(labels, "quantile", formatFloat(quantiles[i])),
+					Value:  float64(v) * 1e-9,
+				})
+			}
+		}
+	})
+	return samples
+}
+
+func summary(name string, labels map[string]string, ps []float64, sum float64, count int64, scale float64) []Sample {
+	samples := make([]Sample, 0, len(ps)+2)
+	for i, p := range ps {
+		samples = append(samples, Sample{Name: name, Type: Summary, Labels: withLabel(labels, "quantile", formatFloat(quantiles[i])), Value: p * scale})
+	}
+	samples = append(samples,
+		Sample{Name: name + "_sum", Type: Summary, Labels: labels, Value: sum * scale},
+		Sample{Name: name + "_count", Type: Summary, Labels: labels, Value: float64(count)})
+	return samples
+}
+
+// Write writes samples grouped by metric families, the families are sorted by name.
+// A family is named by its first sample, the samples of other types in the same family are dropped.
+func Write(w io.Writer, samples []Sample) error {
+	families := make(map[string]*family)
+	var names []string
+	for _, s := range samples {
+		fname := s.Name
+		if s.Type == Summary {
+			fname = strings.TrimSuffix(strings.TrimSuffix(fname, "_sum"), "_count")
+		}
+		f, ok := families[fname]
+		if !ok {
+			f = &family{typ: s.Type}
+			families[fname] = f
+			names = append(names, fname)
+		}
+		if f.typ == s.Type {
+			f.samples = append(f.samples, s)
+		}
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, f.typ)
+		for _, s := range f.samples {
+			bw.WriteString(s.Name)
+			writeLabels(bw, s.Labels)
+			bw.WriteByte(' ')
+			bw.WriteString(formatFloat(s.Value))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+func writeLabels(w *bufio.Writer, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(sanitize(k))
+		w.WriteString(`="`)
+		w.WriteString(escaper.Replace(labels[k]))
+		w.WriteByte('"')
+	}
+	w.WriteByte('}')
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// Name converts a registry path like "/system/cpu/sysusage" to a Prometheus name like "vite_system_cpu_sysusage".
+func Name(namespace, path string) string {
+	name := sanitize(strings.ToLower(path))
+	if name == "" {
+		return ""
+	}
+	if namespace != "" {
+		name = namespace + "_" + name
+	}
+	return name
+}
+
+// sanitize replaces the invalid characters with underscores and trims the underscores
+func sanitize(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, c := range s {
+		valid := c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !valid {
+			if !underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			underscore = true
+			continue
+		}
+		b.WriteRune(c)
+		underscore = false
+	}
+	name := strings.TrimRight(b.String(), "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func counterName(name string) string {
+	if strings.HasSuffix(name, "_total") {
+		return name
+	}
+	return name + "_total"
+}
+
+func withLabel(labels map[string]string, k, v string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for lk, lv := range labels {
+		result[lk] = lv
+	}
+	result[k] = v
+	return result
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
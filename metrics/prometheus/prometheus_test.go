@@ -0,0 +1,86 @@
+package prometheus
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+func TestGather(t *testing.T) {
+	enabled := metrics.MetricsEnabled
+	metrics.MetricsEnabled = true
+	defer func() { metrics.MetricsEnabled = enabled }()
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("/pool/insert", r).Inc(3)
+	metrics.GetOrRegisterGauge("/chain/height", r).Update(42)
+	metrics.GetOrRegisterGaugeFloat64("/system/cpu/sysusage", r).Update(0.5)
+	metrics.GetOrRegisterTimer(metrics.LabeledName("/rpc/duration", "method", "ledger_getProof"), r).Update(2 * time.Second)
+	metrics.GetOrRegisterResettingTimer("/codexec/timeconsuming/x", r).Update(time.Millisecond)
+
+	var buf bytes.Buffer
+	samples := append(Gather(r, "vite"), Sample{Name: "vite_monitor_events_total", Type: Counter, Labels: map[string]string{"type": "pool", "name": `a"b`}, Value: 7})
+	if err := Write(&buf, samples); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, line := range []string{
+		"# TYPE vite_pool_insert_total counter",
+		"vite_pool_insert_total 3",
+		"# TYPE vite_chain_height gauge",
+		"vite_chain_height 42",
+		"vite_system_cpu_sysusage 0.5",
+		"# TYPE vite_rpc_duration_seconds summary",
+		`vite_rpc_duration_seconds{method="ledger_getProof",quantile="0.5"} 2`,
+		`vite_rpc_duration_seconds_count{method="ledger_getProof"} 1`,
+		`vite_rpc_duration_seconds_sum{method="ledger_getProof"} 2`,
+		`vite_codexec_timeconsuming_x_seconds{quantile="0.99"} 0.001`,
+		`vite_monitor_events_total{name="a\"b",type="pool"} 7`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("missing %q in\n%s", line, out)
+		}
+	}
+	if strings.Count(out, "# TYPE vite_rpc_duration_seconds ") != 1 {
+		t.Errorf("a family must have one type line\n%s", out)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Register("/a", metrics.NewFunctionalGauge(func() int64 { return 1 }))
+
+	rec := httptest.NewRecorder()
+	Handler(r, "vite", func() []Sample {
+		return []Sample{{Name: "vite_b", Type: Gauge, Value: 2}}
+	}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("content type %s", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "vite_b 2\n") {
+		t.Fatalf("missing collector samples\n%s", rec.Body.String())
+	}
+}
+
+func TestName(t *testing.T) {
+	for path, name := range map[string]string{
+		"/system/cpu/sysusage":   "vite_system_cpu_sysusage",
+		"/codexec/branch/A.b--c": "vite_codexec_branch_a_b_c",
+		"//":                     "",
+	} {
+		if got := Name("vite", path); got != name {
+			t.Errorf("Name(%s) is %s, expected %s", path, got, name)
+		}
+	}
+
+	name, labels := metrics.SplitLabels(metrics.LabeledName("/rpc/duration", "method", "a,b", "code", "1"))
+	if name != "/rpc/duration" || labels["method"] != "a_b" || labels["code"] != "1" {
+		t.Fatalf("split labels: %s %v", name, labels)
+	}
+}
@@ -107,6 +107,18 @@ func (t *StandardResettingTimer) Snapshot() ResettingTimer {
 	}
 }
 
+// Peek returns chain read-only copy of the contents without resetting the timer.
+func (t *StandardResettingTimer) Peek() ResettingTimer {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	values := make([]int64, len(t.values))
+	copy(values, t.values)
+
+	return &ResettingTimerSnapshot{
+		values: values,
+	}
+}
+
 // Percentiles panics.
 func (t *StandardResettingTimer) Percentiles([]float64) []int64 {
 	panic("Percentiles called on chain StandardResettingTimer")
@@ -36,6 +36,9 @@ func init() {
 
 var m *monitor
 
+// totals accumulates the events since the node started, it's enabled with metrics
+var totals sync.Map // map[string]*Total
+
 var logger log15.Logger
 
 type monitor struct {
@@ -95,6 +98,14 @@ func LogDuration(t string, name string, duration int64) {
 }
 
 func log(t string, name string, i int64) {
+	if metrics.MetricsEnabled {
+		k := key(t, name)
+		value, ok := totals.Load(k)
+		if !ok {
+			value, _ = totals.LoadOrStore(k, &Total{Type: t, Name: name})
+		}
+		value.(*Total).add(i)
+	}
 	// TODO fix
 	//k := key(t, name)
 	//value, ok := m.ms.Load(k)
@@ -105,6 +116,29 @@ func log(t string, name string, i int64) {
 	//}
 }
 
+// Total is the count and the sum of the values of an event since the node started,
+// the values of LogTime and LogDuration are nanoseconds.
+type Total struct {
+	Type string
+	Name string
+	Msg
+}
+
+// Totals returns the totals of all events logged since the metrics are enabled.
+func Totals() []Total {
+	var result []Total
+	totals.Range(func(_, v interface{}) bool {
+		t := v.(*Total)
+		result = append(result, Total{
+			Type: t.Type,
+			Name: t.Name,
+			Msg:  Msg{Cnt: atomic.LoadInt64(&t.Cnt), Sum: atomic.LoadInt64(&t.Sum)},
+		})
+		return true
+	})
+	return result
+}
+
 type stat struct {
 	Cnt int64
 	Avg float64
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/vitelabs/go-vite/common/bloom"
+	"github.com/vitelabs/go-vite/common/fork"
 
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
@@ -388,6 +389,7 @@ func (r *ringStatic) failedRatio() float32 {
 type broadChainReader interface {
 	GetLatestSnapshotBlock() *ledger.SnapshotBlock
 	GetConfirmedTimes(blockHash types.Hash) (uint64, error)
+	ForkSchedule() *fork.Schedule
 }
 
 type broadcaster struct {
@@ -467,7 +469,7 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 		}
 
 		// use the compute hash, because computeHash can`t be forged
-		hash := block.ComputeHash()
+		hash := block.ComputeHash(b.chain.ForkSchedule())
 
 		// check if has exist or record, return true if has exist
 		if exist := b.filter.TestAndAdd(hash[:]); exist {
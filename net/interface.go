@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-errors/errors"
 
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/ledger"
@@ -56,6 +57,7 @@ type Chain interface {
 	chainReader
 	ledgerReader
 	syncCacher
+	ForkSchedule() *fork.Schedule
 }
 
 type IrreversibleReader interface {
@@ -9,7 +9,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config/gen"
 	"github.com/vitelabs/go-vite/ledger"
 )
 
@@ -208,7 +210,8 @@ func TestNewSnapshotBlock_Serialize(t *testing.T) {
 		t.Errorf("deserialize error: %v", err)
 	}
 
-	if nb.Block.ComputeHash() != nb2.Block.ComputeHash() {
+	forks := fork.NewSchedule(config_gen.MakeGenesisConfig("").ForkPoints)
+	if nb.Block.ComputeHash(forks) != nb2.Block.ComputeHash(forks) {
 		t.Error("different hash")
 	}
 }
@@ -1,6 +1,7 @@
 package net
 
 import (
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/ledger"
@@ -63,3 +64,7 @@ func (mc mockChain) GetLedgerReaderByHeight(startHeight uint64, endHeight uint64
 func (mc mockChain) GetSyncCache() interfaces.SyncCache {
 	panic("implement me")
 }
+
+func (mc mockChain) ForkSchedule() *fork.Schedule {
+	panic("implement me")
+}
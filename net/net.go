@@ -47,7 +47,7 @@ type net struct {
 
 	db *database.DB
 
-	dialer       _net.Dialer
+	transport    config.Transport
 	listener     _net.Listener
 	hkr          *handshaker
 	receiveSlots chan struct{}
@@ -73,6 +73,7 @@ type net struct {
 	blackList netool.BlackList
 
 	running int32
+	term    chan struct{}
 
 	log log15.Logger
 
@@ -129,7 +130,7 @@ func (n *net) ConnectNode(node *vnode.Node) (err error) {
 		return fmt.Errorf("node %s has been banned", node.ID)
 	}
 
-	conn, err := _net.Dial("tcp", node.Address())
+	conn, err := n.transport.Dial(node.Address())
 	if err != nil {
 		n.blackList.Ban(node.ID.Bytes(), 10)
 		return
@@ -407,6 +408,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		mineKey: cfg.MineKey,
 	}
 	downloader := newExecutor(50, 10, peers, syncConnFac)
+	downloader.dialer = transportOf(cfg, 5*time.Second)
 
 	reader := newCacheReader(chain, verifier, downloader, irreader, blackHashList)
 
@@ -420,8 +422,9 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 	syncer.SubscribeSyncStatus(broadcaster.subSyncState)
 
 	n := &net{
-		config: cfg,
-		chain:  chain,
+		config:    cfg,
+		chain:     chain,
+		transport: transportOf(cfg, 0),
 		node: &vnode.Node{
 			ID:  id,
 			Net: cfg.NetID,
@@ -434,7 +437,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		fetcher:         fetcher,
 		broadcaster:     broadcaster,
 		downloader:      downloader,
-		syncServer:      newSyncServer(cfg.ListenInterface+":"+strconv.Itoa(cfg.FilePort), chain, syncConnFac),
+		syncServer:      newSyncServer(cfg.ListenInterface+":"+strconv.Itoa(cfg.FilePort), chain, syncConnFac, transportOf(cfg, 0)),
 		handlers:        newHandlers("vite"),
 		hb:              newHeartBeater(peers, chain),
 		blackList: netool.NewBlackList(func(t int64, count int) bool {
@@ -556,6 +559,9 @@ func (n *net) beatLoop() {
 
 	for {
 		select {
+		case <-n.term:
+			return
+
 		case <-beatTicker.C:
 			if n.running == 0 {
 				return
@@ -662,7 +668,9 @@ func (h *heartBeater) state() []byte {
 
 func (n *net) Start() (err error) {
 	if atomic.CompareAndSwapInt32(&n.running, 0, 1) {
-		n.listener, err = _net.Listen("tcp", n.config.ListenInterface+":"+strconv.Itoa(n.config.Port))
+		n.term = make(chan struct{})
+
+		n.listener, err = n.transport.Listen(n.config.ListenInterface + ":" + strconv.Itoa(n.config.Port))
 		if err != nil {
 			return
 		}
@@ -691,7 +699,11 @@ func (n *net) Start() (err error) {
 
 		n.fetcher.start()
 
-		go n.syncer.checkLoop(&n.running)
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.syncer.checkLoop(n.term)
+		}()
 
 		n.wg.Add(1)
 		go n.beatLoop()
@@ -710,6 +722,12 @@ func (n *net) Stop() error {
 
 		_ = n.listener.Close()
 
+		close(n.term)
+
+		for _, p := range n.peers.peers() {
+			_ = p.Close(PeerQuitting)
+		}
+
 		n.reader.stop()
 
 		n.syncer.stop()
@@ -727,6 +745,8 @@ func (n *net) Stop() error {
 		n.finder.clean()
 
 		n.wg.Wait()
+
+		_ = n.db.Close()
 		return nil
 	}
 
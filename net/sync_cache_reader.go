@@ -73,6 +73,7 @@ type cacheReader struct {
 	running bool
 	mu      sync.Mutex
 	cond    *sync.Cond
+	term    chan struct{}
 
 	readHeight uint64
 
@@ -242,6 +243,7 @@ func (s *cacheReader) start() {
 		return
 	}
 	s.running = true
+	s.term = make(chan struct{})
 	s.mu.Unlock()
 
 	s.wg.Add(1)
@@ -253,6 +255,9 @@ func (s *cacheReader) start() {
 
 func (s *cacheReader) stop() {
 	s.mu.Lock()
+	if s.running {
+		close(s.term)
+	}
 	s.running = false
 	s.mu.Unlock()
 
@@ -776,6 +781,7 @@ func (s *cacheReader) cleanLoop() {
 	defer s.wg.Done()
 
 	cache := s.chain.GetSyncCache()
+	term := s.term
 
 	var initDuration = 10 * time.Second
 	var maxDuration = 10 * time.Minute
@@ -797,17 +803,19 @@ Loop:
 			if duration > maxDuration {
 				duration = initDuration
 			}
-			time.Sleep(duration)
-			continue
-		}
-
-		// read chunks
-		for _, c := range cs {
-			if c.To < irevBlock.Height {
-				_ = cache.Delete(c)
+		} else {
+			// read chunks
+			for _, c := range cs {
+				if c.To < irevBlock.Height {
+					_ = cache.Delete(c)
+				}
 			}
 		}
 
-		time.Sleep(duration)
+		select {
+		case <-term:
+			break Loop
+		case <-time.After(duration):
+		}
 	}
 }
@@ -20,12 +20,12 @@ package net
 
 import (
 	"fmt"
-	net2 "net"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/vitelabs/go-vite/common/db/xleveldb/errors"
+	"github.com/vitelabs/go-vite/config"
 
 	"github.com/vitelabs/go-vite/interfaces"
 
@@ -132,7 +132,7 @@ type executor struct {
 	pool    *downloadConnPool
 	factory syncConnInitiator
 	dialing map[string]struct{}
-	dialer  *net2.Dialer
+	dialer  config.Transport
 
 	listeners []taskListener
 	running   bool
@@ -149,11 +149,8 @@ func newExecutor(max, batch int, peers *peerSet, factory syncConnInitiator) *exe
 		pool:    newDownloadConnPool(peers),
 		factory: factory,
 		dialing: make(map[string]struct{}),
-		dialer: &net2.Dialer{
-			Timeout:   5 * time.Second,
-			KeepAlive: 5 * time.Second,
-		},
-		log: netLog.New("module", "downloader"),
+		dialer:  newTCPTransport(5 * time.Second),
+		log:     netLog.New("module", "downloader"),
 	}
 
 	e.cond = sync.NewCond(&e.mu)
@@ -451,7 +448,7 @@ func (e *executor) createConn(p *Peer) (c *syncConn, err error) {
 	e.dialing[addr] = struct{}{}
 	e.mu.Unlock()
 
-	tcp, err := e.dialer.Dial("tcp", addr)
+	tcp, err := e.dialer.Dial(addr)
 
 	e.mu.Lock()
 	delete(e.dialing, addr)
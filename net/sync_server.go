@@ -27,6 +27,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/log15"
 )
@@ -41,24 +42,26 @@ type FileServerStatus struct {
 }
 
 type syncServer struct {
-	addr     string
-	ln       net2.Listener
-	mu       sync.Mutex
-	sconnMap map[peerId]*syncConn // key is addr
-	chain    ledgerReader
-	factory  syncConnReceiver
-	running  int32
-	wg       sync.WaitGroup
-	log      log15.Logger
+	addr      string
+	transport config.Transport
+	ln        net2.Listener
+	mu        sync.Mutex
+	sconnMap  map[peerId]*syncConn // key is addr
+	chain     ledgerReader
+	factory   syncConnReceiver
+	running   int32
+	wg        sync.WaitGroup
+	log       log15.Logger
 }
 
-func newSyncServer(addr string, chain ledgerReader, factory syncConnReceiver) *syncServer {
+func newSyncServer(addr string, chain ledgerReader, factory syncConnReceiver, transport config.Transport) *syncServer {
 	return &syncServer{
-		addr:     addr,
-		sconnMap: make(map[peerId]*syncConn),
-		chain:    chain,
-		factory:  factory,
-		log:      log15.New("module", "server"),
+		addr:      addr,
+		transport: transport,
+		sconnMap:  make(map[peerId]*syncConn),
+		chain:     chain,
+		factory:   factory,
+		log:       log15.New("module", "server"),
 	}
 }
 
@@ -81,7 +84,7 @@ func (s *syncServer) status() FileServerStatus {
 
 func (s *syncServer) start() error {
 	if atomic.CompareAndSwapInt32(&s.running, 0, 1) {
-		if ln, err := net2.Listen("tcp", s.addr); err != nil {
+		if ln, err := s.transport.Listen(s.addr); err != nil {
 			return err
 		} else {
 			s.ln = ln
@@ -184,17 +184,17 @@ func (s *syncer) setState(state syncState) {
 	}
 }
 
-func (s *syncer) checkLoop(run *int32) {
+func (s *syncer) checkLoop(term <-chan struct{}) {
 	checkTicker := time.NewTicker(3 * time.Second)
 	defer checkTicker.Stop()
 
 	for {
-		if *run == 0 {
+		select {
+		case <-term:
 			return
+		case <-checkTicker.C:
 		}
 
-		<-checkTicker.C
-
 		current := s.chain.GetLatestSnapshotBlock().Height
 		syncPeer := s.peers.syncPeer()
 		if syncPeer == nil {
@@ -0,0 +1,38 @@
+package net
+
+import (
+	_net "net"
+	"time"
+
+	"github.com/vitelabs/go-vite/config"
+)
+
+// tcpTransport is the default transport of p2p and file sync
+type tcpTransport struct {
+	dialer _net.Dialer
+}
+
+func newTCPTransport(timeout time.Duration) *tcpTransport {
+	return &tcpTransport{
+		dialer: _net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: timeout,
+		},
+	}
+}
+
+func (t *tcpTransport) Listen(addr string) (_net.Listener, error) {
+	return _net.Listen("tcp", addr)
+}
+
+func (t *tcpTransport) Dial(addr string) (_net.Conn, error) {
+	return t.dialer.Dial("tcp", addr)
+}
+
+// transportOf returns the transport set in config, or TCP if not set
+func transportOf(cfg *config.Net, timeout time.Duration) config.Transport {
+	if cfg.Transport != nil {
+		return cfg.Transport
+	}
+	return newTCPTransport(timeout)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/rpc"
 	"github.com/vitelabs/go-vite/wallet"
 )
 
@@ -35,8 +36,15 @@ type Config struct {
 	LedgerGcRetain uint64          `json:"LedgerGcRetain"`
 	LedgerGc       *bool           `json:"LedgerGc"`
 	OpenPlugins    *bool           `json:"OpenPlugins"`
+	StateProof     *bool           `json:"StateProof"`     // index the state commitment of snapshot blocks for ledger_getProof
 	VmLogWhiteList []types.Address `json:"vmLogWhiteList"` // contract address white list which save VM logs
 	VmLogAll       *bool           `json:"vmLogAll"`       // save all VM logs, it will cost more disk space
+	LedgerBackend  string          `json:"LedgerBackend"`  // key-value engine of the ledger stores, "leveldb" or the experimental "tiered"
+
+	// optional chain plugins, they require OpenPlugins
+	VoteHistoryPlugin    *bool `json:"VoteHistoryPlugin"`    // index the governance votes for the vote history queries
+	TokenHolderPlugin    *bool `json:"TokenHolderPlugin"`    // index the token holders for the holder and supply queries
+	AccountHistoryPlugin *bool `json:"AccountHistoryPlugin"` // index the account transfers for the account history queries
 
 	// genesis
 	GenesisFile string `json:"GenesisFile"`
@@ -73,6 +81,10 @@ type Config struct {
 	MinerEnabled         bool   `json:"Miner"`
 	MinerInterval        int    `json:"MinerInterval"`
 
+	// dev
+	Dev       bool `json:"Dev"`
+	DevPeriod int  `json:"DevPeriod"`
+
 	//rpc
 	RPCEnabled  bool  `json:"RPCEnabled"`
 	IPCEnabled  bool  `json:"IPCEnabled"`
@@ -94,6 +106,10 @@ type Config struct {
 	TestTokenHexPrivKey string   `json:"TestTokenHexPrivKey"`
 	TestTokenTti        string   `json:"TestTokenTti"`
 
+	// authentication, method allowlists and limits of the HTTP and WebSocket endpoints, if nil the
+	// registered modules are served to all the callers without limits
+	RPCAuth *rpc.AuthConfig `json:"RPCAuth"`
+
 	PowServerUrl string `json:"PowServerUrl"`
 
 	//Log level
@@ -109,6 +125,9 @@ type Config struct {
 	// subscribe
 	SubscribeEnabled bool `json:"SubscribeEnabled"`
 
+	// onroad, contract receive scheduling policies keyed by gid
+	OnRoadPolicies map[string]*config.OnRoadPolicy `json:"OnRoadPolicies"`
+
 	// dashboard
 	DashboardTargetURL string
 
@@ -123,6 +142,19 @@ type Config struct {
 	InfluxDBUsername *string `json:"InfluxDBUsername"`
 	InfluxDBPassword *string `json:"InfluxDBPassword"`
 	InfluxDBHostTag  *string `json:"InfluxDBHostTag"`
+
+	// Prometheus scrapes the metrics from http://PrometheusEndpoint/metrics, it enables the metrics collection
+	PrometheusEnable   *bool   `json:"PrometheusEnable"`
+	PrometheusEndpoint *string `json:"PrometheusEndpoint"`
+
+	// health, the load balancer probes http://HealthEndpoint/health and /ready, a zero threshold disables its check
+	HealthEnable        *bool   `json:"HealthEnable"`
+	HealthEndpoint      *string `json:"HealthEndpoint"`
+	HealthMaxHeightLag  *uint64 `json:"HealthMaxHeightLag"`
+	HealthMaxBlockAge   *uint64 `json:"HealthMaxBlockAge"`
+	HealthMinPeerCount  *uint64 `json:"HealthMinPeerCount"`
+	HealthMinFreeDiskMB *uint64 `json:"HealthMinFreeDiskMB"`
+	HealthFlushErrorTTL *uint64 `json:"HealthFlushErrorTTL"`
 }
 
 func (c *Config) makeWalletConfig() *wallet.Config {
@@ -137,12 +169,21 @@ func (c *Config) makeViteConfig() *config.Config {
 		Net:       c.makeNetConfig(),
 		Vm:        c.makeVmConfig(),
 		Subscribe: c.makeSubscribeConfig(),
+		OnRoad:    c.makeOnRoadConfig(),
 		Reward:    c.makeRewardConfig(),
-		Genesis:   config_gen.MakeGenesisConfig(c.GenesisFile),
+		Genesis:   c.makeGenesisConfig(),
+		Health:    c.makeHealthConfig(),
 		LogLevel:  c.LogLevel,
 	}
 }
 
+func (c *Config) makeGenesisConfig() *config.Genesis {
+	if c.Dev {
+		return config_gen.MakeDevGenesisConfig(devAddress(0), devAccounts())
+	}
+	return config_gen.MakeGenesisConfig(c.GenesisFile)
+}
+
 func (c *Config) makeNetConfig() *config.Net {
 	datadir := filepath.Join(c.DataDir, config.DefaultNetDirName)
 
@@ -197,6 +238,43 @@ func (c *Config) makeSubscribeConfig() *config.Subscribe {
 	}
 }
 
+func (c *Config) makeOnRoadConfig() *config.OnRoad {
+	return &config.OnRoad{
+		OnRoadPolicies: c.OnRoadPolicies,
+	}
+}
+
+func (c *Config) makeHealthConfig() *config.Health {
+	hc := config.DefaultHealth()
+	if c.HealthMaxHeightLag != nil {
+		hc.MaxHeightLag = *c.HealthMaxHeightLag
+	}
+	if c.HealthMaxBlockAge != nil {
+		hc.MaxBlockAge = *c.HealthMaxBlockAge
+	}
+	if c.HealthMinPeerCount != nil {
+		hc.MinPeerCount = *c.HealthMinPeerCount
+	}
+	if c.HealthMinFreeDiskMB != nil {
+		hc.MinFreeDiskMB = *c.HealthMinFreeDiskMB
+	}
+	if c.HealthFlushErrorTTL != nil {
+		hc.FlushErrorTTL = *c.HealthFlushErrorTTL
+	}
+	return hc
+}
+
+// healthEndpoint returns the address serving /health and /ready, it is empty if the endpoints are disabled
+func (c *Config) healthEndpoint() string {
+	if c.HealthEnable == nil || !*c.HealthEnable {
+		return ""
+	}
+	if c.HealthEndpoint != nil && len(*c.HealthEndpoint) > 0 {
+		return *c.HealthEndpoint
+	}
+	return fmt.Sprintf("%s:%d", common.DefaultMetricsHost, common.DefaultMetricsPort)
+}
+
 func (c *Config) makeMetricsConfig() *metrics.Config {
 	mc := &metrics.Config{
 		IsEnable:         false,
@@ -218,6 +296,14 @@ func (c *Config) makeMetricsConfig() *metrics.Config {
 			}
 		}
 	}
+	if c.PrometheusEnable != nil && *c.PrometheusEnable {
+		mc.IsEnable = true
+		mc.IsPrometheusEnable = true
+		mc.PrometheusEndpoint = fmt.Sprintf("%s:%d", common.DefaultMetricsHost, common.DefaultMetricsPort)
+		if c.PrometheusEndpoint != nil && len(*c.PrometheusEndpoint) > 0 {
+			mc.PrometheusEndpoint = *c.PrometheusEndpoint
+		}
+	}
 
 	return mc
 }
@@ -227,6 +313,8 @@ func (c *Config) makeMinerConfig() *config.Producer {
 		Producer:         c.MinerEnabled,
 		Coinbase:         c.CoinBase,
 		EntropyStorePath: c.EntropyStorePath,
+		Dev:              c.Dev,
+		DevPeriod:        c.DevPeriod,
 	}
 }
 
@@ -243,6 +331,26 @@ func (c *Config) makeChainConfig() *config.Chain {
 		openPlugins = *c.OpenPlugins
 	}
 
+	// is open the state commitment index
+	stateProof := false
+	if c.StateProof != nil {
+		stateProof = *c.StateProof
+	}
+
+	// is open the optional plugins
+	voteHistoryPlugin := false
+	if c.VoteHistoryPlugin != nil {
+		voteHistoryPlugin = *c.VoteHistoryPlugin
+	}
+	tokenHolderPlugin := false
+	if c.TokenHolderPlugin != nil {
+		tokenHolderPlugin = *c.TokenHolderPlugin
+	}
+	accountHistoryPlugin := false
+	if c.AccountHistoryPlugin != nil {
+		accountHistoryPlugin = *c.AccountHistoryPlugin
+	}
+
 	// save all VM logs, it will cost more disk space
 	vmLogAll := false
 	if c.VmLogAll != nil {
@@ -252,8 +360,15 @@ func (c *Config) makeChainConfig() *config.Chain {
 		LedgerGcRetain: c.LedgerGcRetain,
 		LedgerGc:       ledgerGc,
 		OpenPlugins:    openPlugins,
+		StateProof:     stateProof,
+
+		VoteHistoryPlugin:    voteHistoryPlugin,
+		TokenHolderPlugin:    tokenHolderPlugin,
+		AccountHistoryPlugin: accountHistoryPlugin,
+
 		VmLogWhiteList: c.VmLogWhiteList,
 		VmLogAll:       vmLogAll,
+		Backend:        c.LedgerBackend,
 	}
 }
 
@@ -0,0 +1,70 @@
+package node
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/tyler-smith/go-bip39"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+const (
+	// DevMnemonic is the well-known mnemonic of the dev mode accounts, NEVER use it outside a development chain.
+	DevMnemonic = "test test test test test test test test test test test junk"
+	// DevPassword is the password of the dev mode entropy store.
+	DevPassword = "dev"
+	// DevAccountCount is the number of prefunded accounts derived from DevMnemonic.
+	DevAccountCount = 10
+)
+
+func devAddress(index uint32) types.Address {
+	key, err := derivation.DeriveWithIndex(index, bip39.NewSeed(DevMnemonic, ""))
+	if err != nil {
+		panic(err)
+	}
+	addr, err := key.Address()
+	if err != nil {
+		panic(err)
+	}
+	return *addr
+}
+
+func devAccounts() []types.Address {
+	accounts := make([]types.Address, DevAccountCount)
+	for i := range accounts {
+		accounts[i] = devAddress(uint32(i))
+	}
+	return accounts
+}
+
+// SetupDevMode turns the config into an instant-seal single node development chain.
+// The first dev account is the coinbase and the only SBP, all dev accounts are unlocked.
+func (c *Config) SetupDevMode() {
+	c.Dev = true
+	c.NetSelect = "dev"
+	c.Single = true
+	c.Discover = false
+	c.GenesisFile = ""
+
+	coinbase := devAddress(0)
+	c.EntropyStorePath = coinbase.Hex()
+	c.EntropyStorePassword = DevPassword
+	c.CoinBase = fmt.Sprintf("0:%s", coinbase)
+	c.MinerEnabled = true
+
+	c.DataDir = filepath.Join(c.DataDir, "devmode")
+	c.KeyStoreDir = filepath.Join(c.KeyStoreDir, "devmode", "wallet")
+	c.DataDirPathAbs()
+
+	// expose dev apis over http and ws, on top of the default public modules of rpcapi
+	if len(c.PublicModules) == 0 {
+		c.PublicModules = []string{"ledger", "net", "contract", "util"}
+	}
+	for _, m := range c.PublicModules {
+		if m == "dev" {
+			return
+		}
+	}
+	c.PublicModules = append(c.PublicModules, "dev")
+}
@@ -0,0 +1,21 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/vitelabs/go-vite/vite/health"
+)
+
+// startHealth serves the liveness at http://endpoint/health and the readiness at http://endpoint/ready,
+// the status is 503 if a check fails
+func (node *Node) startHealth(endpoint string) error {
+	if err := node.handle(endpoint, "/health", health.Handler(node.viteServer, false)); err != nil {
+		return err
+	}
+	if err := node.handle(endpoint, "/ready", health.Handler(node.viteServer, true)); err != nil {
+		return err
+	}
+
+	log.Info("Health endpoints opened", "url", fmt.Sprintf("http://%s/health", endpoint))
+	return nil
+}
@@ -0,0 +1,111 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/metrics/prometheus"
+	"github.com/vitelabs/go-vite/monitor"
+)
+
+const (
+	metricsNamespace = "vite"
+	metricsRefresh   = 3 * time.Second
+)
+
+var nodeRegistry = metrics.NewPrefixedChildRegistry(metrics.DefaultRegistry, "/node")
+
+// startPrometheus serves the metrics at http://endpoint/metrics
+func (node *Node) startPrometheus(endpoint string) error {
+	if err := node.handle(endpoint, "/metrics", prometheus.Handler(metrics.DefaultRegistry, metricsNamespace, monitorSamples)); err != nil {
+		return err
+	}
+
+	log.Info("Prometheus endpoint opened", "url", fmt.Sprintf("http://%s/metrics", endpoint))
+	return nil
+}
+
+// handle serves handler at http://endpoint/path, the paths of an endpoint share a server,
+// e.g. the metrics and the health checks
+func (node *Node) handle(endpoint, path string, handler http.Handler) error {
+	srv, ok := node.monitorServers[endpoint]
+	if !ok {
+		listener, err := net.Listen("tcp", endpoint)
+		if err != nil {
+			return err
+		}
+		srv = &http.Server{Handler: http.NewServeMux()}
+		go srv.Serve(listener)
+
+		if node.monitorServers == nil {
+			node.monitorServers = make(map[string]*http.Server)
+		}
+		node.monitorServers[endpoint] = srv
+	}
+	srv.Handler.(*http.ServeMux).Handle(path, handler)
+	return nil
+}
+
+func (node *Node) stopMonitorServers() {
+	for endpoint, srv := range node.monitorServers {
+		srv.Close()
+		log.Info("monitor endpoint closed", "endpoint", endpoint)
+	}
+	node.monitorServers = nil
+}
+
+// monitorSamples exports the totals of the monitor events
+func monitorSamples() []prometheus.Sample {
+	totals := monitor.Totals()
+	samples := make([]prometheus.Sample, 0, 2*len(totals))
+	for _, t := range totals {
+		labels := map[string]string{"type": t.Type, "name": t.Name}
+		samples = append(samples,
+			prometheus.Sample{Name: metricsNamespace + "_monitor_events_total", Type: prometheus.Counter, Labels: labels, Value: float64(t.Cnt)},
+			prometheus.Sample{Name: metricsNamespace + "_monitor_values_total", Type: prometheus.Counter, Labels: labels, Value: float64(t.Sum)})
+	}
+	return samples
+}
+
+// collectNodeMetrics periodically updates the gauges of the pool, the sync and the onroad backlog until stop is closed
+func (node *Node) collectNodeMetrics(stop chan struct{}) {
+	var (
+		snapshotHeight  = metrics.GetOrRegisterGauge("/chain/height", nodeRegistry)
+		snapshotPending = metrics.GetOrRegisterGauge("/pool/pending/snapshot", nodeRegistry)
+		accountPending  = metrics.GetOrRegisterGauge("/pool/pending/account", nodeRegistry)
+		syncTarget      = metrics.GetOrRegisterGauge("/sync/target", nodeRegistry)
+		syncLag         = metrics.GetOrRegisterGauge("/sync/lag", nodeRegistry)
+	)
+
+	ticker := time.NewTicker(metricsRefresh)
+	defer ticker.Stop()
+	for {
+		v := node.viteServer
+		height := v.Chain().GetLatestSnapshotBlock().Height
+		snapshotHeight.Update(int64(height))
+
+		snapshotPending.Update(int64(v.Pool().SnapshotPendingNum()))
+		accountPending.Update(v.Pool().AccountPendingNum().Int64())
+
+		status := v.Net().Status()
+		syncTarget.Update(int64(status.To))
+		if status.To > height {
+			syncLag.Update(int64(status.To - height))
+		} else {
+			syncLag.Update(0)
+		}
+
+		for gid, backlog := range v.OnRoad().Backlogs() {
+			metrics.GetOrRegisterGauge(metrics.LabeledName("/onroad/backlog", "gid", gid.String()), nodeRegistry).Update(int64(backlog))
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
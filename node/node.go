@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -46,8 +47,10 @@ type Node struct {
 	viteServer *vite.Vite
 
 	// metrics
-	metricsConfig *metrics.Config
-	ifxReporter   *influxdb.Reporter
+	metricsConfig  *metrics.Config
+	ifxReporter    *influxdb.Reporter
+	metricsStop    chan struct{}
+	monitorServers map[string]*http.Server // the metrics and the health endpoints
 
 	// List of APIs currently provided by the node
 	rpcAPIs          []rpc.API
@@ -144,7 +147,10 @@ func (node *Node) Start() error {
 	defer node.lock.Unlock()
 
 	// metrics start
-	node.startMetrics()
+	if err := node.startMetrics(); err != nil {
+		log.Error(fmt.Sprintf("Node startMetrics error: %v", err))
+		return err
+	}
 
 	//p2p\vite start
 	log.Info(fmt.Sprintf("Begin Start Vite... "))
@@ -152,6 +158,16 @@ func (node *Node) Start() error {
 		log.Error(fmt.Sprintf("ViteServer start error: %v", err))
 		return err
 	}
+	if metrics.MetricsEnabled {
+		node.metricsStop = make(chan struct{})
+		go node.collectNodeMetrics(node.metricsStop)
+	}
+	if endpoint := node.config.healthEndpoint(); endpoint != "" {
+		if err := node.startHealth(endpoint); err != nil {
+			log.Error(fmt.Sprintf("Node startHealth error: %v", err))
+			return err
+		}
+	}
 
 	//rpc start
 	log.Info(fmt.Sprintf("Begin Start RPC... "))
@@ -236,6 +252,13 @@ func (node *Node) startWallet() (err error) {
 	if err != nil {
 		return
 	}
+	if node.config.Dev {
+		if _, err = node.walletManager.RecoverEntropyStoreFromMnemonic(DevMnemonic, DevPassword); err != nil {
+			log.Error(fmt.Sprintf("node.walletManager.RecoverEntropyStoreFromMnemonic error: %v", err))
+			return err
+		}
+	}
+
 	//unlock account
 	if node.config.EntropyStorePath != "" {
 
@@ -262,11 +285,11 @@ func (node *Node) startWallet() (err error) {
 
 	return nil
 }
-func (node *Node) startMetrics() {
+func (node *Node) startMetrics() error {
 	// init metrics args
 	metricsCfg := node.metricsConfig
 	if metricsCfg == nil {
-		return
+		return nil
 	}
 	if metricsCfg.IsInfluxDBEnable == false || metricsCfg.InfluxDBInfo == nil {
 		log.Info("influxdb export disable or influxdbinfo of reporter is not complete")
@@ -287,16 +310,28 @@ func (node *Node) startMetrics() {
 				"monitor", map[string]string{"host": influxDBInfo.HostTag})
 			if err != nil || rp == nil {
 				log.Error(fmt.Sprintf("new influxdb reporter err: %v", err))
-				return
+			} else {
+				node.ifxReporter = rp
+				log.Info("start influxdb export")
+				node.ifxReporter.Start()
+			}
+		}
+
+		if metricsCfg.IsPrometheusEnable {
+			if err := node.startPrometheus(metricsCfg.PrometheusEndpoint); err != nil {
+				return err
 			}
-			node.ifxReporter = rp
-			log.Info("start influxdb export")
-			node.ifxReporter.Start()
 		}
 	}
+	return nil
 }
 
 func (node *Node) stopMetrics() {
+	if node.metricsStop != nil {
+		close(node.metricsStop)
+		node.metricsStop = nil
+	}
+	node.stopMonitorServers()
 	if node.ifxReporter != nil {
 		log.Info("stop influxdb export")
 		node.ifxReporter.Stop()
@@ -323,6 +358,17 @@ func (node *Node) startRPC() error {
 		filters.Es.Start()
 	}
 
+	// the HTTP and WebSocket endpoints share the rate limits of the callers,
+	// all the registered modules are served without a guard if RPCAuth isn't configured
+	var guard *rpc.Guard
+	if node.config.RPCAuth != nil {
+		var err error
+		if guard, err = rpc.NewGuard(node.config.RPCAuth); err != nil {
+			node.stopInProcess()
+			return err
+		}
+	}
+
 	// Start rpc
 	if node.config.IPCEnabled {
 		if err := node.startIPC(node.GetIpcApis()); err != nil {
@@ -336,7 +382,7 @@ func (node *Node) startRPC() error {
 		if len(node.config.PublicModules) != 0 {
 			apis = rpcapi.GetApis(node.viteServer, node.config.PublicModules...)
 		}
-		if err := node.startHTTP(node.httpEndpoint, apis, nil, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, node.config.HttpExposeAll); err != nil {
+		if err := node.startHTTP(node.httpEndpoint, apis, nil, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, node.config.HttpExposeAll, guard); err != nil {
 			node.stopInProcess()
 			node.stopIPC()
 			return err
@@ -348,7 +394,7 @@ func (node *Node) startRPC() error {
 		if len(node.config.PublicModules) != 0 {
 			apis = rpcapi.GetApis(node.viteServer, node.config.PublicModules...)
 		}
-		if err := node.startWS(node.wsEndpoint, apis, nil, node.config.WSOrigins, node.config.WSExposeAll); err != nil {
+		if err := node.startWS(node.wsEndpoint, apis, nil, node.config.WSOrigins, node.config.WSExposeAll, guard); err != nil {
 			node.stopInProcess()
 			node.stopIPC()
 			node.stopHTTP()
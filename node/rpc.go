@@ -10,29 +10,43 @@ import (
 
 //In-proc apis
 func (node *Node) GetInProcessApis() []rpc.API {
-	return rpcapi.GetApis(node.viteServer, "ledger", "wallet", "private_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx")
+	apiModules := []string{"ledger", "wallet", "private_onroad", "net", "node", "contract", "pledge", "quota", "register", "vote", "mintage", "consensusGroup", "consensus", "pool", "testapi", "pow", "tx"}
+	if node.Config().Dev {
+		apiModules = append(apiModules, "dev")
+	}
+	return rpcapi.GetApis(node.viteServer, apiModules...)
 }
 
 //Ipc apis
 func (node *Node) GetIpcApis() []rpc.API {
-	return rpcapi.GetApis(node.viteServer, "ledger", "wallet", "private_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx")
+	apiModules := []string{"ledger", "wallet", "private_onroad", "net", "node", "contract", "pledge", "quota", "register", "vote", "mintage", "consensusGroup", "consensus", "pool", "testapi", "pow", "tx"}
+	if node.Config().Dev {
+		apiModules = append(apiModules, "dev")
+	}
+	return rpcapi.GetApis(node.viteServer, apiModules...)
 }
 
 //Http apis
 func (node *Node) GetHttpApis() []rpc.API {
-	apiModules := []string{"ledger", "public_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "pow", "tx"}
+	apiModules := []string{"ledger", "public_onroad", "net", "node", "contract", "pledge", "quota", "register", "vote", "mintage", "consensusGroup", "consensus", "pool", "pow", "tx"}
 	if node.Config().NetID > 1 {
 		apiModules = append(apiModules, "testapi")
 	}
+	if node.Config().Dev {
+		apiModules = append(apiModules, "dev")
+	}
 	return rpcapi.GetApis(node.viteServer, apiModules...)
 }
 
 //WS apis
 func (node *Node) GetWSApis() []rpc.API {
-	apiModules := []string{"ledger", "public_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "pow", "tx"}
+	apiModules := []string{"ledger", "public_onroad", "net", "node", "contract", "pledge", "quota", "register", "vote", "mintage", "consensusGroup", "consensus", "pool", "pow", "tx"}
 	if node.Config().NetID > 1 {
 		apiModules = append(apiModules, "testapi")
 	}
+	if node.Config().Dev {
+		apiModules = append(apiModules, "dev")
+	}
 	return rpcapi.GetApis(node.viteServer, apiModules...)
 }
 
@@ -66,12 +80,12 @@ func (node *Node) stopIPC() {
 }
 
 // startHTTP initializes and starts the HTTP RPC endpoint.
-func (node *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, exposeAll bool) error {
+func (node *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, exposeAll bool, guard *rpc.Guard) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, timeouts, exposeAll)
+	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, timeouts, exposeAll, guard)
 	if err != nil {
 		return err
 	}
@@ -99,12 +113,12 @@ func (node *Node) stopHTTP() {
 }
 
 // startWS initializes and starts the websocket RPC endpoint.
-func (node *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool) error {
+func (node *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool, guard *rpc.Guard) error {
 	// Short circuit if the WS endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, guard)
 	if err != nil {
 		return err
 	}
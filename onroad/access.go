@@ -53,3 +53,20 @@ func (manager *Manager) deleteDirect(sendBlock *ledger.AccountBlock) {
 func (manager *Manager) insertBlockToPool(block *vm_db.VmAccountBlock) error {
 	return manager.pool.AddDirectAccountBlock(block.AccountBlock.AccountAddress, block)
 }
+
+// Backlogs returns the number of OnRoad blocks waiting to be received by the contracts of each gid.
+func (manager *Manager) Backlogs() map[types.Gid]uint64 {
+	result := make(map[types.Gid]uint64)
+	manager.onRoadPools.Range(func(key, value interface{}) bool {
+		orPool := value.(onroad_pool.OnRoadPool)
+		var backlog uint64
+		for _, addr := range orPool.GetOnRoadContracts() {
+			if num, err := orPool.GetOnRoadTotalNumByAddr(addr); err == nil {
+				backlog += num
+			}
+		}
+		result[key.(types.Gid)] = backlog
+		return true
+	})
+	return result
+}
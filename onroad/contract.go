@@ -3,10 +3,10 @@ package onroad
 import (
 	"container/heap"
 	"fmt"
-	"github.com/vitelabs/go-vite/common/fork"
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common"
@@ -50,6 +50,9 @@ type ContractWorker struct {
 
 	selectivePendingCache *sync.Map //map[types.Address]*callerPendingMap
 
+	policy SchedulePolicy
+	stats  *scheduleStats
+
 	log log15.Logger
 }
 
@@ -66,6 +69,9 @@ func NewContractWorker(manager *Manager) *ContractWorker {
 		workingAddrList:       make(map[types.Address]bool),
 		selectivePendingCache: &sync.Map{},
 
+		policy: quotaSchedulePolicy{},
+		stats:  newScheduleStats(),
+
 		log: slog.New("worker", "contract"),
 	}
 	processors := make([]*ContractTaskProcessor, ContractTaskProcessorSize)
@@ -90,6 +96,7 @@ func (w *ContractWorker) Start(accEvent producerevent.AccountStartEvent) {
 	defer w.statusMutex.Unlock()
 	if w.status != start {
 		w.isCancel.Store(false)
+		w.policy = w.manager.schedulePolicy(w.gid)
 
 		// 1. get gid`s all contract address if error happened return immediately
 		addressList, err := w.manager.Chain().GetContractList(w.gid)
@@ -114,11 +121,7 @@ func (w *ContractWorker) Start(accEvent producerevent.AccountStartEvent) {
 			if w.isContractInBlackList(address) {
 				return
 			}
-			q := w.GetStakeQuota(address)
-			c := &contractTask{
-				Addr:  address,
-				Quota: q,
-			}
+			c := w.newContractTask(address, w.GetStakeQuota(address))
 
 			if !w.isCancel.Load() {
 				w.pushContractTask(c)
@@ -129,36 +132,38 @@ func (w *ContractWorker) Start(accEvent producerevent.AccountStartEvent) {
 
 		log.Info("addSnapshotEventLis", "gid", w.gid, "event", "snapshotEvent")
 		w.manager.addSnapshotEventLis(w.gid, func(latestHeight uint64) {
+			w.policy.NewSnapshot(latestHeight)
 			pendingTask := make([]*contractTask, len(w.contractAddressList))
 			count := 0
 			for _, addr := range w.contractAddressList {
 				if pushContractTask, callerCount := w.releaseContract(addr); pushContractTask {
 					signalLog.Info(fmt.Sprintf("release contract %v RETRY callers len %v", addr, callerCount), "snapshot", latestHeight, "event", "snapshotEvent")
 
-					q := w.GetStakeQuota(addr)
-					pendingTask[count] = &contractTask{Addr: addr, Quota: q}
+					pendingTask[count] = w.newContractTask(addr, w.GetStakeQuota(addr))
 					count++
 				}
 			}
 			sortedTask := pendingTask[0:count]
 			sort.Slice(sortedTask, func(i, j int) bool {
-				if sortedTask[i].Quota > sortedTask[j].Quota {
-					return true
-				}
-				return false
+				return sortedTask[i].Priority > sortedTask[j].Priority
 			})
 			for _, task := range sortedTask {
 				if w.isCancel.Load() {
 					break
 				}
-				signalLog.Info(fmt.Sprintf("push contract %v %v", task.Addr, task.Quota), "snapshot", latestHeight, "event", "snapshotEvent")
+				signalLog.Info(fmt.Sprintf("push contract %v %v %v", task.Addr, task.Quota, task.Priority), "snapshot", latestHeight, "event", "snapshotEvent")
 
 				w.pushContractTask(task)
 				w.wakeupOneTp()
 			}
 		})
 
-		log.Info("start all tp")
+		w.contractTaskProcessors = w.contractTaskProcessors[:ContractTaskProcessorSize]
+		for i := 0; i < w.policy.ReservedProcessors(); i++ {
+			w.contractTaskProcessors = append(w.contractTaskProcessors, NewReservedContractTaskProcessor(w, ContractTaskProcessorSize+i))
+		}
+
+		log.Info("start all tp", "reserved", w.policy.ReservedProcessors())
 		for _, v := range w.contractTaskProcessors {
 			common.Go(v.work)
 		}
@@ -195,6 +200,7 @@ func (w *ContractWorker) Stop() {
 		w.clearContractBlackList()
 		w.clearWorkingAddrList()
 		w.clearSelectiveBlocksCache()
+		w.stats.clearSeen()
 
 		w.status = stop
 	}
@@ -220,11 +226,8 @@ func (w *ContractWorker) getAndSortAllAddrQuota() {
 	w.contractTaskPQueue = make([]*contractTask, len(quotas))
 	i := 0
 	for addr, quota := range quotas {
-		task := &contractTask{
-			Addr:  addr,
-			Index: i,
-			Quota: quota,
-		}
+		task := w.newContractTask(addr, quota)
+		task.Index = i
 		w.contractTaskPQueue[i] = task
 		i++
 	}
@@ -241,12 +244,21 @@ func (w *ContractWorker) wakeupAllTps() {
 	w.newBlockCond.Broadcast()
 }
 
+func (w *ContractWorker) newContractTask(addr types.Address, quota uint64) *contractTask {
+	return &contractTask{
+		Addr:     addr,
+		Quota:    quota,
+		Priority: w.policy.Priority(addr, quota),
+	}
+}
+
 func (w *ContractWorker) pushContractTask(t *contractTask) {
 	w.ctpMutex.Lock()
 	defer w.ctpMutex.Unlock()
 	for _, v := range w.contractTaskPQueue {
 		if v.Addr == t.Addr {
 			v.Quota = t.Quota
+			v.Priority = t.Priority
 			heap.Fix(&w.contractTaskPQueue, v.Index)
 			return
 		}
@@ -263,6 +275,26 @@ func (w *ContractWorker) popContractTask() *contractTask {
 	return nil
 }
 
+// popReservedContractTask pops the task with the highest priority among the reserved contracts.
+func (w *ContractWorker) popReservedContractTask() *contractTask {
+	w.ctpMutex.Lock()
+	defer w.ctpMutex.Unlock()
+	var target *contractTask
+	for _, v := range w.contractTaskPQueue {
+		if !w.policy.IsReserved(v.Addr) {
+			continue
+		}
+		if target == nil || v.Priority > target.Priority ||
+			(v.Priority == target.Priority && v.Quota > target.Quota) {
+			target = v
+		}
+	}
+	if target == nil {
+		return nil
+	}
+	return heap.Remove(&w.contractTaskPQueue, target.Index).(*contractTask)
+}
+
 func (w *ContractWorker) clearWorkingAddrList() {
 	w.workingAddrListMutex.Lock()
 	defer w.workingAddrListMutex.Unlock()
@@ -304,6 +336,7 @@ func (w *ContractWorker) restrictContract(addr types.Address, state inferiorStat
 	w.blackListMutex.Unlock()
 	if state == OUT {
 		w.selectivePendingCache.Delete(addr)
+		w.stats.drop(addr)
 	}
 }
 
@@ -347,8 +380,10 @@ func (w *ContractWorker) acquireOnRoadBlocks(contractAddr types.Address) *ledger
 		if len(blocks) <= 0 {
 			return nil
 		}
+		now := time.Now()
 		for _, v := range blocks {
 			if isExist := p.addPendingMap(v); !isExist {
+				w.stats.seen(contractAddr, v.Hash, now)
 				addNewCount++
 			}
 		}
@@ -364,14 +399,17 @@ func (w *ContractWorker) acquireOnRoadBlocks(contractAddr types.Address) *ledger
 			}
 			revertHappened = true
 			p.clearPendingMap()
+			w.stats.drop(contractAddr)
 		}
 
 		blocks, _ := w.manager.GetAllCallersFrontOnRoad(w.gid, contractAddr)
+		now := time.Now()
 		for _, v := range blocks {
 			if p.existInInferiorList(v.AccountAddress) {
 				continue
 			}
 			if isExist := p.addPendingMap(v); !isExist {
+				w.stats.seen(contractAddr, v.Hash, now)
 				addNewCount++
 			}
 		}
@@ -470,7 +508,7 @@ func (w *ContractWorker) verifyConfirmedTimes(contractAddr *types.Address, fromH
 		return errors.New("sendBlock confirmedTimes is not ready")
 	}
 
-	if fork.IsSeedFork(sbHeight) && meta.SeedConfirmedTimes > 0 {
+	if w.manager.Chain().ForkSchedule().IsSeedFork(sbHeight) && meta.SeedConfirmedTimes > 0 {
 		isSeedCountOk, err := w.manager.Chain().IsSeedConfirmedNTimes(*fromHash, uint64(meta.SeedConfirmedTimes))
 		if err != nil {
 			return err
@@ -34,12 +34,14 @@ type Manager struct {
 	chain     chain.Chain
 	consensus generator.Consensus
 
-	contractWorkers     map[types.Gid]*ContractWorker
+	contractWorkers     sync.Map //map[types.Gid]*ContractWorker
 	newContractListener sync.Map //map[types.Gid]contractReactFunc
 	newSnapshotListener sync.Map //map[types.Gid]snapshotEventReactFunc
 
 	onRoadPools sync.Map //map[types.Gid]contract_pool.OnRoadPool
 
+	schedulePolicies sync.Map //map[types.Gid]SchedulePolicy
+
 	unlockLid   int
 	netStateLid int
 
@@ -51,13 +53,12 @@ type Manager struct {
 // NewManager creates a onroad Manager.
 func NewManager(net netReader, pool pool, producer producer, consensus generator.Consensus, wallet *wallet.Manager) *Manager {
 	m := &Manager{
-		net:             net,
-		producer:        producer,
-		wallet:          wallet,
-		pool:            pool,
-		consensus:       consensus,
-		contractWorkers: make(map[types.Gid]*ContractWorker),
-		log:             slog.New("w", "manager"),
+		net:       net,
+		producer:  producer,
+		wallet:    wallet,
+		pool:      pool,
+		consensus: consensus,
+		log:       slog.New("w", "manager"),
 	}
 	return m
 }
@@ -108,6 +109,27 @@ func (manager *Manager) prepareOnRoadPool(gid types.Gid) {
 	}
 }
 
+func (manager *Manager) getOnRoadPool(gid types.Gid) (onroad_pool.OnRoadPool, error) {
+	orPool, ok := manager.onRoadPools.Load(gid)
+	if !ok || orPool == nil {
+		return nil, onroad_pool.ErrOnRoadPoolNotAvailable
+	}
+	return orPool.(onroad_pool.OnRoadPool), nil
+}
+
+// SetSchedulePolicy sets the policy used by the contract worker of the gid,
+// it takes effect the next time the worker starts.
+func (manager *Manager) SetSchedulePolicy(gid types.Gid, policy SchedulePolicy) {
+	manager.schedulePolicies.Store(gid, policy)
+}
+
+func (manager *Manager) schedulePolicy(gid types.Gid) SchedulePolicy {
+	if p, ok := manager.schedulePolicies.Load(gid); ok {
+		return p.(SchedulePolicy)
+	}
+	return quotaSchedulePolicy{}
+}
+
 func (manager *Manager) netStateChangedFunc(state net.SyncState) {
 	manager.log.Info("receive chain net event", "state_bak", state)
 	common.Go(func() {
@@ -140,10 +162,12 @@ func (manager *Manager) producerStartEventFunc(accevent producerevent.AccountEve
 
 	manager.lastProducerAccEvent = &event
 
-	w, found := manager.contractWorkers[event.Gid]
-	if !found {
+	var w *ContractWorker
+	if value, found := manager.contractWorkers.Load(event.Gid); found {
+		w = value.(*ContractWorker)
+	} else {
 		w = NewContractWorker(manager)
-		manager.contractWorkers[event.Gid] = w
+		manager.contractWorkers.Store(event.Gid, w)
 	}
 
 	nowTime := time.Now()
@@ -160,13 +184,15 @@ func (manager *Manager) producerStartEventFunc(accevent producerevent.AccountEve
 func (manager *Manager) stopAllWorks() {
 	manager.log.Info("stopAllWorks called")
 	var wg = sync.WaitGroup{}
-	for _, v := range manager.contractWorkers {
+	manager.contractWorkers.Range(func(_, value interface{}) bool {
+		v := value.(*ContractWorker)
 		wg.Add(1)
 		common.Go(func() {
 			v.Stop()
 			wg.Done()
 		})
-	}
+		return true
+	})
 	wg.Wait()
 	manager.log.Info("stopAllWorks end")
 }
@@ -176,8 +202,8 @@ func (manager *Manager) resumeContractWorks() {
 	if manager.lastProducerAccEvent != nil {
 		nowTime := time.Now()
 		if nowTime.After(manager.lastProducerAccEvent.Stime) && nowTime.Before(manager.lastProducerAccEvent.Etime) {
-			cw, ok := manager.contractWorkers[manager.lastProducerAccEvent.Gid]
-			if ok {
+			if value, ok := manager.contractWorkers.Load(manager.lastProducerAccEvent.Gid); ok {
+				cw := value.(*ContractWorker)
 				manager.log.Info("resumeContractWorks found an cw need to resume", "gid", manager.lastProducerAccEvent.Gid)
 				cw.Start(*manager.lastProducerAccEvent)
 				time.AfterFunc(manager.lastProducerAccEvent.Etime.Sub(nowTime), func() {
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common"
-	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/wallet"
@@ -36,16 +35,16 @@ func NewChainInstance(dirName string, clear bool) (chain.Chain, error) {
 	if clear {
 		os.RemoveAll(dataDir)
 	}
-	if len(fork.GetActiveForkPointList()) <= 0 {
-		fork.SetForkPoints(&config.ForkPoints{
+	genesisConfig := &config.Genesis{}
+	json.Unmarshal([]byte(genesisConfigJSON), genesisConfig)
+	if genesisConfig.ForkPoints == nil {
+		genesisConfig.ForkPoints = &config.ForkPoints{
 			SeedFork: &config.ForkPoint{
 				Version: 1,
 				Height:  10000000,
 			},
-		})
+		}
 	}
-	genesisConfig := &config.Genesis{}
-	json.Unmarshal([]byte(genesisConfigJSON), genesisConfig)
 	chainInstance := chain.NewChain(dataDir, &config.Chain{}, genesisConfig)
 	if err := chainInstance.Init(); err != nil {
 		return nil, err
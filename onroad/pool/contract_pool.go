@@ -106,6 +106,17 @@ func (p *contractOnRoadPool) GetOnRoadTotalNumByAddr(contract types.Address) (ui
 	return uint64(cc.(*callerCache).len()), nil
 }
 
+func (p *contractOnRoadPool) GetOnRoadContracts() []types.Address {
+	var result []types.Address
+	p.cache.Range(func(key, value interface{}) bool {
+		if value.(*callerCache).len() > 0 {
+			result = append(result, key.(types.Address))
+		}
+		return true
+	})
+	return result
+}
+
 func (p *contractOnRoadPool) InsertAccountBlocks(orAddr types.Address, blocks []*ledger.AccountBlock) error {
 	mlog := p.log.New("method", "InsertAccountBlocks", "orAddr", orAddr, "len", len(blocks))
 	isWrite := true
@@ -10,6 +10,7 @@ type OnRoadPool interface {
 	DeleteAccountBlocks(orAddr types.Address, blocks []*ledger.AccountBlock) error
 
 	GetOnRoadTotalNumByAddr(addr types.Address) (uint64, error)
+	GetOnRoadContracts() []types.Address
 	GetFrontOnRoadBlocksByAddr(addr types.Address) ([]*ledger.AccountBlock, error)
 
 	IsFrontOnRoadOfCaller(orAddr, caller types.Address, hash types.Hash) (bool, error)
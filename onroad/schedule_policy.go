@@ -0,0 +1,130 @@
+package onroad
+
+import (
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/math"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+)
+
+// SchedulePolicy decides the order in which a ContractWorker serves contracts
+// and how many receives a contract or a caller can get in one snapshot block.
+type SchedulePolicy interface {
+	// Priority returns the priority of the contract task, the higher one is served first.
+	Priority(contract types.Address, quota uint64) uint64
+	// AdmitContract reports whether the contract can receive more in the current snapshot block.
+	AdmitContract(contract types.Address) bool
+	// AdmitCaller reports whether the contract can receive more from the caller in the current snapshot block.
+	AdmitCaller(contract, caller types.Address) bool
+	// Received records a receive block generated by the contract for the caller.
+	Received(contract, caller types.Address)
+	// NewSnapshot resets the counters of the last snapshot block.
+	NewSnapshot(height uint64)
+	// IsReserved reports whether the contract can be served by the reserved task processors.
+	IsReserved(contract types.Address) bool
+	// ReservedProcessors returns the number of task processors which only serve the reserved contracts.
+	ReservedProcessors() int
+}
+
+// quotaSchedulePolicy orders contracts by stake quota only, it is used when no policy is configured for the gid.
+type quotaSchedulePolicy struct{}
+
+func (quotaSchedulePolicy) Priority(contract types.Address, quota uint64) uint64 { return quota }
+func (quotaSchedulePolicy) AdmitContract(contract types.Address) bool            { return true }
+func (quotaSchedulePolicy) AdmitCaller(contract, caller types.Address) bool      { return true }
+func (quotaSchedulePolicy) Received(contract, caller types.Address)              {}
+func (quotaSchedulePolicy) NewSnapshot(height uint64)                            {}
+func (quotaSchedulePolicy) IsReserved(contract types.Address) bool               { return false }
+func (quotaSchedulePolicy) ReservedProcessors() int                              { return 0 }
+
+type contractCaller struct {
+	contract types.Address
+	caller   types.Address
+}
+
+// ConfigSchedulePolicy applies contract weights, caller caps, per snapshot limits and reserved processors
+// from config.OnRoadPolicy.
+type ConfigSchedulePolicy struct {
+	cfg      config.OnRoadPolicy
+	reserved map[types.Address]bool
+
+	contractReceives map[types.Address]uint64
+	callerReceives   map[contractCaller]uint64
+	mu               sync.Mutex
+}
+
+// NewConfigSchedulePolicy creates a ConfigSchedulePolicy.
+func NewConfigSchedulePolicy(cfg config.OnRoadPolicy) *ConfigSchedulePolicy {
+	p := &ConfigSchedulePolicy{
+		cfg:              cfg,
+		reserved:         make(map[types.Address]bool, len(cfg.ReservedContracts)),
+		contractReceives: make(map[types.Address]uint64),
+		callerReceives:   make(map[contractCaller]uint64),
+	}
+	for _, addr := range cfg.ReservedContracts {
+		p.reserved[addr] = true
+	}
+	if p.cfg.ReservedProcessors < 0 {
+		p.cfg.ReservedProcessors = 0
+	}
+	return p
+}
+
+// Weight returns the weight of the contract, the default weight is 1.
+func (p *ConfigSchedulePolicy) Weight(contract types.Address) uint64 {
+	if w, ok := p.cfg.ContractWeights[contract]; ok {
+		return w
+	}
+	return 1
+}
+
+func (p *ConfigSchedulePolicy) Priority(contract types.Address, quota uint64) uint64 {
+	w := p.Weight(contract)
+	if w != 0 && quota > math.MaxUint64/w {
+		return math.MaxUint64
+	}
+	return quota * w
+}
+
+func (p *ConfigSchedulePolicy) AdmitContract(contract types.Address) bool {
+	if p.cfg.MaxReceivesPerSnapshot == 0 {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.contractReceives[contract] < p.cfg.MaxReceivesPerSnapshot
+}
+
+func (p *ConfigSchedulePolicy) AdmitCaller(contract, caller types.Address) bool {
+	if p.cfg.CallerCap == 0 {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.callerReceives[contractCaller{contract, caller}] < p.cfg.CallerCap
+}
+
+func (p *ConfigSchedulePolicy) Received(contract, caller types.Address) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.contractReceives[contract]++
+	if p.cfg.CallerCap > 0 {
+		p.callerReceives[contractCaller{contract, caller}]++
+	}
+}
+
+func (p *ConfigSchedulePolicy) NewSnapshot(height uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.contractReceives = make(map[types.Address]uint64)
+	p.callerReceives = make(map[contractCaller]uint64)
+}
+
+func (p *ConfigSchedulePolicy) IsReserved(contract types.Address) bool {
+	return p.reserved[contract]
+}
+
+func (p *ConfigSchedulePolicy) ReservedProcessors() int {
+	return p.cfg.ReservedProcessors
+}
@@ -0,0 +1,131 @@
+package onroad
+
+import (
+	"container/heap"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/math"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+)
+
+func TestConfigSchedulePolicy(t *testing.T) {
+	noisy, _ := types.HexToAddress(addrStr[0])
+	quiet, _ := types.HexToAddress(addrStr[1])
+	reserved, _ := types.HexToAddress(addrStr[2])
+	caller, _ := types.HexToAddress(addrStrPush[0])
+	caller2, _ := types.HexToAddress(addrStrPush[1])
+
+	p := NewConfigSchedulePolicy(config.OnRoadPolicy{
+		ContractWeights:        map[types.Address]uint64{noisy: 0, quiet: 3},
+		CallerCap:              1,
+		MaxReceivesPerSnapshot: 2,
+		ReservedContracts:      []types.Address{reserved},
+		ReservedProcessors:     1,
+	})
+
+	if p.Priority(noisy, 100) != 0 || p.Priority(quiet, 100) != 300 || p.Priority(reserved, 100) != 100 {
+		t.Fatal("unexpected priority")
+	}
+	if p.Priority(quiet, math.MaxUint64/2) != math.MaxUint64 {
+		t.Fatal("priority should saturate")
+	}
+	if !p.IsReserved(reserved) || p.IsReserved(quiet) || p.ReservedProcessors() != 1 {
+		t.Fatal("unexpected reservation")
+	}
+
+	p.Received(quiet, caller)
+	if p.AdmitCaller(quiet, caller) {
+		t.Fatal("caller should reach the cap")
+	}
+	if !p.AdmitCaller(quiet, caller2) || !p.AdmitContract(quiet) {
+		t.Fatal("other callers should be admitted")
+	}
+	p.Received(quiet, caller2)
+	if p.AdmitContract(quiet) {
+		t.Fatal("contract should reach the max receives per snapshot")
+	}
+	if !p.AdmitContract(noisy) {
+		t.Fatal("limits are per contract")
+	}
+
+	p.NewSnapshot(2)
+	if !p.AdmitContract(quiet) || !p.AdmitCaller(quiet, caller) {
+		t.Fatal("counters should be reset by new snapshot")
+	}
+}
+
+func TestContractTaskPQueue_Priority(t *testing.T) {
+	addr := make([]types.Address, len(addrStr))
+	for i, value := range addrStr {
+		addr[i], _ = types.HexToAddress(value)
+	}
+	w := &ContractWorker{
+		policy: NewConfigSchedulePolicy(config.OnRoadPolicy{
+			ContractWeights:   map[types.Address]uint64{addr[4]: 0, addr[0]: 10},
+			ReservedContracts: []types.Address{addr[1], addr[2]},
+		}),
+	}
+	for i := range addr {
+		w.pushContractTask(w.newContractTask(addr[i], q[i]))
+	}
+
+	// addr[2] has the highest priority among the reserved contracts
+	if task := w.popReservedContractTask(); task == nil || task.Addr != addr[2] {
+		t.Fatal("unexpected reserved task", task)
+	}
+	// priorities: addr[0]=10, addr[3]=4, addr[1]=2, addr[4]=0
+	expected := []types.Address{addr[0], addr[3], addr[1], addr[4]}
+	for _, e := range expected {
+		task := heap.Pop(&w.contractTaskPQueue).(*contractTask)
+		if task.Addr != e {
+			t.Fatalf("expected %v, got %v with priority %v", e, task.Addr, task.Priority)
+		}
+	}
+	if w.popReservedContractTask() != nil {
+		t.Fatal("queue should be empty")
+	}
+}
+
+func TestScheduleStats(t *testing.T) {
+	contract, _ := types.HexToAddress(addrStr[0])
+	s := newScheduleStats()
+	now := time.Now()
+	s.seen(contract, types.DataHash([]byte{1}), now)
+	s.seen(contract, types.DataHash([]byte{1}), now.Add(time.Second))
+	s.seen(contract, types.DataHash([]byte{2}), now)
+	s.received(contract, types.DataHash([]byte{1}), now.Add(3*time.Second))
+	s.received(contract, types.DataHash([]byte{2}), now.Add(time.Second))
+	s.throttled(contract)
+
+	info := &ContractScheduleInfo{Address: contract}
+	s.fill(info)
+	if info.Received != 2 || info.Throttled != 1 {
+		t.Fatal("unexpected counters", info.Received, info.Throttled)
+	}
+	if info.MaxLatency != 3*time.Second || info.AvgLatency != 2*time.Second {
+		t.Fatal("unexpected latency", info.MaxLatency, info.AvgLatency)
+	}
+	if len(s.firstSeen) != 0 {
+		t.Fatal("received send blocks should be forgotten")
+	}
+
+	// a send block not seen has no latency and doesn't lower the average
+	s.received(contract, types.DataHash([]byte{5}), now.Add(4*time.Second))
+	s.fill(info)
+	if info.Received != 3 || info.AvgLatency != 2*time.Second {
+		t.Fatal("unexpected average latency", info.Received, info.AvgLatency)
+	}
+
+	s.seen(contract, types.DataHash([]byte{3}), now)
+	s.seen(contract, types.DataHash([]byte{4}), now)
+	s.forget(contract, types.DataHash([]byte{3}))
+	if len(s.firstSeen[contract]) != 1 {
+		t.Fatal("forgotten send block should be evicted")
+	}
+	s.drop(contract)
+	if len(s.firstSeen) != 0 {
+		t.Fatal("send blocks of a dropped contract should be evicted")
+	}
+}
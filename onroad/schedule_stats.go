@@ -0,0 +1,203 @@
+package onroad
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// ContractScheduleInfo is the backlog and the receive latency of a contract.
+type ContractScheduleInfo struct {
+	Address  types.Address
+	Backlog  uint64 // number of send blocks waiting to be received
+	Reserved bool   // whether the contract can be served by the reserved task processors
+
+	Received        uint64 // receive blocks generated since the node started
+	Throttled       uint64 // times the contract or its callers hit the limits of the schedule policy
+	AvgLatency      time.Duration
+	MaxLatency      time.Duration
+	LastReceiveTime *time.Time
+}
+
+type contractStats struct {
+	received     uint64
+	throttled    uint64
+	measured     uint64 // receives whose send blocks were seen, the latency is measured for them only
+	totalLatency time.Duration
+	maxLatency   time.Duration
+	lastReceive  time.Time
+}
+
+// scheduleStats measures the latency between a send block being seen by the worker and being received.
+// The send blocks are tracked per contract until they are received or the contract is dropped by the worker.
+type scheduleStats struct {
+	firstSeen map[types.Address]map[types.Hash]time.Time
+	contracts map[types.Address]*contractStats
+	mu        sync.Mutex
+}
+
+func newScheduleStats() *scheduleStats {
+	return &scheduleStats{
+		firstSeen: make(map[types.Address]map[types.Hash]time.Time),
+		contracts: make(map[types.Address]*contractStats),
+	}
+}
+
+func (s *scheduleStats) getOrCreate(contract types.Address) *contractStats {
+	cs, ok := s.contracts[contract]
+	if !ok {
+		cs = &contractStats{}
+		s.contracts[contract] = cs
+	}
+	return cs
+}
+
+func (s *scheduleStats) seen(contract types.Address, sendHash types.Hash, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen, ok := s.firstSeen[contract]
+	if !ok {
+		seen = make(map[types.Hash]time.Time)
+		s.firstSeen[contract] = seen
+	}
+	if _, ok := seen[sendHash]; !ok {
+		seen[sendHash] = t
+	}
+}
+
+func (s *scheduleStats) received(contract types.Address, sendHash types.Hash, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs := s.getOrCreate(contract)
+	cs.received++
+	cs.lastReceive = t
+	if since, ok := s.firstSeen[contract][sendHash]; ok {
+		latency := t.Sub(since)
+		cs.measured++
+		cs.totalLatency += latency
+		if latency > cs.maxLatency {
+			cs.maxLatency = latency
+		}
+		s.deleteSeen(contract, sendHash)
+	}
+}
+
+func (s *scheduleStats) throttled(contract types.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.getOrCreate(contract).throttled++
+}
+
+func (s *scheduleStats) forget(contract types.Address, sendHash types.Hash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteSeen(contract, sendHash)
+}
+
+// drop forgets the send blocks of a contract whose pending blocks are dropped by the worker.
+func (s *scheduleStats) drop(contract types.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.firstSeen, contract)
+}
+
+func (s *scheduleStats) deleteSeen(contract types.Address, sendHash types.Hash) {
+	seen, ok := s.firstSeen[contract]
+	if !ok {
+		return
+	}
+	delete(seen, sendHash)
+	if len(seen) == 0 {
+		delete(s.firstSeen, contract)
+	}
+}
+
+// clearSeen is called when the worker stops, the send blocks will be seen again in the next round.
+func (s *scheduleStats) clearSeen() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.firstSeen = make(map[types.Address]map[types.Hash]time.Time)
+}
+
+func (s *scheduleStats) fill(info *ContractScheduleInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, ok := s.contracts[info.Address]
+	if !ok {
+		return
+	}
+	info.Received = cs.received
+	info.Throttled = cs.throttled
+	info.MaxLatency = cs.maxLatency
+	if cs.measured > 0 {
+		info.AvgLatency = cs.totalLatency / time.Duration(cs.measured)
+	}
+	if cs.received > 0 {
+		last := cs.lastReceive
+		info.LastReceiveTime = &last
+	}
+}
+
+func (s *scheduleStats) contractList() []types.Address {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]types.Address, 0, len(s.contracts))
+	for addr := range s.contracts {
+		result = append(result, addr)
+	}
+	return result
+}
+
+// ScheduleInfo returns the backlog and the receive stats of the contracts in the gid,
+// the contracts with more backlog come first.
+func (manager *Manager) ScheduleInfo(gid types.Gid) ([]*ContractScheduleInfo, error) {
+	orPool, err := manager.getOnRoadPool(gid)
+	if err != nil {
+		return nil, err
+	}
+	contracts := orPool.GetOnRoadContracts()
+
+	var w *ContractWorker
+	value, hasWorker := manager.contractWorkers.Load(gid)
+	if hasWorker {
+		w = value.(*ContractWorker)
+	}
+	policy := manager.schedulePolicy(gid)
+	if hasWorker {
+		exist := make(map[types.Address]bool, len(contracts))
+		for _, addr := range contracts {
+			exist[addr] = true
+		}
+		for _, addr := range w.stats.contractList() {
+			if !exist[addr] {
+				contracts = append(contracts, addr)
+			}
+		}
+	}
+
+	result := make([]*ContractScheduleInfo, 0, len(contracts))
+	for _, addr := range contracts {
+		backlog, err := orPool.GetOnRoadTotalNumByAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		info := &ContractScheduleInfo{
+			Address:  addr,
+			Backlog:  backlog,
+			Reserved: policy.IsReserved(addr),
+		}
+		if hasWorker {
+			w.stats.fill(info)
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Backlog != result[j].Backlog {
+			return result[i].Backlog > result[j].Backlog
+		}
+		return result[i].Received > result[j].Received
+	})
+	return result, nil
+}
@@ -5,9 +5,10 @@ import (
 )
 
 type contractTask struct {
-	Addr  types.Address
-	Index int
-	Quota uint64
+	Addr     types.Address
+	Index    int
+	Quota    uint64
+	Priority uint64 // given by SchedulePolicy, tasks with the same priority are ordered by quota
 }
 
 type contractTaskPQueue []*contractTask
@@ -30,6 +31,9 @@ func (q *contractTaskPQueue) Pop() interface{} {
 func (q *contractTaskPQueue) Len() int { return len(*q) }
 
 func (q *contractTaskPQueue) Less(i, j int) bool {
+	if (*q)[i].Priority != (*q)[j].Priority {
+		return (*q)[i].Priority > (*q)[j].Priority
+	}
 	if (*q)[i].Quota < (*q)[j].Quota {
 		return false
 	}
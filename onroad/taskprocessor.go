@@ -12,8 +12,9 @@ import (
 
 // ContractTaskProcessor is to handle onroad and generate new contract receive block.
 type ContractTaskProcessor struct {
-	taskID int
-	worker *ContractWorker
+	taskID   int
+	worker   *ContractWorker
+	reserved bool
 
 	log log15.Logger
 }
@@ -28,6 +29,24 @@ func NewContractTaskProcessor(worker *ContractWorker, index int) *ContractTaskPr
 	}
 }
 
+// NewReservedContractTaskProcessor creates a ContractTaskProcessor which only serves the reserved contracts of SchedulePolicy.
+func NewReservedContractTaskProcessor(worker *ContractWorker, index int) *ContractTaskProcessor {
+	return &ContractTaskProcessor{
+		taskID:   index,
+		worker:   worker,
+		reserved: true,
+
+		log: slog.New("tp", index, "reserved", true),
+	}
+}
+
+func (tp *ContractTaskProcessor) popContractTask() *contractTask {
+	if tp.reserved {
+		return tp.worker.popReservedContractTask()
+	}
+	return tp.worker.popContractTask()
+}
+
 func (tp *ContractTaskProcessor) work() {
 	tp.worker.wg.Add(1)
 	defer tp.worker.wg.Done()
@@ -39,17 +58,16 @@ func (tp *ContractTaskProcessor) work() {
 			tp.log.Info("found cancel true")
 			break
 		}
-		task := tp.worker.popContractTask()
+		task := tp.popContractTask()
 		if task != nil {
-			signalLog.Info(fmt.Sprintf("tp=%v wakeup, pop addr %v quota %v", tp.taskID, task.Addr, task.Quota))
+			signalLog.Info(fmt.Sprintf("tp=%v wakeup, pop addr %v quota %v priority %v", tp.taskID, task.Addr, task.Quota, task.Priority))
 			if tp.worker.isContractInBlackList(task.Addr) || !tp.worker.addContractIntoWorkingList(task.Addr) {
 				continue
 			}
 			canContinue := tp.processOneAddress(task)
 			tp.worker.removeContractFromWorkingList(task.Addr)
 			if canContinue {
-				task.Quota = tp.worker.GetStakeQuota(task.Addr)
-				tp.worker.pushContractTask(task)
+				tp.worker.pushContractTask(tp.worker.newContractTask(task.Addr, tp.worker.GetStakeQuota(task.Addr)))
 			}
 			continue
 		}
@@ -66,12 +84,26 @@ func (tp *ContractTaskProcessor) work() {
 func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canContinue bool) {
 	tp.log.Info("process", "contract", &task.Addr)
 
+	policy := tp.worker.policy
+	if !policy.AdmitContract(task.Addr) {
+		tp.worker.stats.throttled(task.Addr)
+		tp.restrictContract(task.Addr, RETRY)
+		return false
+	}
+
 	sBlock := tp.worker.acquireOnRoadBlocks(task.Addr)
 	if sBlock == nil {
 		return false
 	}
 	blog := tp.log.New("s", sBlock.Hash, "caller", sBlock.AccountAddress, "contract", task.Addr)
 
+	if !policy.AdmitCaller(task.Addr, sBlock.AccountAddress) {
+		blog.Info("caller reaches the receive cap of current snapshot")
+		tp.worker.stats.throttled(task.Addr)
+		tp.worker.restrictContractCaller(task.Addr, sBlock.AccountAddress, RETRY)
+		return true
+	}
+
 	// 1. verify whether the send is legal;
 	var completeBlockHash *types.Hash
 	var completeBlockHeight = sBlock.Height
@@ -138,6 +170,8 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 			tp.worker.restrictContractCaller(task.Addr, sBlock.AccountAddress, OUT)
 			return true
 		}
+		policy.Received(task.Addr, sBlock.AccountAddress)
+		tp.worker.stats.received(task.Addr, sBlock.Hash, time.Now())
 
 		if genResult.IsRetry {
 			blog.Info("impossible situation: vmBlock and vmRetry")
@@ -172,6 +206,7 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 			// no vmBlock no vmRetry in condition that fail to create contract
 			blog.Info(fmt.Sprintf("manager.DeleteDirect, contract %v hash %v", task.Addr, sBlock.Hash))
 			tp.worker.manager.deleteDirect(sBlock)
+			tp.worker.stats.forget(task.Addr, sBlock.Hash)
 			tp.restrictContract(task.Addr, OUT)
 			return false
 		}
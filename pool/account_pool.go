@@ -375,48 +375,94 @@ func (accP *accountPool) tryInsertItems(p batch.Batch, items []batch.Item, lates
 	accP.chainTailMu.Lock()
 	defer accP.chainTailMu.Unlock()
 
-	cp := accP.chainpool
-
 	for i := 0; i < len(items); i++ {
 		item := items[i]
 		block := item.(*accountPoolBlock)
 		accP.log.Info(fmt.Sprintf("[%d]try to insert account block[%d-%s]%d-%d.", p.Id(), block.Height(), block.Hash(), i, len(items)))
-		current := cp.tree.Root()
-		tailHeight, tailHash := current.HeadHH()
-		if block.Height() == tailHeight+1 &&
-			block.PrevHash() == tailHash {
-			block.resetForkVersion()
-			if block.forkVersion() != version {
-				return errors.New("snapshot version update")
-			}
-
-			stat := accP.v.verifyAccount(block, latestSb)
-			if !block.checkForkVersion() {
-				block.resetForkVersion()
-				return errors.New("new fork version")
-			}
-			switch stat.verifyResult() {
-			case verifier.FAIL:
-				accP.log.Warn("add account block to blacklist.", "hash", block.Hash(), "height", block.Height(), "err", stat.err)
-				accP.hashBlacklist.AddAddTimeout(block.Hash(), time.Second*10)
-				return errors.Wrap(stat.err, "fail verifier")
-			case verifier.PENDING:
-				accP.log.Error("snapshot db.", "hash", block.Hash(), "height", block.Height())
-				return errors.Wrap(stat.err, "fail verifier db.")
-			}
-			err := cp.writeBlockToChain(stat.block)
-			if err != nil {
-				accP.log.Error("account block write fail. ",
-					"hash", block.Hash(), "height", block.Height(), "error", err)
-				return err
-			}
-		} else {
-			return errors.New("tail not match")
+		verified, err := accP.verifyItem(block, latestSb, version)
+		if err != nil || verified == nil {
+			return err
+		}
+		if err := accP.insertVerifiedItem(block, verified); err != nil {
+			return err
 		}
 		accP.log.Info(fmt.Sprintf("[%d]try to insert account block[%d-%s]%d-%d [latency:%s]success.", p.Id(), block.Height(), block.Hash(), i, len(items), block.Latency()))
 	}
 	return nil
 }
+
+// tryVerifyItem verifies the block against the tail of the account chain, the block is inserted by tryInsertVerifiedItem.
+func (accP *accountPool) tryVerifyItem(p batch.Batch, block *accountPoolBlock, latestSb *ledger.SnapshotBlock, version uint64) (*accountPoolBlock, error) {
+	accP.chainTailMu.Lock()
+	defer accP.chainTailMu.Unlock()
+	accP.log.Info(fmt.Sprintf("[%d]try to verify account block[%d-%s].", p.Id(), block.Height(), block.Hash()))
+	return accP.verifyItem(block, latestSb, version)
+}
+
+func (accP *accountPool) tryInsertVerifiedItem(p batch.Batch, block *accountPoolBlock, verified *accountPoolBlock) error {
+	accP.chainTailMu.Lock()
+	defer accP.chainTailMu.Unlock()
+	if !block.checkForkVersion() {
+		block.resetForkVersion()
+		return errors.New("new fork version")
+	}
+	if err := accP.insertVerifiedItem(block, verified); err != nil {
+		return err
+	}
+	accP.log.Info(fmt.Sprintf("[%d]try to insert account block[%d-%s] [latency:%s]success.", p.Id(), block.Height(), block.Hash(), block.Latency()))
+	return nil
+}
+
+// verifyItem must be called with chainTailMu held, it returns nil without an error if the block is pending.
+func (accP *accountPool) verifyItem(block *accountPoolBlock, latestSb *ledger.SnapshotBlock, version uint64) (*accountPoolBlock, error) {
+	if !accP.tailMatch(block) {
+		return nil, errors.New("tail not match")
+	}
+	block.resetForkVersion()
+	if block.forkVersion() != version {
+		return nil, errors.New("snapshot version update")
+	}
+
+	stat := accP.v.verifyAccount(block, latestSb)
+	if !block.checkForkVersion() {
+		block.resetForkVersion()
+		return nil, errors.New("new fork version")
+	}
+	switch stat.verifyResult() {
+	case verifier.FAIL:
+		accP.log.Warn("add account block to blacklist.", "hash", block.Hash(), "height", block.Height(), "err", stat.err)
+		reason := "verify fail"
+		if stat.err != nil {
+			reason = stat.err.Error()
+		}
+		accP.pool.dropAccountBlock(block.block, time.Second*10, reason)
+		return nil, errors.Wrap(stat.err, "fail verifier")
+	case verifier.PENDING:
+		accP.log.Error("snapshot db.", "hash", block.Hash(), "height", block.Height())
+		return nil, errors.Wrap(stat.err, "fail verifier db.")
+	}
+	return stat.block, nil
+}
+
+// insertVerifiedItem must be called with chainTailMu held.
+func (accP *accountPool) insertVerifiedItem(block *accountPoolBlock, verified *accountPoolBlock) error {
+	if !accP.tailMatch(block) {
+		return errors.New("tail not match")
+	}
+	err := accP.chainpool.writeBlockToChain(verified)
+	if err != nil {
+		accP.log.Error("account block write fail. ",
+			"hash", block.Hash(), "height", block.Height(), "error", err)
+		return err
+	}
+	return nil
+}
+
+func (accP *accountPool) tailMatch(block *accountPoolBlock) bool {
+	tailHeight, tailHash := accP.chainpool.tree.Root().HeadHH()
+	return block.Height() == tailHeight+1 && block.PrevHash() == tailHash
+}
+
 func (accP *accountPool) checkSnapshotSuccess(block *accountPoolBlock) error {
 	if block.block.IsReceiveBlock() {
 		if !types.IsContractAddr(block.block.AccountAddress) {
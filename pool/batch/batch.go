@@ -11,6 +11,8 @@ var (
 	ErrorArrivedToMax = errors.New("arrived to max")
 	// ErrorReference mean that the dependency item(account or snapshot block) does not exist in chain or batch
 	ErrorReference = errors.New("refer not exist")
+	// ErrorStop can be returned by ItemVerifyFn and ItemInsertFn to stop the batch without an error
+	ErrorStop = errors.New("stop")
 )
 
 // Batch is a batch for block insertion.
@@ -31,6 +33,12 @@ type Batch interface {
 	Exists(hash types.Hash) bool
 	// Batch runs the Batch
 	Batch(snapshotFn BucketExecutorFn, accountFn BucketExecutorFn) error
+	// BatchPipeline runs the Batch like Batch, but the account levels between two snapshot levels are merged into
+	// a dag by the references of the items. An item is verified once the items it refers to have been inserted,
+	// at most parallel items are verified concurrently, and the items are inserted in the order they were added.
+	BatchPipeline(snapshotFn BucketExecutorFn, verifyFn ItemVerifyFn, insertFn ItemInsertFn, parallel int) error
+	// Stopped returns whether the BatchPipeline has stopped by an error, the items verified after it are cancelled.
+	Stopped() bool
 	// Id returns the id of the Batch
 	Id() uint64
 }
@@ -39,6 +47,8 @@ type Batch interface {
 type Level interface {
 	// Buckets returns all buckets in the level.
 	Buckets() []Bucket
+	// Items returns all items in the level in the order they were added.
+	Items() []Item
 	// Add will add the item to the level.
 	Add(item Item) error
 	// SHash is snapshot hash for the level, will return nil for snapshot level.
@@ -79,3 +89,9 @@ type Item interface {
 
 // BucketExecutorFn can insert a bucket
 type BucketExecutorFn func(p Batch, l Level, bucket Bucket, version uint64) error
+
+// ItemVerifyFn verifies an account item, the result is passed to the ItemInsertFn of the item.
+type ItemVerifyFn func(p Batch, l Level, item Item, version uint64) (interface{}, error)
+
+// ItemInsertFn inserts a verified account item.
+type ItemInsertFn func(p Batch, l Level, item Item, verified interface{}, version uint64) error
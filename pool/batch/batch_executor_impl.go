@@ -16,8 +16,12 @@ type batchExecutor struct {
 	p           Batch
 	snapshotFn  BucketExecutorFn
 	accountFn   BucketExecutorFn
+	verifyFn    ItemVerifyFn
+	insertFn    ItemInsertFn
 	maxParallel int
 	log         log15.Logger
+	// stop marks the Batch stopped when the pipeline fails
+	stop func()
 }
 
 func newBatchExecutor(p Batch, snapshotFn BucketExecutorFn, accountFn BucketExecutorFn) *batchExecutor {
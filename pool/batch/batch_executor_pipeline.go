@@ -0,0 +1,173 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+var errPipelineStopped = errors.New("pipeline stopped")
+
+func newPipelineExecutor(p Batch, snapshotFn BucketExecutorFn, verifyFn ItemVerifyFn, insertFn ItemInsertFn, parallel int) *batchExecutor {
+	executor := &batchExecutor{p: p, snapshotFn: snapshotFn, verifyFn: verifyFn, insertFn: insertFn}
+	executor.log = log15.New("module", "pool/batch", "batchId", p.Id())
+	if parallel <= 0 {
+		parallel = runtime.NumCPU()
+	}
+	executor.maxParallel = parallel
+	return executor
+}
+
+// dagNode is an account item in the dag, refs is the number of items it refers to which have not been inserted.
+type dagNode struct {
+	level      Level
+	item       Item
+	refs       int
+	dependents []int
+	result     chan *dagResult
+}
+
+type dagResult struct {
+	verified interface{}
+	err      error
+}
+
+func (self *batchExecutor) executePipeline() error {
+	err := self.executeSegments()
+	if err == ErrorStop {
+		return nil
+	}
+	return err
+}
+
+func (self *batchExecutor) executeSegments() error {
+	var segment []Level
+	for i, level := range self.p.Levels() {
+		if level == nil {
+			continue
+		}
+		if !level.Snapshot() {
+			segment = append(segment, level)
+			continue
+		}
+		if err := self.insertAccountSegment(segment); err != nil {
+			return err
+		}
+		segment = nil
+
+		self.log.Info(fmt.Sprintf("insert queue level[%d][%t] insert.", i, level.Snapshot()))
+		if err := self.insertSnapshotLevel(level); err != nil {
+			return err
+		}
+		level.Done()
+	}
+	return self.insertAccountSegment(segment)
+}
+
+// newDag links the items of the levels by their references, the items are ordered by level and then by the
+// order they were added, so every item comes after the items it refers to.
+func newDag(levels []Level) []*dagNode {
+	var nodes []*dagNode
+	index := make(map[types.Hash]int)
+	for _, l := range levels {
+		for _, item := range l.Items() {
+			n := &dagNode{level: l, item: item, result: make(chan *dagResult, 1)}
+			keys, accounts, _ := item.ReferHashes()
+			for _, r := range accounts {
+				// the item referred to is in chain already if it's not in the dag
+				if i, ok := index[r]; ok {
+					nodes[i].dependents = append(nodes[i].dependents, len(nodes))
+					n.refs++
+				}
+			}
+			for _, k := range keys {
+				index[k] = len(nodes)
+			}
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// insertAccountSegment verifies the items of the account levels concurrently and inserts them one by one.
+func (self *batchExecutor) insertAccountSegment(levels []Level) error {
+	nodes := newDag(levels)
+	if len(nodes) == 0 {
+		return nil
+	}
+	version := self.p.Version()
+	t1 := time.Now()
+
+	ready := make(chan int, len(nodes))
+	for i, n := range nodes {
+		if n.refs == 0 {
+			ready <- i
+		}
+	}
+
+	var stopped int32
+	var wg sync.WaitGroup
+	N := helper.MinInt(len(nodes), self.maxParallel)
+	wg.Add(N)
+	for i := 0; i < N; i++ {
+		common.Go(func() {
+			defer wg.Done()
+			for i := range ready {
+				n := nodes[i]
+				if atomic.LoadInt32(&stopped) == 1 {
+					n.result <- &dagResult{err: errPipelineStopped}
+					continue
+				}
+				verified, err := self.verifyFn(self.p, n.level, n.item, version)
+				n.result <- &dagResult{verified: verified, err: err}
+			}
+		})
+	}
+
+	// the items referred to come first, so an item is always ready when it's waited for
+	num := 0
+	var err error
+	for i, n := range nodes {
+		r := <-n.result
+		if r.err != nil {
+			err = r.err
+			break
+		}
+		if err = self.insertFn(self.p, n.level, n.item, r.verified, version); err != nil {
+			break
+		}
+		num++
+		for _, d := range n.dependents {
+			nodes[d].refs--
+			if nodes[d].refs == 0 {
+				ready <- d
+			}
+		}
+		if i == len(nodes)-1 || nodes[i+1].level != n.level {
+			n.level.Done()
+		}
+	}
+	if err != nil && self.stop != nil {
+		self.stop()
+	}
+	atomic.StoreInt32(&stopped, 1)
+	close(ready)
+	wg.Wait()
+
+	sub := time.Now().Sub(t1)
+	tps := int64(-1)
+	if sub > 0 {
+		tps = int64(num) * time.Second.Nanoseconds() / sub.Nanoseconds()
+	}
+	self.log.Info(fmt.Sprintf("\tlevel[%d-%d][%d][%s][%d/%d][parallel:%d], %v",
+		levels[0].Index(), levels[len(levels)-1].Index(), tps, sub, num, len(nodes), N, err))
+	return err
+}
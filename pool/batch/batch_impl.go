@@ -22,6 +22,7 @@ type batchSnapshot struct {
 	accountExistsF  AccountExistsFunc
 	maxLevel        int
 	id              uint64
+	stopped         int32
 }
 
 func (self *batchSnapshot) Exists(hash types.Hash) bool {
@@ -256,3 +257,15 @@ func (self *batchSnapshot) Batch(snapshotFn BucketExecutorFn, accountFn BucketEx
 	executor := newBatchExecutor(self, snapshotFn, accountFn)
 	return executor.execute()
 }
+
+func (self *batchSnapshot) BatchPipeline(snapshotFn BucketExecutorFn, verifyFn ItemVerifyFn, insertFn ItemInsertFn, parallel int) error {
+	executor := newPipelineExecutor(self, snapshotFn, verifyFn, insertFn, parallel)
+	executor.stop = func() {
+		atomic.StoreInt32(&self.stopped, 1)
+	}
+	return executor.executePipeline()
+}
+
+func (self *batchSnapshot) Stopped() bool {
+	return atomic.LoadInt32(&self.stopped) == 1
+}
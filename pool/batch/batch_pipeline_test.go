@@ -0,0 +1,151 @@
+package batch
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+type pipelineChain struct {
+	mu      sync.Mutex
+	chain   *mockChain
+	order   []types.Hash
+	running int32
+	max     int32
+}
+
+func (pc *pipelineChain) exists(hash types.Hash) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.chain.exists(hash)
+}
+
+func (pc *pipelineChain) verify(p Batch, l Level, item Item, version uint64) (interface{}, error) {
+	running := atomic.AddInt32(&pc.running, 1)
+	defer atomic.AddInt32(&pc.running, -1)
+	for {
+		max := atomic.LoadInt32(&pc.max)
+		if running <= max || atomic.CompareAndSwapInt32(&pc.max, max, running) {
+			break
+		}
+	}
+
+	_, accounts, _ := item.ReferHashes()
+	for _, r := range accounts {
+		if err := pc.exists(r); err != nil {
+			return nil, err
+		}
+	}
+	time.Sleep(time.Millisecond * 5)
+	return item.Hash(), nil
+}
+
+func (pc *pipelineChain) insert(p Batch, l Level, item Item, verified interface{}, version uint64) error {
+	if verified.(types.Hash) != item.Hash() {
+		return errors.New("verify result not match")
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.order = append(pc.order, item.Hash())
+	return pc.chain.insert(item)
+}
+
+func (pc *pipelineChain) insertSnapshot(p Batch, l Level, bucket Bucket, version uint64) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for _, v := range bucket.Items() {
+		pc.order = append(pc.order, v.Hash())
+	}
+	return pc.chain.execute(p, l, bucket, version)
+}
+
+func genPipelineBatch(t *testing.T, pc *pipelineChain, accounts int, height int) Batch {
+	var addrs []types.Address
+	for i := 0; i < accounts; i++ {
+		addrs = append(addrs, common.MockAddress(i))
+	}
+	prevs, geneS := initChain(addrs, pc.chain)
+	b := NewBatch(pc.chain.exists, pc.chain.exists, 1, 100)
+
+	// every account sends to the next one and receives from the previous one
+	var snapshotContent []Item
+	for h := 0; h < height; h++ {
+		var sends []Item
+		for _, addr := range addrs {
+			send := NewMockSendBlcok(prevs[addr])
+			assert.NoError(t, b.AddAItem(send, nil))
+			prevs[addr] = send
+			sends = append(sends, send)
+		}
+		for i, addr := range addrs {
+			from := sends[(i+len(sends)-1)%len(sends)]
+			receive := NewMockReceiveBlcok(prevs[addr], from.Hash())
+			assert.NoError(t, b.AddAItem(receive, nil))
+			prevs[addr] = receive
+			snapshotContent = append(snapshotContent, receive)
+		}
+	}
+	assert.NoError(t, b.AddSItem(NewMockSnapshotBlock(geneS, snapshotContent)))
+	for _, addr := range addrs {
+		assert.NoError(t, b.AddAItem(NewMockSendBlcok(prevs[addr]), nil))
+	}
+	return b
+}
+
+func TestBatchSnapshot_BatchPipeline(t *testing.T) {
+	pc := &pipelineChain{chain: newMockChain()}
+	b := genPipelineBatch(t, pc, 8, 3)
+
+	err := b.BatchPipeline(pc.insertSnapshot, pc.verify, pc.insert, 4)
+	assert.NoError(t, err)
+
+	// the items are inserted in the order of the levels and the order they were added
+	var expected []types.Hash
+	for _, l := range b.Levels() {
+		for _, item := range l.Items() {
+			expected = append(expected, item.Hash())
+		}
+		assert.True(t, l.HasDone())
+	}
+	assert.Equal(t, b.Size(), len(pc.order))
+	assert.Equal(t, expected, pc.order)
+	assert.True(t, pc.max > 1 && pc.max <= 4, "max parallel %d", pc.max)
+	assert.False(t, b.Stopped())
+}
+
+func TestBatchSnapshot_BatchPipelineError(t *testing.T) {
+	pc := &pipelineChain{chain: newMockChain()}
+	b := genPipelineBatch(t, pc, 4, 2)
+
+	var items []Item
+	for _, l := range b.Levels() {
+		if l.Snapshot() {
+			break
+		}
+		items = append(items, l.Items()...)
+	}
+	failed := items[5]
+	errFail := errors.New("verify fail")
+	err := b.BatchPipeline(pc.insertSnapshot, func(p Batch, l Level, item Item, version uint64) (interface{}, error) {
+		if item.Hash() == failed.Hash() {
+			assert.False(t, p.Stopped())
+			return nil, errFail
+		}
+		return pc.verify(p, l, item, version)
+	}, pc.insert, 4)
+	assert.Equal(t, errFail, err)
+	// the verify failures after this are cancelled work
+	assert.True(t, b.Stopped())
+
+	// the items before the failed one are inserted, no item after it is inserted
+	assert.Equal(t, 5, len(pc.order))
+	for i, hash := range pc.order {
+		assert.Equal(t, items[i].Hash(), hash)
+	}
+}
@@ -8,9 +8,14 @@ import (
 type accountLevel struct {
 	level
 	bs    map[types.Address]*bucket
+	items []Item
 	sHash *types.Hash
 }
 
+func (self *accountLevel) Items() []Item {
+	return self.items
+}
+
 func (self *accountLevel) Buckets() (result []Bucket) {
 	for _, v := range self.bs {
 		result = append(result, v)
@@ -39,7 +44,11 @@ func (self *accountLevel) Add(b Item) error {
 	if !ok {
 		self.bs[owner] = newBucket(&owner)
 	}
-	return self.bs[owner].add(b)
+	err := self.bs[owner].add(b)
+	if err == nil {
+		self.items = append(self.items, b)
+	}
+	return err
 }
 
 func (self *accountLevel) Size() int {
@@ -34,6 +34,10 @@ func (self *snapshotLevel) Buckets() (result []Bucket) {
 	return
 }
 
+func (self *snapshotLevel) Items() []Item {
+	return self.bu.Items()
+}
+
 func (self *snapshotLevel) Size() int {
 	return len(self.bu.Items())
 }
@@ -14,7 +14,10 @@ import (
 type Blacklist interface {
 	Add(key types.Hash)
 	AddAddTimeout(key types.Hash, duration time.Duration)
+	AddWithReason(key types.Hash, duration time.Duration, reason string)
 	Exists(key types.Hash) bool
+	// Reason returns why the key is blacklisted, the result is false if it is not in the blacklist
+	Reason(key types.Hash) (string, bool)
 	Remove(key types.Hash)
 }
 
@@ -29,6 +32,7 @@ func NewBlacklist() (Blacklist, error) {
 
 type timeout struct {
 	timeoutT *time.Time
+	reason   string
 }
 
 func (tt *timeout) reset(duration time.Duration) *timeout {
@@ -59,11 +63,16 @@ func (bl *blacklist) Add(key types.Hash) {
 }
 
 func (bl *blacklist) AddAddTimeout(key types.Hash, duration time.Duration) {
+	bl.AddWithReason(key, duration, "")
+}
+
+func (bl *blacklist) AddWithReason(key types.Hash, duration time.Duration, reason string) {
 	value, ok := bl.cache.Get(key)
 	if ok {
-		value.(*timeout).reset(duration)
+		tt := value.(*timeout).reset(duration)
+		tt.reason = reason
 	} else {
-		bl.cache.Add(key, (&timeout{}).reset(duration))
+		bl.cache.Add(key, (&timeout{reason: reason}).reset(duration))
 	}
 }
 
@@ -75,6 +84,18 @@ func (bl *blacklist) Exists(key types.Hash) bool {
 	return false
 }
 
+func (bl *blacklist) Reason(key types.Hash) (string, bool) {
+	value, ok := bl.cache.Get(key)
+	if !ok {
+		return "", false
+	}
+	tt := value.(*timeout)
+	if tt.isTimeout() {
+		return "", false
+	}
+	return tt.reason, true
+}
+
 func (bl *blacklist) Remove(key types.Hash) {
 	bl.cache.Remove(key)
 }
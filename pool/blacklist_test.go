@@ -25,3 +25,28 @@ func TestBlacklist_AddAddTimeout(t *testing.T) {
 	bl.AddAddTimeout(hash, time.Second*5)
 	assert.True(t, bl.Exists(hash))
 }
+
+func TestBlacklist_Reason(t *testing.T) {
+	bl, err := NewBlacklist()
+	if err != nil {
+		assert.Fail(t, err.Error())
+	}
+	hash := common.MockHash(11)
+	_, ok := bl.Reason(hash)
+	assert.False(t, ok)
+
+	bl.AddWithReason(hash, time.Second, "verify fail")
+	reason, ok := bl.Reason(hash)
+	assert.True(t, ok)
+	assert.Equal(t, "verify fail", reason)
+
+	bl.Add(hash)
+	reason, ok = bl.Reason(hash)
+	assert.True(t, ok)
+	assert.Equal(t, "", reason)
+
+	bl.AddWithReason(hash, time.Millisecond*10, "timeout")
+	time.Sleep(time.Millisecond * 20)
+	_, ok = bl.Reason(hash)
+	assert.False(t, ok)
+}
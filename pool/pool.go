@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"runtime"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -39,6 +40,7 @@ type SnapshotProducerWriter interface {
 	lock.ChainInsert
 	lock.ChainRollback
 	AddDirectSnapshotBlock(block *ledger.SnapshotBlock) error
+	RollbackSnapshotTo(height uint64) error
 }
 
 // Reader is a reader of BlockPool
@@ -65,6 +67,7 @@ type BlockPool interface {
 	Reader
 	SnapshotProducerWriter
 	Debug
+	Status
 
 	Start()
 	Stop()
@@ -162,6 +165,11 @@ type pool struct {
 
 	hashBlacklist Blacklist
 	cs            consensus.Consensus
+
+	// the number of account blocks verified concurrently in a batch
+	verifyParallel int
+
+	statusFeed *statusFeed
 }
 
 func (pl *pool) Snapshot() map[string]interface{} {
@@ -198,7 +206,7 @@ func (pl *pool) AccountChainDetail(addr types.Address, chainID string, height ui
 
 // NewPool create a new BlockPool
 func NewPool(bc chainDb) (BlockPool, error) {
-	self := &pool{bc: bc, version: &common.Version{}, rollbackVersion: &common.Version{}}
+	self := &pool{bc: bc, version: &common.Version{}, rollbackVersion: &common.Version{}, statusFeed: newStatusFeed(), verifyParallel: runtime.NumCPU()}
 	self.log = log15.New("module", "pool")
 	var err error
 	self.hashBlacklist, err = NewBlacklist()
@@ -365,6 +373,7 @@ func (pl *pool) AddAccountBlock(address types.Address, block *ledger.AccountBloc
 	}
 	ac := pl.selfPendingAc(address)
 	ac.addBlock(newAccountPoolBlock(block, nil, pl.version, source))
+	pl.statusFeed.notify([]*ledger.AccountBlock{block}, BlockQueued, "")
 
 	ac.setCompactDirty(true)
 	pl.newAccBlockCond.Broadcast()
@@ -495,6 +504,90 @@ func (pl *pool) RollbackAccountTo(addr types.Address, hash types.Hash, height ui
 	return err
 }
 
+// RollbackSnapshotTo deletes the snapshot blocks above the height and all unconfirmed account blocks from the chain.
+// Unlike a fork, the deleted blocks are blacklisted and never inserted again.
+func (pl *pool) RollbackSnapshotTo(height uint64) error {
+	pl.LockInsert()
+	defer pl.UnLockInsert()
+	pl.LockRollback()
+	defer pl.UnLockRollback()
+	defer pl.rollbackVersion.Inc()
+	defer pl.version.Inc()
+
+	head := pl.bc.GetLatestSnapshotBlock()
+	if height >= head.Height {
+		return nil
+	}
+	pl.log.Warn("rollback snapshot chain", "from", head.Height, "to", height)
+
+	snapshots, accounts, e := pl.pendingSc.rw.delToHeight(height + 1)
+	if e != nil {
+		return e
+	}
+
+	if len(snapshots) > 0 {
+		err := pl.pendingSc.rollbackCurrent(snapshots)
+		if err != nil {
+			return err
+		}
+		for _, b := range snapshots {
+			pl.hashBlacklist.Add(b.Hash())
+		}
+		err = pl.pendingSc.CurrentModifyToEmpty()
+		if err != nil {
+			return err
+		}
+	}
+	if err := pl.dropRolledBackAccounts(accounts); err != nil {
+		return err
+	}
+
+	// the account blocks of the first deleted snapshot block are kept as unconfirmed blocks by the chain,
+	// they are deleted once from the lowest unconfirmed height of each account
+	lowest := make(map[types.Address]uint64)
+	for _, b := range pl.bc.GetAllUnconfirmedBlocks() {
+		if h, ok := lowest[b.AccountAddress]; !ok || b.Height < h {
+			lowest[b.AccountAddress] = b.Height
+		}
+	}
+	for addr, height := range lowest {
+		// the blocks may be deleted already as dependencies of another account
+		latest, err := pl.bc.GetLatestAccountBlock(addr)
+		if err != nil {
+			return err
+		}
+		if latest == nil || latest.Height < height {
+			continue
+		}
+		_, accounts, e := pl.selfPendingAc(addr).rw.delToHeight(height)
+		if e != nil {
+			return e
+		}
+		if err := pl.dropRolledBackAccounts(accounts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pl *pool) dropRolledBackAccounts(accounts map[types.Address][]commonBlock) error {
+	for k, v := range accounts {
+		p := pl.selfPendingAc(k)
+		err := p.rollbackCurrent(v)
+		if err != nil {
+			return err
+		}
+		for _, b := range v {
+			pl.dropAccountBlock(b.(*accountPoolBlock).block, 0, "snapshot chain reverted")
+		}
+		err = p.CurrentModifyToEmpty()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (pl *pool) selfPendingAc(addr types.Address) *accountPool {
 	chain, ok := pl.pendingAc.Load(addr)
 
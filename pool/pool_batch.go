@@ -227,7 +227,7 @@ func (pl *pool) insertQueue(q batch.Batch) error {
 			pl.log.Info(fmt.Sprintln(queueResult))
 		}
 	}()
-	return q.Batch(pl.insertSnapshotBucketForTree, pl.insertAccountBucketForTree)
+	return q.BatchPipeline(pl.insertSnapshotBucketForTree, pl.verifyAccountItemForTree, pl.insertAccountItemForTree, pl.verifyParallel)
 }
 
 func (pl *pool) insertSnapshotBucketForTree(p batch.Batch, l batch.Level, bucket batch.Bucket, version uint64) error {
@@ -237,20 +237,60 @@ func (pl *pool) insertSnapshotBucketForTree(p batch.Batch, l batch.Level, bucket
 	return pl.insertSnapshotBucket(p, l, bucket, version)
 }
 
-func (pl *pool) insertAccountBucketForTree(p batch.Batch, l batch.Level, bucket batch.Bucket, version uint64) error {
+func (pl *pool) verifyAccountItemForTree(p batch.Batch, l batch.Level, item batch.Item, version uint64) (interface{}, error) {
 	pl.RLockInsert()
 	defer pl.RUnLockInsert()
-	return pl.insertAccountBucket(p, l, bucket, version)
+	return pl.verifyAccountItem(p, l, item, version)
+}
+
+func (pl *pool) insertAccountItemForTree(p batch.Batch, l batch.Level, item batch.Item, verified interface{}, version uint64) error {
+	pl.RLockInsert()
+	defer pl.RUnLockInsert()
+	return pl.insertAccountItem(p, l, item, verified, version)
+}
+
+// verifyAccountItem verifies an account block of the batch pipeline, the blocks it refers to have been inserted.
+func (pl *pool) verifyAccountItem(p batch.Batch, l batch.Level, item batch.Item, version uint64) (interface{}, error) {
+	latestSb := pl.bc.GetLatestSnapshotBlock()
+	block := item.(*accountPoolBlock)
+	verified, err := pl.selfPendingAc(*block.Owner()).tryVerifyItem(p, block, latestSb, version)
+	if err != nil {
+		pl.blacklistLevel(p, l, version)
+		return nil, err
+	}
+	if verified == nil {
+		return nil, batch.ErrorStop
+	}
+	return verified, nil
+}
+
+func (pl *pool) insertAccountItem(p batch.Batch, l batch.Level, item batch.Item, verified interface{}, version uint64) error {
+	block := item.(*accountPoolBlock)
+	err := pl.selfPendingAc(*block.Owner()).tryInsertVerifiedItem(p, block, verified.(*accountPoolBlock))
+	if err != nil {
+		pl.blacklistLevel(p, l, version)
+		return err
+	}
+	return nil
+}
+
+// blacklistLevel blacklists the snapshot block of the level for a failed item, unless the failure is caused by
+// a stopped pipeline or a new pool version, which cancel the work of the batch.
+func (pl *pool) blacklistLevel(p batch.Batch, l batch.Level, version uint64) {
+	if p.Stopped() || pl.version.Val() != version {
+		return
+	}
+	sHash := l.SHash()
+	if sHash != nil {
+		pl.hashBlacklist.AddAddTimeout(*sHash, time.Second*50)
+	}
 }
 
 func (pl *pool) insertAccountBucket(p batch.Batch, l batch.Level, bucket batch.Bucket, version uint64) error {
 	latestSb := pl.bc.GetLatestSnapshotBlock()
 	err := pl.selfPendingAc(*bucket.Owner()).tryInsertItems(p, bucket.Items(), latestSb, version)
 	if err != nil {
-		sHash := l.SHash()
-		if sHash != nil {
-			pl.hashBlacklist.AddAddTimeout(*sHash, time.Second*50)
-		}
+		pl.blacklistLevel(p, l, version)
 		return err
 	}
 	return nil
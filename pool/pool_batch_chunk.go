@@ -106,7 +106,7 @@ func (pl *pool) insertChunksToChain(chunks []ledger.SnapshotChunk, source types.
 				pl.log.Info("[A]add block to batch.", "account", vv.AccountAddress, "height", vv.Height, "block", vv.Hash, "batchId", b.Id())
 				err := b.AddAItem(block, sHash)
 				if err != nil && err == batch.ErrorArrivedToMax {
-					err := b.Batch(pl.insertSnapshotBucketForChunks, pl.insertAccountsBucketForChunks)
+					err := b.BatchPipeline(pl.insertSnapshotBucketForChunks, pl.verifyAccountItem, pl.insertAccountItem, pl.verifyParallel)
 					if err != nil {
 						return err
 					}
@@ -132,7 +132,7 @@ func (pl *pool) insertChunksToChain(chunks []ledger.SnapshotChunk, source types.
 			pl.log.Info("[S]add block to batch.", "block", v.SnapshotBlock.Hash, "batchId", b.Id())
 			err := b.AddSItem(block)
 			if err != nil && err == batch.ErrorArrivedToMax {
-				err := b.Batch(pl.insertSnapshotBucketForChunks, pl.insertAccountsBucketForChunks)
+				err := b.BatchPipeline(pl.insertSnapshotBucketForChunks, pl.verifyAccountItem, pl.insertAccountItem, pl.verifyParallel)
 				if err != nil {
 					return err
 				}
@@ -150,7 +150,7 @@ func (pl *pool) insertChunksToChain(chunks []ledger.SnapshotChunk, source types.
 	}
 
 	if b.Size() > 0 {
-		return b.Batch(pl.insertSnapshotBucketForChunks, pl.insertAccountsBucketForChunks)
+		return b.BatchPipeline(pl.insertSnapshotBucketForChunks, pl.verifyAccountItem, pl.insertAccountItem, pl.verifyParallel)
 	}
 	return nil
 }
@@ -159,10 +159,6 @@ func (pl *pool) insertSnapshotBucketForChunks(p batch.Batch, l batch.Level, buck
 	return pl.insertSnapshotBucket(p, l, bucket, version)
 }
 
-func (pl *pool) insertAccountsBucketForChunks(p batch.Batch, l batch.Level, bucket batch.Bucket, version uint64) error {
-	return pl.insertAccountBucket(p, l, bucket, version)
-}
-
 func (pl *pool) checkSnapshotInsert(headHH ledger.HashHeight, tailHH ledger.HashHeight, hashes map[types.Hash]struct{}) ChainState {
 	cur := pl.pendingSc.CurrentChain()
 
@@ -0,0 +1,279 @@
+package pool
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// BlockStatus is the state of an account block from the view of BlockPool
+type BlockStatus string
+
+const (
+	// BlockUnknown means the block was never received or has been evicted from the pool
+	BlockUnknown BlockStatus = "unknown"
+	// BlockQueued means the block is received and waiting to be linked to its account chain
+	BlockQueued BlockStatus = "queued"
+	// BlockMissingDependency means the previous blocks of the block are missing and being fetched
+	BlockMissingDependency BlockStatus = "missingDependency"
+	// BlockPending means the block is on the current branch, waiting to be verified and inserted into the chain
+	BlockPending BlockStatus = "pending"
+	// BlockForked means the block is on a fork branch, it is inserted only if the branch becomes the current one
+	BlockForked BlockStatus = "forked"
+	// BlockDropped means the block is in the blacklist
+	BlockDropped BlockStatus = "dropped"
+	// BlockInserted means the block is in the chain but not snapshotted yet
+	BlockInserted BlockStatus = "inserted"
+	// BlockConfirmed means the block is snapshotted
+	BlockConfirmed BlockStatus = "confirmed"
+	// BlockRolledBack means the block is deleted from the chain, it is only reported to status subscribers
+	BlockRolledBack BlockStatus = "rolledBack"
+)
+
+// AccountBlockStatus describes where an account block is in BlockPool
+type AccountBlockStatus struct {
+	Hash    types.Hash
+	Address *types.Address
+	Height  uint64
+	Status  BlockStatus
+	// Reason explains why the block is dropped or rolled back
+	Reason string
+	// WaitingFor is the hash of the missing block which the block depends on
+	WaitingFor *types.Hash
+	// ConfirmedTimes is the number of snapshot blocks confirming the block
+	ConfirmedTimes uint64
+	// Time is when the status is observed
+	Time time.Time
+
+	block *ledger.AccountBlock
+}
+
+func newAccountBlockStatus(block *ledger.AccountBlock, status BlockStatus, reason string) *AccountBlockStatus {
+	addr := block.AccountAddress
+	return &AccountBlockStatus{
+		Hash:    block.Hash,
+		Address: &addr,
+		Height:  block.Height,
+		Status:  status,
+		Reason:  reason,
+		Time:    time.Now(),
+	}
+}
+
+// AccountBlockStatusCallback is called when the status of an account block changes.
+// It is called synchronously by the pool and the chain, so it must not block.
+type AccountBlockStatusCallback func(status *AccountBlockStatus)
+
+// Status reports the state of account blocks in BlockPool
+type Status interface {
+	GetAccountBlockStatus(hash types.Hash) *AccountBlockStatus
+	GetPendingAccountBlocks(addr types.Address) []*AccountBlockStatus
+	SubscribeAccountBlockStatus(fn AccountBlockStatusCallback) (subID int)
+	UnsubscribeAccountBlockStatus(subID int)
+}
+
+type statusFeed struct {
+	mu        sync.RWMutex
+	subs      map[int]AccountBlockStatusCallback
+	currentID int
+}
+
+func newStatusFeed() *statusFeed {
+	return &statusFeed{subs: make(map[int]AccountBlockStatusCallback)}
+}
+
+func (sf *statusFeed) subscribe(fn AccountBlockStatusCallback) int {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.currentID++
+	sf.subs[sf.currentID] = fn
+	return sf.currentID
+}
+
+func (sf *statusFeed) unsubscribe(subID int) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	delete(sf.subs, subID)
+}
+
+func (sf *statusFeed) notify(blocks []*ledger.AccountBlock, status BlockStatus, reason string) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if len(sf.subs) == 0 {
+		return
+	}
+	for _, b := range blocks {
+		s := newAccountBlockStatus(b, status, reason)
+		for _, fn := range sf.subs {
+			fn(s)
+		}
+	}
+}
+
+func (pl *pool) SubscribeAccountBlockStatus(fn AccountBlockStatusCallback) int {
+	return pl.statusFeed.subscribe(fn)
+}
+
+func (pl *pool) UnsubscribeAccountBlockStatus(subID int) {
+	pl.statusFeed.unsubscribe(subID)
+}
+
+// dropAccountBlock puts the block to blacklist and notifies subscribers with the reason.
+func (pl *pool) dropAccountBlock(block *ledger.AccountBlock, duration time.Duration, reason string) {
+	pl.hashBlacklist.AddWithReason(block.Hash, duration, reason)
+	pl.statusFeed.notify([]*ledger.AccountBlock{block}, BlockDropped, reason)
+}
+
+func (pl *pool) GetAccountBlockStatus(hash types.Hash) *AccountBlockStatus {
+	block, err := pl.bc.GetAccountBlockByHash(hash)
+	if err != nil {
+		pl.log.Error("get account block fail", "hash", hash, "err", err)
+	}
+	if block != nil {
+		return pl.chainBlockStatus(block)
+	}
+
+	var result *AccountBlockStatus
+	pl.pendingAc.Range(func(_, v interface{}) bool {
+		for _, s := range v.(*accountPool).blockStatuses() {
+			if s.Hash == hash {
+				result = s
+				return false
+			}
+		}
+		return true
+	})
+	if reason, ok := pl.hashBlacklist.Reason(hash); ok {
+		if result == nil {
+			result = &AccountBlockStatus{Hash: hash, Time: time.Now()}
+		}
+		result.Status = BlockDropped
+		result.Reason = reason
+	}
+	if result == nil {
+		return &AccountBlockStatus{Hash: hash, Status: BlockUnknown, Time: time.Now()}
+	}
+	pl.fillWaitingFor(result)
+	return result
+}
+
+// GetPendingAccountBlocks returns the blocks of the account which are in the pool, sorted by height.
+func (pl *pool) GetPendingAccountBlocks(addr types.Address) []*AccountBlockStatus {
+	p, ok := pl.pendingAc.Load(addr)
+	if !ok {
+		return nil
+	}
+	result := p.(*accountPool).blockStatuses()
+	for _, s := range result {
+		if reason, ok := pl.hashBlacklist.Reason(s.Hash); ok {
+			s.Status = BlockDropped
+			s.Reason = reason
+			continue
+		}
+		pl.fillWaitingFor(s)
+	}
+	return result
+}
+
+func (pl *pool) chainBlockStatus(block *ledger.AccountBlock) *AccountBlockStatus {
+	result := newAccountBlockStatus(block, BlockInserted, "")
+	times, err := pl.bc.GetConfirmedTimes(block.Hash)
+	if err != nil {
+		pl.log.Error("get confirmed times fail", "hash", block.Hash, "err", err)
+		return result
+	}
+	if times > 0 {
+		result.Status = BlockConfirmed
+		result.ConfirmedTimes = times
+	}
+	return result
+}
+
+// fillWaitingFor finds the send block which a pending receive block waits for.
+func (pl *pool) fillWaitingFor(s *AccountBlockStatus) {
+	if s.Status != BlockPending || s.WaitingFor != nil || s.block == nil || !s.block.IsReceiveBlock() {
+		return
+	}
+	from := s.block.FromBlockHash
+	send, err := pl.bc.GetAccountBlockByHash(from)
+	if err == nil && send == nil {
+		s.WaitingFor = &from
+	}
+}
+
+func (accP *accountPool) blockStatuses() []*AccountBlockStatus {
+	states := accP.blockStates()
+	result := make([]*AccountBlockStatus, 0, len(states))
+	now := time.Now()
+	for _, state := range states {
+		b := state.block.(*accountPoolBlock)
+		result = append(result, &AccountBlockStatus{
+			Hash:       b.Hash(),
+			Address:    &accP.address,
+			Height:     b.Height(),
+			Status:     state.status,
+			WaitingFor: state.waitingFor,
+			Time:       now,
+			block:      b.block,
+		})
+	}
+	return result
+}
+
+type blockState struct {
+	block      commonBlock
+	status     BlockStatus
+	waitingFor *types.Hash
+}
+
+// byStateHeight sorts blockState by height
+type byStateHeight []*blockState
+
+func (a byStateHeight) Len() int           { return len(a) }
+func (a byStateHeight) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byStateHeight) Less(i, j int) bool { return a[i].block.Height() < a[j].block.Height() }
+
+// blockStates collects all blocks which are not in the chain from free blocks, snippet chains and branches.
+func (bcp *BCPool) blockStates() []*blockState {
+	bcp.chainHeadMu.Lock()
+	defer bcp.chainHeadMu.Unlock()
+
+	bcp.chainTailMu.Lock()
+	defer bcp.chainTailMu.Unlock()
+
+	bcp.blockpool.pendingMu.Lock()
+	defer bcp.blockpool.pendingMu.Unlock()
+
+	var result []*blockState
+	for _, b := range bcp.blockpool.freeBlocks {
+		result = append(result, &blockState{block: b, status: BlockQueued})
+	}
+	for _, c := range bcp.chainpool.snippetChains {
+		tailHash := c.tailHash
+		for _, b := range c.heightBlocks {
+			result = append(result, &blockState{block: b, status: BlockMissingDependency, waitingFor: &tailHash})
+		}
+	}
+
+	main := bcp.chainpool.tree.Main()
+	for _, branch := range bcp.chainpool.tree.Branches() {
+		tailHeight, _ := branch.TailHH()
+		headHeight, _ := branch.HeadHH()
+		for h := tailHeight + 1; h <= headHeight; h++ {
+			k := branch.GetKnot(h, false)
+			if k == nil {
+				continue
+			}
+			status := BlockForked
+			if mk := main.GetKnot(h, true); mk != nil && mk.Hash() == k.Hash() {
+				status = BlockPending
+			}
+			result = append(result, &blockState{block: k.(commonBlock), status: status})
+		}
+	}
+	sort.Stable(byStateHeight(result))
+	return result
+}
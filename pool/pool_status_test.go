@@ -0,0 +1,83 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool/tree"
+)
+
+/**
+                 +----+
+          +------+ 5' |  fork
+          |      +----+
++---+   +-+-+    +---+          +---+    +---+
+| 3 +---+ 4 +----+ 5 |  main    | 7 +----+ 8 |  snippet, 6 is missing
++---+   +---+    +---+          +---+    +---+
+disk
+                                         +---+
+                                         | 9 |  free
+                                         +---+
+*/
+func TestBCPool_blockStates(t *testing.T) {
+	tr := tree.NewTree()
+	diskChain := tree.NewMockBranchRoot()
+	for i := 0; i < 3; i++ {
+		height, hash := diskChain.HeadHH()
+		diskChain.AddHead(newMockCommonBlockByHH(height, hash, "root"))
+	}
+
+	cp := &chainPool{
+		poolID: "unittest",
+		tree:   tr,
+		log:    log15.New("module", "unittest"),
+	}
+	cp.snippetChains = make(map[string]*snippetChain)
+	cp.tree.Init(cp.poolID, diskChain)
+	bcp := &BCPool{
+		ID:        "unittest",
+		chainpool: cp,
+		blockpool: &blockPool{freeBlocks: make(map[types.Hash]commonBlock)},
+	}
+
+	main := tr.Main()
+	b4 := newMockCommonBlock(diskChain.GetKnot(3, false).(commonBlock), "main")
+	b5 := newMockCommonBlock(b4, "main")
+	assert.NoError(t, tr.AddHead(main, b4))
+	assert.NoError(t, tr.AddHead(main, b5))
+
+	fork := tr.ForkBranch(main, 4, b4.Hash())
+	b5f := newMockCommonBlock(b4, "fork")
+	assert.NoError(t, tr.AddHead(fork, b5f))
+
+	missing := common.MockHash(6)
+	b7 := newMockCommonBlockByHH(6, missing, "snippet")
+	b8 := newMockCommonBlock(b7, "snippet")
+	snippet := newSnippetChain(b8, "snippet1")
+	snippet.addTail(b7)
+	cp.snippetChains[snippet.id()] = snippet
+
+	b9 := newMockCommonBlockByHH(8, common.MockHash(8), "free")
+	bcp.blockpool.putBlock(b9.Hash(), b9)
+
+	states := bcp.blockStates()
+	assert.Equal(t, 6, len(states))
+
+	result := make(map[types.Hash]*blockState)
+	for i, s := range states {
+		if i > 0 {
+			assert.True(t, states[i-1].block.Height() <= s.block.Height())
+		}
+		result[s.block.Hash()] = s
+	}
+	assert.Equal(t, BlockPending, result[b4.Hash()].status)
+	assert.Equal(t, BlockPending, result[b5.Hash()].status)
+	assert.Equal(t, BlockForked, result[b5f.Hash()].status)
+	assert.Equal(t, BlockMissingDependency, result[b7.Hash()].status)
+	assert.Equal(t, BlockMissingDependency, result[b8.Hash()].status)
+	assert.Equal(t, missing, *result[b8.Hash()].waitingFor)
+	assert.Equal(t, BlockQueued, result[b9.Hash()].status)
+}
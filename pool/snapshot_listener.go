@@ -13,7 +13,11 @@ func (pl *pool) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error
 }
 
 func (pl *pool) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
-	// ignore
+	accountBlocks := make([]*ledger.AccountBlock, len(blocks))
+	for i, b := range blocks {
+		accountBlocks[i] = b.AccountBlock
+	}
+	pl.statusFeed.notify(accountBlocks, BlockInserted, "")
 	return nil
 }
 
@@ -33,6 +37,7 @@ func (pl *pool) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
 			tps = len(v.AccountBlocks)
 		}
 		fmt.Printf("[Insert] Height:%d, Hash:%s, Timestamp:%s, Producer:%s, Time:%s, Cnt:%d\n", block.Height, block.Hash, block.Timestamp, block.Producer(), time.Now(), tps)
+		pl.statusFeed.notify(v.AccountBlocks, BlockConfirmed, "")
 	}
 	return nil
 }
@@ -43,7 +48,7 @@ func (pl *pool) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error
 }
 
 func (pl *pool) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
-	// ignore
+	pl.statusFeed.notify(blocks, BlockRolledBack, "account chain rolled back")
 	return nil
 }
 
@@ -53,6 +58,8 @@ func (pl *pool) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) erro
 }
 
 func (pl *pool) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
-	// ignore
+	for _, v := range chunks {
+		pl.statusFeed.notify(v.AccountBlocks, BlockRolledBack, "snapshot chain rolled back")
+	}
 	return nil
 }
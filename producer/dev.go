@@ -0,0 +1,258 @@
+package producer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool"
+	"github.com/vitelabs/go-vite/producer/producerevent"
+	"github.com/vitelabs/go-vite/verifier"
+	"github.com/vitelabs/go-vite/wallet"
+)
+
+const (
+	// devCheckInterval is how often the dev producer looks for account blocks to snapshot
+	devCheckInterval = 200 * time.Millisecond
+	// devMaxMine is the max number of snapshot blocks sealed by one Mine call
+	devMaxMine = 1000
+	// devMaxTimeAhead is the max distance between snapshot timestamp and local time, see SnapshotVerifier.verifyTimestamp
+	devMaxTimeAhead = time.Hour
+	// devContractWindow keeps the contract worker of the coinbase running for the lifetime of the node
+	devContractWindow = 10 * 365 * 24 * time.Hour
+)
+
+// DevProducer seals snapshot blocks on demand for a single node development chain.
+type DevProducer interface {
+	Producer
+	// Mine seals n snapshot blocks immediately.
+	Mine(n int) ([]*ledger.SnapshotBlock, error)
+	// SetTime sets the timestamp of the next snapshot block, later blocks keep the offset to local time.
+	SetTime(t time.Time) error
+	// Snapshot returns an id of the current chain state which can be passed to Revert.
+	Snapshot() uint64
+	// Revert deletes all blocks after the snapshot id.
+	Revert(id uint64) error
+}
+
+type devProducer struct {
+	producerLifecycle
+	tools     *tools
+	worker    *worker
+	coinbase  *AddressContext
+	cs        consensus.Consensus
+	period    time.Duration
+	accountFn func(producerevent.AccountEvent)
+
+	// mu serializes sealing, time setting and reverting
+	mu sync.Mutex
+	// offset of the block time to local time, read by the interval loop without mu
+	offset int64
+
+	closed chan struct{}
+	wg     sync.WaitGroup
+	log    log15.Logger
+}
+
+// NewDevProducer creates an instant-seal producer, a snapshot block is sealed as soon as there are account blocks
+// to snapshot or every period if period is positive.
+func NewDevProducer(rw chain.Chain,
+	coinbase *AddressContext,
+	cs consensus.Consensus,
+	verifier *verifier.SnapshotVerifier,
+	wt *wallet.Manager,
+	p pool.SnapshotProducerWriter,
+	period time.Duration) *devProducer {
+	chain := newChainRw(rw, verifier, wt, p)
+	return &devProducer{
+		tools:    chain,
+		worker:   newWorker(chain, coinbase),
+		coinbase: coinbase,
+		cs:       cs,
+		period:   period,
+		log:      log15.New("module", "producer/dev"),
+	}
+}
+
+func (self *devProducer) Init() error {
+	if !self.PreInit() {
+		return errors.New("pre init fail.")
+	}
+	defer self.PostInit()
+
+	return self.worker.Init()
+}
+
+func (self *devProducer) Start() error {
+	if !self.PreStart() {
+		return errors.New("pre start fail.")
+	}
+	defer self.PostStart()
+
+	if self.coinbase == nil {
+		return errors.New("coinbase must not be nil.")
+	}
+	if err := self.worker.Start(); err != nil {
+		return err
+	}
+
+	// unconfirmed account blocks are dropped at fork points, seal past them before accepting any block
+	head := self.tools.chain.GetLatestSnapshotBlock()
+	if last := self.tools.chain.ForkSchedule().GetLastForkPoint(); head.Height < last.Height {
+		if _, err := self.Mine(int(last.Height - head.Height)); err != nil {
+			return err
+		}
+	}
+
+	// the coinbase is the only producer of the delegate group, it receives for all contracts
+	if fn := self.accountFn; fn != nil {
+		now := time.Now()
+		e := producerevent.AccountStartEvent{
+			Gid:     types.DELEGATE_GID,
+			Address: self.coinbase.Address,
+			Stime:   now.Add(-time.Second),
+			Etime:   now.Add(devContractWindow),
+		}
+		common.Go(func() {
+			fn(e)
+		})
+	}
+
+	self.closed = make(chan struct{})
+	self.wg.Add(1)
+	common.Go(self.loop)
+	self.log.Info("dev producer started.", "coinbase", self.coinbase.Address, "period", self.period)
+	return nil
+}
+
+func (self *devProducer) Stop() error {
+	if !self.PreStop() {
+		return errors.New("pre stop fail.")
+	}
+	defer self.PostStop()
+
+	close(self.closed)
+	self.wg.Wait()
+	return self.worker.Stop()
+}
+
+func (self *devProducer) SetAccountEventFunc(accountFn func(producerevent.AccountEvent)) {
+	self.accountFn = accountFn
+}
+
+func (self *devProducer) GetCoinBase() types.Address {
+	return self.coinbase.Address
+}
+
+func (self *devProducer) loop() {
+	defer self.wg.Done()
+	ticker := time.NewTicker(devCheckInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-self.closed:
+			return
+		case <-ticker.C:
+		}
+		if len(self.tools.chain.GetContentNeedSnapshot()) == 0 && (self.period <= 0 || time.Since(last) < self.period) {
+			continue
+		}
+		// don't run ahead of local time when sealing automatically
+		head := self.tools.chain.GetLatestSnapshotBlock()
+		if !self.nextTime().After(*head.Timestamp) {
+			continue
+		}
+		if _, err := self.Mine(1); err != nil {
+			self.log.Error("seal snapshot block fail.", "err", err)
+		}
+		last = time.Now()
+	}
+}
+
+func (self *devProducer) Mine(n int) ([]*ledger.SnapshotBlock, error) {
+	if n <= 0 || n > devMaxMine {
+		return nil, errors.Errorf("count must be in [1, %d]", devMaxMine)
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var result []*ledger.SnapshotBlock
+	for i := 0; i < n; i++ {
+		b, err := self.seal()
+		if err != nil {
+			return result, err
+		}
+		result = append(result, b)
+	}
+	return result, nil
+}
+
+func (self *devProducer) seal() (*ledger.SnapshotBlock, error) {
+	// the coinbase owns every slot of the snapshot group, any whole second after head is a valid timestamp
+	head := self.tools.chain.GetLatestSnapshotBlock()
+	timestamp := self.nextTime()
+	if !timestamp.After(*head.Timestamp) {
+		timestamp = head.Timestamp.Add(time.Second)
+	}
+	if timestamp.After(time.Now().Add(devMaxTimeAhead)) {
+		return nil, errors.Errorf("snapshot timestamp[%s] is more than %s ahead of local time", timestamp, devMaxTimeAhead)
+	}
+
+	periodStime, periodEtime := self.cs.SBPReader().GetPeriodTimeIndex().Index2Time(self.cs.SBPReader().GetPeriodTimeIndex().Time2Index(timestamp))
+	e := &consensus.Event{
+		Gid:         types.SNAPSHOT_GID,
+		Address:     self.coinbase.Address,
+		Stime:       timestamp,
+		Etime:       timestamp.Add(time.Second),
+		Timestamp:   timestamp,
+		PeriodStime: periodStime,
+		PeriodEtime: periodEtime,
+	}
+	if err := self.tools.checkAddressLock(e.Address, self.coinbase); err != nil {
+		return nil, err
+	}
+	return self.worker.seal(e)
+}
+
+func (self *devProducer) nextTime() time.Time {
+	return time.Now().Add(time.Duration(atomic.LoadInt64(&self.offset))).Truncate(time.Second)
+}
+
+func (self *devProducer) SetTime(t time.Time) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	head := self.tools.chain.GetLatestSnapshotBlock()
+	if !t.After(*head.Timestamp) {
+		return errors.Errorf("time must be after the latest snapshot block[%s]", head.Timestamp)
+	}
+	offset := time.Until(t)
+	if offset > devMaxTimeAhead {
+		return errors.Errorf("time can't be more than %s ahead of local time", devMaxTimeAhead)
+	}
+	atomic.StoreInt64(&self.offset, int64(offset))
+	return nil
+}
+
+func (self *devProducer) Snapshot() uint64 {
+	return self.tools.chain.GetLatestSnapshotBlock().Height
+}
+
+func (self *devProducer) Revert(id uint64) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if id < self.tools.chain.ForkSchedule().GetLastForkPoint().Height {
+		return errors.Errorf("can't revert to %d, which is before the last fork point", id)
+	}
+	return self.tools.pool.RollbackSnapshotTo(id)
+}
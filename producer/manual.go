@@ -0,0 +1,152 @@
+package producer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool"
+	"github.com/vitelabs/go-vite/producer/producerevent"
+	"github.com/vitelabs/go-vite/verifier"
+	"github.com/vitelabs/go-vite/wallet"
+)
+
+// ManualProducer only produces when asked, it doesn't subscribe to consensus events driven by local time.
+// Test networks use it to drive all producers by one fake clock.
+type ManualProducer interface {
+	Producer
+	// Produce seals the snapshot block of the slot starting at t if the slot belongs to the coinbase,
+	// and starts the contract worker if the coinbase owns the delegate slot at t.
+	// The returned block is nil if the snapshot slot belongs to another producer.
+	Produce(t time.Time) (*ledger.SnapshotBlock, error)
+}
+
+type manualProducer struct {
+	producerLifecycle
+	tools     *tools
+	worker    *worker
+	coinbase  *AddressContext
+	cs        consensus.Reader
+	accountFn func(producerevent.AccountEvent)
+
+	// mu serializes producing
+	mu  sync.Mutex
+	log log15.Logger
+}
+
+func NewManualProducer(rw chain.Chain,
+	coinbase *AddressContext,
+	cs consensus.Reader,
+	verifier *verifier.SnapshotVerifier,
+	wt *wallet.Manager,
+	p pool.SnapshotProducerWriter) *manualProducer {
+	chain := newChainRw(rw, verifier, wt, p)
+	return &manualProducer{
+		tools:    chain,
+		worker:   newWorker(chain, coinbase),
+		coinbase: coinbase,
+		cs:       cs,
+		log:      log15.New("module", "producer/manual"),
+	}
+}
+
+func (self *manualProducer) Init() error {
+	if !self.PreInit() {
+		return errors.New("pre init fail.")
+	}
+	defer self.PostInit()
+
+	return self.worker.Init()
+}
+
+func (self *manualProducer) Start() error {
+	if !self.PreStart() {
+		return errors.New("pre start fail.")
+	}
+	defer self.PostStart()
+
+	if self.coinbase == nil {
+		return errors.New("coinbase must not be nil.")
+	}
+	return self.worker.Start()
+}
+
+func (self *manualProducer) Stop() error {
+	if !self.PreStop() {
+		return errors.New("pre stop fail.")
+	}
+	defer self.PostStop()
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.worker.Stop()
+}
+
+func (self *manualProducer) SetAccountEventFunc(accountFn func(producerevent.AccountEvent)) {
+	self.accountFn = accountFn
+}
+
+func (self *manualProducer) GetCoinBase() types.Address {
+	return self.coinbase.Address
+}
+
+func (self *manualProducer) Produce(t time.Time) (*ledger.SnapshotBlock, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.GetStatus() != common.LifecycleStarted {
+		return nil, errors.New("producer is not started.")
+	}
+
+	if fn := self.accountFn; fn != nil {
+		e, err := self.slot(types.DELEGATE_GID, t)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			tmpEvent := producerevent.AccountStartEvent{
+				Gid:     e.Gid,
+				Address: e.Address,
+				Stime:   e.Stime,
+				Etime:   e.Etime,
+			}
+			common.Go(func() {
+				fn(tmpEvent)
+			})
+		}
+	}
+
+	e, err := self.slot(types.SNAPSHOT_GID, t)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	if err := self.tools.checkAddressLock(e.Address, self.coinbase); err != nil {
+		return nil, err
+	}
+	self.log.Info("produce snapshot block.", "addr", e.Address, "time", t)
+	return self.worker.seal(e)
+}
+
+// slot returns the event of the coinbase in gid starting at t, nil if the slot belongs to others
+func (self *manualProducer) slot(gid types.Gid, t time.Time) (*consensus.Event, error) {
+	index, err := self.cs.VoteTimeToIndex(gid, t)
+	if err != nil {
+		return nil, err
+	}
+	events, _, err := self.cs.ReadByIndex(gid, index)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		if e.Address == self.coinbase.Address && e.Stime.Equal(t) {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
@@ -1,7 +1,6 @@
 package producer
 
 import (
-	"github.com/vitelabs/go-vite/common/fork"
 	"time"
 
 	"github.com/pkg/errors"
@@ -52,11 +51,12 @@ func (self *tools) generateSnapshot(e *consensus.Event, coinbase *AddressContext
 	}
 
 	// add version
-	if fork.IsLeafFork(block.Height) {
-		block.Version = fork.GetLastForkPoint().Version
+	forks := self.chain.ForkSchedule()
+	if forks.IsLeafFork(block.Height) {
+		block.Version = forks.GetLastForkPoint().Version
 	}
 
-	block.Hash = block.ComputeHash()
+	block.Hash = block.ComputeHash(forks)
 	manager, err := self.wt.GetEntropyStoreManager(coinbase.EntryPath)
 	if err != nil {
 		return nil, err
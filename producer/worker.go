@@ -14,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common"
 	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/monitor"
 )
@@ -82,6 +83,11 @@ func (self *worker) genAndInsert(e *consensus.Event) {
 	defer wLog.Info("genAndInsert end.", "event", e)
 	defer monitor.LogTime("producer", "snapshotGenInsert", time.Now())
 	defer self.wg.Done()
+	self.seal(e)
+}
+
+// seal generates a snapshot block for the event and inserts it into the pool.
+func (self *worker) seal(e *consensus.Event) (*ledger.SnapshotBlock, error) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 	// lock pool
@@ -95,18 +101,19 @@ func (self *worker) genAndInsert(e *consensus.Event) {
 	b, err := self.tools.generateSnapshot(e, self.coinbase, seed, self.getSeedByHash)
 	if err != nil {
 		wLog.Error("produce snapshot block fail[generate].", "err", err)
-		return
+		return nil, err
 	}
 
 	// insert snapshot block
 	err = self.tools.insertSnapshot(b)
 	if err != nil {
 		wLog.Error("produce snapshot block fail[insert].", "err", err)
-		return
+		return nil, err
 	}
 
 	// todo
 	self.storeSeedHash(seed, b.SeedHash)
+	return b, nil
 }
 
 func (self *worker) randomSeed() uint64 {
@@ -0,0 +1,248 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AuthConfig configures the authentication, the method access and the limits of the HTTP and WebSocket endpoints
+type AuthConfig struct {
+	// HMAC-SHA256 secret of the JWT bearer tokens, the subject of a token is the name of its client
+	JWTSecret string `json:"JWTSecret"`
+	// policies of the clients keyed by name, a client authenticates with its APIKey or a JWT
+	Clients map[string]*ClientPolicy `json:"Clients"`
+	// policy of the requests without credentials. If it is nil, they are rejected when
+	// JWTSecret or Clients is configured, otherwise they can call the public namespaces.
+	Anonymous *ClientPolicy `json:"Anonymous"`
+
+	IPRate  float64 `json:"IPRate"` // requests per second of an IP, 0 means no limit
+	IPBurst int     `json:"IPBurst"`
+
+	MaxBatchSize     int `json:"MaxBatchSize"`     // max requests of a batch, 0 means no limit
+	MaxSubscriptions int `json:"MaxSubscriptions"` // max subscriptions of a WebSocket connection, 0 means no limit
+}
+
+// ClientPolicy limits the methods and the request rate of a client
+type ClientPolicy struct {
+	// sent in the header "X-API-Key" or the header "Authorization: Bearer <key>"
+	APIKey string `json:"APIKey"`
+	// namespaces like "ledger" or methods like "ledger_getSnapshotChainHeight" the client can call,
+	// empty or "*" allows all public namespaces. The private namespaces like "wallet" must be listed explicitly.
+	Allow []string `json:"Allow"`
+	Deny  []string `json:"Deny"`
+	Rate  float64  `json:"Rate"` // requests per second, 0 means no limit
+	Burst int      `json:"Burst"`
+}
+
+// Guard authenticates the callers of a server and enforces their policies and the limits,
+// it can be shared by the servers of several endpoints.
+type Guard struct {
+	cfg       AuthConfig
+	keys      map[string]string // api key to client name
+	anonymous *ClientPolicy
+	limiter   *limiter
+}
+
+// caller is the authenticated origin of a request
+type caller struct {
+	name   string // empty for anonymous
+	policy *ClientPolicy
+	ip     string
+}
+
+type callerKey struct{}
+
+// NewGuard checks cfg and returns its guard, a nil cfg allows anonymous callers without limits
+func NewGuard(cfg *AuthConfig) (*Guard, error) {
+	g := &Guard{
+		keys:    make(map[string]string),
+		limiter: newLimiter(),
+	}
+	if cfg != nil {
+		g.cfg = *cfg
+	}
+
+	for name, p := range g.cfg.Clients {
+		if p == nil {
+			return nil, fmt.Errorf("policy of rpc client %s is empty", name)
+		}
+		if p.APIKey == "" {
+			continue
+		}
+		if other, ok := g.keys[p.APIKey]; ok {
+			return nil, fmt.Errorf("rpc clients %s and %s have the same api key", other, name)
+		}
+		g.keys[p.APIKey] = name
+	}
+
+	g.anonymous = g.cfg.Anonymous
+	if g.anonymous == nil && g.cfg.JWTSecret == "" && len(g.cfg.Clients) == 0 {
+		g.anonymous = &ClientPolicy{}
+	}
+	return g, nil
+}
+
+// authenticate returns the caller of r
+func (g *Guard) authenticate(r *http.Request) (*caller, error) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	token := r.Header.Get("X-API-Key")
+	if token == "" {
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimSpace(auth[len("Bearer "):])
+		}
+	}
+
+	if token == "" {
+		if g.anonymous == nil {
+			return nil, errors.New("missing credentials")
+		}
+		return &caller{policy: g.anonymous, ip: ip}, nil
+	}
+
+	name, ok := g.keys[token]
+	if !ok {
+		if g.cfg.JWTSecret == "" || strings.Count(token, ".") != 2 {
+			return nil, errors.New("invalid api key")
+		}
+		if name, err = verifyJWT(token, []byte(g.cfg.JWTSecret), time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	policy, ok := g.cfg.Clients[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown rpc client %s", name)
+	}
+	return &caller{name: name, policy: policy, ip: ip}, nil
+}
+
+// check returns an error if c can't call svc_method now
+func (g *Guard) check(c *caller, svc, method string, private bool) Error {
+	full := svc + serviceMethodSeparator + method
+	if !c.policy.allows(svc, full, private) {
+		observeRejection("forbidden")
+		return &forbiddenError{full}
+	}
+
+	if !g.limiter.allow("ip:"+c.ip, g.cfg.IPRate, g.cfg.IPBurst) {
+		observeRejection("ip_rate")
+		return &limitExceededError{fmt.Sprintf("rate limit of %s exceeded", c.ip)}
+	}
+	key := "client:" + c.name
+	if c.name == "" {
+		// anonymous callers share a policy, their rates are limited by IP
+		key = "anonymous:" + c.ip
+	}
+	if !g.limiter.allow(key, c.policy.Rate, c.policy.Burst) {
+		observeRejection("client_rate")
+		return &limitExceededError{"rate limit exceeded"}
+	}
+	return nil
+}
+
+// checkBatch returns an error if a batch of n requests is too large
+func (g *Guard) checkBatch(n int) Error {
+	if g.cfg.MaxBatchSize > 0 && n > g.cfg.MaxBatchSize {
+		observeRejection("batch")
+		return &limitExceededError{fmt.Sprintf("batch of %d requests exceeds the limit %d", n, g.cfg.MaxBatchSize)}
+	}
+	return nil
+}
+
+// checkSubscriptions returns an error if a connection with n subscriptions can't subscribe more
+func (g *Guard) checkSubscriptions(n int) Error {
+	if g.cfg.MaxSubscriptions > 0 && n >= g.cfg.MaxSubscriptions {
+		observeRejection("subscriptions")
+		return &limitExceededError{fmt.Sprintf("subscriptions of the connection exceed the limit %d", g.cfg.MaxSubscriptions)}
+	}
+	return nil
+}
+
+// allows returns whether the method full of the namespace svc is allowed,
+// the private namespaces must be allowed explicitly
+func (p *ClientPolicy) allows(svc, full string, private bool) bool {
+	for _, d := range p.Deny {
+		if d == svc || d == full {
+			return false
+		}
+	}
+	if len(p.Allow) == 0 {
+		return !private
+	}
+	for _, a := range p.Allow {
+		if a == svc || a == full || (a == "*" && !private) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyJWT checks a HS256 token and returns its subject
+func verifyJWT(token string, secret []byte, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed jwt")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return "", err
+	}
+	if header.Alg != "HS256" {
+		return "", fmt.Errorf("unsupported jwt algorithm %s", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("malformed jwt signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if subtle.ConstantTimeCompare(sig, mac.Sum(nil)) != 1 {
+		return "", errors.New("invalid jwt signature")
+	}
+
+	var claims struct {
+		Sub string `json:"sub"`
+		Exp int64  `json:"exp"`
+		Nbf int64  `json:"nbf"`
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return "", err
+	}
+	if claims.Exp != 0 && now.Unix() >= claims.Exp {
+		return "", errors.New("jwt is expired")
+	}
+	if claims.Nbf != 0 && now.Unix() < claims.Nbf {
+		return "", errors.New("jwt is not valid yet")
+	}
+	if claims.Sub == "" {
+		return "", errors.New("jwt has no subject")
+	}
+	return claims.Sub, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.New("malformed jwt")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("malformed jwt")
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testJWT(secret, claims string) string {
+	enc := base64.RawURLEncoding
+	signing := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signing))
+	return signing + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestGuard_Authenticate(t *testing.T) {
+	g, err := NewGuard(&AuthConfig{
+		JWTSecret: "secret",
+		Clients: map[string]*ClientPolicy{
+			"explorer": {APIKey: "key1"},
+			"wallet":   {},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		header, value, query string
+		name                 string
+	}{
+		{"X-API-Key", "key1", "", "explorer"},
+		{"Authorization", "Bearer key1", "", "explorer"},
+		{"", "", "apikey=key1", ""},
+		{"Authorization", "Bearer " + testJWT("secret", `{"sub":"wallet"}`), "", "wallet"},
+		{"", "", "", ""},
+		{"X-API-Key", "key2", "", ""},
+		{"Authorization", "Bearer " + testJWT("other", `{"sub":"wallet"}`), "", ""},
+		{"Authorization", "Bearer " + testJWT("secret", `{"sub":"wallet","exp":1}`), "", ""},
+		{"Authorization", "Bearer " + testJWT("secret", `{"sub":"nobody"}`), "", ""},
+	} {
+		r := httptest.NewRequest("POST", "/?"+c.query, nil)
+		if c.header != "" {
+			r.Header.Set(c.header, c.value)
+		}
+		caller, err := g.authenticate(r)
+		if c.name == "" {
+			if err == nil {
+				t.Errorf("%s %s %s: expected an error", c.header, c.value, c.query)
+			}
+			continue
+		}
+		if err != nil || caller.name != c.name {
+			t.Errorf("%s %s %s: caller %v, error %v", c.header, c.value, c.query, caller, err)
+		}
+	}
+
+	// anonymous callers are allowed without auth
+	g, _ = NewGuard(nil)
+	if caller, err := g.authenticate(httptest.NewRequest("POST", "/", nil)); err != nil || caller.name != "" {
+		t.Fatalf("anonymous caller %v, error %v", caller, err)
+	}
+
+	if _, err := NewGuard(&AuthConfig{Clients: map[string]*ClientPolicy{"a": {APIKey: "k"}, "b": {APIKey: "k"}}}); err == nil {
+		t.Fatal("duplicated api keys must be rejected")
+	}
+}
+
+func TestClientPolicy_Allows(t *testing.T) {
+	for _, c := range []struct {
+		policy  ClientPolicy
+		full    string
+		private bool
+		allowed bool
+	}{
+		{ClientPolicy{}, "ledger_getSnapshotChainHeight", false, true},
+		{ClientPolicy{}, "wallet_list", true, false},
+		{ClientPolicy{Allow: []string{"*"}}, "wallet_list", true, false},
+		{ClientPolicy{Allow: []string{"wallet"}}, "wallet_list", true, true},
+		{ClientPolicy{Allow: []string{"wallet_list"}}, "wallet_newMnemonicAndEntropyStore", true, false},
+		{ClientPolicy{Allow: []string{"ledger"}}, "net_syncInfo", false, false},
+		{ClientPolicy{Deny: []string{"ledger_getProof"}}, "ledger_getProof", false, false},
+		{ClientPolicy{Allow: []string{"*"}, Deny: []string{"ledger"}}, "ledger_getProof", false, false},
+	} {
+		svc := strings.SplitN(c.full, serviceMethodSeparator, 2)[0]
+		if allowed := c.policy.allows(svc, c.full, c.private); allowed != c.allowed {
+			t.Errorf("%+v %s: allowed %v", c.policy, c.full, allowed)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	l := newLimiter()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if !l.allow("a", 1, 3) {
+			t.Fatalf("request %d is in the burst", i)
+		}
+	}
+	if l.allow("a", 1, 3) {
+		t.Fatal("the bucket is empty")
+	}
+	if !l.allow("b", 1, 3) {
+		t.Fatal("the buckets are separated by key")
+	}
+
+	now = now.Add(time.Second)
+	if !l.allow("a", 1, 3) || l.allow("a", 1, 3) {
+		t.Fatal("a token is refilled per second")
+	}
+
+	now = now.Add(2 * bucketSweepInterval)
+	l.allow("c", 1, 3)
+	if len(l.buckets) != 1 {
+		t.Fatalf("idle buckets are not dropped: %d", len(l.buckets))
+	}
+}
+
+type PrivateService struct{}
+
+func (s *PrivateService) Secret() string {
+	return "secret"
+}
+
+func TestServer_Guard(t *testing.T) {
+	g, _ := NewGuard(&AuthConfig{
+		Clients:      map[string]*ClientPolicy{"admin": {APIKey: "admin", Allow: []string{"private"}}},
+		Anonymous:    &ClientPolicy{Rate: 1, Burst: 1},
+		MaxBatchSize: 2,
+	})
+	server := NewServer()
+	server.SetGuard(g)
+	if err := server.registerAPI(API{Namespace: "test", Service: new(Service), Public: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.registerAPI(API{Namespace: "private", Service: new(Service)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.registerAPI(API{Namespace: "test", Service: new(PrivateService)}); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(key, body string) string {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	req := func(method string) string {
+		return `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[]}`
+	}
+
+	if resp := call("", req("private_rets")); !strings.Contains(resp, "-32004") {
+		t.Fatalf("anonymous caller can call a private namespace: %s", resp)
+	}
+	if resp := call("admin", req("private_rets")); strings.Contains(resp, "error") {
+		t.Fatalf("admin can't call a private namespace: %s", resp)
+	}
+	if resp := call("", req("test_secret")); !strings.Contains(resp, "-32004") {
+		t.Fatalf("anonymous caller can call a private method of a public namespace: %s", resp)
+	}
+	if resp := call("wrong", req("test_rets")); !strings.Contains(resp, "invalid api key") {
+		t.Fatalf("invalid api key is accepted: %s", resp)
+	}
+	if resp := call("admin", "["+req("test_rets")+","+req("test_rets")+","+req("test_rets")+"]"); strings.Count(resp, "-32005") != 3 {
+		t.Fatalf("batch limit is not enforced: %s", resp)
+	}
+
+	// the burst of an anonymous caller is one request, the private methods of "test" don't hide its public ones
+	if resp := call("", req("test_rets")); strings.Contains(resp, "error") {
+		t.Fatalf("anonymous caller can't call a public method: %s", resp)
+	}
+	if resp := call("", req("test_rets")); !strings.Contains(resp, "-32005") {
+		t.Fatalf("rate limit is not enforced: %s", resp)
+	}
+}
@@ -78,3 +78,26 @@ type invalidMessageError struct{ message string }
 func (e *invalidMessageError) ErrorCode() int { return -32700 }
 
 func (e *invalidMessageError) Error() string { return e.message }
+
+// the credentials of the request are missing or invalid
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32003 }
+
+func (e *unauthorizedError) Error() string { return e.message }
+
+// the caller isn't allowed to call the method
+type forbiddenError struct{ method string }
+
+func (e *forbiddenError) ErrorCode() int { return -32004 }
+
+func (e *forbiddenError) Error() string {
+	return fmt.Sprintf("The method %s is not allowed", e.method)
+}
+
+// the caller exceeds a rate, batch or subscription limit
+type limitExceededError struct{ message string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return e.message }
@@ -235,6 +235,15 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// untilEOF and writes the response to w and order the server to process chain
 	// single request.
 	ctx := r.Context()
+	if srv.guard != nil {
+		c, err := srv.guard.authenticate(r)
+		if err != nil {
+			observeRejection("unauthorized")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ctx = context.WithValue(ctx, callerKey{}, c)
+	}
 	ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
 	ctx = context.WithValue(ctx, "scheme", r.Proto)
 	ctx = context.WithValue(ctx, "local", r.Host)
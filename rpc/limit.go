@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// interval of dropping the idle buckets
+const bucketSweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	refill time.Duration // time to refill an empty bucket
+}
+
+// limiter keeps token buckets keyed by the caller, e.g. a client name or an IP
+type limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token from the bucket of key, the bucket refills rate tokens per second up to burst.
+// A zero rate means no limit.
+func (l *limiter) allow(key string, rate float64, burst int) bool {
+	if rate <= 0 {
+		return true
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) > bucketSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now, refill: time.Duration(float64(burst) / rate * float64(time.Second))}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sweep drops the buckets which are full again
+func (l *limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if idle := now.Sub(b.last); idle > bucketSweepInterval && idle > b.refill {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,26 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+// observeCall records the latency and the failure of a call of method
+func observeCall(method string, start time.Time, failed bool) {
+	if !metrics.MetricsEnabled {
+		return
+	}
+	metrics.GetOrRegisterTimer(metrics.LabeledName("/rpc/duration", "method", method), nil).UpdateSince(start)
+	if failed {
+		metrics.GetOrRegisterCounter(metrics.LabeledName("/rpc/errors", "method", method), nil).Inc(1)
+	}
+}
+
+// observeRejection counts the requests rejected by a Guard
+func observeRejection(reason string) {
+	if !metrics.MetricsEnabled {
+		return
+	}
+	metrics.GetOrRegisterCounter(metrics.LabeledName("/rpc/rejected", "reason", reason), nil).Inc(1)
+}
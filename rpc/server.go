@@ -25,6 +25,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	log "github.com/vitelabs/go-vite/log15"
@@ -120,6 +121,57 @@ func (s *Server) RegisterName(name string, rcvr interface{}) error {
 	return nil
 }
 
+// SetGuard authenticates the callers of the HTTP and WebSocket requests and enforces the policies of g
+func (s *Server) SetGuard(g *Guard) {
+	s.guard = g
+}
+
+// registerAPI registers the service of api, the methods of a non-public api are served to the callers
+// allowed to call them explicitly if the server has a guard. The privacy is tracked per method since the
+// public and the private services of a namespace are merged.
+func (s *Server) registerAPI(api API) error {
+	if err := s.RegisterName(api.Namespace, api.Service); err != nil {
+		return err
+	}
+	if s.private == nil {
+		s.private = make(map[string]bool)
+	}
+	methods, subscriptions := suitableCallbacks(reflect.ValueOf(api.Service), reflect.TypeOf(api.Service))
+	for name := range methods {
+		s.private[api.Namespace+serviceMethodSeparator+name] = !api.Public
+	}
+	for name := range subscriptions {
+		s.private[api.Namespace+serviceMethodSeparator+name] = !api.Public
+	}
+	return nil
+}
+
+// guardRequests rejects the requests the caller in ctx can't make
+func (s *Server) guardRequests(ctx context.Context, reqs []*serverRequest, batch bool) {
+	c, ok := ctx.Value(callerKey{}).(*caller)
+	if s.guard == nil || !ok {
+		return
+	}
+
+	if batch {
+		if err := s.guard.checkBatch(len(reqs)); err != nil {
+			for _, req := range reqs {
+				req.err = err
+			}
+			return
+		}
+	}
+	for _, req := range reqs {
+		if req.err != nil || req.isUnsubscribe {
+			continue
+		}
+		method := formatName(req.callb.method.Name)
+		if err := s.guard.check(c, req.svcname, method, s.private[req.svcname+serviceMethodSeparator+method]); err != nil {
+			req.err = err
+		}
+	}
+}
+
 // serveRequest will reads requests from the codec, calls the RPC callback and
 // writes the response to the given codec.
 //
@@ -192,6 +244,8 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 			}
 			return nil
 		}
+		s.guardRequests(ctx, reqs, batch)
+
 		// If chain single shot request is executing, run and return immediately
 		if singleShot {
 			if batch {
@@ -283,6 +337,11 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	if req.callb.isSubscribe {
+		if notifier, supported := NotifierFromContext(ctx); supported && s.guard != nil {
+			if err := s.guard.checkSubscriptions(notifier.count()); err != nil {
+				return codec.CreateErrorResponse(&req.id, err), nil
+			}
+		}
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
 			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
@@ -321,7 +380,10 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		}
 	}()
 	// execute RPC method and return result
+	start := time.Now()
 	reply := req.callb.method.Func.Call(arguments)
+	observeCall(req.svcname+serviceMethodSeparator+formatName(req.callb.method.Name), start,
+		req.callb.errPos >= 0 && !reply[req.callb.errPos].IsNil())
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
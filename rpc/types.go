@@ -66,6 +66,8 @@ type subscriptions map[string]*callback  // collection of subscription callbacks
 // Server represents a RPC server
 type Server struct {
 	services serviceRegistry
+	private  map[string]bool // methods of the APIs which aren't public, keyed by namespace_method
+	guard    *Guard
 
 	run      int32
 	codecsMu sync.Mutex
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+type ConsensusApi struct {
+	cs  consensus.Consensus
+	log log15.Logger
+}
+
+func NewConsensusApi(vite *vite.Vite) *ConsensusApi {
+	return &ConsensusApi{
+		cs:  vite.Consensus(),
+		log: log15.New("module", "rpc_api/consensus_api"),
+	}
+}
+
+func (c ConsensusApi) String() string {
+	return "ConsensusApi"
+}
+
+type ProducerSlot struct {
+	Address   types.Address `json:"address"`
+	StartTime int64         `json:"startTime"`
+	EndTime   int64         `json:"endTime"`
+}
+
+type PeriodSchedule struct {
+	Gid        types.Gid       `json:"gid"`
+	Index      uint64          `json:"index"`
+	StartTime  int64           `json:"startTime"`
+	EndTime    int64           `json:"endTime"`
+	VoteTime   int64           `json:"voteTime"`
+	Determined bool            `json:"determined"`
+	Slots      []*ProducerSlot `json:"slots"`
+}
+
+func newPeriodSchedule(source *consensus.PeriodSchedule) *PeriodSchedule {
+	target := &PeriodSchedule{
+		Gid:        source.Gid,
+		Index:      source.Index,
+		StartTime:  source.STime.Unix(),
+		EndTime:    source.ETime.Unix(),
+		VoteTime:   source.VoteTime.Unix(),
+		Determined: source.Determined,
+		Slots:      make([]*ProducerSlot, len(source.Plans)),
+	}
+	for i, plan := range source.Plans {
+		target.Slots[i] = &ProducerSlot{
+			Address:   plan.Member,
+			StartTime: plan.STime.Unix(),
+			EndTime:   plan.ETime.Unix(),
+		}
+	}
+	return target
+}
+
+// GetSchedule returns the planned producers slot by slot for every period between fromTime and toTime(unix seconds).
+// The slots of a period are empty if the period is not determined yet.
+func (c ConsensusApi) GetSchedule(gid types.Gid, fromTime int64, toTime int64) ([]*PeriodSchedule, error) {
+	schedules, err := c.cs.ReadSchedule(gid, time.Unix(fromTime, 0), time.Unix(toTime, 0))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*PeriodSchedule, len(schedules))
+	for i, schedule := range schedules {
+		result[i] = newPeriodSchedule(schedule)
+	}
+	return result, nil
+}
+
+type SimulationVote struct {
+	Name     string        `json:"name"`
+	Address  types.Address `json:"address"`
+	Votes    string        `json:"votes"`
+	Promoted bool          `json:"promoted"`
+	Demoted  bool          `json:"demoted"`
+}
+
+type ElectionSimulation struct {
+	Index       uint64            `json:"index"`
+	ProofHash   types.Hash        `json:"proofHash"`
+	ProofHeight uint64            `json:"proofHeight"`
+	Determined  bool              `json:"determined"`
+	Line        *string           `json:"line"`
+	Ranking     []*SimulationVote `json:"ranking"`
+	Producers   []*SimulationVote `json:"producers"`
+}
+
+func newSimulationVotes(votes []*core.Vote) []*SimulationVote {
+	result := make([]*SimulationVote, len(votes))
+	for i, v := range votes {
+		target := &SimulationVote{Name: v.Name, Address: v.Addr, Votes: *bigIntToString(v.Balance)}
+		for _, t := range v.Type {
+			switch t {
+			case core.SUCCESS_RATE_PROMOTION, core.RANDOM_PROMOTION:
+				target.Promoted = true
+			case core.SUCCESS_RATE_DEMOTION:
+				target.Demoted = true
+			}
+		}
+		result[i] = target
+	}
+	return result
+}
+
+// SimulateElection re-runs the SBP election of the index, the votes of SBPs in voteOverrides(SBP name -> votes) are replaced.
+func (c ConsensusApi) SimulateElection(index uint64, voteOverrides map[string]string) (*ElectionSimulation, error) {
+	overrides := make(map[string]*big.Int, len(voteOverrides))
+	for name, votes := range voteOverrides {
+		v := votes
+		amount, err := stringToBigInt(&v)
+		if err != nil {
+			return nil, err
+		}
+		overrides[name] = amount
+	}
+	result, err := c.cs.API().SimulateElection(index, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return &ElectionSimulation{
+		Index:       result.Index,
+		ProofHash:   result.ProofHash,
+		ProofHeight: result.ProofHeight,
+		Determined:  result.Determined,
+		Line:        bigIntToString(result.Line),
+		Ranking:     newSimulationVotes(result.Ranking),
+		Producers:   newSimulationVotes(result.Producers),
+	}, nil
+}
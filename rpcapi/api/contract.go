@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/vm"
 	"github.com/vitelabs/go-vite/vm/abi"
 	"github.com/vitelabs/go-vite/vm/util"
 	"strings"
@@ -109,3 +110,30 @@ func (c *ContractApi) GetCallOffChainData(abiStr string, offChainName string, pa
 	}
 	return abiContract.PackOffChain(offChainName, arguments...)
 }
+
+type ContractDisassembly struct {
+	*vm.Disassembly
+	Assembly string `json:"assembly"`
+}
+
+// Disassemble returns the annotated assembly of the code of a contract, the function
+// selectors are matched against the methods of abiStr if it is given
+func (c *ContractApi) Disassemble(addr types.Address, abiStr *string) (*ContractDisassembly, error) {
+	code, err := c.chain.GetContractCode(addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, errors.New("contract code not found")
+	}
+	var contractAbi *abi.ABIContract
+	if abiStr != nil && len(*abiStr) > 0 {
+		abiContract, err := abi.JSONToABIContract(strings.NewReader(*abiStr))
+		if err != nil {
+			return nil, err
+		}
+		contractAbi = &abiContract
+	}
+	d := vm.Disassemble(code, contractAbi)
+	return &ContractDisassembly{Disassembly: d, Assembly: d.String()}, nil
+}
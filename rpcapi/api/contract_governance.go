@@ -0,0 +1,392 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+const maxGovernancePageSize = 1000
+
+type GovernanceQueryParams struct {
+	SnapshotHash *types.Hash `json:"snapshotHash"` // latest snapshot block if nil
+	Gid          *types.Gid  `json:"gid"`          // snapshot consensus group if nil
+	PageIndex    int         `json:"pageIndex"`
+	PageSize     int         `json:"pageSize"`
+	OrderBy      string      `json:"orderBy"`
+	Desc         bool        `json:"desc"`
+}
+
+func (params *GovernanceQueryParams) gid() types.Gid {
+	if params.Gid == nil {
+		return types.SNAPSHOT_GID
+	}
+	return *params.Gid
+}
+
+func (params *GovernanceQueryParams) offset() (int, error) {
+	if params.PageSize <= 0 || params.PageSize > maxGovernancePageSize {
+		return 0, errors.New("invalid pageSize")
+	}
+	if params.PageIndex < 0 || params.PageIndex >= math.MaxInt32/params.PageSize {
+		return 0, errors.New("invalid pageIndex")
+	}
+	return params.PageIndex * params.PageSize, nil
+}
+
+// page returns the range of the page in a list of total items
+func (params *GovernanceQueryParams) page(total int) (int, int, error) {
+	start, err := params.offset()
+	if err != nil {
+		return 0, 0, err
+	}
+	end := start + params.PageSize
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	return start, end, nil
+}
+
+// sort sorts a list by the less function of params.OrderBy, ties are kept in order
+func (params *GovernanceQueryParams) sort(length int, swap func(i, j int), lessFuncs map[string]func(i, j int) bool, defaultOrderBy string) error {
+	orderBy := params.OrderBy
+	if orderBy == "" {
+		orderBy = defaultOrderBy
+	}
+	less, ok := lessFuncs[orderBy]
+	if !ok {
+		return errors.New("invalid orderBy " + orderBy)
+	}
+	if params.Desc {
+		sort.Stable(&lessSwapper{length, func(i, j int) bool { return less(j, i) }, swap})
+	} else {
+		sort.Stable(&lessSwapper{length, less, swap})
+	}
+	return nil
+}
+
+type lessSwapper struct {
+	length int
+	less   func(i, j int) bool
+	swap   func(i, j int)
+}
+
+func (s *lessSwapper) Len() int           { return s.length }
+func (s *lessSwapper) Less(i, j int) bool { return s.less(i, j) }
+func (s *lessSwapper) Swap(i, j int)      { s.swap(i, j) }
+
+func (r *ContractApi) getGovernanceSnapshot(snapshotHash *types.Hash) (*ledger.SnapshotBlock, error) {
+	if snapshotHash == nil {
+		return r.chain.GetLatestSnapshotBlock(), nil
+	}
+	sb, err := r.chain.GetSnapshotHeaderByHash(*snapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil {
+		return nil, errors.New("snapshot block not found")
+	}
+	return sb, nil
+}
+
+func (r *ContractApi) getVoteHistoryPlugin() (*chain_plugins.VoteHistory, error) {
+	plugins := r.chain.Plugins()
+	if plugins == nil {
+		return nil, errors.New("config.OpenPlugins is false, api can't work")
+	}
+	plugin, ok := plugins.GetPlugin("voteHistory").(*chain_plugins.VoteHistory)
+	if !ok || plugin == nil {
+		return nil, errors.New("plugins-VoteHistory's service not provided, set VoteHistoryPlugin to true")
+	}
+	if err := plugins.CheckIndex("voteHistory"); err != nil {
+		return nil, err
+	}
+	return plugin, nil
+}
+
+// getVoteWeights returns the votes of all voters in the consensus group and their weights at the snapshot block
+func (r *ContractApi) getVoteWeights(sb *ledger.SnapshotBlock, gid types.Gid) ([]*types.VoteInfo, map[types.Address]*big.Int, error) {
+	group, err := r.chain.GetConsensusGroup(sb.Hash, gid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if group == nil {
+		return nil, nil, errors.New("consensus group not found")
+	}
+	votes, err := r.chain.GetVoteList(sb.Hash, gid)
+	if err != nil {
+		return nil, nil, err
+	}
+	addrList := make([]types.Address, len(votes))
+	for i, v := range votes {
+		addrList[i] = v.VoteAddr
+	}
+	weights, err := r.chain.GetConfirmedBalanceList(addrList, group.CountingTokenId, sb.Hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return votes, weights, nil
+}
+
+type SBPInfoPage struct {
+	SnapshotHeight string     `json:"snapshotHeight"`
+	Count          int        `json:"totalCount"`
+	List           []*SBPInfo `json:"sbpList"`
+}
+
+// GetSBPListByPage returns a page of the registrations, including the revoked ones, at the snapshot block.
+// The list is ordered by "name", "stakeAmount" or "expirationHeight".
+func (r *ContractApi) GetSBPListByPage(params GovernanceQueryParams) (*SBPInfoPage, error) {
+	sb, err := r.getGovernanceSnapshot(params.SnapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.chain.GetAllRegisterList(sb.Hash, params.gid())
+	if err != nil {
+		return nil, err
+	}
+	err = params.sort(len(list), func(i, j int) { list[i], list[j] = list[j], list[i] }, map[string]func(i, j int) bool{
+		"name":             func(i, j int) bool { return list[i].Name < list[j].Name },
+		"stakeAmount":      func(i, j int) bool { return list[i].Amount.Cmp(list[j].Amount) < 0 },
+		"expirationHeight": func(i, j int) bool { return list[i].ExpirationHeight < list[j].ExpirationHeight },
+	}, "name")
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := params.page(len(list))
+	if err != nil {
+		return nil, err
+	}
+	targetList := make([]*SBPInfo, 0, end-start)
+	for _, info := range list[start:end] {
+		targetList = append(targetList, newSBPInfo(info, sb))
+	}
+	return &SBPInfoPage{Uint64ToString(sb.Height), len(list), targetList}, nil
+}
+
+type SBPVotes struct {
+	Name                  string        `json:"sbpName"`
+	BlockProducingAddress types.Address `json:"blockProducingAddress"`
+	IsActive              bool          `json:"isActive"`
+	Votes                 string        `json:"votes"`
+	VoterCount            int           `json:"voterCount"`
+
+	votes *big.Int
+}
+
+type SBPVotesPage struct {
+	SnapshotHeight string      `json:"snapshotHeight"`
+	Count          int         `json:"totalCount"`
+	List           []*SBPVotes `json:"sbpVoteList"`
+}
+
+// GetSBPVoteListByPage returns a page of the votes of registrations at the snapshot block.
+// The list is ordered by "votes", "voterCount" or "name", the largest votes first by default.
+func (r *ContractApi) GetSBPVoteListByPage(params GovernanceQueryParams) (*SBPVotesPage, error) {
+	sb, err := r.getGovernanceSnapshot(params.SnapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	registrations, err := r.chain.GetAllRegisterList(sb.Hash, params.gid())
+	if err != nil {
+		return nil, err
+	}
+	votes, weights, err := r.getVoteWeights(sb, params.gid())
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*SBPVotes, len(registrations))
+	sbpMap := make(map[string]*SBPVotes, len(registrations))
+	for i, info := range registrations {
+		list[i] = &SBPVotes{Name: info.Name, BlockProducingAddress: info.BlockProducingAddress, IsActive: info.IsActive(), votes: big.NewInt(0)}
+		sbpMap[info.Name] = list[i]
+	}
+	for _, v := range votes {
+		if sbp, ok := sbpMap[v.SbpName]; ok {
+			sbp.VoterCount++
+			if weight, ok := weights[v.VoteAddr]; ok {
+				sbp.votes.Add(sbp.votes, weight)
+			}
+		}
+	}
+
+	if params.OrderBy == "" {
+		params.OrderBy, params.Desc = "votes", true
+	}
+	err = params.sort(len(list), func(i, j int) { list[i], list[j] = list[j], list[i] }, map[string]func(i, j int) bool{
+		"votes":      func(i, j int) bool { return list[i].votes.Cmp(list[j].votes) < 0 },
+		"voterCount": func(i, j int) bool { return list[i].VoterCount < list[j].VoterCount },
+		"name":       func(i, j int) bool { return list[i].Name < list[j].Name },
+	}, "votes")
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := params.page(len(list))
+	if err != nil {
+		return nil, err
+	}
+	for _, sbp := range list[start:end] {
+		sbp.Votes = *bigIntToString(sbp.votes)
+	}
+	return &SBPVotesPage{Uint64ToString(sb.Height), len(list), list[start:end]}, nil
+}
+
+type SBPVoter struct {
+	Address types.Address `json:"address"`
+	Votes   string        `json:"votes"`
+	// snapshot block confirming the vote, empty if the vote is not in the vote history
+	VoteSnapshotHeight string `json:"voteSnapshotHeight,omitempty"`
+	VoteTime           int64  `json:"voteTime,omitempty"`
+
+	votes *big.Int
+}
+
+type SBPVoterPage struct {
+	SnapshotHeight string      `json:"snapshotHeight"`
+	Count          int         `json:"totalCount"`
+	List           []*SBPVoter `json:"voterList"`
+}
+
+// GetSBPVoterListByPage returns a page of the voters of an sbp at the snapshot block and since when
+// they vote for it if the vote history plugin is open. The list is ordered by "votes", "address" or
+// "voteTime", the largest votes first by default.
+func (r *ContractApi) GetSBPVoterListByPage(name string, params GovernanceQueryParams) (*SBPVoterPage, error) {
+	sb, err := r.getGovernanceSnapshot(params.SnapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	votes, weights, err := r.getVoteWeights(sb, params.gid())
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*SBPVoter, 0)
+	for _, v := range votes {
+		if v.SbpName != name {
+			continue
+		}
+		voter := &SBPVoter{Address: v.VoteAddr, votes: big.NewInt(0)}
+		if weight, ok := weights[v.VoteAddr]; ok {
+			voter.votes = weight
+		}
+		list = append(list, voter)
+	}
+
+	if plugin, _ := r.getVoteHistoryPlugin(); plugin != nil {
+		for _, voter := range list {
+			record, err := plugin.GetLatestVote(voter.Address, params.gid(), sb.Height)
+			if err != nil {
+				return nil, err
+			}
+			if record != nil && record.SbpName == name {
+				voter.VoteSnapshotHeight = Uint64ToString(record.SnapshotHeight)
+				voter.VoteTime = record.Timestamp
+			}
+		}
+	}
+
+	if params.OrderBy == "" {
+		params.OrderBy, params.Desc = "votes", true
+	}
+	err = params.sort(len(list), func(i, j int) { list[i], list[j] = list[j], list[i] }, map[string]func(i, j int) bool{
+		"votes":    func(i, j int) bool { return list[i].votes.Cmp(list[j].votes) < 0 },
+		"address":  func(i, j int) bool { return list[i].Address.String() < list[j].Address.String() },
+		"voteTime": func(i, j int) bool { return list[i].VoteTime < list[j].VoteTime },
+	}, "votes")
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := params.page(len(list))
+	if err != nil {
+		return nil, err
+	}
+	for _, voter := range list[start:end] {
+		voter.Votes = *bigIntToString(voter.votes)
+	}
+	return &SBPVoterPage{Uint64ToString(sb.Height), len(list), list[start:end]}, nil
+}
+
+type VoteRecord struct {
+	Voter types.Address `json:"voter"`
+	// voted sbp, empty if the vote is cancelled
+	SbpName string `json:"sbpName"`
+	// sbp voted before, empty if unknown
+	PrevSbpName      string     `json:"prevSbpName"`
+	SnapshotHeight   string     `json:"snapshotHeight"`
+	Timestamp        int64      `json:"timestamp"`
+	SendBlockHash    types.Hash `json:"sendBlockHash"`
+	ReceiveBlockHash types.Hash `json:"receiveBlockHash"`
+}
+
+type VoteRecordPage struct {
+	SnapshotHeight string        `json:"snapshotHeight"`
+	Count          int           `json:"totalCount"`
+	List           []*VoteRecord `json:"voteRecordList"`
+}
+
+func newVoteRecordPage(sb *ledger.SnapshotBlock, records []*chain_plugins.VoteRecord, total int) *VoteRecordPage {
+	list := make([]*VoteRecord, len(records))
+	for i, record := range records {
+		list[i] = &VoteRecord{
+			Voter:            record.Voter,
+			SbpName:          record.SbpName,
+			PrevSbpName:      record.PrevSbpName,
+			SnapshotHeight:   Uint64ToString(record.SnapshotHeight),
+			Timestamp:        record.Timestamp,
+			SendBlockHash:    record.SendBlockHash,
+			ReceiveBlockHash: record.ReceiveBlockHash,
+		}
+	}
+	return &VoteRecordPage{Uint64ToString(sb.Height), total, list}
+}
+
+// GetSBPVoteHistory returns a page of the votes for and the votes leaving an sbp confirmed
+// at or before the snapshot block, the latest first. It requires the vote history plugin.
+func (r *ContractApi) GetSBPVoteHistory(name string, params GovernanceQueryParams) (*VoteRecordPage, error) {
+	plugin, err := r.getVoteHistoryPlugin()
+	if err != nil {
+		return nil, err
+	}
+	sb, err := r.getGovernanceSnapshot(params.SnapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := params.offset()
+	if err != nil {
+		return nil, err
+	}
+	records, total, err := plugin.GetVoteHistoryBySBP(name, params.gid(), sb.Height, offset, params.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return newVoteRecordPage(sb, records, total), nil
+}
+
+// GetVoterVoteHistory returns a page of the vote changes of a voter confirmed at or before
+// the snapshot block, the latest first. It requires the vote history plugin.
+func (r *ContractApi) GetVoterVoteHistory(voter types.Address, params GovernanceQueryParams) (*VoteRecordPage, error) {
+	plugin, err := r.getVoteHistoryPlugin()
+	if err != nil {
+		return nil, err
+	}
+	sb, err := r.getGovernanceSnapshot(params.SnapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := params.offset()
+	if err != nil {
+		return nil, err
+	}
+	records, total, err := plugin.GetVoteHistoryByVoter(voter, params.gid(), sb.Height, offset, params.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return newVoteRecordPage(sb, records, total), nil
+}
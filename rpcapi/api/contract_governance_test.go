@@ -0,0 +1,44 @@
+package api
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGovernanceQueryParams(t *testing.T) {
+	list := []int{3, 1, 2, 1}
+	lessFuncs := map[string]func(i, j int) bool{"value": func(i, j int) bool { return list[i] < list[j] }}
+	swap := func(i, j int) { list[i], list[j] = list[j], list[i] }
+
+	params := &GovernanceQueryParams{PageIndex: 1, PageSize: 3, Desc: true}
+	if err := params.sort(len(list), swap, lessFuncs, "value"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(list, []int{3, 2, 1, 1}) {
+		t.Fatalf("sorted list not match, %v", list)
+	}
+	if start, end, err := params.page(len(list)); err != nil || start != 3 || end != 4 {
+		t.Fatalf("page not match, [%v, %v), err %v", start, end, err)
+	}
+
+	params = &GovernanceQueryParams{PageIndex: 2, PageSize: 3, OrderBy: "name"}
+	if err := params.sort(len(list), swap, lessFuncs, "value"); err == nil {
+		t.Fatal("expected invalid orderBy error")
+	}
+	if start, end, err := params.page(len(list)); err != nil || start != 4 || end != 4 {
+		t.Fatalf("page out of range not match, [%v, %v), err %v", start, end, err)
+	}
+	params.PageSize = 0
+	if _, _, err := params.page(len(list)); err == nil {
+		t.Fatal("expected invalid page size error")
+	}
+	params.PageSize = maxGovernancePageSize + 1
+	if _, _, err := params.page(len(list)); err == nil {
+		t.Fatal("expected page size limit error")
+	}
+	params.PageIndex, params.PageSize = math.MaxInt64/2, 2
+	if _, _, err := params.page(len(list)); err == nil {
+		t.Fatal("expected invalid page index error")
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/state"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	defaultOffChainCallTimeout = 1000 // milliseconds
+	maxOffChainCallTimeout     = 5000 // milliseconds
+)
+
+const (
+	OffChainCallErrRevert        = "revert"
+	OffChainCallErrOutOfQuota    = "outOfQuota"
+	OffChainCallErrInvalidOpCode = "invalidOpcode"
+	OffChainCallErrTimeout       = "timeout"
+	OffChainCallErrCanceled      = "canceled"
+	OffChainCallErrPanic         = "panic"
+	OffChainCallErrExecution     = "executionError"
+)
+
+type CallOffChainMethodV2Param struct {
+	Addr         types.Address `json:"address"`
+	Code         []byte        `json:"code"`
+	Data         []byte        `json:"data"`
+	SnapshotHash *types.Hash   `json:"snapshotHash"` // the latest snapshot block if nil
+	Quota        *uint64       `json:"quota"`        // vm.OffChainReaderQuota if nil, no more than it
+	Timeout      *uint64       `json:"timeout"`      // milliseconds, 1000 if nil, no more than 5000
+}
+
+type OffChainCallError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type CallOffChainMethodResult struct {
+	// return data of the offchain method, or the revert data if error type is revert
+	ReturnData     []byte             `json:"returnData"`
+	QuotaUsed      string             `json:"quotaUsed"`
+	SnapshotHash   types.Hash         `json:"snapshotHash"`
+	SnapshotHeight string             `json:"snapshotHeight"`
+	Error          *OffChainCallError `json:"error,omitempty"`
+}
+
+func (param *CallOffChainMethodV2Param) limits() (uint64, time.Duration, error) {
+	quota := vm.OffChainReaderQuota
+	if param.Quota != nil {
+		if *param.Quota == 0 || *param.Quota > vm.OffChainReaderQuota {
+			return 0, 0, errors.New("invalid quota")
+		}
+		quota = *param.Quota
+	}
+	timeout := uint64(defaultOffChainCallTimeout)
+	if param.Timeout != nil {
+		if *param.Timeout == 0 || *param.Timeout > maxOffChainCallTimeout {
+			return 0, 0, errors.New("invalid timeout")
+		}
+		timeout = *param.Timeout
+	}
+	return quota, time.Duration(timeout) * time.Millisecond, nil
+}
+
+// CallOffChainMethodV2 executes an offchain method on the state of an account as of a snapshot block,
+// bounded by a quota and a timeout. Execution failures are returned in the error field of the result.
+func (c *ContractApi) CallOffChainMethodV2(ctx context.Context, param CallOffChainMethodV2Param) (*CallOffChainMethodResult, error) {
+	quota, timeout, err := param.limits()
+	if err != nil {
+		return nil, err
+	}
+	sb := c.chain.GetLatestSnapshotBlock()
+	if param.SnapshotHash != nil {
+		if sb, err = c.chain.GetSnapshotHeaderByHash(*param.SnapshotHash); err != nil {
+			return nil, err
+		}
+		if sb == nil {
+			return nil, errors.New("snapshot block " + param.SnapshotHash.String() + " not found")
+		}
+	}
+	prevHash, err := getConfirmedPrevBlockHash(c.chain, param.Addr, sb.Height)
+	if err != nil {
+		return nil, err
+	}
+	db, err := newSnapshotVmDb(c.chain, param.Addr, sb.Hash, prevHash)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	returnData, quotaUsed, err := vm.NewVM(nil).OffChainReaderWithContext(ctx, db, param.Code, param.Data, quota)
+	result := &CallOffChainMethodResult{
+		ReturnData:     returnData,
+		QuotaUsed:      Uint64ToString(quotaUsed),
+		SnapshotHash:   sb.Hash,
+		SnapshotHeight: Uint64ToString(sb.Height),
+	}
+	if err != nil {
+		result.Error = &OffChainCallError{Type: offChainCallErrType(ctx, err), Message: err.Error()}
+	}
+	return result, nil
+}
+
+func offChainCallErrType(ctx context.Context, err error) string {
+	switch err {
+	case util.ErrExecutionReverted:
+		return OffChainCallErrRevert
+	case util.ErrOutOfQuota:
+		return OffChainCallErrOutOfQuota
+	case util.ErrInvalidOpCode:
+		return OffChainCallErrInvalidOpCode
+	case util.ErrOffChainReaderPanic:
+		return OffChainCallErrPanic
+	case util.ErrExecutionCanceled:
+		if ctx.Err() == context.DeadlineExceeded {
+			return OffChainCallErrTimeout
+		}
+		return OffChainCallErrCanceled
+	default:
+		return OffChainCallErrExecution
+	}
+}
+
+// getConfirmedPrevBlockHash returns the hash of the latest account block confirmed by the snapshot block
+// of snapshotHeight, or an empty hash if there is none
+func getConfirmedPrevBlockHash(c chain.Chain, addr types.Address, snapshotHeight uint64) (*types.Hash, error) {
+	latestHeight, err := c.GetLatestAccountHeight(addr)
+	if err != nil {
+		return nil, err
+	}
+	// account blocks are confirmed in order of height, find the highest confirmed one
+	low, high := uint64(0), latestHeight
+	for low < high {
+		mid := high - (high-low)/2
+		hash, err := c.GetAccountBlockHashByHeight(addr, mid)
+		if err != nil {
+			return nil, err
+		}
+		if hash == nil {
+			return nil, errors.New("account block not found")
+		}
+		confirmSb, err := c.GetConfirmSnapshotHeaderByAbHash(*hash)
+		if err != nil {
+			return nil, err
+		}
+		if confirmSb != nil && confirmSb.Height <= snapshotHeight {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	if low == 0 {
+		return &types.Hash{}, nil
+	}
+	return c.GetAccountBlockHashByHeight(addr, low)
+}
+
+// snapshotVmDb reads storage and balances of the account as of the latest snapshot block of the db
+// instead of the latest state
+type snapshotVmDb struct {
+	vm_db.VmDb
+	chain        chain.Chain
+	storage      chain_state.StorageDatabaseInterface
+	snapshotHash types.Hash
+}
+
+func newSnapshotVmDb(c chain.Chain, addr types.Address, snapshotHash types.Hash, prevHash *types.Hash) (vm_db.VmDb, error) {
+	db, err := vm_db.NewVmDb(c, &addr, &snapshotHash, prevHash)
+	if err != nil {
+		return nil, err
+	}
+	_, _, stateDB := c.DBs()
+	storage, err := stateDB.NewStorageDatabase(snapshotHash, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotVmDb{VmDb: db, chain: c, storage: storage, snapshotHash: snapshotHash}, nil
+}
+
+func (db *snapshotVmDb) GetValue(key []byte) ([]byte, error) {
+	return db.storage.GetValue(key)
+}
+
+func (db *snapshotVmDb) GetOriginalValue(key []byte) ([]byte, error) {
+	return db.storage.GetValue(key)
+}
+
+func (db *snapshotVmDb) NewStorageIterator(prefix []byte) (interfaces.StorageIterator, error) {
+	return db.storage.NewStorageIterator(prefix)
+}
+
+func (db *snapshotVmDb) GetBalance(tokenTypeId *types.TokenTypeId) (*big.Int, error) {
+	balanceMap, err := db.chain.GetConfirmedBalanceList([]types.Address{*db.Address()}, *tokenTypeId, db.snapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	if balance, ok := balanceMap[*db.Address()]; ok && balance != nil {
+		return balance, nil
+	}
+	return big.NewInt(0), nil
+}
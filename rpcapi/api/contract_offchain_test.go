@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+func TestCallOffChainMethodV2Limits(t *testing.T) {
+	quota, timeout, err := (&CallOffChainMethodV2Param{}).limits()
+	if err != nil || quota != vm.OffChainReaderQuota || timeout != time.Second {
+		t.Fatalf("default limits not match, %v, %v, %v", quota, timeout, err)
+	}
+	tooMuch, zero := vm.OffChainReaderQuota+1, uint64(0)
+	if _, _, err := (&CallOffChainMethodV2Param{Quota: &tooMuch}).limits(); err == nil {
+		t.Fatal("expected invalid quota error")
+	}
+	if _, _, err := (&CallOffChainMethodV2Param{Timeout: &zero}).limits(); err == nil {
+		t.Fatal("expected invalid timeout error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for err, errType := range map[error]string{
+		util.ErrExecutionReverted: OffChainCallErrRevert,
+		util.ErrOutOfQuota:        OffChainCallErrOutOfQuota,
+		util.ErrInvalidOpCode:     OffChainCallErrInvalidOpCode,
+		util.ErrExecutionCanceled: OffChainCallErrCanceled,
+		util.ErrStackUnderflow:    OffChainCallErrExecution,
+	} {
+		if got := offChainCallErrType(ctx, err); got != errType {
+			t.Fatalf("error type of %v not match, expected %v, got %v", err, errType, got)
+		}
+	}
+}
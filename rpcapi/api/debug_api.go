@@ -213,10 +213,10 @@ func (api DebugApi) peersDetails() map[string]interface{} {
 }
 
 func (api DebugApi) GetForkInfo() config.ForkPoints {
-	return fork.GetForkPoints()
+	return api.v.Chain().ForkSchedule().GetForkPoints()
 }
 func (api DebugApi) GetRecentActiveFork() *fork.ForkPointItem {
-	return fork.GetRecentActiveFork(api.v.Chain().GetLatestSnapshotBlock().Height)
+	return api.v.Chain().ForkSchedule().GetRecentActiveFork(api.v.Chain().GetLatestSnapshotBlock().Height)
 }
 
 func (api DebugApi) GetOnRoadInfoUnconfirmed(addr types.Address) ([]*types.Hash, error) {
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/producer"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+var ErrDevModeDisabled = errors.New("dev mode is not enabled")
+
+// DevApi controls the instant-seal producer of a node started with --dev.
+type DevApi struct {
+	vite *vite.Vite
+	log  log15.Logger
+}
+
+func NewDevApi(vite *vite.Vite) *DevApi {
+	return &DevApi{
+		vite: vite,
+		log:  log15.New("module", "rpc_api/dev_api"),
+	}
+}
+
+func (d DevApi) String() string {
+	return "DevApi"
+}
+
+func (d DevApi) producer() (producer.DevProducer, error) {
+	p, ok := d.vite.Producer().(producer.DevProducer)
+	if !ok {
+		return nil, ErrDevModeDisabled
+	}
+	return p, nil
+}
+
+// Mine seals count snapshot blocks immediately and returns them.
+func (d DevApi) Mine(count int) ([]*ledger.HashHeight, error) {
+	p, err := d.producer()
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := p.Mine(count)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ledger.HashHeight, len(blocks))
+	for i, b := range blocks {
+		result[i] = &ledger.HashHeight{Hash: b.Hash, Height: b.Height}
+	}
+	return result, nil
+}
+
+// SetTime sets the timestamp(unix seconds) of the next snapshot block.
+func (d DevApi) SetTime(timestamp int64) error {
+	p, err := d.producer()
+	if err != nil {
+		return err
+	}
+	return p.SetTime(time.Unix(timestamp, 0))
+}
+
+// Snapshot returns an id of the current ledger, which is the latest snapshot height.
+func (d DevApi) Snapshot() (uint64, error) {
+	p, err := d.producer()
+	if err != nil {
+		return 0, err
+	}
+	return p.Snapshot(), nil
+}
+
+// Revert rolls the ledger back to the snapshot id, blocks after it are discarded.
+func (d DevApi) Revert(id uint64) error {
+	p, err := d.producer()
+	if err != nil {
+		return err
+	}
+	d.log.Info("revert", "id", id)
+	return p.Revert(id)
+}
@@ -45,6 +45,7 @@ type subscription struct {
 type EventSystem struct {
 	vite      *vite.Vite
 	chain     *ChainSubscribe
+	stakes    *api.StakePositionReader  // shared by the stake subscriptions
 	install   chan *subscription        // install filter
 	uninstall chan *subscription        // remove filter
 	acCh      chan []*AccountChainEvent // Channel to receive new account chain event
@@ -67,6 +68,7 @@ const (
 func NewEventSystem(v *vite.Vite) *EventSystem {
 	es := &EventSystem{
 		vite:      v,
+		stakes:    api.NewStakePositionReader(v.Chain()),
 		acCh:      make(chan []*AccountChainEvent, acChanSize),
 		acDelCh:   make(chan []*AccountChainEvent, acDelChanSize),
 		sbCh:      make(chan []*SnapshotChainEvent, sbChanSize),
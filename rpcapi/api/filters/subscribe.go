@@ -6,6 +6,7 @@ import (
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/rpc"
 	"github.com/vitelabs/go-vite/rpcapi/api"
 	"github.com/vitelabs/go-vite/vite"
@@ -677,6 +678,93 @@ func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map
 	return rpcSub, nil
 }
 
+// CreateBlockStatusSubscription notifies the status changes of account blocks in the pool, such as queued, dropped,
+// inserted, confirmed and rolled back. Only changes of the address are sent if it is not nil.
+func (s *SubscribeApi) CreateBlockStatusSubscription(ctx context.Context, addr *types.Address) (*rpc.Subscription, error) {
+	s.log.Info("createBlockStatusSubscription")
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	statusCh := make(chan *pool.AccountBlockStatus, 128)
+	subID := s.vite.Pool().SubscribeAccountBlockStatus(func(status *pool.AccountBlockStatus) {
+		if addr != nil && (status.Address == nil || *status.Address != *addr) {
+			return
+		}
+		// the callback is called by the pool, never block it
+		select {
+		case statusCh <- status:
+		default:
+			s.log.Warn("block status subscription is full, drop status", "id", rpcSub.ID, "hash", status.Hash, "status", status.Status)
+		}
+	})
+
+	go func() {
+		defer s.vite.Pool().UnsubscribeAccountBlockStatus(subID)
+		for {
+			select {
+			case status := <-statusCh:
+				notifier.Notify(rpcSub.ID, api.ToBlockStatus(status))
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// CreateStakeSubscription notifies the stake events of an address in the quota contract and the dex fund contract,
+// such as created, confirmed by the callback of a delegated stake, withdrawable and withdrawn. The stakes are checked
+// as of every inserted or rolled back snapshot block, the stakes at subscription time don't produce events.
+func (s *SubscribeApi) CreateStakeSubscription(ctx context.Context, addr types.Address) (*rpc.Subscription, error) {
+	s.log.Info("createStakeSubscription")
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	c := s.vite.Chain()
+	sb := c.GetLatestSnapshotBlock()
+	positions, err := s.eventSystem.stakes.GetStakePositions(addr, sb)
+	if err != nil {
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		snapshotBlockCh := make(chan []*SnapshotBlock, 128)
+		sbSub := s.eventSystem.SubscribeSnapshotBlocks(snapshotBlockCh, SnapshotBlocksSubscriptionV2)
+		for {
+			select {
+			case <-snapshotBlockCh:
+				latest := c.GetLatestSnapshotBlock()
+				if latest.Hash == sb.Hash {
+					continue
+				}
+				current, err := s.eventSystem.stakes.GetStakePositions(addr, latest)
+				if err != nil {
+					s.log.Error("get stake positions failed", "addr", addr, "height", latest.Height, "err", err)
+					continue
+				}
+				if events := api.DiffStakePositions(addr, positions, sb.Height, current, latest); len(events) > 0 {
+					notifier.Notify(rpcSub.ID, events)
+				}
+				positions, sb = current, latest
+			case <-rpcSub.Err():
+				sbSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				sbSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // Deprecated: use ledger_getVmLogsByFilter instead
 func (s *SubscribeApi) GetLogs(param RpcFilterParam) ([]*Logs, error) {
 	logs, err := api.GetLogs(s.vite.Chain(), param.AddrRange, param.Topics)
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+const maxAccountHistoryPageSize = 1000
+
+type AccountHistoryParams struct {
+	Counterparty *types.Address     `json:"counterparty"`
+	TokenId      *types.TokenTypeId `json:"tokenId"`
+	StartTime    *int64             `json:"startTime"` // unix seconds of the confirming snapshot block, inclusive
+	EndTime      *int64             `json:"endTime"`   // unix seconds of the confirming snapshot block, inclusive
+	Direction    string             `json:"direction"` // "send", "receive" or empty for both
+	Cursor       string             `json:"cursor"`    // nextCursor of the previous page, empty for the first page
+	PageSize     int                `json:"pageSize"`
+	Asc          bool               `json:"asc"` // the earliest first if true, otherwise the latest first
+}
+
+func (params *AccountHistoryParams) filter() (*chain_plugins.AccountHistoryFilter, error) {
+	if (params.StartTime != nil && *params.StartTime < 0) || (params.EndTime != nil && *params.EndTime < 0) {
+		return nil, errors.New("invalid startTime or endTime")
+	}
+	filter := &chain_plugins.AccountHistoryFilter{
+		Counterparty: params.Counterparty,
+		TokenId:      params.TokenId,
+		StartTime:    params.StartTime,
+		EndTime:      params.EndTime,
+	}
+	switch params.Direction {
+	case "":
+	case "send":
+		isSend := true
+		filter.IsSend = &isSend
+	case "receive":
+		isSend := false
+		filter.IsSend = &isSend
+	default:
+		return nil, errors.New("invalid direction " + params.Direction)
+	}
+	return filter, nil
+}
+
+func (params *AccountHistoryParams) cursor() (*chain_plugins.HistoryPosition, error) {
+	if len(params.Cursor) == 0 {
+		return nil, nil
+	}
+	buf, err := hex.DecodeString(params.Cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	position, err := chain_plugins.BytesToHistoryPosition(buf)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &position, nil
+}
+
+type AccountHistoryItem struct {
+	Direction      string `json:"direction"`
+	SnapshotHeight string `json:"snapshotHeight"`
+	Timestamp      int64  `json:"timestamp"`
+	// one of them is the block of the account, the receive block is nil if the send block is not received
+	SendBlock    *AccountBlock `json:"sendBlock"`
+	ReceiveBlock *AccountBlock `json:"receiveBlock"`
+}
+
+type AccountHistoryPage struct {
+	List []*AccountHistoryItem `json:"list"`
+	// empty if there are no more pages, the list may be short or empty with a cursor if the scan limit is hit
+	NextCursor string `json:"nextCursor"`
+}
+
+// GetAccountHistory returns a page of the confirmed blocks of an account filtered by counterparty, token,
+// time and direction, with each send block joined with its receive block
+func (l *LedgerApi) GetAccountHistory(addr types.Address, params AccountHistoryParams) (*AccountHistoryPage, error) {
+	if params.PageSize <= 0 || params.PageSize > maxAccountHistoryPageSize {
+		return nil, errors.New("invalid pageSize")
+	}
+	filter, err := params.filter()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := params.cursor()
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := l.chain.Plugins()
+	if plugins == nil {
+		return nil, errors.New("config.OpenPlugins is false, api can't work")
+	}
+	plugin, ok := plugins.GetPlugin("accountHistory").(*chain_plugins.AccountHistory)
+	if !ok || plugin == nil {
+		return nil, errors.New("plugins-AccountHistory's service not provided, set AccountHistoryPlugin to true")
+	}
+	if err := plugins.CheckIndex("accountHistory"); err != nil {
+		return nil, err
+	}
+
+	records, next, err := plugin.GetHistory(addr, filter, cursor, params.PageSize, params.Asc)
+	if err != nil {
+		return nil, err
+	}
+	page := &AccountHistoryPage{List: make([]*AccountHistoryItem, 0, len(records))}
+	if next != nil {
+		page.NextCursor = hex.EncodeToString(next.Bytes())
+	}
+	for _, record := range records {
+		item, err := l.joinAccountHistory(record)
+		if err != nil {
+			return nil, err
+		}
+		page.List = append(page.List, item)
+	}
+	return page, nil
+}
+
+func (l *LedgerApi) joinAccountHistory(record *chain_plugins.AccountHistoryRecord) (*AccountHistoryItem, error) {
+	block, err := l.chain.GetAccountBlockByHash(record.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block " + record.BlockHash.String() + " not found")
+	}
+	var sendBlock, receiveBlock *ledger.AccountBlock
+	item := &AccountHistoryItem{
+		SnapshotHeight: Uint64ToString(record.SnapshotHeight),
+		Timestamp:      record.Timestamp,
+	}
+	if record.IsSend() {
+		item.Direction = "send"
+		sendBlock = block
+		if receiveBlock, err = l.chain.GetReceiveAbBySendAb(block.Hash); err != nil {
+			return nil, err
+		}
+	} else {
+		item.Direction = "receive"
+		receiveBlock = block
+		if sendBlock, err = l.chain.GetAccountBlockByHash(block.FromBlockHash); err != nil {
+			return nil, err
+		}
+	}
+
+	if sendBlock != nil {
+		if item.SendBlock, err = l.ledgerBlockToRpcBlock(sendBlock); err != nil {
+			return nil, err
+		}
+	}
+	if receiveBlock != nil {
+		if item.ReceiveBlock, err = l.ledgerBlockToRpcBlock(receiveBlock); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
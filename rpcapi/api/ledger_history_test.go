@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain/plugins"
+)
+
+func TestAccountHistoryParams(t *testing.T) {
+	position := chain_plugins.HistoryPosition{Height: 10, Index: 2}
+	params := &AccountHistoryParams{Direction: "receive", Cursor: hex.EncodeToString(position.Bytes())}
+	filter, err := params.filter()
+	if err != nil || filter.IsSend == nil || *filter.IsSend {
+		t.Fatalf("filter not match, %+v, %v", filter, err)
+	}
+	if cursor, err := params.cursor(); err != nil || *cursor != position {
+		t.Fatalf("cursor not match, %+v, %v", cursor, err)
+	}
+
+	params = &AccountHistoryParams{Direction: "deposit", Cursor: "0a"}
+	if _, err := params.filter(); err == nil {
+		t.Fatal("expected invalid direction error")
+	}
+	if _, err := params.cursor(); err == nil {
+		t.Fatal("expected invalid cursor error")
+	}
+	startTime := int64(-1)
+	params = &AccountHistoryParams{StartTime: &startTime}
+	if _, err := params.filter(); err == nil {
+		t.Fatal("expected invalid startTime error")
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain/proof"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+type StateProof struct {
+	SnapshotHash   types.Hash    `json:"snapshotHash"`
+	SnapshotHeight string        `json:"snapshotHeight"`
+	StateRoot      types.Hash    `json:"stateRoot"`
+	Address        types.Address `json:"address"`
+	Key            string        `json:"key"`
+	LeafKey        types.Hash    `json:"leafKey"`
+	Exists         bool          `json:"exists"`
+	Value          string        `json:"value"` // hex, empty if the key is absent
+	Siblings       []types.Hash  `json:"siblings"`
+
+	// the leaf of another key met on the path of an absent key
+	Leaf *StateProofLeaf `json:"leaf,omitempty"`
+}
+
+type StateProofLeaf struct {
+	LeafKey   types.Hash `json:"leafKey"`
+	ValueHash types.Hash `json:"valueHash"`
+}
+
+// GetProof returns the value of a state key of addr at the snapshot block and its proof against the state root of the block.
+// key is a token id for the balance, "code" for the contract code, or a hex storage key of the contract.
+// The state root is a local index and isn't part of consensus, it works only if config.StateProof is true.
+func (l *LedgerApi) GetProof(addr types.Address, key string, snapshotHash types.Hash) (*StateProof, error) {
+	stateProof := l.chain.StateProof()
+	if stateProof == nil {
+		return nil, errors.New("config.StateProof is false, api can't work")
+	}
+
+	var leafKey types.Hash
+	switch {
+	case key == "code":
+		leafKey = chain_proof.CodeKey(addr)
+	case types.IsValidHexTokenTypeId(key):
+		tokenId, err := types.HexToTokenTypeId(key)
+		if err != nil {
+			return nil, err
+		}
+		leafKey = chain_proof.BalanceKey(addr, tokenId)
+	default:
+		storageKey, err := hex.DecodeString(strings.TrimPrefix(key, "0x"))
+		if err != nil {
+			return nil, errors.New("key must be a token id, \"code\" or a hex storage key")
+		}
+		if len(storageKey) == 0 || len(storageKey) > types.HashSize {
+			return nil, errors.New("the length of a storage key must be in [1, 32]")
+		}
+		leafKey = chain_proof.StorageKey(addr, storageKey)
+	}
+
+	p, err := stateProof.GetProof(snapshotHash, leafKey)
+	if err != nil {
+		l.log.Error("GetProof failed, error is "+err.Error(), "method", "GetProof")
+		return nil, err
+	}
+
+	result := &StateProof{
+		SnapshotHash:   p.SnapshotHash,
+		SnapshotHeight: Uint64ToString(p.SnapshotHeight),
+		StateRoot:      p.Root,
+		Address:        addr,
+		Key:            key,
+		LeafKey:        p.Key,
+		Exists:         p.Value != nil,
+		Value:          hex.EncodeToString(p.Value),
+		Siblings:       p.Proof.Siblings,
+	}
+	if result.Siblings == nil {
+		result.Siblings = []types.Hash{}
+	}
+	if p.Proof.Leaf != nil {
+		result.Leaf = &StateProofLeaf{LeafKey: p.Proof.Leaf.Key, ValueHash: p.Proof.Leaf.ValueHash}
+	}
+	return result, nil
+}
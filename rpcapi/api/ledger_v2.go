@@ -539,7 +539,7 @@ func calcPoWDifficulty(c chain.Chain, param CalcPoWDifficultyParam) (result *Cal
 	if err != nil {
 		return nil, err
 	}
-	quotaRequired, err := vm.GasRequiredForBlock(db, block, util.QuotaTableByHeight(sb.Height), sb.Height)
+	quotaRequired, err := vm.GasRequiredForBlock(db, block, util.QuotaTableByHeight(c.ForkSchedule(), sb.Height), sb.Height)
 	if err != nil {
 		return nil, err
 	}
@@ -624,7 +624,7 @@ func calcQuotaRequired(c chain.Chain, param CalcQuotaRequiredParam) (*CalcQuotaR
 	if err != nil {
 		return nil, err
 	}
-	quotaRequired, err := vm.GasRequiredForBlock(db, block, util.QuotaTableByHeight(sb.Height), sb.Height)
+	quotaRequired, err := vm.GasRequiredForBlock(db, block, util.QuotaTableByHeight(c.ForkSchedule(), sb.Height), sb.Height)
 	if err != nil {
 		return nil, err
 	}
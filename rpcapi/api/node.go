@@ -0,0 +1,28 @@
+package api
+
+import (
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vite/health"
+)
+
+type NodeApi struct {
+	vite *vite.Vite
+	log  log15.Logger
+}
+
+func NewNodeApi(vite *vite.Vite) *NodeApi {
+	return &NodeApi{
+		vite: vite,
+		log:  log15.New("module", "rpc_api/node_api"),
+	}
+}
+
+func (n NodeApi) String() string {
+	return "NodeApi"
+}
+
+// Health returns the same report as http://HealthEndpoint/health and /ready
+func (n *NodeApi) Health() health.Report {
+	return health.Evaluate(health.Collect(n.vite), n.vite.Config().Health)
+}
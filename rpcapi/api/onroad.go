@@ -2,9 +2,12 @@ package api
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/go-errors/errors"
 	"github.com/vitelabs/go-vite/common/math"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/onroad"
 	"github.com/vitelabs/go-vite/vite"
 )
 
@@ -34,13 +37,20 @@ func (pub PublicOnroadApi) GetOnroadInfoByAddress(address types.Address) (*RpcAc
 	return pub.api.GetOnroadInfoByAddress(address)
 }
 
+// Info returns the backlog and receive latency of the contracts in the gid, default gid is the delegate consensus group.
+func (pub PublicOnroadApi) Info(gid *types.Gid) ([]*OnroadContractInfo, error) {
+	return pub.api.Info(gid)
+}
+
 type PrivateOnroadApi struct {
 	ledgerApi *LedgerApi
+	manager   *onroad.Manager
 }
 
 func NewPrivateOnroadApi(vite *vite.Vite) *PrivateOnroadApi {
 	return &PrivateOnroadApi{
 		ledgerApi: NewLedgerApi(vite),
+		manager:   vite.OnRoad(),
 	}
 }
 
@@ -105,3 +115,45 @@ func (pri PrivateOnroadApi) GetOnroadInfoInBatch(addrList []types.Address) ([]*R
 	}
 	return resultList, nil
 }
+
+type OnroadContractInfo struct {
+	Address  types.Address `json:"address"`
+	Backlog  string        `json:"backlog"`
+	Reserved bool          `json:"reserved"`
+
+	Received        string `json:"received"`
+	Throttled       string `json:"throttled"`
+	AvgLatency      int64  `json:"avgLatency"` // in milliseconds
+	MaxLatency      int64  `json:"maxLatency"` // in milliseconds
+	LastReceiveTime *int64 `json:"lastReceiveTime,omitempty"`
+}
+
+// Info returns the backlog and receive latency of the contracts in the gid, default gid is the delegate consensus group.
+func (pri PrivateOnroadApi) Info(gid *types.Gid) ([]*OnroadContractInfo, error) {
+	g := types.DELEGATE_GID
+	if gid != nil {
+		g = *gid
+	}
+	list, err := pri.manager.ScheduleInfo(g)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*OnroadContractInfo, len(list))
+	for i, v := range list {
+		info := &OnroadContractInfo{
+			Address:    v.Address,
+			Backlog:    Uint64ToString(v.Backlog),
+			Reserved:   v.Reserved,
+			Received:   Uint64ToString(v.Received),
+			Throttled:  Uint64ToString(v.Throttled),
+			AvgLatency: int64(v.AvgLatency / time.Millisecond),
+			MaxLatency: int64(v.MaxLatency / time.Millisecond),
+		}
+		if v.LastReceiveTime != nil {
+			t := v.LastReceiveTime.Unix()
+			info.LastReceiveTime = &t
+		}
+		result[i] = info
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+type PoolApi struct {
+	pool pool.BlockPool
+	log  log15.Logger
+}
+
+func NewPoolApi(vite *vite.Vite) *PoolApi {
+	return &PoolApi{
+		pool: vite.Pool(),
+		log:  log15.New("module", "rpc_api/pool_api"),
+	}
+}
+
+func (p PoolApi) String() string {
+	return "PoolApi"
+}
+
+type BlockStatus struct {
+	Hash           types.Hash     `json:"hash"`
+	Address        *types.Address `json:"address,omitempty"`
+	Height         string         `json:"height,omitempty"`
+	Status         string         `json:"status"`
+	Reason         string         `json:"reason,omitempty"`
+	WaitingFor     *types.Hash    `json:"waitingFor,omitempty"`
+	ConfirmedTimes string         `json:"confirmedTimes,omitempty"`
+	Timestamp      int64          `json:"timestamp"`
+}
+
+func ToBlockStatus(s *pool.AccountBlockStatus) *BlockStatus {
+	result := &BlockStatus{
+		Hash:       s.Hash,
+		Address:    s.Address,
+		Status:     string(s.Status),
+		Reason:     s.Reason,
+		WaitingFor: s.WaitingFor,
+		Timestamp:  s.Time.Unix(),
+	}
+	if s.Height > 0 {
+		result.Height = Uint64ToString(s.Height)
+	}
+	if s.ConfirmedTimes > 0 {
+		result.ConfirmedTimes = Uint64ToString(s.ConfirmedTimes)
+	}
+	return result
+}
+
+// GetBlockStatus tells whether the account block is queued, pending, waiting for a missing block, dropped, inserted or confirmed.
+func (p PoolApi) GetBlockStatus(hash types.Hash) *BlockStatus {
+	return ToBlockStatus(p.pool.GetAccountBlockStatus(hash))
+}
+
+// PendingByAddress returns the blocks of the address which are in the pool and not inserted into the chain.
+func (p PoolApi) PendingByAddress(addr types.Address) []*BlockStatus {
+	list := p.pool.GetPendingAccountBlocks(addr)
+	result := make([]*BlockStatus, len(list))
+	for i, s := range list {
+		result[i] = ToBlockStatus(s)
+	}
+	return result
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
@@ -10,6 +11,7 @@ import (
 	"github.com/vitelabs/go-vite/vm/quota"
 	"github.com/vitelabs/go-vite/vm/util"
 	"math"
+	"math/big"
 	"sort"
 )
 
@@ -234,3 +236,148 @@ func (p *QuotaApi) GetQuotaCoefficient() (*QuotaCoefficientInfo, error) {
 	qc, globalQuota, isCongestion := quota.CalcQc(p.chain, p.chain.GetLatestSnapshotBlock().Height)
 	return &QuotaCoefficientInfo{bigIntToString(qc), Uint64ToString(globalQuota), Float64ToString(float64(globalQuota)/21000/74, 2), isCongestion}, nil
 }
+
+// defaultNetworkStatusSnapshotCount is the count of snapshot blocks in the global quota history by default
+const defaultNetworkStatusSnapshotCount = 75
+
+type GlobalQuotaPoint struct {
+	SnapshotHeight string  `json:"snapshotHeight"`
+	GlobalQuota    string  `json:"globalQuota"`
+	BlockCount     string  `json:"blockCount"`
+	Qc             *string `json:"qc"`
+	IsCongestion   bool    `json:"isCongestion"`
+	// difficulty of a block using one ut without stake quota
+	PoWDifficulty *string `json:"powDifficulty"`
+}
+
+type QuotaProjection struct {
+	Address       types.Address `json:"address"`
+	TargetUtps    string        `json:"targetUtps"`
+	QuotaRequired string        `json:"quotaRequired"`
+	StakeAmount   string        `json:"stakeAmount"`
+	CurrentQuota  string        `json:"currentQuota"`
+	// stake amount for the target at the current quota congestion ratio
+	RequiredStakeAmount   string `json:"requiredStakeAmount"`
+	AdditionalStakeAmount string `json:"additionalStakeAmount"`
+	// difficulty of a block using one ut, an account calculates pow for one block a snapshot block at most
+	PoWDifficulty *string `json:"powDifficulty"`
+	CanPoW        bool    `json:"canPoW"`
+}
+
+type NetworkStatus struct {
+	SnapshotHeight string              `json:"snapshotHeight"`
+	Qc             *string             `json:"qc"`
+	GlobalQuota    string              `json:"globalQuota"`
+	GlobalUt       string              `json:"globalUtPerSecond"`
+	IsCongestion   bool                `json:"isCongestion"`
+	PoWDifficulty  *string             `json:"powDifficulty"`
+	History        []*GlobalQuotaPoint `json:"history"`
+	Projection     *QuotaProjection    `json:"projection,omitempty"`
+}
+
+// GetNetworkStatus returns the quota congestion of the network and the global quota of the latest snapshotCount
+// snapshot blocks. If addr and targetUtps are given, it projects the stake amount and pow difficulty required.
+func (p *QuotaApi) GetNetworkStatus(snapshotCount *int, addr *types.Address, targetUtps *string) (*NetworkStatus, error) {
+	count := defaultNetworkStatusSnapshotCount
+	if snapshotCount != nil {
+		count = *snapshotCount
+	}
+	if count < 0 {
+		return nil, errors.New("snapshotCount is negative")
+	}
+	if (addr == nil) != (targetUtps == nil) {
+		return nil, errors.New("addr and targetUtps must be given together")
+	}
+
+	sb := p.chain.GetLatestSnapshotBlock()
+	history := p.chain.GetGlobalQuotaList(count)
+	status := &NetworkStatus{
+		SnapshotHeight: Uint64ToString(sb.Height),
+		History:        make([]*GlobalQuotaPoint, len(history)),
+	}
+	for i, globalQuota := range history {
+		height := sb.Height - uint64(len(history)-1-i)
+		qc, isCongestion := p.calcQc(height, globalQuota.QuotaUsedTotal)
+		difficulty, err := quota.CalcPoWDifficultyByQc(quota.QuotaPerUt, qc, isCongestion)
+		if err != nil {
+			return nil, err
+		}
+		status.History[i] = &GlobalQuotaPoint{
+			SnapshotHeight: Uint64ToString(height),
+			GlobalQuota:    Uint64ToString(globalQuota.QuotaUsedTotal),
+			BlockCount:     Uint64ToString(globalQuota.BlockCount),
+			Qc:             bigIntToString(qc),
+			IsCongestion:   isCongestion,
+			PoWDifficulty:  bigIntToString(difficulty),
+		}
+	}
+
+	globalQuota := p.chain.GetGlobalQuota().QuotaUsedTotal
+	qc, isCongestion := p.calcQc(sb.Height, globalQuota)
+	difficulty, err := quota.CalcPoWDifficultyByQc(quota.QuotaPerUt, qc, isCongestion)
+	if err != nil {
+		return nil, err
+	}
+	status.Qc = bigIntToString(qc)
+	status.GlobalQuota = Uint64ToString(globalQuota)
+	status.GlobalUt = Float64ToString(float64(globalQuota)/float64(quota.QuotaPerUt)/74, 2)
+	status.IsCongestion = isCongestion
+	status.PoWDifficulty = bigIntToString(difficulty)
+
+	if addr != nil {
+		status.Projection, err = p.projectQuota(*addr, *targetUtps, qc, isCongestion)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+func (p *QuotaApi) calcQc(sbHeight uint64, globalQuota uint64) (*big.Int, bool) {
+	if !p.chain.ForkSchedule().IsDexFork(sbHeight) {
+		return big.NewInt(0), false
+	}
+	return quota.CalcQcByGlobalQuota(globalQuota)
+}
+
+func (p *QuotaApi) projectQuota(addr types.Address, targetUtps string, qc *big.Int, isCongestion bool) (*QuotaProjection, error) {
+	utps, err := StringToFloat64(targetUtps)
+	if err != nil {
+		return nil, err
+	}
+	if utps < 0 {
+		return nil, errors.New("targetUtps is negative")
+	}
+	quotaRequired := uint64(math.Ceil(utps * float64(quota.QuotaPerUt)))
+	requiredAmount, err := quota.CalcStakeAmountByQc(quotaRequired, qc, isCongestion)
+	if err != nil {
+		return nil, err
+	}
+	amount, q, err := p.chain.GetStakeQuota(addr)
+	if err != nil {
+		return nil, err
+	}
+	additionalAmount := new(big.Int).Sub(requiredAmount, amount)
+	if additionalAmount.Sign() < 0 {
+		additionalAmount.SetInt64(0)
+	}
+	difficulty, err := quota.CalcPoWDifficultyByQc(quota.QuotaPerUt, qc, isCongestion)
+	if err != nil {
+		return nil, err
+	}
+	db, err := getVmDb(p.chain, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaProjection{
+		Address:               addr,
+		TargetUtps:            targetUtps,
+		QuotaRequired:         Uint64ToString(quotaRequired),
+		StakeAmount:           *bigIntToString(amount),
+		CurrentQuota:          Uint64ToString(q.Current()),
+		RequiredStakeAmount:   *bigIntToString(requiredAmount),
+		AdditionalStakeAmount: *bigIntToString(additionalAmount),
+		PoWDifficulty:         bigIntToString(difficulty),
+		CanPoW:                quota.CanPoW(db, addr),
+	}, nil
+}
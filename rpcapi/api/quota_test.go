@@ -0,0 +1,142 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/quota"
+)
+
+type quotaTestChain struct {
+	chain.Chain
+	latest      *ledger.SnapshotBlock
+	forks       *fork.Schedule
+	history     []types.QuotaInfo
+	stakeAmount *big.Int
+	stakeQuota  types.Quota
+}
+
+func (c *quotaTestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.latest
+}
+
+func (c *quotaTestChain) ForkSchedule() *fork.Schedule {
+	return c.forks
+}
+
+func (c *quotaTestChain) GetGlobalQuotaList(count int) []types.QuotaInfo {
+	if count > len(c.history) {
+		count = len(c.history)
+	}
+	return c.history[len(c.history)-count:]
+}
+
+func (c *quotaTestChain) GetGlobalQuota() types.QuotaInfo {
+	return c.history[len(c.history)-1]
+}
+
+func (c *quotaTestChain) GetStakeQuota(addr types.Address) (*big.Int, *types.Quota, error) {
+	return c.stakeAmount, &c.stakeQuota, nil
+}
+
+func (c *quotaTestChain) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *quotaTestChain) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	return nil
+}
+
+func TestQuotaApi_GetNetworkStatus(t *testing.T) {
+	quota.InitQuotaConfig(false, false)
+	congestedQuota := 21000 * 74 * uint64(60)
+	c := &quotaTestChain{
+		latest:      &ledger.SnapshotBlock{Height: 100},
+		forks:       fork.NewSchedule(&config.ForkPoints{DexFork: &config.ForkPoint{Height: 99, Version: 1}}),
+		history:     []types.QuotaInfo{{QuotaUsedTotal: congestedQuota}, {QuotaUsedTotal: 0}, {QuotaUsedTotal: congestedQuota}},
+		stakeAmount: big.NewInt(0),
+		stakeQuota:  types.NewQuota(0, 100, 0, 0, false, 0),
+	}
+	p := &QuotaApi{chain: c}
+
+	negative, two := -1, 2
+	if _, err := p.GetNetworkStatus(&negative, nil, nil); err == nil {
+		t.Fatal("expected negative snapshotCount error")
+	}
+	if _, err := p.GetNetworkStatus(nil, &types.Address{1}, nil); err == nil {
+		t.Fatal("expected error of addr without targetUtps")
+	}
+
+	// the quota before the dex fork is never congested
+	status, err := p.GetNetworkStatus(nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.History) != 3 || status.SnapshotHeight != "100" || !status.IsCongestion || status.GlobalQuota != Uint64ToString(congestedQuota) {
+		t.Fatalf("status not match, %+v", status)
+	}
+	for i, expected := range []struct {
+		height       string
+		isCongestion bool
+	}{{"98", false}, {"99", false}, {"100", true}} {
+		point := status.History[i]
+		if point.SnapshotHeight != expected.height || point.IsCongestion != expected.isCongestion {
+			t.Fatalf("history %v not match, %+v", i, point)
+		}
+	}
+	if *status.History[0].Qc != "0" || *status.History[2].Qc != *status.Qc {
+		t.Fatalf("qc not match, %v, %v, %v", *status.History[0].Qc, *status.History[2].Qc, *status.Qc)
+	}
+
+	status, err = p.GetNetworkStatus(&two, nil, nil)
+	if err != nil || len(status.History) != 2 || status.History[0].SnapshotHeight != "99" || status.Projection != nil {
+		t.Fatalf("status of 2 snapshot blocks not match, %+v, %v", status, err)
+	}
+
+	// the stake amount is projected at the current congestion
+	addr, utps := types.Address{1}, "1.5"
+	status, err = p.GetNetworkStatus(&two, &addr, &utps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qc, _ := quota.CalcQcByGlobalQuota(congestedQuota)
+	required, _ := quota.CalcStakeAmountByQc(31500, qc, true)
+	projection := status.Projection
+	if projection.QuotaRequired != "31500" || projection.RequiredStakeAmount != required.String() ||
+		projection.AdditionalStakeAmount != required.String() || projection.CurrentQuota != "100" || !projection.CanPoW {
+		t.Fatalf("projection not match, %+v", projection)
+	}
+}
+
+func TestQuotaApi_projectQuota(t *testing.T) {
+	quota.InitQuotaConfig(false, false)
+	c := &quotaTestChain{
+		latest:      &ledger.SnapshotBlock{Height: 100},
+		stakeAmount: new(big.Int).Mul(big.NewInt(1e6), big.NewInt(1e18)),
+	}
+	p := &QuotaApi{chain: c}
+	addr := types.Address{1}
+
+	for _, utps := range []string{"-1", "x"} {
+		if _, err := p.projectQuota(addr, utps, big.NewInt(0), false); err == nil {
+			t.Fatalf("expected invalid targetUtps error, %v", utps)
+		}
+	}
+
+	projection, err := p.projectQuota(addr, "1", big.NewInt(0), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	required, _ := quota.CalcStakeAmountByQuota(21000)
+	difficulty, _ := quota.CalcPoWDifficultyByQc(21000, big.NewInt(0), false)
+	if projection.QuotaRequired != "21000" || projection.RequiredStakeAmount != required.String() ||
+		projection.AdditionalStakeAmount != "0" || projection.StakeAmount != c.stakeAmount.String() ||
+		*projection.PoWDifficulty != difficulty.String() {
+		t.Fatalf("projection not match, %+v", projection)
+	}
+}
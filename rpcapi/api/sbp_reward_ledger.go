@@ -0,0 +1,194 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/vm/contracts"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+const maxSBPRewardLedgerCycles = 90
+
+type SBPRewardLedgerParam struct {
+	Name       string `json:"sbpName"`
+	StartCycle string `json:"startCycle"`
+	EndCycle   string `json:"endCycle"`   // startCycle if empty
+	ShareRatio *uint8 `json:"shareRatio"` // percent of the total reward split to voters, 100 if nil
+}
+
+type VoterReward struct {
+	Address types.Address `json:"address"`
+	Votes   string        `json:"votes"`
+	Reward  string        `json:"reward"`
+}
+
+type SBPRewardLedgerCycle struct {
+	Cycle            string `json:"cycle"`
+	StartTime        int64  `json:"startTime"`
+	EndTime          int64  `json:"endTime"`
+	BlockReward      string `json:"blockReward"`
+	VoteReward       string `json:"voteReward"`
+	TotalReward      string `json:"totalReward"`
+	ExpectedBlockNum string `json:"expectedBlockNum"`
+	ProducedBlockNum string `json:"producedBlockNum"`
+	// votes are the confirmed balances of the voters in a single snapshot block, the last one before the cycle
+	// starts. They are not averaged over the rounds of the cycle, votes changed during the cycle aren't counted.
+	VoteSnapshotHeight string         `json:"voteSnapshotHeight"`
+	VoteSnapshotHash   types.Hash     `json:"voteSnapshotHash"`
+	TotalVotes         string         `json:"totalVotes"`
+	VoterReward        string         `json:"voterReward"`
+	VoterRewardList    []*VoterReward `json:"voterRewardList"`
+}
+
+type SBPRewardLedger struct {
+	Name       string                  `json:"sbpName"`
+	ShareRatio uint8                   `json:"shareRatio"`
+	CycleList  []*SBPRewardLedgerCycle `json:"cycleList"`
+}
+
+func (param *SBPRewardLedgerParam) cycles() (uint64, uint64, uint8, error) {
+	if len(param.Name) == 0 {
+		return 0, 0, 0, errors.New("sbpName is required")
+	}
+	start, err := StringToUint64(param.StartCycle)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	end := start
+	if len(param.EndCycle) > 0 {
+		if end, err = StringToUint64(param.EndCycle); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	if end < start || end-start >= maxSBPRewardLedgerCycles {
+		return 0, 0, 0, errors.New("invalid startCycle or endCycle")
+	}
+	shareRatio := uint8(100)
+	if param.ShareRatio != nil {
+		if *param.ShareRatio > 100 {
+			return 0, 0, 0, errors.New("invalid shareRatio")
+		}
+		shareRatio = *param.ShareRatio
+	}
+	return start, end, shareRatio, nil
+}
+
+// GetSBPRewardLedger returns the reward of an SBP in each cycle between startCycle and endCycle, with the
+// share of the total reward split to its voters in proportion to their votes
+func (r *ContractApi) GetSBPRewardLedger(param SBPRewardLedgerParam) (*SBPRewardLedger, error) {
+	start, end, shareRatio, err := param.cycles()
+	if err != nil {
+		return nil, err
+	}
+	db, err := getVmDb(r.chain, types.AddressGovernance)
+	if err != nil {
+		return nil, err
+	}
+	reader := util.NewVMConsensusReader(r.cs.SBPReader())
+	ledger := &SBPRewardLedger{Name: param.Name, ShareRatio: shareRatio, CycleList: make([]*SBPRewardLedgerCycle, 0, end-start+1)}
+	for index := start; index <= end; index++ {
+		m, err := contracts.CalcRewardByIndex(db, reader, index)
+		if err != nil {
+			return nil, err
+		}
+		reward, ok := m[param.Name]
+		if !ok {
+			reward = &contracts.Reward{VoteReward: big.NewInt(0), BlockReward: big.NewInt(0), TotalReward: big.NewInt(0)}
+		}
+		cycle, err := r.sbpRewardLedgerCycle(param.Name, index, reward, shareRatio)
+		if err != nil {
+			return nil, err
+		}
+		ledger.CycleList = append(ledger.CycleList, cycle)
+	}
+	return ledger, nil
+}
+
+func (r *ContractApi) sbpRewardLedgerCycle(name string, index uint64, reward *contracts.Reward, shareRatio uint8) (*SBPRewardLedgerCycle, error) {
+	startTime, endTime := r.cs.SBPReader().GetDayTimeIndex().Index2Time(index)
+	sb, err := r.chain.GetSnapshotHeaderBeforeTime(&startTime)
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil {
+		sb = r.chain.GetGenesisSnapshotBlock()
+	}
+	votes, err := r.getSBPVotes(sb.Hash, name)
+	if err != nil {
+		return nil, err
+	}
+
+	voterReward := new(big.Int).Mul(reward.TotalReward, big.NewInt(int64(shareRatio)))
+	voterReward.Quo(voterReward, big.NewInt(100))
+	totalVotes, voterRewardList := splitVoterReward(voterReward, votes)
+	return &SBPRewardLedgerCycle{
+		Cycle:              Uint64ToString(index),
+		StartTime:          startTime.Unix(),
+		EndTime:            endTime.Unix(),
+		BlockReward:        *bigIntToString(reward.BlockReward),
+		VoteReward:         *bigIntToString(reward.VoteReward),
+		TotalReward:        *bigIntToString(reward.TotalReward),
+		ExpectedBlockNum:   Uint64ToString(reward.ExpectedBlockNum),
+		ProducedBlockNum:   Uint64ToString(reward.BlockNum),
+		VoteSnapshotHeight: Uint64ToString(sb.Height),
+		VoteSnapshotHash:   sb.Hash,
+		TotalVotes:         *bigIntToString(totalVotes),
+		VoterReward:        *bigIntToString(voterReward),
+		VoterRewardList:    voterRewardList,
+	}, nil
+}
+
+func (r *ContractApi) getSBPVotes(snapshotHash types.Hash, name string) (map[types.Address]*big.Int, error) {
+	group, err := r.chain.GetConsensusGroup(snapshotHash, types.SNAPSHOT_GID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, errors.New("snapshot consensus group not found")
+	}
+	voteList, err := r.chain.GetVoteList(snapshotHash, types.SNAPSHOT_GID)
+	if err != nil {
+		return nil, err
+	}
+	voters := make([]types.Address, 0)
+	for _, vote := range voteList {
+		if vote.SbpName == name {
+			voters = append(voters, vote.VoteAddr)
+		}
+	}
+	if len(voters) == 0 {
+		return make(map[types.Address]*big.Int), nil
+	}
+	return r.chain.GetConfirmedBalanceList(voters, group.CountingTokenId, snapshotHash)
+}
+
+// splitVoterReward splits amount to voters in proportion to their votes, rounding down. Voters are sorted by
+// votes, the largest first.
+func splitVoterReward(amount *big.Int, votes map[types.Address]*big.Int) (*big.Int, []*VoterReward) {
+	totalVotes := big.NewInt(0)
+	voters := make([]types.Address, 0, len(votes))
+	for addr, v := range votes {
+		if v == nil || v.Sign() <= 0 {
+			continue
+		}
+		totalVotes.Add(totalVotes, v)
+		voters = append(voters, addr)
+	}
+	sort.Slice(voters, func(i, j int) bool {
+		if cmp := votes[voters[i]].Cmp(votes[voters[j]]); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(voters[i].Bytes(), voters[j].Bytes()) < 0
+	})
+	list := make([]*VoterReward, len(voters))
+	for i, addr := range voters {
+		reward := new(big.Int).Mul(amount, votes[addr])
+		reward.Quo(reward, totalVotes)
+		list[i] = &VoterReward{Address: addr, Votes: *bigIntToString(votes[addr]), Reward: *bigIntToString(reward)}
+	}
+	return totalVotes, list
+}
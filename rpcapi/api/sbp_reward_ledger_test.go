@@ -0,0 +1,35 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func TestSplitVoterReward(t *testing.T) {
+	voter1, voter2, voter3 := types.Address{1}, types.Address{2}, types.Address{3}
+	votes := map[types.Address]*big.Int{voter1: big.NewInt(100), voter2: big.NewInt(300), voter3: big.NewInt(0)}
+	totalVotes, list := splitVoterReward(big.NewInt(1001), votes)
+	if totalVotes.Cmp(big.NewInt(400)) != 0 || len(list) != 2 {
+		t.Fatalf("split not match, %v, %v", totalVotes, list)
+	}
+	if list[0].Address != voter2 || list[0].Reward != "750" || list[1].Address != voter1 || list[1].Reward != "250" {
+		t.Fatalf("voter rewards not match, %+v, %+v", list[0], list[1])
+	}
+
+	ratio := uint8(101)
+	for _, param := range []SBPRewardLedgerParam{
+		{StartCycle: "1"},
+		{Name: "s1", StartCycle: "2", EndCycle: "1"},
+		{Name: "s1", StartCycle: "1", EndCycle: "1000"},
+		{Name: "s1", StartCycle: "1", ShareRatio: &ratio},
+	} {
+		if _, _, _, err := param.cycles(); err == nil {
+			t.Fatalf("expected invalid param error, %+v", param)
+		}
+	}
+	if start, end, shareRatio, err := (&SBPRewardLedgerParam{Name: "s1", StartCycle: "5"}).cycles(); err != nil || start != 5 || end != 5 || shareRatio != 100 {
+		t.Fatalf("cycles not match, %v, %v, %v, %v", start, end, shareRatio, err)
+	}
+}
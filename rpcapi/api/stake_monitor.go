@@ -0,0 +1,278 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	StakeEventCreated      = "created"      // a stake is created, or a dex stake is submitted
+	StakeEventConfirmed    = "confirmed"    // a dex stake is confirmed by the callback of the quota contract
+	StakeEventWithdrawable = "withdrawable" // a stake reaches its expiration height
+	StakeEventWithdrawn    = "withdrawn"    // a stake is withdrawn, or a dex stake is canceled or failed
+
+	StakeSourceQuota = "quota"
+	StakeSourceDex   = "dex"
+)
+
+// StakePosition is a stake of an address in the quota contract or in the dex fund contract
+type StakePosition struct {
+	Source           string
+	Id               string
+	Amount           *big.Int
+	Beneficiary      types.Address
+	Bid              uint8
+	IsDelegated      bool
+	DelegateAddress  types.Address
+	ExpirationHeight uint64
+	Confirmed        bool
+}
+
+type StakeEvent struct {
+	Type             string        `json:"type"`
+	Source           string        `json:"source"`
+	Id               string        `json:"id"`
+	StakeAddress     types.Address `json:"stakeAddress"`
+	Beneficiary      types.Address `json:"beneficiary"`
+	Amount           string        `json:"amount"`
+	Bid              uint8         `json:"bid"`
+	IsDelegated      bool          `json:"isDelegated"`
+	DelegateAddress  types.Address `json:"delegateAddress"`
+	ExpirationHeight string        `json:"expirationHeight"`
+	SnapshotHeight   string        `json:"snapshotHeight"`
+	SnapshotHash     types.Hash    `json:"snapshotHash"`
+}
+
+// GetStakePositions returns the stakes of an address as of a snapshot block, keyed by source and id. Stakes
+// delegated to the dex fund contract are returned as dex stakes only.
+func GetStakePositions(c chain.Chain, addr types.Address, sb *ledger.SnapshotBlock) (map[string]*StakePosition, error) {
+	quotaDb, dexDb, err := newStakeVmDbs(c, sb)
+	if err != nil {
+		return nil, err
+	}
+	return readStakePositions(quotaDb, dexDb, addr)
+}
+
+// StakePositionReader reads the stake positions of addresses as of the latest snapshot block for the stake
+// subscriptions. The contract dbs and the positions of an address are read once per snapshot block and shared by
+// all subscribers, the returned positions must not be modified.
+type StakePositionReader struct {
+	chain chain.Chain
+
+	mu        sync.Mutex
+	sb        *ledger.SnapshotBlock
+	quotaDb   vm_db.VmDb
+	dexDb     vm_db.VmDb
+	positions map[types.Address]map[string]*StakePosition
+}
+
+func NewStakePositionReader(c chain.Chain) *StakePositionReader {
+	return &StakePositionReader{chain: c}
+}
+
+// GetStakePositions returns the stakes of an address as of sb like GetStakePositions, the positions of the previous
+// snapshot block are dropped once sb changes
+func (r *StakePositionReader) GetStakePositions(addr types.Address, sb *ledger.SnapshotBlock) (map[string]*StakePosition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sb == nil || r.sb.Hash != sb.Hash {
+		quotaDb, dexDb, err := newStakeVmDbs(r.chain, sb)
+		if err != nil {
+			return nil, err
+		}
+		r.sb, r.quotaDb, r.dexDb = sb, quotaDb, dexDb
+		r.positions = make(map[types.Address]map[string]*StakePosition)
+	}
+	if positions, ok := r.positions[addr]; ok {
+		return positions, nil
+	}
+	positions, err := readStakePositions(r.quotaDb, r.dexDb, addr)
+	if err != nil {
+		return nil, err
+	}
+	r.positions[addr] = positions
+	return positions, nil
+}
+
+func newStakeVmDbs(c chain.Chain, sb *ledger.SnapshotBlock) (quotaDb, dexDb vm_db.VmDb, err error) {
+	if quotaDb, err = newConfirmedVmDb(c, types.AddressQuota, sb); err != nil {
+		return nil, nil, err
+	}
+	if dexDb, err = newConfirmedVmDb(c, types.AddressDexFund, sb); err != nil {
+		return nil, nil, err
+	}
+	return quotaDb, dexDb, nil
+}
+
+func readStakePositions(quotaDb, dexDb vm_db.VmDb, addr types.Address) (map[string]*StakePosition, error) {
+	positions := make(map[string]*StakePosition)
+	if err := getQuotaStakePositions(quotaDb, addr, positions); err != nil {
+		return nil, err
+	}
+	if err := getDexStakePositions(quotaDb, dexDb, addr, positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+func newConfirmedVmDb(c chain.Chain, addr types.Address, sb *ledger.SnapshotBlock) (vm_db.VmDb, error) {
+	prevHash, err := getConfirmedPrevBlockHash(c, addr, sb.Height)
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshotVmDb(c, addr, sb.Hash, prevHash)
+}
+
+func getQuotaStakePositions(db vm_db.VmDb, addr types.Address, positions map[string]*StakePosition) error {
+	iterator, err := db.NewStorageIterator(abi.GetStakeInfoKeyPrefix(addr))
+	if err != nil {
+		return err
+	}
+	defer iterator.Release()
+	for iterator.Next() {
+		if !abi.IsStakeInfoKey(iterator.Key()) || len(iterator.Value()) == 0 {
+			continue
+		}
+		info, err := abi.UnpackStakeInfo(iterator.Value())
+		if err != nil || info.Amount == nil || info.Amount.Sign() <= 0 {
+			continue
+		}
+		if info.IsDelegated && info.DelegateAddress == types.AddressDexFund {
+			continue
+		}
+		var id string
+		if info.Id != nil {
+			id = info.Id.String()
+		} else {
+			id = fmt.Sprintf("%s-%s-%t-%d", info.Beneficiary, info.DelegateAddress, info.IsDelegated, info.Bid)
+		}
+		positions[StakeSourceQuota+"-"+id] = &StakePosition{
+			Source:           StakeSourceQuota,
+			Id:               id,
+			Amount:           info.Amount,
+			Beneficiary:      info.Beneficiary,
+			Bid:              info.Bid,
+			IsDelegated:      info.IsDelegated,
+			DelegateAddress:  info.DelegateAddress,
+			ExpirationHeight: info.ExpirationHeight,
+			Confirmed:        true,
+		}
+	}
+	return iterator.Error()
+}
+
+func getDexStakePositions(quotaDb, dexDb vm_db.VmDb, addr types.Address, positions map[string]*StakePosition) error {
+	var infos []*dex.DelegateStakeInfo
+	// stakes of the old version are not indexed by id
+	if vipStaking, ok := dex.GetVIPStaking(dexDb, addr); ok && len(vipStaking.StakingHashes) < int(vipStaking.StakedTimes) {
+		infos = append(infos, &dex.DelegateStakeInfo{StakeType: dex.StakeForVIP, Amount: dex.StakeForVIPAmount.Bytes(), Status: dex.StakeConfirmed})
+	}
+	if superVipStaking, ok := dex.GetSuperVIPStaking(dexDb, addr); ok && len(superVipStaking.StakingHashes) < int(superVipStaking.StakedTimes) {
+		infos = append(infos, &dex.DelegateStakeInfo{StakeType: dex.StakeForSuperVIP, Amount: dex.StakeForSuperVIPAmount.Bytes(), Status: dex.StakeConfirmed})
+	}
+	if amount := dex.GetMiningStakedAmount(dexDb, addr); amount.Sign() > 0 {
+		infos = append(infos, &dex.DelegateStakeInfo{StakeType: dex.StakeForMining, Amount: amount.Bytes(), Status: dex.StakeConfirmed})
+	}
+	list, _, err := dex.GetStakeInfoList(dexDb, addr, func(*dex.DelegateStakeAddressIndex) bool { return true })
+	if err != nil {
+		return err
+	}
+	infos = append(infos, list...)
+
+	for _, info := range infos {
+		position := &StakePosition{
+			Source:          StakeSourceDex,
+			Amount:          new(big.Int).SetBytes(info.Amount),
+			Beneficiary:     types.AddressDexFund,
+			Bid:             uint8(info.StakeType),
+			IsDelegated:     true,
+			DelegateAddress: types.AddressDexFund,
+			Confirmed:       info.Status == dex.StakeConfirmed,
+		}
+		var quotaInfo *types.StakeInfo
+		if len(info.Id) > 0 {
+			id, _ := types.BytesToHash(info.Id)
+			position.Id = id.String()
+			if position.Confirmed {
+				// the stake in the quota contract is missing until the callback is received
+				if quotaInfo, err = abi.GetStakeInfoById(quotaDb, info.Id); err != nil && err != util.ErrDataNotExist {
+					return err
+				}
+			}
+		} else {
+			position.Id = fmt.Sprintf("%d", info.StakeType)
+			if quotaInfo, err = abi.GetStakeInfo(quotaDb, addr, types.AddressDexFund, types.AddressDexFund, true, position.Bid); err != nil {
+				return err
+			}
+		}
+		if quotaInfo != nil {
+			position.ExpirationHeight = quotaInfo.ExpirationHeight
+		}
+		positions[StakeSourceDex+"-"+position.Id] = position
+	}
+	return nil
+}
+
+// DiffStakePositions returns the events between the stakes as of the snapshot block of prevHeight and the
+// stakes as of sb, sorted by key
+func DiffStakePositions(addr types.Address, prev map[string]*StakePosition, prevHeight uint64, cur map[string]*StakePosition, sb *ledger.SnapshotBlock) []*StakeEvent {
+	keys := make([]string, 0, len(prev)+len(cur))
+	for key := range cur {
+		keys = append(keys, key)
+	}
+	for key := range prev {
+		if _, ok := cur[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var events []*StakeEvent
+	for _, key := range keys {
+		p, c := prev[key], cur[key]
+		switch {
+		case c == nil:
+			events = append(events, newStakeEvent(StakeEventWithdrawn, addr, p, sb))
+			continue
+		case p == nil:
+			events = append(events, newStakeEvent(StakeEventCreated, addr, c, sb))
+			if c.Confirmed && c.Source == StakeSourceDex {
+				events = append(events, newStakeEvent(StakeEventConfirmed, addr, c, sb))
+			}
+		case c.Confirmed && !p.Confirmed:
+			events = append(events, newStakeEvent(StakeEventConfirmed, addr, c, sb))
+		}
+		if c.ExpirationHeight > 0 && c.ExpirationHeight <= sb.Height &&
+			(p == nil || p.ExpirationHeight == 0 || p.ExpirationHeight > prevHeight) {
+			events = append(events, newStakeEvent(StakeEventWithdrawable, addr, c, sb))
+		}
+	}
+	return events
+}
+
+func newStakeEvent(typ string, addr types.Address, position *StakePosition, sb *ledger.SnapshotBlock) *StakeEvent {
+	return &StakeEvent{
+		Type:             typ,
+		Source:           position.Source,
+		Id:               position.Id,
+		StakeAddress:     addr,
+		Beneficiary:      position.Beneficiary,
+		Amount:           *bigIntToString(position.Amount),
+		Bid:              position.Bid,
+		IsDelegated:      position.IsDelegated,
+		DelegateAddress:  position.DelegateAddress,
+		ExpirationHeight: Uint64ToString(position.ExpirationHeight),
+		SnapshotHeight:   Uint64ToString(sb.Height),
+		SnapshotHash:     sb.Hash,
+	}
+}
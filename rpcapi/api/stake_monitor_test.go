@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// stakeTestDb is the storage of a contract, reading errKey fails
+type stakeTestDb struct {
+	vm_db.VmDb
+	addr    types.Address
+	storage *vm_db.Unsaved
+	errKey  []byte
+}
+
+func newStakeTestDb(addr types.Address) *stakeTestDb {
+	return &stakeTestDb{addr: addr, storage: vm_db.NewUnsaved()}
+}
+
+func (db *stakeTestDb) Address() *types.Address {
+	return &db.addr
+}
+
+func (db *stakeTestDb) GetValue(key []byte) ([]byte, error) {
+	if db.errKey != nil && string(key) == string(db.errKey) {
+		return nil, errors.New("read failed")
+	}
+	value, _ := db.storage.GetValue(key)
+	return value, nil
+}
+
+func (db *stakeTestDb) SetValue(key []byte, value []byte) error {
+	db.storage.SetValue(key, value)
+	return nil
+}
+
+func (db *stakeTestDb) NewStorageIterator(prefix []byte) (interfaces.StorageIterator, error) {
+	return db.storage.NewStorageIterator(prefix), nil
+}
+
+func (db *stakeTestDb) setStakeInfo(stakeAddr types.Address, index uint64, id *types.Hash, amount int64, expirationHeight uint64,
+	beneficiary types.Address, isDelegated bool, bid uint8) {
+	key := abi.GetStakeInfoKey(stakeAddr, index)
+	var value []byte
+	if id != nil {
+		value, _ = abi.ABIQuota.PackVariable(abi.VariableNameStakeInfoV2, big.NewInt(amount), expirationHeight, beneficiary, *id)
+		db.SetValue(id.Bytes(), key)
+	} else {
+		delegateAddr := types.Address{}
+		if isDelegated {
+			delegateAddr = types.AddressDexFund
+		}
+		value, _ = abi.ABIQuota.PackVariable(abi.VariableNameStakeInfo, big.NewInt(amount), expirationHeight, beneficiary, isDelegated, delegateAddr, bid)
+	}
+	db.SetValue(key, value)
+}
+
+func (db *stakeTestDb) setDexStake(addr types.Address, id types.Hash, stakeType uint8, amount int64, confirmed bool) {
+	dex.SaveDelegateStakeInfo(db, id, stakeType, addr, types.Address{}, big.NewInt(amount))
+	serialNo := dex.SaveDelegateStakeAddressIndex(db, id, int32(stakeType), addr.Bytes())
+	if confirmed {
+		info, _ := dex.GetDelegateStakeInfo(db, id.Bytes())
+		dex.ConfirmDelegateStakeInfo(db, id, info, serialNo)
+	}
+}
+
+type stakeTestChain struct {
+	chain.Chain
+}
+
+func (c *stakeTestChain) GetLatestAccountHeight(addr types.Address) (uint64, error) {
+	return 0, errors.New("chain stopped")
+}
+
+func TestDiffStakePositions(t *testing.T) {
+	addr := types.Address{1}
+	quotaStake := &StakePosition{Source: StakeSourceQuota, Id: "q", Amount: big.NewInt(1), ExpirationHeight: 20, Confirmed: true}
+	dexStake := &StakePosition{Source: StakeSourceDex, Id: "d", Amount: big.NewInt(2), IsDelegated: true}
+	confirmedDexStake := *dexStake
+	confirmedDexStake.Confirmed, confirmedDexStake.ExpirationHeight = true, 30
+
+	diff := func(prev map[string]*StakePosition, prevHeight uint64, cur map[string]*StakePosition, height uint64, expected ...string) {
+		events := DiffStakePositions(addr, prev, prevHeight, cur, &ledger.SnapshotBlock{Height: height})
+		if len(events) != len(expected)/2 {
+			t.Fatalf("events at %v not match, %+v", height, events)
+		}
+		for i, e := range events {
+			if e.Source+":"+e.Type != expected[2*i]+":"+expected[2*i+1] || e.StakeAddress != addr {
+				t.Fatalf("event %v at %v not match, %+v", i, height, e)
+			}
+		}
+	}
+
+	s10 := map[string]*StakePosition{"quota-q": quotaStake, "dex-d": dexStake}
+	diff(nil, 0, s10, 10, StakeSourceDex, StakeEventCreated, StakeSourceQuota, StakeEventCreated)
+	s20 := map[string]*StakePosition{"quota-q": quotaStake, "dex-d": &confirmedDexStake}
+	diff(s10, 10, s20, 20, StakeSourceDex, StakeEventConfirmed, StakeSourceQuota, StakeEventWithdrawable)
+	diff(s20, 20, s20, 25)
+	s30 := map[string]*StakePosition{"dex-d": &confirmedDexStake}
+	diff(s20, 25, s30, 30, StakeSourceDex, StakeEventWithdrawable, StakeSourceQuota, StakeEventWithdrawn)
+	// withdrawable again after a rollback below the expiration height
+	diff(s30, 29, s30, 31, StakeSourceDex, StakeEventWithdrawable)
+}
+
+func TestStakePositionReader(t *testing.T) {
+	addr, other := types.Address{1}, types.Address{2}
+	quotaStakeId, vipId := types.DataHash([]byte{1}), types.DataHash([]byte{2})
+	superVipId, missingId := types.DataHash([]byte{3}), types.DataHash([]byte{4})
+	quotaDb, dexDb := newStakeTestDb(types.AddressQuota), newStakeTestDb(types.AddressDexFund)
+
+	quotaDb.setStakeInfo(addr, 1, nil, 10, 100, addr, false, 0)
+	quotaDb.setStakeInfo(addr, 2, &quotaStakeId, 20, 200, other, false, 0)
+	// the stake of the old version delegated to the dex fund contract is a dex stake
+	quotaDb.setStakeInfo(addr, 3, nil, 5, 300, types.AddressDexFund, true, dex.StakeForMining)
+	dex.SaveMiningStakedAmount(dexDb, addr, big.NewInt(5))
+	// the confirmed dex stake and its stake in the quota contract
+	dexDb.setDexStake(addr, vipId, dex.StakeForVIP, 30, true)
+	quotaDb.setStakeInfo(types.AddressDexFund, 1, &vipId, 30, 400, types.AddressDexFund, false, 0)
+	dexDb.setDexStake(addr, superVipId, dex.StakeForSuperVIP, 40, false)
+	// the stake in the quota contract is deleted once withdrawn
+	dexDb.setDexStake(addr, missingId, dex.StakeForVIP, 50, true)
+
+	r := NewStakePositionReader(&stakeTestChain{})
+	sb := &ledger.SnapshotBlock{Height: 10, Hash: types.Hash{10}}
+	r.sb, r.quotaDb, r.dexDb = sb, quotaDb, dexDb
+	r.positions = make(map[types.Address]map[string]*StakePosition)
+
+	positions, err := r.GetStakePositions(addr, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]StakePosition{
+		"quota-" + fmt.Sprintf("%s-%s-%t-%d", addr, types.Address{}, false, 0): {Amount: big.NewInt(10), Beneficiary: addr, ExpirationHeight: 100, Confirmed: true},
+		"quota-" + quotaStakeId.String():                                       {Amount: big.NewInt(20), Beneficiary: other, ExpirationHeight: 200, Confirmed: true},
+		"dex-" + fmt.Sprintf("%d", dex.StakeForMining):                         {Amount: big.NewInt(5), Beneficiary: types.AddressDexFund, Bid: dex.StakeForMining, ExpirationHeight: 300, Confirmed: true},
+		"dex-" + vipId.String():                                                {Amount: big.NewInt(30), Beneficiary: types.AddressDexFund, Bid: dex.StakeForVIP, ExpirationHeight: 400, Confirmed: true},
+		"dex-" + superVipId.String():                                           {Amount: big.NewInt(40), Beneficiary: types.AddressDexFund, Bid: dex.StakeForSuperVIP},
+		"dex-" + missingId.String():                                            {Amount: big.NewInt(50), Beneficiary: types.AddressDexFund, Bid: dex.StakeForVIP, Confirmed: true},
+	}
+	if len(positions) != len(expected) {
+		t.Fatalf("positions not match, %+v", positions)
+	}
+	for key, e := range expected {
+		p, ok := positions[key]
+		if !ok || p.Amount.Cmp(e.Amount) != 0 || p.Beneficiary != e.Beneficiary || p.Bid != e.Bid ||
+			p.ExpirationHeight != e.ExpirationHeight || p.Confirmed != e.Confirmed || p.IsDelegated != (p.Source == StakeSourceDex) {
+			t.Fatalf("position %v not match, %+v", key, p)
+		}
+	}
+
+	// the positions are read once per snapshot block
+	quotaDb.setStakeInfo(addr, 4, nil, 60, 500, addr, false, 1)
+	if cached, err := r.GetStakePositions(addr, sb); err != nil || len(cached) != len(positions) {
+		t.Fatalf("positions are read again, %+v, %v", cached, err)
+	}
+	if positions, err := r.GetStakePositions(other, sb); err != nil || len(positions) != 0 {
+		t.Fatalf("positions of other not match, %+v, %v", positions, err)
+	}
+
+	// the errors of reading the stake in the quota contract are returned
+	quotaDb.errKey = missingId.Bytes()
+	if _, err := readStakePositions(quotaDb, dexDb, addr); err == nil {
+		t.Fatal("expected error of reading the stake by id")
+	}
+	// the dbs of a new snapshot block are created from the chain
+	if _, err := r.GetStakePositions(addr, &ledger.SnapshotBlock{Height: 11, Hash: types.Hash{11}}); err == nil {
+		t.Fatal("expected error of the chain")
+	}
+	if r.sb != sb {
+		t.Fatalf("snapshot block of the reader is changed to %+v", r.sb)
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"math"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+const maxTokenPageSize = 1000
+
+type TokenApi struct {
+	chain chain.Chain
+	log   log15.Logger
+}
+
+func NewTokenApi(vite *vite.Vite) *TokenApi {
+	return &TokenApi{
+		chain: vite.Chain(),
+		log:   log15.New("module", "rpc_api/token_api"),
+	}
+}
+
+func (t TokenApi) String() string {
+	return "TokenApi"
+}
+
+var tokenSupplyTypes = map[byte]string{
+	chain_plugins.TokenSupplyGenesis: "genesis",
+	chain_plugins.TokenSupplyIssue:   "issue",
+	chain_plugins.TokenSupplyReIssue: "reissue",
+	chain_plugins.TokenSupplyBurn:    "burn",
+}
+
+type TokenHolder struct {
+	Address types.Address `json:"address"`
+	Balance string        `json:"balance"`
+}
+
+type TokenHolderPage struct {
+	Count int            `json:"totalCount"`
+	List  []*TokenHolder `json:"holderList"`
+}
+
+type TokenSupplyRecord struct {
+	TokenId     types.TokenTypeId `json:"tokenId"`
+	Type        string            `json:"type"`
+	Amount      string            `json:"amount"`
+	TotalSupply string            `json:"totalSupply"`
+	// owner of an issued token, beneficiary of a reissue or burner
+	Address          *types.Address `json:"address,omitempty"`
+	SnapshotHeight   string         `json:"snapshotHeight"`
+	Timestamp        int64          `json:"timestamp"`
+	SendBlockHash    *types.Hash    `json:"sendBlockHash,omitempty"`
+	ReceiveBlockHash *types.Hash    `json:"receiveBlockHash,omitempty"`
+}
+
+type TokenSupplyRecordPage struct {
+	Count int                  `json:"totalCount"`
+	List  []*TokenSupplyRecord `json:"supplyRecordList"`
+}
+
+func (t *TokenApi) getTokenHolderPlugin() (*chain_plugins.TokenHolder, error) {
+	plugins := t.chain.Plugins()
+	if plugins == nil {
+		return nil, errors.New("config.OpenPlugins is false, api can't work")
+	}
+	plugin, ok := plugins.GetPlugin("tokenHolder").(*chain_plugins.TokenHolder)
+	if !ok || plugin == nil {
+		return nil, errors.New("plugins-TokenHolder's service not provided, set TokenHolderPlugin to true")
+	}
+	if err := plugins.CheckIndex("tokenHolder"); err != nil {
+		return nil, err
+	}
+	return plugin, nil
+}
+
+func tokenPageOffset(pageIndex int, pageSize int) (int, error) {
+	if pageSize <= 0 || pageSize > maxTokenPageSize {
+		return 0, errors.New("invalid pageSize")
+	}
+	if pageIndex < 0 || pageIndex >= math.MaxInt32/pageSize {
+		return 0, errors.New("invalid pageIndex")
+	}
+	return pageIndex * pageSize, nil
+}
+
+// GetHolders returns a page of the holders of a token confirmed by the latest snapshot block, the largest balance first
+func (t *TokenApi) GetHolders(tokenId types.TokenTypeId, pageIndex int, pageSize int) (*TokenHolderPage, error) {
+	offset, err := tokenPageOffset(pageIndex, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := t.getTokenHolderPlugin()
+	if err != nil {
+		return nil, err
+	}
+	holders, total, err := plugin.GetHolders(tokenId, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	page := &TokenHolderPage{Count: total, List: make([]*TokenHolder, len(holders))}
+	for i, holder := range holders {
+		page.List[i] = &TokenHolder{Address: holder.Address, Balance: *bigIntToString(holder.Balance)}
+	}
+	return page, nil
+}
+
+// GetSupplyHistory returns a page of the supply changes of a token by genesis, issue, reissue and burn, the latest first
+func (t *TokenApi) GetSupplyHistory(tokenId types.TokenTypeId, pageIndex int, pageSize int) (*TokenSupplyRecordPage, error) {
+	offset, err := tokenPageOffset(pageIndex, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := t.getTokenHolderPlugin()
+	if err != nil {
+		return nil, err
+	}
+	records, total, err := plugin.GetSupplyHistory(tokenId, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	page := &TokenSupplyRecordPage{Count: total, List: make([]*TokenSupplyRecord, len(records))}
+	for i, record := range records {
+		page.List[i] = &TokenSupplyRecord{
+			TokenId:        record.TokenId,
+			Type:           tokenSupplyTypes[record.Kind],
+			Amount:         *bigIntToString(record.Amount),
+			TotalSupply:    *bigIntToString(record.TotalSupply),
+			SnapshotHeight: Uint64ToString(record.SnapshotHeight),
+			Timestamp:      record.Timestamp,
+		}
+		if record.Kind != chain_plugins.TokenSupplyGenesis {
+			address, sendBlockHash, receiveBlockHash := record.Address, record.SendBlockHash, record.ReceiveBlockHash
+			page.List[i].Address = &address
+			page.List[i].SendBlockHash = &sendBlockHash
+			page.List[i].ReceiveBlockHash = &receiveBlockHash
+		}
+	}
+	return page, nil
+}
@@ -62,6 +62,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewNetApi(vite),
 			Public:    true,
 		}
+	case "node":
+		return rpc.API{
+			Namespace: "node",
+			Version:   "1.0",
+			Service:   api.NewNodeApi(vite),
+			Public:    true,
+		}
 	case "contract":
 		return rpc.API{
 			Namespace: "contract",
@@ -90,6 +97,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewAssetApi(vite),
 			Public:    true,
 		}
+	case "token":
+		return rpc.API{
+			Namespace: "token",
+			Version:   "1.0",
+			Service:   api.NewTokenApi(vite),
+			Public:    true,
+		}
 	case "pledge":
 		return rpc.API{
 			Namespace: "pledge",
@@ -97,6 +111,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewQuotaApi(vite),
 			Public:    true,
 		}
+	case "quota":
+		return rpc.API{
+			Namespace: "quota",
+			Version:   "1.0",
+			Service:   api.NewQuotaApi(vite),
+			Public:    true,
+		}
 	case "dexfund":
 		return rpc.API{
 			Namespace: "dexfund",
@@ -132,6 +153,27 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewConsensusGroupApi(vite),
 			Public:    true,
 		}
+	case "pool":
+		return rpc.API{
+			Namespace: "pool",
+			Version:   "1.0",
+			Service:   api.NewPoolApi(vite),
+			Public:    true,
+		}
+	case "consensus":
+		return rpc.API{
+			Namespace: "consensus",
+			Version:   "1.0",
+			Service:   api.NewConsensusApi(vite),
+			Public:    true,
+		}
+	case "dev":
+		return rpc.API{
+			Namespace: "dev",
+			Version:   "1.0",
+			Service:   api.NewDevApi(vite),
+			Public:    true,
+		}
 	case "tx":
 		return rpc.API{
 			Namespace: "tx",
@@ -0,0 +1,232 @@
+package mock_conn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+var errNetworkUnreachable = errors.New("mock network unreachable")
+var errListenerClosed = errors.New("mock listener closed")
+
+// Network is an in-memory network of hosts, every host is identified by an IP.
+// Connections are synchronous pipes, they report TCP addresses so that they can replace TCP connections of p2p.
+// The network can be split into partitions, hosts of different partitions can't reach each other.
+type Network struct {
+	mu        sync.Mutex
+	listeners map[string]*listener // key is ip:port
+	conns     map[*conn]struct{}
+	ports     map[string]int // next ephemeral port of ip
+	groups    map[string]int // partition of ip, hosts not in map are in partition 0
+}
+
+func NewNetwork() *Network {
+	return &Network{
+		listeners: make(map[string]*listener),
+		conns:     make(map[*conn]struct{}),
+		ports:     make(map[string]int),
+		groups:    make(map[string]int),
+	}
+}
+
+// Host returns the transport of host ip, which can be used as config.Transport of p2p.
+func (nw *Network) Host(ip string) *Host {
+	if net.ParseIP(ip) == nil {
+		panic(fmt.Errorf("invalid ip %s", ip))
+	}
+	return &Host{
+		nw: nw,
+		ip: ip,
+	}
+}
+
+// Partition splits the network, hosts in the same group can reach each other,
+// hosts not in any group form another partition. Connections across partitions are closed.
+func (nw *Network) Partition(groups ...[]string) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	nw.groups = make(map[string]int)
+	for i, group := range groups {
+		for _, ip := range group {
+			nw.groups[ip] = i + 1
+		}
+	}
+
+	for c := range nw.conns {
+		if !nw.reachable(c.local.IP.String(), c.remote.IP.String()) {
+			delete(nw.conns, c)
+			_ = c.Conn.Close()
+		}
+	}
+}
+
+// Heal removes all partitions, closed connections are not recovered, hosts should dial again.
+func (nw *Network) Heal() {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	nw.groups = make(map[string]int)
+}
+
+// Conns returns the count of open connections.
+func (nw *Network) Conns() int {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	return len(nw.conns)
+}
+
+func (nw *Network) reachable(ip1, ip2 string) bool {
+	return nw.groups[ip1] == nw.groups[ip2]
+}
+
+func (nw *Network) listen(ip, addr string) (net.Listener, error) {
+	host, port, err := splitAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host != ip && !net.ParseIP(host).IsUnspecified() {
+		return nil, fmt.Errorf("can't listen %s on host %s", addr, ip)
+	}
+
+	la := &net.TCPAddr{IP: net.ParseIP(ip), Port: port}
+
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	key := la.String()
+	if _, ok := nw.listeners[key]; ok {
+		return nil, fmt.Errorf("listen %s: address already in use", key)
+	}
+
+	ln := &listener{
+		nw:     nw,
+		addr:   la,
+		accept: make(chan net.Conn),
+		term:   make(chan struct{}),
+	}
+	nw.listeners[key] = ln
+
+	return ln, nil
+}
+
+func (nw *Network) dial(ip, addr string) (net.Conn, error) {
+	host, port, err := splitAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	ra := &net.TCPAddr{IP: net.ParseIP(host), Port: port}
+
+	nw.mu.Lock()
+	ln, ok := nw.listeners[ra.String()]
+	if !ok || !nw.reachable(ip, host) {
+		nw.mu.Unlock()
+		return nil, fmt.Errorf("dial %s: %v", addr, errNetworkUnreachable)
+	}
+	nw.ports[ip]++
+	la := &net.TCPAddr{IP: net.ParseIP(ip), Port: 30000 + nw.ports[ip]}
+
+	c1, c2 := net.Pipe()
+	local := &conn{Conn: c1, nw: nw, local: la, remote: ra}
+	remote := &conn{Conn: c2, nw: nw, local: ra, remote: la}
+	nw.conns[local] = struct{}{}
+	nw.conns[remote] = struct{}{}
+	nw.mu.Unlock()
+
+	select {
+	case ln.accept <- remote:
+		return local, nil
+	case <-ln.term:
+		_ = local.Close()
+		_ = remote.Close()
+		return nil, fmt.Errorf("dial %s: %v", addr, errNetworkUnreachable)
+	}
+}
+
+func splitAddr(addr string) (host string, port int, err error) {
+	var p string
+	host, p, err = net.SplitHostPort(addr)
+	if err != nil {
+		return
+	}
+	if host == "" {
+		host = "0.0.0.0"
+	}
+	port, err = strconv.Atoi(p)
+	return
+}
+
+// Host is the endpoint of a Network
+type Host struct {
+	nw *Network
+	ip string
+}
+
+func (h *Host) IP() string {
+	return h.ip
+}
+
+func (h *Host) Listen(addr string) (net.Listener, error) {
+	return h.nw.listen(h.ip, addr)
+}
+
+func (h *Host) Dial(addr string) (net.Conn, error) {
+	return h.nw.dial(h.ip, addr)
+}
+
+type conn struct {
+	net.Conn
+	nw            *Network
+	local, remote *net.TCPAddr
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *conn) Close() error {
+	c.nw.mu.Lock()
+	delete(c.nw.conns, c)
+	c.nw.mu.Unlock()
+
+	return c.Conn.Close()
+}
+
+type listener struct {
+	nw     *Network
+	addr   *net.TCPAddr
+	accept chan net.Conn
+	once   sync.Once
+	term   chan struct{}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-l.term:
+		return nil, errListenerClosed
+	}
+}
+
+func (l *listener) Close() error {
+	l.once.Do(func() {
+		l.nw.mu.Lock()
+		delete(l.nw.listeners, l.addr.String())
+		l.nw.mu.Unlock()
+
+		close(l.term)
+	})
+	return nil
+}
+
+func (l *listener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,72 @@
+package mock_conn
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func TestNetwork_Partition(t *testing.T) {
+	nw := NewNetwork()
+	h1, h2 := nw.Host("10.0.0.1"), nw.Host("10.0.0.2")
+
+	ln, err := h2.Listen("0.0.0.0:8483")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	c1, err := h1.Dial("10.0.0.2:8483")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2 := <-accepted
+
+	if addr := c2.RemoteAddr().(*net.TCPAddr); addr.IP.String() != "10.0.0.1" {
+		t.Fatalf("remote address of accepted conn is %s", addr)
+	}
+	if addr := c1.RemoteAddr().String(); addr != "10.0.0.2:8483" {
+		t.Fatalf("remote address of dialed conn is %s", addr)
+	}
+
+	go func() {
+		_, _ = c1.Write([]byte("hello"))
+	}()
+	buf := make([]byte, 5)
+	if _, err = io.ReadFull(c2, buf); err != nil || string(buf) != "hello" {
+		t.Fatalf("read %q %v", buf, err)
+	}
+
+	nw.Partition([]string{"10.0.0.1"})
+	if _, err = c2.Read(buf); err == nil {
+		t.Fatal("conn across partitions should be closed")
+	}
+	if _, err = h1.Dial("10.0.0.2:8483"); err == nil {
+		t.Fatal("host across partitions should be unreachable")
+	}
+	if nw.Conns() != 0 {
+		t.Fatalf("%d conns left", nw.Conns())
+	}
+
+	nw.Heal()
+	if c1, err = h1.Dial("10.0.0.2:8483"); err != nil {
+		t.Fatal(err)
+	}
+	<-accepted
+	_ = c1.Close()
+
+	if _, err = h1.Dial("10.0.0.3:8483"); err == nil {
+		t.Fatal("should not dial address without listener")
+	}
+}
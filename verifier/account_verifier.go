@@ -3,7 +3,6 @@ package verifier
 import (
 	"bytes"
 	"fmt"
-	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/helper"
 	"math/big"
 
@@ -79,7 +78,7 @@ func (v *AccountVerifier) verifyConfirmedTimes(recvBlock *ledger.AccountBlock, s
 	if sendConfirmedTimes < uint64(meta.SendConfirmedTimes) {
 		return ErrVerifyConfirmedTimesNotEnough
 	}
-	if fork.IsSeedFork(sbHeight) && meta.SeedConfirmedTimes > 0 {
+	if v.chain.ForkSchedule().IsSeedFork(sbHeight) && meta.SeedConfirmedTimes > 0 {
 		isSeedCountOk, err := v.chain.IsSeedConfirmedNTimes(recvBlock.FromBlockHash, uint64(meta.SeedConfirmedTimes))
 		if err != nil {
 			return err
@@ -408,6 +407,11 @@ func (v *AccountVerifier) vmVerify(block *ledger.AccountBlock, snapshotHashHeigh
 }
 
 func (v *AccountVerifier) verifyVMResult(origBlock *ledger.AccountBlock, genBlock *ledger.AccountBlock) error {
+	return VerifyVMResult(origBlock, genBlock)
+}
+
+// VerifyVMResult compares the block generated by vm with the original one, the error names the first inconsistent field.
+func VerifyVMResult(origBlock *ledger.AccountBlock, genBlock *ledger.AccountBlock) error {
 	// BlockType AccountAddress ToAddress PrevHash Height Amount TokenId FromBlockHash Data Fee LogHash Nonce SendBlockList
 	if origBlock.Hash == genBlock.Hash {
 		return nil
@@ -0,0 +1,366 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/verifier"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// CheckpointInterval is the number of snapshot blocks audited between two checkpoints.
+const CheckpointInterval = 100
+
+// StorageDiff is a storage key whose value written by the replayed block differs from the stored one.
+type StorageDiff struct {
+	Key       []byte `json:"key"`
+	Generated []byte `json:"generated"`
+	Stored    []byte `json:"stored"`
+}
+
+// BalanceDiff is a token whose balance after the replayed block differs from the stored one.
+type BalanceDiff struct {
+	TokenId   types.TokenTypeId `json:"tokenId"`
+	Generated *big.Int          `json:"generated"`
+	Stored    *big.Int          `json:"stored"`
+}
+
+// Divergence describes an account block whose vm result is inconsistent with the stored one.
+type Divergence struct {
+	SnapshotHeight uint64               `json:"snapshotHeight"`
+	SnapshotHash   types.Hash           `json:"snapshotHash"`
+	Block          *ledger.AccountBlock `json:"block"`
+	Generated      *ledger.AccountBlock `json:"generated,omitempty"` // nil if vm failed
+	Reason         string               `json:"reason"`
+
+	StorageDiff []*StorageDiff `json:"storageDiff,omitempty"`
+	BalanceDiff []*BalanceDiff `json:"balanceDiff,omitempty"`
+	// ExactState is true if the stored state is from the redo log of the block,
+	// otherwise the redo log is gone and the stored state is the one at the snapshot block,
+	// which includes the later blocks of the account in the same snapshot.
+	ExactState bool `json:"exactState"`
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("snapshot %d %s, account block %s %d %s: %s",
+		d.SnapshotHeight, d.SnapshotHash, d.Block.AccountAddress, d.Block.Height, d.Block.Hash, d.Reason)
+}
+
+// Checkpoint records the progress of an audit, so that it can be resumed.
+type Checkpoint struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+	// Next is the next snapshot height to audit
+	Next uint64 `json:"next"`
+	// Blocks is the number of account blocks audited
+	Blocks     uint64      `json:"blocks"`
+	Divergence *Divergence `json:"divergence,omitempty"`
+	UpdateTime time.Time   `json:"updateTime"`
+}
+
+// LoadCheckpoint reads the checkpoint file, it returns nil if the file doesn't exist.
+func LoadCheckpoint(file string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, errors.Wrapf(err, "parse checkpoint %s", file)
+	}
+	return cp, nil
+}
+
+func saveCheckpoint(file string, cp *Checkpoint) error {
+	cp.UpdateTime = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Auditor replays the stored account blocks through vm against the historical state,
+// and compares the results with the stored blocks by the rules of the account verifier.
+type Auditor struct {
+	chain     chain.Chain
+	consensus generator.Consensus
+	hc        *historyChain
+
+	checkpointFile string
+	// Progress is called after each checkpoint is saved
+	Progress func(cp *Checkpoint)
+
+	log log15.Logger
+}
+
+// NewAuditor creates an Auditor, the progress is saved to checkpointFile if it is not empty.
+func NewAuditor(c chain.Chain, cs generator.Consensus, checkpointFile string) *Auditor {
+	return &Auditor{
+		chain:          c,
+		consensus:      cs,
+		hc:             newHistoryChain(c),
+		checkpointFile: checkpointFile,
+		log:            log15.New("module", "audit"),
+	}
+}
+
+// Run audits the snapshot blocks in [from, to] and returns the first divergence.
+// It resumes from the checkpoint if the checkpoint is of the same range.
+func (a *Auditor) Run(from, to uint64) (*Divergence, error) {
+	if from < 2 {
+		// account blocks in genesis are not generated by vm
+		from = 2
+	}
+	if latest := a.chain.GetLatestSnapshotBlock(); to > latest.Height {
+		to = latest.Height
+	}
+	if from > to {
+		return nil, errors.Errorf("invalid range [%d, %d]", from, to)
+	}
+
+	cp := &Checkpoint{From: from, To: to, Next: from}
+	if a.checkpointFile != "" {
+		saved, err := LoadCheckpoint(a.checkpointFile)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil && saved.From == from && saved.To == to && saved.Next > from {
+			a.log.Info(fmt.Sprintf("resume from snapshot height %d", saved.Next))
+			cp.Next = saved.Next
+			cp.Blocks = saved.Blocks
+		}
+	}
+
+	for cp.Next <= to {
+		d, n, err := a.AuditSnapshot(cp.Next)
+		if err != nil {
+			a.checkpoint(cp)
+			return nil, errors.Wrapf(err, "audit snapshot %d", cp.Next)
+		}
+		if d != nil {
+			cp.Divergence = d
+			a.checkpoint(cp)
+			return d, nil
+		}
+		cp.Blocks += uint64(n)
+		cp.Next++
+		if (cp.Next-from)%CheckpointInterval == 0 {
+			a.checkpoint(cp)
+		}
+	}
+	a.checkpoint(cp)
+	return nil, nil
+}
+
+func (a *Auditor) checkpoint(cp *Checkpoint) {
+	if a.checkpointFile != "" {
+		if err := saveCheckpoint(a.checkpointFile, cp); err != nil {
+			a.log.Error(fmt.Sprintf("save checkpoint failed, err:%v", err))
+		}
+	}
+	if a.Progress != nil {
+		a.Progress(cp)
+	}
+}
+
+// AuditSnapshot replays the account blocks confirmed by the snapshot block of the height,
+// it returns the first divergence and the number of account blocks replayed.
+func (a *Auditor) AuditSnapshot(height uint64) (*Divergence, int, error) {
+	prev, err := a.chain.GetSnapshotHeaderByHeight(height - 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if prev == nil {
+		return nil, 0, errors.Errorf("snapshot block %d not found", height-1)
+	}
+	chunks, err := a.chain.GetSubLedger(height-1, height)
+	if err != nil {
+		return nil, 0, err
+	}
+	var chunk *ledger.SnapshotChunk
+	for _, c := range chunks {
+		if c.SnapshotBlock != nil && c.SnapshotBlock.Height == height {
+			chunk = c
+		}
+	}
+	if chunk == nil {
+		return nil, 0, errors.Errorf("snapshot chunk %d not found", height)
+	}
+
+	if err := a.hc.reset(prev); err != nil {
+		return nil, 0, err
+	}
+	for i, block := range chunk.AccountBlocks {
+		d, err := a.replay(prev, chunk.SnapshotBlock, block)
+		if err != nil {
+			return nil, i, err
+		}
+		if d != nil {
+			return d, i, nil
+		}
+	}
+	return nil, len(chunk.AccountBlocks), nil
+}
+
+// replay generates block on the state of prev, snapshot is the snapshot block confirming block.
+func (a *Auditor) replay(prev, snapshot *ledger.SnapshotBlock, block *ledger.AccountBlock) (*Divergence, error) {
+	d, gen, err := a.generate(prev, block)
+	if err != nil || d == nil {
+		return nil, err
+	}
+	d.SnapshotHeight = snapshot.Height
+	d.SnapshotHash = snapshot.Hash
+	if gen != nil {
+		if err := a.diff(d, gen); err != nil {
+			a.log.Error(fmt.Sprintf("diff state failed, err:%v", err))
+		}
+	}
+	return d, nil
+}
+
+// generate returns the divergence of block and the block generated if vm succeeds.
+func (a *Auditor) generate(prev *ledger.SnapshotBlock, block *ledger.AccountBlock) (*Divergence, *vm_db.VmAccountBlock, error) {
+	var fromBlock *ledger.AccountBlock
+	if block.IsReceiveBlock() {
+		var err error
+		fromBlock, err = a.chain.GetAccountBlockByHash(block.FromBlockHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fromBlock == nil {
+			return &Divergence{Block: block, Reason: verifier.ErrVerifyDependentSendBlockNotExists.Error()}, nil, nil
+		}
+	}
+	gen, err := generator.NewGenerator(a.hc, a.consensus, block.AccountAddress, &prev.Hash, &block.PrevHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	genResult, err := gen.GenerateWithBlock(block, fromBlock)
+	if err != nil {
+		return &Divergence{Block: block, Reason: fmt.Sprintf("generate failed, err:%v", err)}, nil, nil
+	}
+	if genResult == nil || genResult.VMBlock == nil {
+		reason := "vm failed, blockList is empty"
+		if genResult != nil && genResult.Err != nil {
+			reason = genResult.Err.Error()
+		}
+		return &Divergence{Block: block, Reason: reason}, nil, nil
+	}
+	if err := verifier.VerifyVMResult(block, genResult.VMBlock.AccountBlock); err != nil {
+		return &Divergence{
+			Block:     block,
+			Generated: genResult.VMBlock.AccountBlock,
+			Reason:    fmt.Sprintf("%v: %v", verifier.ErrVerifyVmResultInconsistent, err),
+		}, genResult.VMBlock, nil
+	}
+	a.hc.apply(genResult.VMBlock)
+	return nil, nil, nil
+}
+
+// diff compares the state written by the replayed block with the redo log of the stored block,
+// or with the state at the snapshot block if the redo log is gone.
+func (a *Auditor) diff(d *Divergence, gen *vm_db.VmAccountBlock) error {
+	addr := d.Block.AccountAddress
+	genStorage := make(map[string][]byte)
+	for _, kv := range gen.VmDb.GetUnsavedStorage() {
+		genStorage[string(kv[0])] = kv[1]
+	}
+	genBalance := gen.VmDb.GetUnsavedBalanceMap()
+
+	storedStorage := make(map[string][]byte)
+	storedBalance := make(map[types.TokenTypeId]*big.Int)
+
+	_, _, stateDB := a.chain.DBs()
+	snapshotLog, ok, err := stateDB.Redo().QueryLog(d.SnapshotHeight)
+	if err != nil {
+		return err
+	}
+	if ok {
+		for _, item := range snapshotLog[addr] {
+			if item.Height != d.Block.Height {
+				continue
+			}
+			d.ExactState = true
+			for _, kv := range item.Storage {
+				storedStorage[string(kv[0])] = kv[1]
+			}
+			for tokenId, balance := range item.BalanceMap {
+				storedBalance[tokenId] = balance
+			}
+		}
+	}
+	if !d.ExactState {
+		for key := range genStorage {
+			value, err := stateDB.GetSnapshotValue(d.SnapshotHeight, addr, []byte(key))
+			if err != nil {
+				return err
+			}
+			storedStorage[key] = value
+		}
+		for tokenId := range genBalance {
+			balanceMap := make(map[types.Address]*big.Int, 1)
+			if err := stateDB.GetSnapshotBalanceList(balanceMap, d.SnapshotHash, []types.Address{addr}, tokenId); err != nil {
+				return err
+			}
+			storedBalance[tokenId] = balanceMap[addr]
+		}
+	}
+
+	for key := range unionKeys(genStorage, storedStorage) {
+		if !bytes.Equal(genStorage[key], storedStorage[key]) {
+			d.StorageDiff = append(d.StorageDiff, &StorageDiff{Key: []byte(key), Generated: genStorage[key], Stored: storedStorage[key]})
+		}
+	}
+	sort.Slice(d.StorageDiff, func(i, j int) bool {
+		return bytes.Compare(d.StorageDiff[i].Key, d.StorageDiff[j].Key) < 0
+	})
+
+	tokens := make(map[types.TokenTypeId]struct{})
+	for tokenId := range genBalance {
+		tokens[tokenId] = struct{}{}
+	}
+	for tokenId := range storedBalance {
+		tokens[tokenId] = struct{}{}
+	}
+	for tokenId := range tokens {
+		g, s := genBalance[tokenId], storedBalance[tokenId]
+		if g == nil || s == nil || g.Cmp(s) != 0 {
+			d.BalanceDiff = append(d.BalanceDiff, &BalanceDiff{TokenId: tokenId, Generated: g, Stored: s})
+		}
+	}
+	sort.Slice(d.BalanceDiff, func(i, j int) bool {
+		return bytes.Compare(d.BalanceDiff[i].TokenId.Bytes(), d.BalanceDiff[j].TokenId.Bytes()) < 0
+	})
+	return nil
+}
+
+func unionKeys(a, b map[string][]byte) map[string]struct{} {
+	result := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		result[k] = struct{}{}
+	}
+	for k := range b {
+		result[k] = struct{}{}
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/verifier"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "checkpoint.json")
+
+	if cp, err := LoadCheckpoint(file); err != nil || cp != nil {
+		t.Fatal("missing checkpoint should be nil", cp, err)
+	}
+
+	block := &ledger.AccountBlock{Height: 3, Hash: types.DataHash([]byte{1})}
+	saved := &Checkpoint{From: 2, To: 100, Next: 50, Blocks: 120, Divergence: &Divergence{
+		SnapshotHeight: 50,
+		Block:          block,
+		Reason:         "inconsistent",
+		StorageDiff:    []*StorageDiff{{Key: []byte{1}, Generated: []byte{2}}},
+	}}
+	if err := saveCheckpoint(file, saved); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := LoadCheckpoint(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.From != 2 || cp.To != 100 || cp.Next != 50 || cp.Blocks != 120 {
+		t.Fatal("unexpected checkpoint", cp)
+	}
+	if cp.Divergence == nil || cp.Divergence.Block.Hash != block.Hash || len(cp.Divergence.StorageDiff) != 1 {
+		t.Fatal("unexpected divergence", cp.Divergence)
+	}
+}
+
+func TestAuditor(t *testing.T) {
+	c, accounts, closeChain := newTestChain(t, "test_audit", 2)
+	defer closeChain()
+	a, b := accounts[0], accounts[1]
+
+	send1 := send(t, c, a, b, 10)
+	send2 := send(t, c, a, b, 5)
+	snapshot(t, c)
+	receive(t, c, send1, nil)
+	receive(t, c, send2, nil)
+	send3 := send(t, c, b, a, 1)
+	snapshot(t, c)
+	// the stored receive block has a log the vm doesn't generate
+	logHash := types.DataHash([]byte{1})
+	tampered := receive(t, c, send3, func(block *ledger.AccountBlock) {
+		block.LogHash = &logHash
+		block.Hash = block.ComputeHash()
+	})
+	sb4 := snapshot(t, c)
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "checkpoint.json")
+	auditor := NewAuditor(c, &test_tools.MockConsensus{}, file)
+
+	if d, n, err := auditor.AuditSnapshot(3); err != nil || d != nil || n != 3 {
+		t.Fatalf("audit snapshot 3, %v, %v, %v", d, n, err)
+	}
+
+	d, err := auditor.Run(0, 3)
+	if err != nil || d != nil {
+		t.Fatalf("audit [2, 3], %v, %v", d, err)
+	}
+	if cp, err := LoadCheckpoint(file); err != nil || cp.Next != 4 || cp.Blocks != 5 || cp.Divergence != nil {
+		t.Fatalf("checkpoint of [2, 3] is %+v, %v", cp, err)
+	}
+
+	d, err = auditor.Run(2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || d.SnapshotHeight != sb4.Height || d.SnapshotHash != sb4.Hash || d.Block.Hash != tampered.Hash ||
+		!strings.Contains(d.Reason, verifier.ErrVerifyVmResultInconsistent.Error()) || !strings.Contains(d.Reason, "LogHash") {
+		t.Fatalf("divergence is %+v", d)
+	}
+	if d.Generated == nil || d.Generated.LogHash != nil || !d.ExactState || len(d.StorageDiff) != 0 || len(d.BalanceDiff) != 0 {
+		t.Fatalf("diff of divergence is %+v, %+v, %+v, %v", d.Generated, d.StorageDiff, d.BalanceDiff, d.ExactState)
+	}
+	if cp, err := LoadCheckpoint(file); err != nil || cp.Next != sb4.Height || cp.Divergence == nil {
+		t.Fatalf("checkpoint of the divergence is %+v, %v", cp, err)
+	}
+}
@@ -0,0 +1,304 @@
+package audit
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/state"
+	"github.com/vitelabs/go-vite/common/db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// same as the accumulate height of the quota list in chain cache
+const quotaAccumulateHeight = 75
+
+// historyChain reconstructs the world state right before a snapshot block is inserted,
+// that is the state of the previous snapshot block plus the account blocks already replayed in the snapshot chunk.
+//
+// The quota used lists and the global quota are rebuilt from the account blocks confirmed by the snapshot blocks
+// in the quota window, like the quota list in chain cache. The unconfirmed part of a quota used list only has
+// the replayed blocks, while the node producing a block may have had other unconfirmed blocks of the account.
+type historyChain struct {
+	chain.Chain
+	stateDB *chain_state.StateDB
+
+	// the previous snapshot block of the audited one
+	snapshot *ledger.SnapshotBlock
+
+	unsaved  map[types.Address]*vm_db.Unsaved
+	blocks   map[types.Address][]*ledger.AccountBlock
+	metas    map[types.Address]*ledger.ContractMeta
+	replayed map[types.Hash]struct{}
+
+	// quota of each account confirmed by the snapshot blocks in the window ending at quotaHeight, the earliest first
+	quotaWindow []map[types.Address]*types.QuotaInfo
+	quotaHeight uint64
+	globalQuota types.QuotaInfo
+}
+
+func newHistoryChain(c chain.Chain) *historyChain {
+	_, _, stateDB := c.DBs()
+	return &historyChain{
+		Chain:   c,
+		stateDB: stateDB,
+	}
+}
+
+// reset discards the replayed blocks and sets the state to the snapshot block.
+func (hc *historyChain) reset(snapshot *ledger.SnapshotBlock) error {
+	if err := hc.moveQuotaWindow(snapshot.Height); err != nil {
+		return err
+	}
+	hc.snapshot = snapshot
+	hc.unsaved = make(map[types.Address]*vm_db.Unsaved)
+	hc.blocks = make(map[types.Address][]*ledger.AccountBlock)
+	hc.metas = make(map[types.Address]*ledger.ContractMeta)
+	hc.replayed = make(map[types.Hash]struct{})
+	return nil
+}
+
+// moveQuotaWindow sets the quota window to the quotaAccumulateHeight-1 snapshot blocks ending at height.
+// Snapshots are audited in order, so usually only the snapshot block of height is read.
+func (hc *historyChain) moveQuotaWindow(height uint64) error {
+	start := uint64(2)
+	if height >= quotaAccumulateHeight {
+		start = height - quotaAccumulateHeight + 2
+	}
+	if hc.quotaHeight+1 == height && len(hc.quotaWindow) > 0 {
+		start = height
+	} else {
+		hc.quotaWindow = nil
+	}
+	hc.quotaHeight = height
+	if start <= height {
+		// the chunk of the start height has the account blocks only if the sub ledger begins before it
+		chunks, err := hc.Chain.GetSubLedger(start-1, height)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range chunks {
+			if chunk.SnapshotBlock == nil || chunk.SnapshotBlock.Height < start || chunk.SnapshotBlock.Height > height {
+				continue
+			}
+			quotaMap := make(map[types.Address]*types.QuotaInfo)
+			for _, block := range chunk.AccountBlocks {
+				qi, ok := quotaMap[block.AccountAddress]
+				if !ok {
+					qi = &types.QuotaInfo{}
+					quotaMap[block.AccountAddress] = qi
+				}
+				qi.BlockCount++
+				qi.QuotaTotal += block.Quota
+				qi.QuotaUsedTotal += block.QuotaUsed
+			}
+			hc.quotaWindow = append(hc.quotaWindow, quotaMap)
+		}
+	}
+	if n := len(hc.quotaWindow) - (quotaAccumulateHeight - 1); n > 0 {
+		hc.quotaWindow = hc.quotaWindow[n:]
+	}
+
+	hc.globalQuota = types.QuotaInfo{}
+	for _, quotaMap := range hc.quotaWindow {
+		for _, qi := range quotaMap {
+			hc.globalQuota.BlockCount += qi.BlockCount
+			hc.globalQuota.QuotaTotal += qi.QuotaTotal
+			hc.globalQuota.QuotaUsedTotal += qi.QuotaUsedTotal
+		}
+	}
+	return nil
+}
+
+// apply puts the result of a replayed block into the state.
+func (hc *historyChain) apply(block *vm_db.VmAccountBlock) {
+	ab := block.AccountBlock
+	addr := ab.AccountAddress
+	u, ok := hc.unsaved[addr]
+	if !ok {
+		u = vm_db.NewUnsaved()
+		hc.unsaved[addr] = u
+	}
+	for _, kv := range block.VmDb.GetUnsavedStorage() {
+		u.SetValue(kv[0], kv[1])
+	}
+	for tokenId, balance := range block.VmDb.GetUnsavedBalanceMap() {
+		u.SetBalance(&tokenId, new(big.Int).Set(balance))
+	}
+	for contract, meta := range block.VmDb.GetUnsavedContractMeta() {
+		hc.metas[contract] = meta
+	}
+	hc.blocks[addr] = append(hc.blocks[addr], ab)
+	hc.replayed[ab.Hash] = struct{}{}
+	for _, send := range ab.SendBlockList {
+		hc.replayed[send.Hash] = struct{}{}
+	}
+}
+
+func (hc *historyChain) confirmHeight(hash types.Hash) (uint64, error) {
+	if _, ok := hc.replayed[hash]; ok {
+		return 0, nil
+	}
+	sb, err := hc.Chain.GetConfirmSnapshotHeaderByAbHash(hash)
+	if err != nil || sb == nil || sb.Height > hc.snapshot.Height {
+		return 0, err
+	}
+	return sb.Height, nil
+}
+
+func (hc *historyChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	sb, err := hc.Chain.GetConfirmSnapshotHeaderByAbHash(abHash)
+	if err != nil || sb == nil || sb.Height > hc.snapshot.Height {
+		return nil, err
+	}
+	return sb, nil
+}
+
+func (hc *historyChain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	height, err := hc.confirmHeight(blockHash)
+	if err != nil || height == 0 {
+		return 0, err
+	}
+	return hc.snapshot.Height + 1 - height, nil
+}
+
+func (hc *historyChain) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	return hc.blocks[addr]
+}
+
+// GetLatestAccountBlock returns the latest replayed block, or the latest block confirmed by the snapshot.
+func (hc *historyChain) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error) {
+	if list := hc.blocks[addr]; len(list) > 0 {
+		return list[len(list)-1], nil
+	}
+	latest, err := hc.Chain.GetLatestAccountBlock(addr)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+	if h, err := hc.confirmHeight(latest.Hash); err != nil || h > 0 {
+		return latest, err
+	}
+
+	// the confirm height grows with the account height, find the highest block confirmed by the snapshot
+	var result *ledger.AccountBlock
+	low, high := uint64(1), latest.Height-1
+	for low <= high {
+		mid := low + (high-low)/2
+		block, err := hc.Chain.GetAccountBlockByHeight(addr, mid)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		h, err := hc.confirmHeight(block.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if h > 0 {
+			result = block
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+	return result, nil
+}
+
+// GetQuotaUsedList returns the quota of address in the quota window, the last one is of the replayed blocks.
+func (hc *historyChain) GetQuotaUsedList(address types.Address) []types.QuotaInfo {
+	list := make([]types.QuotaInfo, quotaAccumulateHeight)
+	offset := len(list) - 1 - len(hc.quotaWindow)
+	for i, quotaMap := range hc.quotaWindow {
+		if qi, ok := quotaMap[address]; ok {
+			list[offset+i] = *qi
+		}
+	}
+	last := &list[len(list)-1]
+	for _, b := range hc.blocks[address] {
+		last.BlockCount++
+		last.QuotaTotal += b.Quota
+		last.QuotaUsedTotal += b.QuotaUsed
+	}
+	return list
+}
+
+// GetGlobalQuota returns the quota of all accounts in the quota window.
+func (hc *historyChain) GetGlobalQuota() types.QuotaInfo {
+	return hc.globalQuota
+}
+
+func (hc *historyChain) GetBalance(addr types.Address, tokenId types.TokenTypeId) (*big.Int, error) {
+	if u, ok := hc.unsaved[addr]; ok {
+		if balance, ok := u.GetBalance(&tokenId); ok {
+			return new(big.Int).Set(balance), nil
+		}
+	}
+	balanceMap := make(map[types.Address]*big.Int, 1)
+	if err := hc.stateDB.GetSnapshotBalanceList(balanceMap, hc.snapshot.Hash, []types.Address{addr}, tokenId); err != nil {
+		return nil, err
+	}
+	if balance, ok := balanceMap[addr]; ok && balance != nil {
+		return balance, nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (hc *historyChain) GetValue(addr types.Address, key []byte) ([]byte, error) {
+	if u, ok := hc.unsaved[addr]; ok {
+		if value, ok := u.GetValue(key); ok {
+			return value, nil
+		}
+	}
+	return hc.stateDB.GetSnapshotValue(hc.snapshot.Height, addr, key)
+}
+
+func (hc *historyChain) GetStorageIterator(address types.Address, prefix []byte) (interfaces.StorageIterator, error) {
+	iter, err := hc.stateDB.NewSnapshotStorageIteratorByHeight(hc.snapshot.Height, address, prefix)
+	if err != nil {
+		return nil, err
+	}
+	u, ok := hc.unsaved[address]
+	if !ok {
+		return iter, nil
+	}
+	return db.NewMergedIterator([]interfaces.StorageIterator{
+		u.NewStorageIterator(prefix),
+		iter,
+	}, u.IsDelete), nil
+}
+
+func (hc *historyChain) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
+	sd, err := hc.stateDB.NewStorageDatabase(hc.snapshot.Hash, types.AddressQuota)
+	if err != nil {
+		return nil, err
+	}
+	return abi.GetStakeBeneficialAmount(sd, addr)
+}
+
+// GetContractMeta returns the meta only if the contract is created before the replayed block.
+func (hc *historyChain) GetContractMeta(contractAddress types.Address) (*ledger.ContractMeta, error) {
+	if meta, ok := hc.metas[contractAddress]; ok {
+		return meta, nil
+	}
+	meta, err := hc.Chain.GetContractMeta(contractAddress)
+	if err != nil || meta == nil || types.IsBuiltinContractAddr(contractAddress) {
+		return meta, err
+	}
+	h, err := hc.confirmHeight(meta.CreateBlockHash)
+	if err != nil || h == 0 {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (hc *historyChain) IsContractAccount(address types.Address) (bool, error) {
+	if types.IsBuiltinContractAddrInUse(address) {
+		return true, nil
+	}
+	meta, err := hc.GetContractMeta(address)
+	return meta != nil, err
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/config/gen"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+)
+
+type mockSBPReader struct {
+	core.SBPStatReader
+	periodTimeIndex core.TimeIndex
+}
+
+func (r *mockSBPReader) GetPeriodTimeIndex() core.TimeIndex {
+	return r.periodTimeIndex
+}
+
+type mockChainConsensus struct {
+	test_tools.MockCssVerifier
+	reader *mockSBPReader
+}
+
+func (c *mockChainConsensus) SBPReader() core.SBPStatReader {
+	return c.reader
+}
+
+// newTestChain creates a chain of the dev genesis, accounts are prefunded and have quota by staking.
+// The returned function stops the chain and removes its data.
+func newTestChain(t *testing.T, name string, accountCount int) (chain.Chain, []types.Address, func()) {
+	vm.InitVMConfig(false, true, true, false, "")
+	dir := filepath.Join(test_tools.DefaultDataDir(), name)
+	os.RemoveAll(dir)
+
+	producer, _, _ := types.CreateAddress()
+	accounts := make([]types.Address, accountCount)
+	for i := range accounts {
+		accounts[i], _, _ = types.CreateAddress()
+	}
+	c := chain.NewChain(dir, &config.Chain{}, config_gen.MakeDevGenesisConfig(producer, accounts))
+	if err := c.Init(); err != nil {
+		t.Fatal(err)
+	}
+	c.SetConsensus(&mockChainConsensus{reader: &mockSBPReader{
+		periodTimeIndex: core.NewTimeIndex(*c.GetGenesisSnapshotBlock().Timestamp, 75*time.Second),
+	}})
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return c, accounts, func() {
+		c.Stop()
+		c.Destroy()
+		os.RemoveAll(dir)
+	}
+}
+
+func insertBlock(t *testing.T, c chain.Chain, addr types.Address, generate func(gen *generator.Generator) (*generator.GenResult, error)) *ledger.AccountBlock {
+	prevHash := types.Hash{}
+	prev, err := c.GetLatestAccountBlock(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev != nil {
+		prevHash = prev.Hash
+	}
+	gen, err := generator.NewGenerator(c, &test_tools.MockConsensus{}, addr, &c.GetLatestSnapshotBlock().Hash, &prevHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := generate(gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Err != nil || result.VMBlock == nil {
+		t.Fatalf("generate block of %s failed, %v", addr, result.Err)
+	}
+	if err := c.InsertAccountBlock(result.VMBlock); err != nil {
+		t.Fatal(err)
+	}
+	return result.VMBlock.AccountBlock
+}
+
+func send(t *testing.T, c chain.Chain, from, to types.Address, amount int64) *ledger.AccountBlock {
+	return insertBlock(t, c, from, func(gen *generator.Generator) (*generator.GenResult, error) {
+		return gen.GenerateWithMessage(&generator.IncomingMessage{
+			BlockType:      ledger.BlockTypeSendCall,
+			AccountAddress: from,
+			ToAddress:      &to,
+			TokenId:        &ledger.ViteTokenId,
+			Amount:         big.NewInt(amount),
+		}, nil, nil)
+	})
+}
+
+// receive inserts the receive block of sendBlock, tamper modifies the block before it is inserted
+func receive(t *testing.T, c chain.Chain, sendBlock *ledger.AccountBlock, tamper func(block *ledger.AccountBlock)) *ledger.AccountBlock {
+	return insertBlock(t, c, sendBlock.ToAddress, func(gen *generator.Generator) (*generator.GenResult, error) {
+		result, err := gen.GenerateWithOnRoad(sendBlock, nil, nil, nil)
+		if err == nil && tamper != nil && result.VMBlock != nil {
+			tamper(result.VMBlock.AccountBlock)
+		}
+		return result, err
+	})
+}
+
+// snapshot inserts a snapshot block confirming all unconfirmed account blocks
+func snapshot(t *testing.T, c chain.Chain) *ledger.SnapshotBlock {
+	latest := c.GetLatestSnapshotBlock()
+	content := make(ledger.SnapshotContent)
+	for _, block := range c.GetAllUnconfirmedBlocks() {
+		content[block.AccountAddress] = &ledger.HashHeight{Hash: block.Hash, Height: block.Height}
+	}
+	timestamp := latest.Timestamp.Add(time.Second)
+	sb := &ledger.SnapshotBlock{
+		PrevHash:        latest.Hash,
+		Height:          latest.Height + 1,
+		Timestamp:       &timestamp,
+		SnapshotContent: content,
+	}
+	sb.Hash = sb.ComputeHash(c.ForkSchedule())
+	if _, err := c.InsertSnapshotBlock(sb); err != nil {
+		t.Fatal(err)
+	}
+	return sb
+}
+
+func TestHistoryChain(t *testing.T) {
+	c, accounts, closeChain := newTestChain(t, "test_audit_history", 2)
+	defer closeChain()
+	a, b := accounts[0], accounts[1]
+
+	sb1 := c.GetLatestSnapshotBlock()
+	send1 := send(t, c, a, b, 10)
+	send2 := send(t, c, a, b, 5)
+	sb2 := snapshot(t, c)
+	receive(t, c, send1, nil)
+	receive2 := receive(t, c, send2, nil)
+	sb3 := snapshot(t, c)
+	send(t, c, a, b, 1)
+	snapshot(t, c)
+
+	hc := newHistoryChain(c)
+	if err := hc.reset(sb2); err != nil {
+		t.Fatal(err)
+	}
+	balance2, err := hc.GetBalance(b, ledger.ViteTokenId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest, err := hc.GetLatestAccountBlock(a); err != nil || latest == nil || latest.Hash != send2.Hash {
+		t.Fatalf("latest block of a at %d is %+v, %v", sb2.Height, latest, err)
+	}
+	// the genesis block of b
+	if latest, err := hc.GetLatestAccountBlock(b); err != nil || latest == nil || latest.Height != 1 {
+		t.Fatalf("latest block of b at %d is %+v, %v", sb2.Height, latest, err)
+	}
+	if times, err := hc.GetConfirmedTimes(send1.Hash); err != nil || times != 1 {
+		t.Fatalf("confirmed times of send1 at %d is %d, %v", sb2.Height, times, err)
+	}
+	if times, err := hc.GetConfirmedTimes(receive2.Hash); err != nil || times != 0 {
+		t.Fatalf("confirmed times of receive2 at %d is %d, %v", sb2.Height, times, err)
+	}
+	usedList := hc.GetQuotaUsedList(a)
+	if len(usedList) != quotaAccumulateHeight {
+		t.Fatalf("length of quota used list is %d", len(usedList))
+	}
+	if last := usedList[len(usedList)-2]; last.BlockCount != 2 || last.QuotaUsedTotal != send1.QuotaUsed+send2.QuotaUsed {
+		t.Fatalf("quota used of a at %d is %+v", sb2.Height, last)
+	}
+	if global := hc.GetGlobalQuota(); global.BlockCount != 2 || global.QuotaUsedTotal != send1.QuotaUsed+send2.QuotaUsed {
+		t.Fatalf("global quota at %d is %+v", sb2.Height, global)
+	}
+
+	// the quota window slides to the next snapshot block
+	if err := hc.reset(sb3); err != nil {
+		t.Fatal(err)
+	}
+	balance3, err := hc.GetBalance(b, ledger.ViteTokenId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).Sub(balance3, balance2).Cmp(big.NewInt(15)) != 0 {
+		t.Fatalf("balance of b is %v at %d and %v at %d", balance2, sb2.Height, balance3, sb3.Height)
+	}
+	if latest, err := hc.GetLatestAccountBlock(b); err != nil || latest == nil || latest.Hash != receive2.Hash {
+		t.Fatalf("latest block of b at %d is %+v, %v", sb3.Height, latest, err)
+	}
+	if global := hc.GetGlobalQuota(); global.BlockCount != 4 {
+		t.Fatalf("global quota at %d is %+v", sb3.Height, global)
+	}
+	if usedList := hc.GetQuotaUsedList(a); usedList[len(usedList)-3].BlockCount != 2 || usedList[len(usedList)-2].BlockCount != 0 {
+		t.Fatalf("quota used list of a at %d is %+v", sb3.Height, usedList[len(usedList)-3:])
+	}
+
+	// the window is read again after going back
+	if err := hc.reset(sb1); err != nil {
+		t.Fatal(err)
+	}
+	if global := hc.GetGlobalQuota(); global.BlockCount != 0 {
+		t.Fatalf("global quota at %d is %+v", sb1.Height, global)
+	}
+}
@@ -46,7 +46,7 @@ func (self *SnapshotVerifier) verifyTimestamp(block *ledger.SnapshotBlock) error
 }
 
 func (self *SnapshotVerifier) verifyDataValidity(block *ledger.SnapshotBlock) error {
-	computedHash := block.ComputeHash()
+	computedHash := block.ComputeHash(self.reader.ForkSchedule())
 	if block.Hash.IsZero() || computedHash != block.Hash {
 		return ErrVerifyHashFailed
 	}
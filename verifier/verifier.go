@@ -168,7 +168,7 @@ func (v *verifier) GetSnapshotVerifier() *SnapshotVerifier {
 }
 
 func (v *verifier) VerifySnapshotBlockHash(block *ledger.SnapshotBlock) error {
-	computedHash := block.ComputeHash()
+	computedHash := block.ComputeHash(v.Sv.reader.ForkSchedule())
 	if block.Hash.IsZero() || computedHash != block.Hash {
 		return ErrVerifyHashFailed
 	}
@@ -0,0 +1,14 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package health
+
+import "syscall"
+
+// diskFree returns the bytes available to the user on the disk holding dir
+func diskFree(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
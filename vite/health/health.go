@@ -0,0 +1,201 @@
+// Package health checks whether a node works and whether it is ready to serve the requests.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/flusher"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+const mb = 1 << 20
+
+// State is a snapshot of the node states being checked
+type State struct {
+	Now time.Time
+
+	SyncState          net.SyncState
+	Height             uint64
+	BlockTime          time.Time
+	IrreversibleHeight uint64
+	PeerHeight         uint64 // the highest snapshot height of the peers and the sync target
+	PeerCount          int
+	Single             bool
+
+	// the data dir and the disk space are only read by the checks, they are not reported
+	DataDir  string
+	FreeDisk uint64
+	DiskErr  error
+
+	Flusher chain_flusher.Status
+}
+
+// Check is the result of a check, Reason explains why it failed.
+// A failed liveness check makes the node unhealthy, the others make it not ready.
+type Check struct {
+	Name     string `json:"name"`
+	Ok       bool   `json:"ok"`
+	Liveness bool   `json:"liveness"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Report is the result of all checks
+type Report struct {
+	Healthy bool    `json:"healthy"`
+	Ready   bool    `json:"ready"`
+	Checks  []Check `json:"checks"`
+
+	SyncState          string `json:"syncState"`
+	Height             string `json:"height"`
+	IrreversibleHeight string `json:"irreversibleHeight"`
+	PeerHeight         string `json:"peerHeight"`
+	PeerCount          int    `json:"peerCount"`
+}
+
+// Collect reads the states of v
+func Collect(v *vite.Vite) State {
+	s := State{
+		Now:     time.Now(),
+		Single:  v.Config().Net != nil && v.Config().Net.Single,
+		DataDir: v.Config().DataDir,
+	}
+
+	latest := v.Chain().GetLatestSnapshotBlock()
+	s.Height = latest.Height
+	if latest.Timestamp != nil {
+		s.BlockTime = *latest.Timestamp
+	}
+	if irreversible := v.Pool().GetIrreversibleBlock(); irreversible != nil {
+		s.IrreversibleHeight = irreversible.Height
+	}
+
+	status := v.Net().Status()
+	s.SyncState = status.State
+	s.PeerHeight = status.To
+	info := v.Net().Info()
+	for _, p := range info.Peers {
+		if p.Height > s.PeerHeight {
+			s.PeerHeight = p.Height
+		}
+	}
+	s.PeerCount = v.Net().PeerCount()
+
+	s.FreeDisk, s.DiskErr = diskFree(s.DataDir)
+
+	if f := v.Chain().Flusher(); f != nil {
+		s.Flusher = f.Status()
+	}
+	return s
+}
+
+// Evaluate checks s with the thresholds of cfg, the default thresholds are used if cfg is nil
+func Evaluate(s State, cfg *config.Health) Report {
+	if cfg == nil {
+		cfg = config.DefaultHealth()
+	}
+
+	var checks []Check
+	add := func(name string, liveness bool, reason string) {
+		checks = append(checks, Check{Name: name, Ok: reason == "", Liveness: liveness, Reason: reason})
+	}
+
+	// liveness
+	if cfg.MinFreeDiskMB > 0 {
+		reason := ""
+		if s.DiskErr != nil {
+			reason = "can't read the free disk space of the data dir"
+		} else if s.FreeDisk < cfg.MinFreeDiskMB*mb {
+			reason = fmt.Sprintf("free disk space of the data dir is below %d MB", cfg.MinFreeDiskMB)
+		}
+		add("diskSpace", true, reason)
+	}
+
+	reason := ""
+	if s.Flusher.Aborted {
+		reason = "flusher is aborted"
+	} else if cfg.FlushErrorTTL > 0 && s.Flusher.LastError != nil {
+		if age := s.Now.Sub(s.Flusher.LastErrorTime); age < time.Duration(cfg.FlushErrorTTL)*time.Second {
+			reason = fmt.Sprintf("flush failed %s ago: %v, %d failures in total", age.Round(time.Second), s.Flusher.LastError, s.Flusher.ErrorCount)
+		}
+	}
+	add("flusher", true, reason)
+
+	// readiness
+	reason = ""
+	if s.SyncState == net.Syncing {
+		reason = fmt.Sprintf("node is syncing, snapshot height %d, target %d", s.Height, s.PeerHeight)
+	} else if s.SyncState != net.SyncDone {
+		reason = fmt.Sprintf("sync is not done, state %s, snapshot height %d", s.SyncState, s.Height)
+	}
+	add("sync", false, reason)
+
+	if cfg.MaxHeightLag > 0 {
+		reason = ""
+		if s.PeerHeight > s.Height+cfg.MaxHeightLag {
+			reason = fmt.Sprintf("snapshot height %d is %d behind the highest peer %d, max lag is %d", s.Height, s.PeerHeight-s.Height, s.PeerHeight, cfg.MaxHeightLag)
+		}
+		add("heightLag", false, reason)
+	}
+
+	if cfg.MaxBlockAge > 0 {
+		reason = ""
+		if age := s.Now.Sub(s.BlockTime); age > time.Duration(cfg.MaxBlockAge)*time.Second {
+			reason = fmt.Sprintf("latest snapshot block %d is %s old, max age is %ds", s.Height, age.Round(time.Second), cfg.MaxBlockAge)
+		}
+		add("blockAge", false, reason)
+	}
+
+	if cfg.MinPeerCount > 0 && !s.Single {
+		reason = ""
+		if uint64(s.PeerCount) < cfg.MinPeerCount {
+			reason = fmt.Sprintf("%d peers connected, at least %d required", s.PeerCount, cfg.MinPeerCount)
+		}
+		add("peerCount", false, reason)
+	}
+
+	report := Report{
+		Healthy:            true,
+		Ready:              true,
+		Checks:             checks,
+		SyncState:          s.SyncState.String(),
+		Height:             fmt.Sprint(s.Height),
+		IrreversibleHeight: fmt.Sprint(s.IrreversibleHeight),
+		PeerHeight:         fmt.Sprint(s.PeerHeight),
+		PeerCount:          s.PeerCount,
+	}
+	for _, c := range checks {
+		if c.Ok {
+			continue
+		}
+		report.Ready = false
+		if c.Liveness {
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+// Handler serves the report of v, the status is 503 if the node is unhealthy,
+// or not ready if ready is true.
+func Handler(v *vite.Vite, ready bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := Evaluate(Collect(v), v.Config().Health)
+
+		ok := report.Healthy
+		if ready {
+			ok = report.Ready
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if ok {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,101 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/flusher"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/net"
+	"gotest.tools/assert"
+)
+
+func okState(now time.Time) State {
+	return State{
+		Now:        now,
+		SyncState:  net.SyncDone,
+		Height:     100,
+		BlockTime:  now.Add(-time.Second),
+		PeerHeight: 101,
+		PeerCount:  3,
+		DataDir:    "/data",
+		FreeDisk:   10 << 30,
+	}
+}
+
+func failed(r Report) map[string]string {
+	result := make(map[string]string)
+	for _, c := range r.Checks {
+		if !c.Ok {
+			result[c.Name] = c.Reason
+		}
+	}
+	return result
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Now()
+	cfg := config.DefaultHealth()
+
+	r := Evaluate(okState(now), cfg)
+	assert.Assert(t, r.Healthy && r.Ready, "%v", failed(r))
+	assert.Equal(t, 6, len(r.Checks))
+
+	// syncing nodes are healthy but not ready
+	s := okState(now)
+	s.SyncState = net.Syncing
+	s.PeerHeight = 2000
+	s.BlockTime = now.Add(-time.Hour)
+	s.PeerCount = 0
+	r = Evaluate(s, cfg)
+	assert.Assert(t, r.Healthy && !r.Ready)
+	reasons := failed(r)
+	assert.Equal(t, 4, len(reasons), "%v", reasons)
+	assert.Assert(t, strings.Contains(reasons["heightLag"], "1900 behind"), reasons["heightLag"])
+	assert.Assert(t, strings.Contains(reasons["blockAge"], "1h0m0s old"), reasons["blockAge"])
+	assert.Assert(t, strings.Contains(reasons["peerCount"], "0 peers"), reasons["peerCount"])
+
+	// nodes which haven't started syncing or failed to sync are not ready
+	for _, state := range []net.SyncState{net.SyncInit, net.SyncError, net.SyncCancel} {
+		s = okState(now)
+		s.SyncState = state
+		r = Evaluate(s, cfg)
+		assert.Assert(t, r.Healthy && !r.Ready)
+		assert.Assert(t, strings.Contains(failed(r)["sync"], "sync is not done"), "%v", failed(r))
+	}
+
+	// a single node has no peers
+	s = okState(now)
+	s.Single = true
+	s.PeerCount = 0
+	r = Evaluate(s, cfg)
+	assert.Assert(t, r.Ready, "%v", failed(r))
+
+	// the recent flusher errors and the low disk space make the node unhealthy
+	s = okState(now)
+	s.FreeDisk = 100 << 20
+	s.Flusher = chain_flusher.Status{LastError: errors.New("disk full"), LastErrorTime: now.Add(-time.Minute), ErrorCount: 2}
+	r = Evaluate(s, cfg)
+	assert.Assert(t, !r.Healthy && !r.Ready)
+	reasons = failed(r)
+	assert.Equal(t, "free disk space of the data dir is below 1024 MB", reasons["diskSpace"])
+	assert.Equal(t, "flush failed 1m0s ago: disk full, 2 failures in total", reasons["flusher"])
+	report, _ := json.Marshal(r)
+	assert.Assert(t, !strings.Contains(string(report), "/data"), string(report))
+
+	s.Flusher.LastErrorTime = now.Add(-time.Hour)
+	s.FreeDisk = 10 << 30
+	r = Evaluate(s, cfg)
+	assert.Assert(t, r.Healthy, "%v", failed(r))
+
+	// zero thresholds disable the checks
+	s = okState(now)
+	s.FreeDisk = 0
+	s.PeerHeight = 2000
+	r = Evaluate(s, &config.Health{})
+	assert.Assert(t, r.Healthy && r.Ready, "%v", failed(r))
+	assert.Equal(t, 2, len(r.Checks))
+}
@@ -0,0 +1,521 @@
+// Package testnet runs a network of full vite nodes in one process for tests.
+//
+// Nodes are connected by an in-memory network which can be partitioned and healed, every node is an SBP of
+// a generated genesis and produces snapshot blocks only when the fake clock of the network advances,
+// so that forks and rollbacks can be reproduced deterministically.
+package testnet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tyler-smith/go-bip39"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/config/biz"
+	"github.com/vitelabs/go-vite/config/gen"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/net/vnode"
+	"github.com/vitelabs/go-vite/producer"
+	"github.com/vitelabs/go-vite/tools/mock_conn"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+const (
+	// Mnemonic derives the SBP of every node, the i-th node produces by the i-th address. NEVER use it outside tests.
+	Mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	password = "testnet"
+
+	netID    = 7
+	port     = 8483
+	filePort = 8484
+
+	// slot is the snapshot block interval of the generated genesis
+	slot = time.Second
+)
+
+var (
+	// DefaultWaitTimeout is how long Advance waits for a snapshot block to reach the partition of its producer.
+	DefaultWaitTimeout = 10 * time.Second
+	// DefaultConnectTimeout is how long Start, Restart and Heal wait for nodes to connect and sync.
+	DefaultConnectTimeout = time.Minute
+)
+
+// Config is the config of a test network.
+type Config struct {
+	// Nodes is the count of nodes, every node is an SBP.
+	Nodes int
+	// Accounts are prefunded in genesis besides the SBPs.
+	Accounts []types.Address
+	// DataDir is the root of the data dirs of nodes, a temp dir is created and removed by Stop if empty.
+	DataDir string
+}
+
+// Network is a network of full nodes in one process.
+type Network struct {
+	cfg     *Config
+	dir     string
+	tempDir bool
+
+	nw      *mock_conn.Network
+	genesis *config.Genesis
+	nodes   []*Node
+
+	// mu serializes the operations of the network
+	mu        sync.Mutex
+	clock     time.Time
+	partition map[int]int // index of node -> group, nodes not in map are in group 0
+
+	log log15.Logger
+}
+
+// Node is a full node of the test network.
+type Node struct {
+	Index    int
+	IP       string
+	Coinbase types.Address
+
+	dir     string
+	peerKey ed25519.PrivateKey
+	cfg     *config.Config
+	wallet  *wallet.Manager
+	vite    *vite.Vite
+}
+
+// New creates a network of cfg.Nodes nodes, the nodes are started by Start.
+func New(cfg *Config) (*Network, error) {
+	if cfg.Nodes <= 0 {
+		return nil, errors.New("node count must be positive")
+	}
+
+	t := &Network{
+		cfg:       cfg,
+		dir:       cfg.DataDir,
+		nw:        mock_conn.NewNetwork(),
+		partition: make(map[int]int),
+		log:       log15.New("module", "testnet"),
+	}
+	if t.dir == "" {
+		dir, err := ioutil.TempDir("", "testnet")
+		if err != nil {
+			return nil, err
+		}
+		t.dir = dir
+		t.tempDir = true
+	}
+
+	producers := make([]types.Address, cfg.Nodes)
+	for i := range producers {
+		addr, err := Address(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		producers[i] = addr
+	}
+	t.genesis = config_gen.MakeTestNetGenesisConfig(producers, cfg.Accounts)
+
+	for i := 0; i < cfg.Nodes; i++ {
+		var d [32]byte
+		d[0] = byte(i + 1)
+		_, peerKey, err := ed25519.GenerateKeyFromD(d)
+		if err != nil {
+			return nil, err
+		}
+		t.nodes = append(t.nodes, &Node{
+			Index:    i,
+			IP:       "10.0.0." + strconv.Itoa(i+1),
+			Coinbase: producers[i],
+			dir:      filepath.Join(t.dir, "node"+strconv.Itoa(i)),
+			peerKey:  peerKey,
+		})
+	}
+	for _, n := range t.nodes {
+		n.cfg = t.makeConfig(n)
+	}
+	return t, nil
+}
+
+// Address returns the i-th address derived from Mnemonic.
+func Address(i uint32) (types.Address, error) {
+	key, err := derivation.DeriveWithIndex(i, bip39.NewSeed(Mnemonic, ""))
+	if err != nil {
+		return types.Address{}, err
+	}
+	addr, err := key.Address()
+	if err != nil {
+		return types.Address{}, err
+	}
+	return *addr, nil
+}
+
+func (t *Network) makeConfig(n *Node) *config.Config {
+	var staticNodes []string
+	for _, other := range t.nodes {
+		if other == n {
+			continue
+		}
+		id, _ := vnode.Bytes2NodeID(other.peerKey.PubByte())
+		staticNodes = append(staticNodes, fmt.Sprintf("%s@%s:%d/%d", id, other.IP, port, netID))
+	}
+
+	entropyStorePath, _ := Address(0)
+	return &config.Config{
+		Producer: &config.Producer{
+			Producer:         true,
+			Coinbase:         fmt.Sprintf("%d:%s", n.Index, n.Coinbase),
+			EntropyStorePath: entropyStorePath.Hex(),
+			Manual:           true,
+		},
+		Chain:     &config.Chain{},
+		Vm:        &config.Vm{},
+		Subscribe: &config.Subscribe{},
+		OnRoad:    &config.OnRoad{},
+		Net: &config.Net{
+			Name:            "testnet-" + strconv.Itoa(n.Index),
+			NetID:           netID,
+			ListenInterface: "0.0.0.0",
+			Port:            port,
+			FilePort:        filePort,
+			DataDir:         filepath.Join(n.dir, config.DefaultNetDirName),
+			PeerKey:         n.peerKey.Hex(),
+			Discover:        false,
+			StaticNodes:     staticNodes,
+			MaxPeers:        config.DefaultMaxPeers,
+			MaxInboundRatio: config.DefaultMaxInboundRatio,
+			MinPeers:        config.DefaultMinPeers,
+			MaxPendingPeers: config.DefaultMaxPendingPeers,
+			ForwardStrategy: config.DefaultForwardStrategy,
+			AccessControl:   config.DefaultAccessControl,
+			Transport:       t.nw.Host(n.IP),
+		},
+		Reward:  &biz.Reward{Name: "testnet-" + strconv.Itoa(n.Index)},
+		Genesis: t.genesis,
+		DataDir: n.dir,
+	}
+}
+
+// Start starts all nodes, the fake clock starts from the genesis snapshot block.
+func (t *Network) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, n := range t.nodes {
+		if err := t.startNode(n); err != nil {
+			return errors.Wrapf(err, "start node %d", n.Index)
+		}
+	}
+	t.clock = *t.nodes[0].vite.Chain().GetGenesisSnapshotBlock().Timestamp
+	return t.waitConnected(DefaultConnectTimeout)
+}
+
+// Stop stops all running nodes, and removes the data dir if it is created by New.
+func (t *Network) Stop() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var result error
+	for _, n := range t.nodes {
+		if n.vite == nil {
+			continue
+		}
+		if err := t.stopNode(n); err != nil && result == nil {
+			result = errors.Wrapf(err, "stop node %d", n.Index)
+		}
+	}
+	if t.tempDir {
+		if err := os.RemoveAll(t.dir); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+func (t *Network) startNode(n *Node) (err error) {
+	if n.wallet == nil {
+		walletDir := filepath.Join(n.dir, "wallet")
+		if err = os.MkdirAll(walletDir, 0700); err != nil {
+			return
+		}
+		n.wallet = wallet.New(&wallet.Config{DataDir: walletDir})
+		if err = n.wallet.Start(); err != nil {
+			return
+		}
+		if _, err = n.wallet.RecoverEntropyStoreFromMnemonic(Mnemonic, password); err != nil {
+			return
+		}
+		if err = n.wallet.Unlock(n.cfg.EntropyStorePath, password); err != nil {
+			return
+		}
+	}
+
+	v, err := vite.New(n.cfg, n.wallet)
+	if err != nil {
+		return
+	}
+	if err = v.Init(); err != nil {
+		return
+	}
+	if err = v.Start(); err != nil {
+		return
+	}
+	n.vite = v
+	return nil
+}
+
+func (t *Network) stopNode(n *Node) error {
+	v := n.vite
+	n.vite = nil
+	if err := v.Stop(); err != nil {
+		return err
+	}
+	// release the stores, so that the node can be restarted on the same data dir
+	return v.Chain().Destroy()
+}
+
+// Node returns the i-th node.
+func (t *Network) Node(i int) *Node {
+	return t.nodes[i]
+}
+
+// Len returns the count of nodes.
+func (t *Network) Len() int {
+	return len(t.nodes)
+}
+
+// Vite returns the running vite of the node, nil if the node is killed.
+func (n *Node) Vite() *vite.Vite {
+	return n.vite
+}
+
+// Head returns the latest snapshot block of the node, nil if the node is killed.
+func (n *Node) Head() *ledger.SnapshotBlock {
+	if n.vite == nil {
+		return nil
+	}
+	return n.vite.Chain().GetLatestSnapshotBlock()
+}
+
+// Kill stops the i-th node, its data dir is kept for Restart.
+func (t *Network) Kill(i int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := t.nodes[i]
+	if n.vite == nil {
+		return errors.Errorf("node %d is not running", i)
+	}
+	return t.stopNode(n)
+}
+
+// Restart starts the killed i-th node on its data dir.
+func (t *Network) Restart(i int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := t.nodes[i]
+	if n.vite != nil {
+		return errors.Errorf("node %d is running", i)
+	}
+	if err := t.startNode(n); err != nil {
+		return err
+	}
+	return t.waitConnected(DefaultConnectTimeout)
+}
+
+// Partition splits the network, nodes in the same group can reach each other,
+// nodes not in any group form another partition. Connections across partitions are closed.
+func (t *Network) Partition(groups ...[]int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partition = make(map[int]int)
+	ipGroups := make([][]string, len(groups))
+	for g, group := range groups {
+		for _, i := range group {
+			t.partition[i] = g + 1
+			ipGroups[g] = append(ipGroups[g], t.nodes[i].IP)
+		}
+	}
+	t.nw.Partition(ipGroups...)
+}
+
+// Heal removes all partitions and waits until nodes reconnect by their static nodes.
+func (t *Network) Heal() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partition = make(map[int]int)
+	t.nw.Heal()
+	return t.waitConnected(DefaultConnectTimeout)
+}
+
+// waitConnected waits until every running node connects to all running nodes in its partition and exits syncing
+func (t *Network) waitConnected(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for _, n := range t.nodes {
+		if n.vite == nil {
+			continue
+		}
+		peers := 0
+		for _, other := range t.nodes {
+			if other != n && other.vite != nil && t.partition[other.Index] == t.partition[n.Index] {
+				peers++
+			}
+		}
+		for {
+			nt := n.vite.Net()
+			if nt.PeerCount() >= peers && nt.SyncState() != net.SyncInit && nt.SyncState() != net.Syncing {
+				break
+			}
+			if time.Now().After(deadline) {
+				return errors.Errorf("node %d has %d peers and sync state %s, want %d peers in %s", n.Index, nt.PeerCount(), nt.SyncState(), peers, timeout)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// Now returns the fake clock.
+func (t *Network) Now() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.clock
+}
+
+// Advance moves the fake clock forward by d. For every snapshot slot passed, the running SBP of the slot
+// seals a block, Advance waits until the block reaches all running nodes in the partition of the producer
+// if the producer is on the longest chain of the partition.
+func (t *Network) Advance(d time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	end := t.clock.Add(d)
+	for next := t.clock.Add(slot); !next.After(end); next = next.Add(slot) {
+		if err := t.produce(next); err != nil {
+			return err
+		}
+		t.clock = next
+	}
+	t.clock = end
+	return nil
+}
+
+func (t *Network) produce(slotTime time.Time) error {
+	for _, n := range t.nodes {
+		if n.vite == nil {
+			continue
+		}
+		p, ok := n.vite.Producer().(producer.ManualProducer)
+		if !ok {
+			return errors.Errorf("producer of node %d is not manual", n.Index)
+		}
+		// after Heal or Restart, a node on a minority fork seals blocks the others never accept
+		winning := n.Head().Height >= t.highest(n.Index)
+		block, err := p.Produce(slotTime)
+		if err != nil {
+			return errors.Wrapf(err, "node %d produce at %s", n.Index, slotTime)
+		}
+		if block == nil {
+			continue
+		}
+		t.log.Info("produced", "node", n.Index, "height", block.Height, "hash", block.Hash, "time", slotTime, "winning", winning)
+		if !winning {
+			continue
+		}
+		if err := t.waitBlock(n.Index, block, DefaultWaitTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// highest returns the height of the longest chain of the running nodes in the partition of the i-th node
+func (t *Network) highest(i int) uint64 {
+	var height uint64
+	for _, n := range t.nodes {
+		if n.vite == nil || t.partition[n.Index] != t.partition[i] {
+			continue
+		}
+		if h := n.Head(); h != nil && h.Height > height {
+			height = h.Height
+		}
+	}
+	return height
+}
+
+// waitBlock waits until the block is received by all running nodes in the partition of the i-th node
+func (t *Network) waitBlock(i int, block *ledger.SnapshotBlock, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for _, n := range t.nodes {
+		if n.vite == nil || t.partition[n.Index] != t.partition[i] {
+			continue
+		}
+		for {
+			ok, err := n.vite.Chain().IsSnapshotBlockExisted(block.Hash)
+			if err != nil {
+				return err
+			}
+			if ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				return errors.Errorf("snapshot block %s/%d of node %d doesn't reach node %d in %s", block.Hash, block.Height, i, n.Index, timeout)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// WaitConverged waits until all running nodes have the same latest snapshot block, returns the block.
+func (t *Network) WaitConverged(timeout time.Duration) (*ledger.SnapshotBlock, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		head, err := t.converged()
+		if err == nil {
+			return head, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (t *Network) converged() (*ledger.SnapshotBlock, error) {
+	var head *ledger.SnapshotBlock
+	var first *Node
+	for _, n := range t.nodes {
+		h := n.Head()
+		if h == nil {
+			continue
+		}
+		if head == nil {
+			head, first = h, n
+			continue
+		}
+		if h.Hash != head.Hash {
+			return nil, errors.Errorf("node %d is at %s/%d, node %d is at %s/%d", first.Index, head.Hash, head.Height, n.Index, h.Hash, h.Height)
+		}
+	}
+	if head == nil {
+		return nil, errors.New("no node is running")
+	}
+	return head, nil
+}
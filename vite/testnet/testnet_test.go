@@ -0,0 +1,66 @@
+package testnet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNetwork(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip test network in short mode")
+	}
+
+	network, err := New(&Config{Nodes: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = network.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer network.Stop()
+
+	if err = network.Advance(20 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	head, err := network.WaitConverged(30 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("converged at %d", head.Height)
+
+	network.Partition([]int{0}, []int{1, 2})
+	if err = network.Advance(20 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if network.Node(0).Head().Hash == network.Node(1).Head().Hash {
+		t.Fatal("partitions should fork")
+	}
+
+	if err = network.Heal(); err != nil {
+		t.Fatal(err)
+	}
+	if err = network.Advance(20 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if head, err = network.WaitConverged(30 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("converged at %d after heal", head.Height)
+
+	if err = network.Kill(2); err != nil {
+		t.Fatal(err)
+	}
+	if err = network.Advance(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err = network.Restart(2); err != nil {
+		t.Fatal(err)
+	}
+	if err = network.Advance(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if head, err = network.WaitConverged(30 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("converged at %d after restart", head.Height)
+}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
 
 	"github.com/vitelabs/go-vite/chain"
-	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/consensus"
@@ -75,9 +75,6 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 		}
 	}
 
-	// set fork points
-	fork.SetForkPoints(cfg.ForkPoints)
-
 	// chain
 	chain := chain.NewChain(cfg.DataDir, cfg.Chain, cfg.Genesis)
 
@@ -113,11 +110,30 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 	}
 
 	if addressContext != nil {
-		vite.producer = producer.NewProducer(chain, net, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, pl)
+		if cfg.Producer.Manual {
+			vite.producer = producer.NewManualProducer(chain, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, pl)
+		} else if cfg.Producer.Dev {
+			vite.producer = producer.NewDevProducer(chain, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, pl, time.Duration(cfg.Producer.DevPeriod)*time.Second)
+		} else {
+			vite.producer = producer.NewProducer(chain, net, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, pl)
+		}
 	}
 
 	// onroad
 	or := onroad.NewManager(net, pl, vite.producer, vite.consensus, walletManager)
+	if cfg.OnRoad != nil {
+		for gidStr, policy := range cfg.OnRoad.OnRoadPolicies {
+			if policy == nil {
+				continue
+			}
+			var gid types.Gid
+			gid, err = types.HexToGid(gidStr)
+			if err != nil {
+				return
+			}
+			or.SetSchedulePolicy(gid, onroad.NewConfigSchedulePolicy(*policy))
+		}
+	}
 
 	// set onroad
 	vite.onRoad = or
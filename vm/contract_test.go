@@ -27,8 +27,9 @@ func TestRun(t *testing.T) {
 	}
 	for _, test := range tests {
 		vm := NewVM(nil)
-		vm.i = newInterpreter(1, false)
-		vm.gasTable = util.QuotaTableByHeight(1)
+		vm.forks = testForks
+		vm.i = newInterpreter(testForks, 1, false)
+		vm.gasTable = util.QuotaTableByHeight(testForks, 1)
 		//vm.Debug = true
 		sendCallBlock := ledger.AccountBlock{
 			AccountAddress: types.Address{},
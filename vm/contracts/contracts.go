@@ -218,15 +218,15 @@ func newEarthContracts() map[types.Address]*builtinContract {
 }
 
 // GetBuiltinContractMethod finds method instance of built-in contract method by address and method id
-func GetBuiltinContractMethod(addr types.Address, methodSelector []byte, sbHeight uint64) (BuiltinContractMethod, bool, error) {
+func GetBuiltinContractMethod(addr types.Address, methodSelector []byte, forks *fork.Schedule, sbHeight uint64) (BuiltinContractMethod, bool, error) {
 	var contractsMap map[types.Address]*builtinContract
-	if fork.IsEarthFork(sbHeight) {
+	if forks.IsEarthFork(sbHeight) {
 		contractsMap = earthContracts
-	} else if fork.IsLeafFork(sbHeight) {
+	} else if forks.IsLeafFork(sbHeight) {
 		contractsMap = leafContracts
-	} else if fork.IsStemFork(sbHeight) {
+	} else if forks.IsStemFork(sbHeight) {
 		contractsMap = dexAgentContracts
-	} else if fork.IsDexFork(sbHeight) {
+	} else if forks.IsDexFork(sbHeight) {
 		contractsMap = dexContracts
 	} else {
 		contractsMap = simpleContracts
@@ -39,7 +39,7 @@ func (p *MethodIssue) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
 	}
 	sb, err := db.LatestSnapshotBlock()
 	util.DealWithErr(err)
-	if err = checkToken(*param, sb.Height); err != nil {
+	if err = checkToken(*param, db.ForkSchedule(), sb.Height); err != nil {
 		return err
 	}
 	block.Data, _ = abi.ABIAsset.PackMethod(
@@ -54,17 +54,17 @@ func (p *MethodIssue) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
 	return nil
 }
 
-func checkToken(param abi.ParamIssue, sbHeight uint64) error {
+func checkToken(param abi.ParamIssue, forks *fork.Schedule, sbHeight uint64) error {
 	if param.TotalSupply.Cmp(helper.Tt256m1) > 0 ||
 		len(param.TokenName) == 0 || len(param.TokenName) > tokenNameLengthMax ||
 		len(param.TokenSymbol) == 0 || len(param.TokenSymbol) > tokenSymbolLengthMax {
 		return util.ErrInvalidMethodParam
 	}
-	if !fork.IsEarthFork(sbHeight) && (param.TotalSupply.Sign() <= 0 ||
+	if !forks.IsEarthFork(sbHeight) && (param.TotalSupply.Sign() <= 0 ||
 		param.TotalSupply.Cmp(new(big.Int).Exp(helper.Big10, new(big.Int).SetUint64(uint64(param.Decimals)), nil)) < 0) {
 		return util.ErrInvalidMethodParam
 	}
-	if fork.IsEarthFork(sbHeight) && !param.IsReIssuable && param.TotalSupply.Sign() <= 0 {
+	if forks.IsEarthFork(sbHeight) && !param.IsReIssuable && param.TotalSupply.Sign() <= 0 {
 		return util.ErrInvalidMethodParam
 	}
 	if ok, _ := regexp.MatchString("^([a-zA-Z_]+[ ]?)*[a-zA-Z_]$", param.TokenName); !ok {
@@ -235,7 +235,7 @@ func (p *MethodBurn) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
 func (p *MethodBurn) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
 	oldTokenInfo, err := abi.GetTokenByID(db, sendBlock.TokenId)
 	util.DealWithErr(err)
-	if oldTokenInfo == nil || (!util.CheckFork(db, fork.IsEarthFork) && !oldTokenInfo.IsReIssuable) ||
+	if oldTokenInfo == nil || (!util.CheckFork(db, (*fork.Schedule).IsEarthFork) && !oldTokenInfo.IsReIssuable) ||
 		(oldTokenInfo.OwnerBurnOnly && oldTokenInfo.Owner != sendBlock.AccountAddress) {
 		return nil, util.ErrInvalidMethodParam
 	}
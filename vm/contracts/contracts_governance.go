@@ -75,7 +75,7 @@ func (p *MethodRegister) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, se
 
 	snapshotBlock := vm.GlobalStatus().SnapshotBlock()
 	sb, err := db.LatestSnapshotBlock()
-	if isLeafFork := fork.IsLeafFork(sb.Height); (!isLeafFork && sendBlock.Amount.Cmp(SbpStakeAmountPreMainnet) != 0) ||
+	if isLeafFork := db.ForkSchedule().IsLeafFork(sb.Height); (!isLeafFork && sendBlock.Amount.Cmp(SbpStakeAmountPreMainnet) != 0) ||
 		(isLeafFork && sendBlock.Amount.Cmp(SbpStakeAmountMainnet) != 0) ||
 		sendBlock.TokenId != ledger.ViteTokenId {
 		return nil, util.ErrInvalidMethodParam
@@ -129,7 +129,7 @@ func (p *MethodRegister) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, se
 	stakeParam, _ := abi.GetRegisterStakeParamOfConsensusGroup(groupInfo.RegisterConditionParam)
 
 	var registerInfo []byte
-	if fork.IsEarthFork(sb.Height) {
+	if db.ForkSchedule().IsEarthFork(sb.Height) {
 		// save withdraw reward address -> sbp name
 		saveWithdrawRewardAddress(db, oldWithdrawRewardAddress, param.RewardWithdrawAddress, sendBlock.AccountAddress, param.SbpName)
 		registerInfo, _ = abi.ABIGovernance.PackVariable(
@@ -265,7 +265,7 @@ func (p *MethodRevoke) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, send
 		rewardTime = -1
 	}
 	var registerInfo []byte
-	if fork.IsEarthFork(snapshotBlock.Height) {
+	if db.ForkSchedule().IsEarthFork(snapshotBlock.Height) {
 		registerInfo, _ = abi.ABIGovernance.PackVariable(
 			abi.VariableNameRegistrationInfoV2,
 			old.Name,
@@ -365,7 +365,7 @@ func (p *MethodWithdrawReward) DoReceive(db vm_db.VmDb, block *ledger.AccountBlo
 	}
 	if endTime != old.RewardTime {
 		var registerInfo []byte
-		if fork.IsEarthFork(sb.Height) {
+		if db.ForkSchedule().IsEarthFork(sb.Height) {
 			registerInfo, _ = abi.ABIGovernance.PackVariable(
 				abi.VariableNameRegistrationInfoV2,
 				old.Name,
@@ -394,7 +394,7 @@ func (p *MethodWithdrawReward) DoReceive(db vm_db.VmDb, block *ledger.AccountBlo
 		if reward != nil && reward.TotalReward.Sign() > 0 {
 			// send reward by reIssue vite token
 			var methodName string
-			if !util.CheckFork(db, fork.IsLeafFork) {
+			if !util.CheckFork(db, (*fork.Schedule).IsLeafFork) {
 				methodName = abi.MethodNameReIssue
 			} else {
 				methodName = abi.MethodNameReIssueV2
@@ -511,11 +511,11 @@ func getSnapshotGroupStakeAmount(db vm_db.VmDb, reader util.ConsensusReader, gen
 	if err != nil {
 		return nil, forkIndex, err
 	}
-	if !fork.IsLeafFork(sb.Height) {
+	if !db.ForkSchedule().IsLeafFork(sb.Height) {
 		return SbpStakeAmountPreMainnet, 0, nil
 	}
 	if forkIndex == 0 {
-		forkSb, err := db.GetSnapshotBlockByHeight(fork.GetLeafForkPoint().Height)
+		forkSb, err := db.GetSnapshotBlockByHeight(db.ForkSchedule().GetLeafForkPoint().Height)
 		if err != nil {
 			return nil, forkIndex, err
 		}
@@ -708,7 +708,7 @@ func (p *MethodUpdateBlockProducingAddress) DoReceive(db vm_db.VmDb, block *ledg
 		}
 	}
 	var registerInfo []byte
-	if util.CheckFork(db, fork.IsEarthFork) {
+	if util.CheckFork(db, (*fork.Schedule).IsEarthFork) {
 		registerInfo, _ = abi.ABIGovernance.PackVariable(
 			abi.VariableNameRegistrationInfoV2,
 			old.Name,
@@ -817,7 +817,7 @@ func (p *MethodVote) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
 func (p *MethodVote) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
 	latestSb, err := db.LatestSnapshotBlock()
 	util.DealWithErr(err)
-	if block.Amount.Sign() != 0 || (types.IsContractAddr(block.AccountAddress) && !fork.IsStemFork(latestSb.Height)) {
+	if block.Amount.Sign() != 0 || (types.IsContractAddr(block.AccountAddress) && !db.ForkSchedule().IsStemFork(latestSb.Height)) {
 		return util.ErrInvalidMethodParam
 	}
 	param := new(abi.ParamVote)
@@ -882,7 +882,7 @@ func (p *MethodCancelVote) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) err
 	latestSb, err := db.LatestSnapshotBlock()
 	util.DealWithErr(err)
 	if block.Amount.Sign() != 0 ||
-		(types.IsContractAddr(block.AccountAddress) && !fork.IsStemFork(latestSb.Height)) {
+		(types.IsContractAddr(block.AccountAddress) && !db.ForkSchedule().IsStemFork(latestSb.Height)) {
 		return util.ErrInvalidMethodParam
 	}
 	if p.MethodName == abi.MethodNameCancelVoteV3 {
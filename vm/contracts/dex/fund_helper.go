@@ -3,7 +3,6 @@ package dex
 import (
 	"bytes"
 	"fmt"
-	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
@@ -500,7 +499,7 @@ func IsDexFeeFork(db vm_db.VmDb) bool {
 	if latestSb, err := db.LatestSnapshotBlock(); err != nil {
 		panic(err)
 	} else {
-		return fork.IsDexFeeFork(latestSb.Height)
+		return db.ForkSchedule().IsDexFeeFork(latestSb.Height)
 	}
 }
 
@@ -508,7 +507,7 @@ func IsStemFork(db vm_db.VmDb) bool {
 	if latestSb, err := db.LatestSnapshotBlock(); err != nil {
 		panic(err)
 	} else {
-		return fork.IsStemFork(latestSb.Height)
+		return db.ForkSchedule().IsStemFork(latestSb.Height)
 	}
 }
 
@@ -516,7 +515,7 @@ func IsLeafFork(db vm_db.VmDb) bool {
 	if latestSb, err := db.LatestSnapshotBlock(); err != nil {
 		panic(err)
 	} else {
-		return fork.IsLeafFork(latestSb.Height)
+		return db.ForkSchedule().IsLeafFork(latestSb.Height)
 	}
 }
 
@@ -524,7 +523,7 @@ func IsEarthFork(db vm_db.VmDb) bool {
 	if latestSb, err := db.LatestSnapshotBlock(); err != nil {
 		panic(err)
 	} else {
-		return fork.IsEarthFork(latestSb.Height)
+		return db.ForkSchedule().IsEarthFork(latestSb.Height)
 	}
 }
 
@@ -532,7 +531,7 @@ func IsDexMiningFork(db vm_db.VmDb) bool {
 	if latestSb, err := db.LatestSnapshotBlock(); err != nil {
 		panic(err)
 	} else {
-		return fork.IsDexMiningFork(latestSb.Height)
+		return db.ForkSchedule().IsDexMiningFork(latestSb.Height)
 	}
 }
 
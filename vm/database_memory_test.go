@@ -2,6 +2,7 @@ package vm
 
 import (
 	"encoding/hex"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/crypto"
 	"github.com/vitelabs/go-vite/interfaces"
@@ -29,15 +30,17 @@ type memoryDatabase struct {
 	originalStorage map[string][]byte
 	logList         []*ledger.VmLog
 	sb              *ledger.SnapshotBlock
+	forks           *fork.Schedule
 }
 
-func newMemoryDatabase(addr types.Address, sb *ledger.SnapshotBlock) *memoryDatabase {
+func newMemoryDatabase(addr types.Address, sb *ledger.SnapshotBlock, forks *fork.Schedule) *memoryDatabase {
 	return &memoryDatabase{
 		addr:            addr,
 		storage:         make(map[string][]byte),
 		originalStorage: make(map[string][]byte),
 		logList:         make([]*ledger.VmLog, 0),
 		sb:              sb,
+		forks:           forks,
 	}
 }
 func (db *memoryDatabase) GetBalance(tokenTypeID *types.TokenTypeId) (*big.Int, error) {
@@ -139,6 +142,10 @@ func (db *memoryDatabase) NewStorageIterator(prefix []byte) (interfaces.StorageI
 	return nil, nil
 }
 
+func (db *memoryDatabase) ForkSchedule() *fork.Schedule {
+	return db.forks
+}
+
 func (db *memoryDatabase) Address() *types.Address {
 	return &db.addr
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/crypto/ed25519"
@@ -40,6 +41,10 @@ func newNoDatabase() *testDatabase {
 	}
 }
 
+func (db *testDatabase) ForkSchedule() *fork.Schedule {
+	return testForks
+}
+
 func (db *testDatabase) Address() *types.Address {
 	return &db.addr
 }
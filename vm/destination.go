@@ -2,6 +2,7 @@ package vm
 
 import (
 	"bytes"
+	"github.com/hashicorp/golang-lru"
 	"github.com/vitelabs/go-vite/common/types"
 	"math/big"
 )
@@ -9,6 +10,46 @@ import (
 type bitvec []byte
 type destinations map[types.Address]bitvec
 
+// codeBitmapCacheSize is the max count of analysed codes shared by all VMs
+const codeBitmapCacheSize = 4096
+
+// analysedCodes caches the JUMPDEST analysis across VMs, so a hot contract is analysed
+// once instead of once a block. The bitmaps are never modified once cached.
+var analysedCodes = newCodeBitmapCache(codeBitmapCacheSize)
+
+// codeBitmapCache is keyed by the code address, a hit is confirmed by comparing the code,
+// so a changed code is never matched to a stale bitmap. Comparing is much cheaper than
+// hashing or analysing the code again.
+type codeBitmapCache struct {
+	cache *lru.Cache
+}
+
+type analysedCode struct {
+	code []byte
+	bits bitvec
+}
+
+func newCodeBitmapCache(size int) *codeBitmapCache {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &codeBitmapCache{cache: cache}
+}
+
+// get returns the bitmap of the code of addr, it is safe for concurrent use
+func (c *codeBitmapCache) get(addr types.Address, code []byte) bitvec {
+	if v, ok := c.cache.Get(addr); ok {
+		if analysed := v.(*analysedCode); bytes.Equal(analysed.code, code) {
+			return analysed.bits
+		}
+	}
+	// the code is copied, the caller may reuse its buffer
+	analysed := &analysedCode{code: append([]byte(nil), code...), bits: codeBitmap(code)}
+	c.cache.Add(addr, analysed)
+	return analysed.bits
+}
+
 func (bits *bitvec) set(pos uint64) {
 	(*bits)[pos/8] |= 0x80 >> (pos % 8)
 }
@@ -34,7 +75,7 @@ func (d destinations) has(addr types.Address, code []byte, dest *big.Int) bool {
 
 	m, analysed := d[addr]
 	if !analysed {
-		m = codeBitmap(code)
+		m = analysedCodes.get(addr, code)
 		d[addr] = m
 	}
 	return opCode(code[udest]) == JUMPDEST && m.codeSegment(udest)
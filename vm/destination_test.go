@@ -3,8 +3,12 @@ package vm
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"github.com/vitelabs/go-vite/common/types"
+	"io/ioutil"
 	"math/big"
+	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -177,3 +181,167 @@ func TestContainsCertainStatusCode(t *testing.T) {
 		}
 	}
 }
+
+func TestCodeBitmapCache(t *testing.T) {
+	codes := [][]byte{
+		{byte(PUSH1), 0x01, byte(JUMPDEST)},
+		{byte(PUSH2), byte(JUMPDEST), 0x01, byte(JUMPDEST)},
+		{byte(PUSH32), 0x01},
+	}
+	c := newCodeBitmapCache(2)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, code := range codes {
+				if m := c.get(types.Address{byte(i)}, code); !bytes.Equal(m, codeBitmap(code)) {
+					t.Errorf("cached bitmap of %v is %v", code, m)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if c.cache.Len() != 2 {
+		t.Fatalf("cache size is not bounded, len %v", c.cache.Len())
+	}
+	// a copy of a cached code shares its bitmap
+	addr := types.Address{2}
+	code := append([]byte{}, codes[2]...)
+	cached := c.get(addr, codes[2])
+	if m := c.get(addr, code); &m[0] != &cached[0] || c.cache.Len() != 2 {
+		t.Fatalf("bitmap of a copied code is %v, len %v", m, c.cache.Len())
+	}
+	// a changed code is analysed again, the cached code isn't changed with the buffer of the caller
+	code[0] = byte(PUSH1)
+	if m := c.get(addr, code); !bytes.Equal(m, codeBitmap(code)) {
+		t.Fatalf("bitmap of a changed code is %v", m)
+	}
+	if m := c.get(addr, codes[2]); !bytes.Equal(m, codeBitmap(codes[2])) {
+		t.Fatalf("bitmap of the original code is %v", m)
+	}
+}
+
+// runTestCodes returns the contract codes of the vm run fixtures
+func runTestCodes(b *testing.B) [][]byte {
+	files, err := filepath.Glob("./test/run_test/*.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	var codes [][]byte
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var cases map[string]struct{ Code string }
+		if err := json.Unmarshal(data, &cases); err != nil {
+			b.Fatal(err)
+		}
+		for _, c := range cases {
+			if len(c.Code) == 0 || seen[c.Code] {
+				continue
+			}
+			seen[c.Code] = true
+			code, _ := hex.DecodeString(c.Code)
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// copyCodes returns a copy of codes, every VM loads its own copy of a code from the db
+func copyCodes(codes [][]byte) [][]byte {
+	copies := make([][]byte, len(codes))
+	for i, code := range codes {
+		copies[i] = append([]byte{}, code...)
+	}
+	return copies
+}
+
+// BenchmarkCodeBitmap_PerVM analyses the codes as every new VM did before the shared cache
+func BenchmarkCodeBitmap_PerVM(b *testing.B) {
+	codes := runTestCodes(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, code := range codes {
+			codeBitmap(code)
+		}
+	}
+}
+
+// codeAddrs returns an address for each code
+func codeAddrs(codes [][]byte) []types.Address {
+	addrs := make([]types.Address, len(codes))
+	for i := range codes {
+		addrs[i] = types.Address{byte(i), byte(i >> 8)}
+	}
+	return addrs
+}
+
+func BenchmarkCodeBitmap_Shared(b *testing.B) {
+	codes := runTestCodes(b)
+	addrs := codeAddrs(codes)
+	for i, code := range codes {
+		analysedCodes.get(addrs[i], code)
+	}
+	codes = copyCodes(codes)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, code := range codes {
+			analysedCodes.get(addrs[j], code)
+		}
+	}
+}
+
+func BenchmarkCodeBitmap_SharedParallel(b *testing.B) {
+	codes := runTestCodes(b)
+	addrs := codeAddrs(codes)
+	for i, code := range codes {
+		analysedCodes.get(addrs[i], code)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		codes := copyCodes(codes)
+		for pb.Next() {
+			for j, code := range codes {
+				analysedCodes.get(addrs[j], code)
+			}
+		}
+	})
+}
+
+// largeCode is a 16KB code of pushes and JUMPDESTs, the size of a big dApp contract
+func largeCode() []byte {
+	code := make([]byte, 0, 16*1024)
+	for len(code) < cap(code)-34 {
+		code = append(code, byte(PUSH32))
+		code = append(code, make([]byte, 32)...)
+		code = append(code, byte(JUMPDEST))
+	}
+	return code
+}
+
+func BenchmarkCodeBitmap_LargeCodePerVM(b *testing.B) {
+	code := largeCode()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		codeBitmap(code)
+	}
+}
+
+func BenchmarkCodeBitmap_LargeCodeShared(b *testing.B) {
+	addr := types.Address{0xff}
+	analysedCodes.get(addr, largeCode())
+	code := largeCode()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		analysedCodes.get(addr, code)
+	}
+}
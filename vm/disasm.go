@@ -0,0 +1,239 @@
+package vm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/vitelabs/go-vite/vm/abi"
+)
+
+// Instruction is a disassembled instruction of a contract code
+type Instruction struct {
+	Pc  uint64 `json:"pc"`
+	Op  string `json:"op"`
+	Arg string `json:"arg,omitempty"` // hex encoded data of a push
+	// fork enabling the opcode, empty if it is valid since genesis
+	Fork     string `json:"fork,omitempty"`
+	OffChain bool   `json:"offchain,omitempty"` // valid in off-chain code only
+	Invalid  bool   `json:"invalid,omitempty"`
+	// target of a jump resolved from the preceding push
+	JumpTarget      *uint64 `json:"jumpTarget,omitempty"`
+	InvalidJumpDest bool    `json:"invalidJumpDest,omitempty"`
+	// signature of the abi method of a pushed function selector, "unknown" if the abi has none
+	Selector string `json:"selector,omitempty"`
+	Block    int    `json:"block"`
+
+	op opCode
+}
+
+// BasicBlock is a sequence of instructions entered at the first one and left at the last one
+type BasicBlock struct {
+	Start    uint64 `json:"start"`
+	End      uint64 `json:"end"` // pc of the last instruction
+	JumpDest bool   `json:"jumpDest"`
+	// entered by a fall through, a static jump or a dynamic jump to its JUMPDEST
+	Reachable   bool     `json:"reachable"`
+	JumpSources []uint64 `json:"jumpSources,omitempty"`
+}
+
+// Disassembly is the annotated assembly of a contract code
+type Disassembly struct {
+	Instructions []*Instruction `json:"instructions"`
+	Blocks       []*BasicBlock  `json:"blocks"`
+	// solidity metadata appended to the code, it is never executed
+	Metadata string `json:"metadata,omitempty"`
+	// forks of the fork-gated opcodes in the code
+	Forks []string `json:"forks,omitempty"`
+}
+
+// opForkInfo returns the fork enabling op, derived from the instruction sets selected by newInterpreter
+func opForkInfo(op opCode) (fork string, offChain bool, valid bool) {
+	switch {
+	case simpleInstructionSet[op].valid:
+		return "", false, true
+	case randInstructionSet[op].valid:
+		return "SeedFork", false, true
+	case earthInstructionSet[op].valid:
+		return "EarthFork", false, true
+	case offchainSimpleInstructionSet[op].valid:
+		return "", true, true
+	case offchainRandInstructionSet[op].valid:
+		return "SeedFork", true, true
+	case offchainEarthInstructionSet[op].valid:
+		return "EarthFork", true, true
+	}
+	return "", false, false
+}
+
+// Disassemble decodes code into annotated instructions and basic blocks. The function selectors
+// of the methods, callbacks and off-chain methods of contractAbi are matched if it is not nil.
+func Disassemble(code []byte, contractAbi *abi.ABIContract) *Disassembly {
+	d := &Disassembly{Instructions: make([]*Instruction, 0), Blocks: make([]*BasicBlock, 0)}
+	if containsAuxCode(code) {
+		d.Metadata = hex.EncodeToString(code[len(code)-43:])
+		code = code[:len(code)-43]
+	}
+
+	forks := make(map[string]bool)
+	index := make(map[uint64]*Instruction)
+	for pc := uint64(0); pc < uint64(len(code)); pc++ {
+		op := opCode(code[pc])
+		ins := &Instruction{Pc: pc, Op: op.String(), op: op}
+		var valid bool
+		ins.Fork, ins.OffChain, valid = opForkInfo(op)
+		if !valid {
+			ins.Invalid = true
+			ins.Op = fmt.Sprintf("INVALID(0x%02x)", byte(op))
+		}
+		if ins.Fork != "" {
+			forks[ins.Fork] = true
+		}
+		if op.isPush() {
+			// data of a push at the end of code is truncated
+			end := pc + uint64(op-PUSH1) + 1
+			if end >= uint64(len(code)) {
+				end = uint64(len(code)) - 1
+			}
+			ins.Arg = hex.EncodeToString(code[pc+1 : end+1])
+			pc = end
+		}
+		d.Instructions = append(d.Instructions, ins)
+		index[ins.Pc] = ins
+	}
+	for f := range forks {
+		d.Forks = append(d.Forks, f)
+	}
+	sort.Strings(d.Forks)
+
+	selectors := abiSelectors(contractAbi)
+	for i, ins := range d.Instructions {
+		// PUSHn target, JUMP or JUMPI
+		if (ins.op == JUMP || ins.op == JUMPI) && i > 0 && d.Instructions[i-1].op.isPush() {
+			arg, _ := hex.DecodeString(d.Instructions[i-1].Arg)
+			if target := new(big.Int).SetBytes(arg); target.IsUint64() {
+				t := target.Uint64()
+				ins.JumpTarget = &t
+				dest, ok := index[t]
+				ins.InvalidJumpDest = !ok || dest.op != JUMPDEST
+			}
+		}
+		// PUSH4 selector, EQ or PUSH4 selector, DUPn, EQ
+		if ins.op == PUSH4 && len(ins.Arg) == 8 && isSelectorCompare(d.Instructions[i+1:]) {
+			if sig, ok := selectors[ins.Arg]; ok {
+				ins.Selector = sig
+			} else {
+				ins.Selector = "unknown"
+			}
+		}
+	}
+
+	d.splitBlocks(index)
+	return d
+}
+
+// splitBlocks starts a block at the first instruction, every JUMPDEST and
+// every instruction after a jump or a halt
+func (d *Disassembly) splitBlocks(index map[uint64]*Instruction) {
+	var block *BasicBlock
+	fallThrough, leader := true, true
+	for _, ins := range d.Instructions {
+		if leader || ins.op == JUMPDEST {
+			block = &BasicBlock{Start: ins.Pc, JumpDest: ins.op == JUMPDEST}
+			block.Reachable = fallThrough || block.JumpDest
+			d.Blocks = append(d.Blocks, block)
+		}
+		block.End = ins.Pc
+		ins.Block = len(d.Blocks) - 1
+
+		switch {
+		case ins.Invalid || ins.op == STOP || ins.op == RETURN || ins.op == REVERT || ins.op == SELFDESTRUCT || ins.op == JUMP:
+			fallThrough, leader = false, true
+		case ins.op == JUMPI:
+			fallThrough, leader = true, true
+		default:
+			fallThrough, leader = true, false
+		}
+	}
+
+	for _, ins := range d.Instructions {
+		if ins.JumpTarget != nil && !ins.InvalidJumpDest {
+			dest := d.Blocks[index[*ins.JumpTarget].Block]
+			dest.JumpSources = append(dest.JumpSources, ins.Pc)
+		}
+	}
+}
+
+func isSelectorCompare(next []*Instruction) bool {
+	if len(next) > 0 && next[0].op == EQ {
+		return true
+	}
+	return len(next) > 1 && next[0].op >= DUP1 && next[0].op <= DUP16 && next[1].op == EQ
+}
+
+func abiSelectors(contractAbi *abi.ABIContract) map[string]string {
+	selectors := make(map[string]string)
+	if contractAbi == nil {
+		return selectors
+	}
+	for _, methods := range []map[string]abi.Method{contractAbi.Methods, contractAbi.Callbacks, contractAbi.OffChains} {
+		for _, m := range methods {
+			selectors[hex.EncodeToString(m.Id())] = m.Sig()
+		}
+	}
+	return selectors
+}
+
+// String returns the annotated assembly, one instruction a line
+func (d *Disassembly) String() string {
+	var sb strings.Builder
+	for i, ins := range d.Instructions {
+		if i == 0 || d.Instructions[i-1].Block != ins.Block {
+			block := d.Blocks[ins.Block]
+			sb.WriteString(fmt.Sprintf("; block %d [%04x-%04x]", ins.Block, block.Start, block.End))
+			if len(block.JumpSources) > 0 {
+				sources := make([]string, len(block.JumpSources))
+				for j, s := range block.JumpSources {
+					sources[j] = fmt.Sprintf("%04x", s)
+				}
+				sb.WriteString(" <- " + strings.Join(sources, ","))
+			}
+			if !block.Reachable {
+				sb.WriteString(" unreachable")
+			}
+			sb.WriteString("\n")
+		}
+
+		line := fmt.Sprintf("%04x  %s", ins.Pc, ins.Op)
+		if ins.Arg != "" {
+			line += " 0x" + ins.Arg
+		}
+		var notes []string
+		if ins.Selector != "" {
+			notes = append(notes, "selector "+ins.Selector)
+		}
+		if ins.JumpTarget != nil {
+			note := fmt.Sprintf("-> %04x", *ins.JumpTarget)
+			if ins.InvalidJumpDest {
+				note += " invalid jump destination"
+			}
+			notes = append(notes, note)
+		}
+		if ins.Fork != "" {
+			notes = append(notes, "requires "+ins.Fork)
+		}
+		if ins.OffChain {
+			notes = append(notes, "off-chain only")
+		}
+		if len(notes) > 0 {
+			line = fmt.Sprintf("%-40s ; %s", line, strings.Join(notes, ", "))
+		}
+		sb.WriteString(line + "\n")
+	}
+	if d.Metadata != "" {
+		sb.WriteString("; metadata " + d.Metadata + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,55 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vitelabs/go-vite/vm/abi"
+)
+
+func TestDisassemble(t *testing.T) {
+	contractAbi, err := abi.JSONToABIContract(strings.NewReader(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := []byte{byte(PUSH4)}
+	code = append(code, contractAbi.Methods["transfer"].Id()...)
+	code = append(code,
+		byte(DUP2), byte(EQ), byte(PUSH1), 0x0c, byte(JUMPI), // 0x05
+		byte(STOP),               // 0x0a
+		byte(ADD),                // 0x0b
+		byte(JUMPDEST),           // 0x0c
+		byte(RANDOM), byte(STOP), // 0x0d
+	)
+
+	d := Disassemble(code, &contractAbi)
+	if len(d.Instructions) != 10 {
+		t.Fatalf("expected 10 instructions, got %v", len(d.Instructions))
+	}
+	if sel := d.Instructions[0].Selector; sel != "transfer(address,uint256)" {
+		t.Fatalf("selector not matched, got %v", sel)
+	}
+	if jumpi := d.Instructions[4]; jumpi.JumpTarget == nil || *jumpi.JumpTarget != 0x0c || jumpi.InvalidJumpDest {
+		t.Fatalf("invalid jump target of %+v", jumpi)
+	}
+	if len(d.Blocks) != 4 {
+		t.Fatalf("expected 4 blocks, got %v", len(d.Blocks))
+	}
+	if b := d.Blocks[2]; b.Start != 0x0b || b.Reachable {
+		t.Fatalf("block after STOP must be unreachable, %+v", b)
+	}
+	if b := d.Blocks[3]; !b.JumpDest || len(b.JumpSources) != 1 || b.JumpSources[0] != 0x09 {
+		t.Fatalf("invalid jump destination block %+v", b)
+	}
+	if len(d.Forks) != 1 || d.Forks[0] != "SeedFork" || d.Instructions[8].Fork != "SeedFork" {
+		t.Fatalf("fork-gated RANDOM not marked, %v", d.Forks)
+	}
+	if text := d.String(); !strings.Contains(text, "requires SeedFork") || !strings.Contains(text, "selector transfer(address,uint256)") {
+		t.Fatalf("unexpected assembly\n%v", text)
+	}
+
+	// selectors are reported without abi too
+	if d := Disassemble(code, nil); d.Instructions[0].Selector != "unknown" {
+		t.Fatalf("selector not found without abi")
+	}
+}
@@ -349,7 +349,7 @@ func gasSStore(vm *VM, c *contract, stack *stack, mem *memory, memorySize uint64
 		loc        = stack.back(0)
 		locHash, _ = types.BigToHash(loc)
 	)
-	if fork.IsEarthFork(vm.latestSnapshotHeight) {
+	if vm.forks.IsEarthFork(vm.latestSnapshotHeight) {
 		c.storageModified[loc.String()] = struct{}{}
 		if len(c.storageModified) > contractModifyStorageMax {
 			return 0, true, util.ErrStorageModifyLimitReached
@@ -451,6 +451,7 @@ func gasCall(vm *VM, c *contract, stack *stack, mem *memory, memorySize uint64)
 			tokenID,
 			mem.get(inOffset.Int64(), inSize.Int64())),
 		vm.gasTable,
+		vm.forks,
 		vm.latestSnapshotHeight)
 	if err != nil {
 		return 0, true, err
@@ -491,7 +492,7 @@ func GasRequiredForBlock(db vm_db.VmDb, block *ledger.AccountBlock, gasTable *ut
 	if block.BlockType == ledger.BlockTypeReceive {
 		return gasReceive(block, nil, gasTable)
 	}
-	cost, err := gasRequiredForSendBlock(block, gasTable, sbHeight)
+	cost, err := gasRequiredForSendBlock(block, gasTable, db.ForkSchedule(), sbHeight)
 	if err != nil {
 		return 0, err
 	}
@@ -509,11 +510,11 @@ func GasRequiredForBlock(db vm_db.VmDb, block *ledger.AccountBlock, gasTable *ut
 	return cost, nil
 }
 
-func gasRequiredForSendBlock(block *ledger.AccountBlock, gasTable *util.QuotaTable, sbHeight uint64) (uint64, error) {
+func gasRequiredForSendBlock(block *ledger.AccountBlock, gasTable *util.QuotaTable, forks *fork.Schedule, sbHeight uint64) (uint64, error) {
 	if block.BlockType == ledger.BlockTypeSendCreate {
 		return gasSendCreate(block, gasTable)
 	} else if block.BlockType == ledger.BlockTypeSendCall {
-		return gasUserSendCall(block, gasTable, sbHeight)
+		return gasUserSendCall(block, gasTable, forks, sbHeight)
 	} else {
 		return 0, util.ErrBlockTypeNotSupported
 	}
@@ -531,9 +532,9 @@ func gasReceiveCreate(block *ledger.AccountBlock, meta *ledger.ContractMeta, gas
 	return util.BlockGasCost(nil, gasTable.CreateTxResponseQuota, snapshotCount, gasTable)
 }
 
-func gasUserSendCall(block *ledger.AccountBlock, gasTable *util.QuotaTable, sbHeight uint64) (uint64, error) {
+func gasUserSendCall(block *ledger.AccountBlock, gasTable *util.QuotaTable, forks *fork.Schedule, sbHeight uint64) (uint64, error) {
 	if types.IsBuiltinContractAddrInUse(block.ToAddress) {
-		method, ok, err := contracts.GetBuiltinContractMethod(block.ToAddress, block.Data, sbHeight)
+		method, ok, err := contracts.GetBuiltinContractMethod(block.ToAddress, block.Data, forks, sbHeight)
 		if !ok || err != nil {
 			return 0, util.ErrAbiMethodNotFound
 		}
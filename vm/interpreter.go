@@ -21,14 +21,14 @@ var (
 	offchainEarthInterpreter  = &interpreter{offchainEarthInstructionSet}
 )
 
-func newInterpreter(blockHeight uint64, offChain bool) *interpreter {
-	if fork.IsEarthFork(blockHeight) {
+func newInterpreter(forks *fork.Schedule, blockHeight uint64, offChain bool) *interpreter {
+	if forks.IsEarthFork(blockHeight) {
 		if offChain {
 			return offchainEarthInterpreter
 		}
 		return earthInterpreter
 	}
-	if fork.IsSeedFork(blockHeight) {
+	if forks.IsSeedFork(blockHeight) {
 		if offChain {
 			return offchainRandInterpreter
 		}
@@ -89,6 +89,10 @@ func (i *interpreter) runLoop(vm *VM, c *contract) (ret []byte, err error) {
 			mem.resize(memorySize)
 		}
 
+		if vm.tracer != nil {
+			vm.tracer(newTraceStep(currentPc, op, cost, c.quotaLeft, st, mem))
+		}
+
 		res, err := operation.execute(&pc, vm, c, mem, st)
 
 		if nodeConfig.IsDebug {
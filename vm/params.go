@@ -10,8 +10,8 @@ const (
 	callDepth  uint16 = 512  // Maximum Depth of call.
 	stackLimit uint64 = 1024 // Maximum size of VM stack allowed.
 
-	maxCodeSize       int    = 24575 // Maximum bytecode to permit for a contract
-	offChainReaderGas uint64 = 1000000
+	maxCodeSize         int    = 24575   // Maximum bytecode to permit for a contract
+	OffChainReaderQuota uint64 = 1000000 // Quota of an offchain reader
 
 	snapshotCountMin         uint8 = 0
 	snapshotCountMax         uint8 = 75
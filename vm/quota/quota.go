@@ -62,6 +62,7 @@ type quotaDb interface {
 	GetUnconfirmedBlocks(address types.Address) []*ledger.AccountBlock
 	GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error)
 	GetConfirmedTimes(blockHash types.Hash) (uint64, error)
+	ForkSchedule() *fork.Schedule
 }
 
 // CalcBlockQuotaUsed recalculate quotaUsed field of an account block
@@ -283,13 +284,24 @@ func CalcPoWDifficulty(db quotaDb, quotaRequired uint64, q types.Quota, sbHeight
 	if q.Current() >= quotaRequired {
 		return big.NewInt(0), nil
 	}
+	qc, _, isCongestion := CalcQc(db, sbHeight)
+	return CalcPoWDifficultyByQc(quotaRequired, qc, isCongestion)
+}
+
+// CalcPoWDifficultyByQc calculate pow difficulty of a block without stake quota by quota and quota congestion ratio
+func CalcPoWDifficultyByQc(quotaRequired uint64, qc *big.Int, isCongestion bool) (*big.Int, error) {
+	if quotaRequired > quotaLimitForBlock {
+		return nil, util.ErrBlockQuotaLimitReached
+	}
 	index, err := getIndexByQuota(quotaRequired)
 	if err != nil {
 		return nil, err
 	}
 	difficulty := new(big.Int).Set(quotaConfig.difficultyList[index])
-	difficultyByQc, _, err := calcStakeTargetParamByQc(db, difficulty, sbHeight)
-	return difficultyByQc, err
+	if !isCongestion {
+		return difficulty, nil
+	}
+	return calcStakeTargetParam(qc, isCongestion, difficulty)
 }
 
 // CalcStakeAmountByQuota calculate stake amount by expected quota used per second
@@ -303,14 +315,15 @@ func CalcStakeAmountByQuota(q uint64) (*big.Int, error) {
 	return new(big.Int).Set(quotaConfig.stakeAmountList[index]), nil
 }
 
-func calcStakeTargetParamByQc(db quotaDb, target *big.Int, sbHeight uint64) (*big.Int, *big.Int, error) {
-	qc, _, isCongestion := CalcQc(db, sbHeight)
-	if !isCongestion {
-		return target, qc, nil
+// CalcStakeAmountByQc calculate stake amount by expected quota used per second and quota congestion ratio
+func CalcStakeAmountByQc(q uint64, qc *big.Int, isCongestion bool) (*big.Int, error) {
+	amount, err := CalcStakeAmountByQuota(q)
+	if err != nil || !isCongestion || amount.Sign() == 0 {
+		return amount, err
 	}
-	newTarget, err := calcStakeTargetParam(qc, isCongestion, target)
-	return newTarget, qc, err
+	return calcStakeTargetParam(qc, isCongestion, amount)
 }
+
 func calcStakeTargetParam(qc *big.Int, isCongestion bool, target *big.Int) (*big.Int, error) {
 	newTarget := new(big.Int).Mul(target, qcDivision)
 	newTarget.Div(newTarget, qc)
@@ -348,15 +361,22 @@ func calcStakeParam(qc *big.Int, isCongestion bool, param *big.Int) *big.Int {
 
 // CalcQc calculate quota congestion ratio
 func CalcQc(db quotaDb, sbHeight uint64) (*big.Int, uint64, bool) {
-	if !fork.IsDexFork(sbHeight) {
+	if !db.ForkSchedule().IsDexFork(sbHeight) {
 		return big.NewInt(0), 0, false
 	}
 	globalQuota := db.GetGlobalQuota().QuotaUsedTotal
+	qc, isCongestion := CalcQcByGlobalQuota(globalQuota)
+	return qc, globalQuota, isCongestion
+}
+
+// CalcQcByGlobalQuota calculate quota congestion ratio by quota used by all accounts in the
+// latest snapshot blocks, it is valid since dex fork
+func CalcQcByGlobalQuota(globalQuota uint64) (*big.Int, bool) {
 	qcIndex := (globalQuota + qcGap - 1) / qcGap
 	if qcIndex < quotaConfig.qcIndexMin {
-		return qcDivision, globalQuota, false
+		return qcDivision, false
 	} else if qcIndex >= quotaConfig.qcIndexMax {
 		qcIndex = quotaConfig.qcIndexMax
 	}
-	return quotaConfig.qcMap[qcIndex], globalQuota, true
+	return quotaConfig.qcMap[qcIndex], true
 }
@@ -15,8 +15,10 @@ import (
 	"testing"
 )
 
-func initForkPointsForQuotaTest() {
-	fork.SetForkPoints(&config.ForkPoints{
+var testForks = newForkScheduleForQuotaTest()
+
+func newForkScheduleForQuotaTest() *fork.Schedule {
+	forks := fork.NewSchedule(&config.ForkPoints{
 		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
 		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
 		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
@@ -24,7 +26,8 @@ func initForkPointsForQuotaTest() {
 		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
 		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
 		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7}})
-	fork.SetActiveChecker(mockActiveChecker{})
+	forks.SetActiveChecker(mockActiveChecker{})
+	return forks
 }
 
 type mockActiveChecker struct {
@@ -41,6 +44,10 @@ type testQuotaDb struct {
 	globalQuota          types.QuotaInfo
 }
 
+func (db *testQuotaDb) ForkSchedule() *fork.Schedule {
+	return testForks
+}
+
 func (db *testQuotaDb) Address() *types.Address {
 	return &db.addr
 }
@@ -65,7 +72,6 @@ func (db *testQuotaDb) GetLatestAccountBlock(addr types.Address) (*ledger.Accoun
 
 func TestCalcPoWDifficulty(t *testing.T) {
 	InitQuotaConfig(false, false)
-	initForkPointsForQuotaTest()
 	testCases := []struct {
 		sbHeight      uint64
 		globalTotal   uint64
@@ -918,7 +924,6 @@ func TestCalcQuotaV3(t *testing.T) {
 		},
 	}
 	InitQuotaConfig(false, false)
-	initForkPointsForQuotaTest()
 	for _, testCase := range testCases {
 		db := &testQuotaDb{testCase.addr, updateUnconfirmedQuotaInfo(testCase.quotaInfoList, testCase.unconfirmedList), testCase.unconfirmedList, types.QuotaInfo{QuotaUsedTotal: testCase.globalQuota}}
 		quotaTotal, stakeQuota, quotaAddition, snapshotCurrentQuota, quotaAvg, _, _, err := calcQuotaV3(db, testCase.addr, getStakeAmount(testCase.stakeAmount), testCase.difficulty, testCase.sbHeight)
@@ -1282,7 +1287,6 @@ func TestCalcQuotaForBlock(t *testing.T) {
 		},
 	}
 	InitQuotaConfig(false, false)
-	initForkPointsForQuotaTest()
 	for _, testCase := range testCases {
 		db := &testQuotaDb{testCase.addr, updateUnconfirmedQuotaInfo(testCase.quotaInfoList, testCase.unconfirmedList), testCase.unconfirmedList, types.QuotaInfo{QuotaUsedTotal: testCase.globalQuota}}
 		quotaTotal, quotaAddition, err := GetQuotaForBlock(db, testCase.addr, getStakeAmount(testCase.stakeAmount), testCase.difficulty, testCase.sbHeight)
@@ -1340,6 +1344,40 @@ func TestCalcStakeAmountByUtps(t *testing.T) {
 	}
 }
 
+func TestCalcStakeAmountByQc(t *testing.T) {
+	InitQuotaConfig(false, false)
+	testCases := []struct {
+		globalTotal uint64
+		q           uint64
+		congestion  bool
+		name        string
+	}{
+		{0, 21000, false, "no_congestion"},
+		{74 * 50 * 21000, 21000, false, "no_congestion_below_min_index"},
+		{74 * 51 * 21000, 21000, true, "congestion"},
+		{74 * 51 * 21000, 0, true, "congestion_zero_quota"},
+		{74 * 1000 * 21000, 22000, true, "congestion_above_max_index"},
+	}
+	for _, testCase := range testCases {
+		qc, isCongestion := CalcQcByGlobalQuota(testCase.globalTotal)
+		if isCongestion != testCase.congestion {
+			t.Fatalf("%v CalcQcByGlobalQuota failed, congestion expected %v, got %v", testCase.name, testCase.congestion, isCongestion)
+		}
+		amount, err := CalcStakeAmountByQc(testCase.q, qc, isCongestion)
+		if err != nil {
+			t.Fatalf("%v CalcStakeAmountByQc failed, error %v", testCase.name, err)
+		}
+		if q := calcStakeQuota(qc, isCongestion, amount); q < testCase.q {
+			t.Fatalf("%v CalcStakeAmountByQc failed, quota of stake amount %v expected %v, got %v", testCase.name, amount, testCase.q, q)
+		}
+		if amount.Sign() > 0 {
+			if q := calcStakeQuota(qc, isCongestion, new(big.Int).Sub(amount, big.NewInt(1))); q >= testCase.q {
+				t.Fatalf("%v CalcStakeAmountByQc failed, stake amount %v is not the minimum", testCase.name, amount)
+			}
+		}
+	}
+}
+
 var (
 	testTokenID     = types.TokenTypeId{'V', 'I', 'T', 'E', ' ', 'T', 'O', 'K', 'E', 'N'}
 	testAddr, _     = types.HexToAddress("vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a")
@@ -1369,7 +1407,7 @@ func makeNumeratorList() []uint64 {
 
 func TestCalcDexQuota(t *testing.T) {
 	InitQuotaConfig(false, false)
-	gasTable := util.QuotaTableByHeight(1)
+	gasTable := util.QuotaTableByHeight(testForks, 1)
 	dataLenMap := make(map[string]int)
 	methodNameDexFundUserDepositData, _ := abi.ABIDexFund.PackMethod(abi.MethodNameDexFundUserDeposit)
 	dataLenMap[abi.MethodNameDexFundUserDeposit] = len(methodNameDexFundUserDepositData)
@@ -0,0 +1,155 @@
+package vm
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// TraceStep is the state of the interpreter before an instruction is executed,
+// the quota cost of the instruction is already charged.
+type TraceStep struct {
+	Pc         uint64
+	Op         string
+	Cost       uint64
+	QuotaLeft  uint64
+	Stack      []*big.Int
+	MemorySize int
+}
+
+// Tracer is called by the interpreter for every instruction
+type Tracer func(step *TraceStep)
+
+func newTraceStep(pc uint64, op opCode, cost uint64, quotaLeft uint64, st *stack, mem *memory) *TraceStep {
+	stackCopy := make([]*big.Int, st.len())
+	for i, v := range st.data {
+		stackCopy[i] = new(big.Int).Set(v)
+	}
+	return &TraceStep{
+		Pc:         pc,
+		Op:         opCodeToString[op],
+		Cost:       cost,
+		QuotaLeft:  quotaLeft,
+		Stack:      stackCopy,
+		MemorySize: mem.len(),
+	}
+}
+
+// RunConfig is the environment of a code executed by RunCode
+type RunConfig struct {
+	Forks          *fork.Schedule
+	SnapshotHeight uint64
+	SnapshotTime   time.Time
+	Seed           uint64
+
+	Caller  types.Address
+	Address types.Address
+	Code    []byte
+	Data    []byte
+	Amount  *big.Int
+	TokenId types.TokenTypeId
+	Quota   uint64
+
+	Tracer Tracer
+}
+
+// RunResult is the result of a code executed by RunCode. The poststate is left in the database,
+// like the interpreter RunCode doesn't revert it on errors, the caller decides whether to discard it.
+type RunResult struct {
+	ReturnData []byte
+	Err        error
+	QuotaLeft  uint64
+	QuotaUsed  uint64
+	Logs       []*ledger.VmLog
+	LogHash    *types.Hash
+	SendBlocks []*ledger.AccountBlock
+}
+
+// RunSnapshotBlock returns the snapshot block RunCode executes cfg at
+func RunSnapshotBlock(cfg *RunConfig) *ledger.SnapshotBlock {
+	sbTime := cfg.SnapshotTime
+	return &ledger.SnapshotBlock{
+		Height:    cfg.SnapshotHeight,
+		Timestamp: &sbTime,
+		Hash:      types.DataHash([]byte{1, 1}),
+	}
+}
+
+// RunCode executes code as the receive of a call to the account of db without a chain, db holds
+// the prestate of cfg.Address. The interpreter and the quota table are selected by the snapshot
+// height in the fork schedule.
+func RunCode(cfg *RunConfig, db vm_db.VmDb) *RunResult {
+	sb := RunSnapshotBlock(cfg)
+	vm := NewVM(nil)
+	vm.forks = cfg.Forks
+	vm.i = newInterpreter(cfg.Forks, cfg.SnapshotHeight, false)
+	vm.gasTable = util.QuotaTableByHeight(cfg.Forks, cfg.SnapshotHeight)
+	vm.globalStatus = NewTestGlobalStatus(cfg.Seed, sb)
+	vm.latestSnapshotHeight = cfg.SnapshotHeight
+	vm.tracer = cfg.Tracer
+
+	amount := cfg.Amount
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	sendCallBlock := ledger.AccountBlock{
+		AccountAddress: cfg.Caller,
+		ToAddress:      cfg.Address,
+		BlockType:      ledger.BlockTypeSendCall,
+		Data:           cfg.Data,
+		Amount:         amount,
+		Fee:            big.NewInt(0),
+		TokenId:        cfg.TokenId,
+	}
+	receiveCallBlock := &ledger.AccountBlock{
+		AccountAddress: cfg.Address,
+		BlockType:      ledger.BlockTypeReceive,
+	}
+
+	c := newContract(receiveCallBlock, db, &sendCallBlock, sendCallBlock.Data, cfg.Quota)
+	c.setCallCode(cfg.Address, cfg.Code)
+	util.AddBalance(db, &sendCallBlock.TokenId, sendCallBlock.Amount)
+	ret, err := c.run(vm)
+
+	return &RunResult{
+		ReturnData: ret,
+		Err:        err,
+		QuotaLeft:  c.quotaLeft,
+		QuotaUsed:  cfg.Quota - c.quotaLeft,
+		Logs:       db.GetLogList(),
+		LogHash:    db.GetLogListHash(),
+		SendBlocks: vm.sendBlockList,
+	}
+}
+
+// TestGlobalStatus is a global status with a fixed seed and snapshot block
+type TestGlobalStatus struct {
+	seed          uint64
+	snapshotBlock *ledger.SnapshotBlock
+	randSource    helper.Source64
+	setRandSeed   bool
+}
+
+func NewTestGlobalStatus(seed uint64, snapshotBlock *ledger.SnapshotBlock) *TestGlobalStatus {
+	return &TestGlobalStatus{seed: seed, snapshotBlock: snapshotBlock}
+}
+func (g *TestGlobalStatus) Seed() (uint64, error) {
+	return g.seed, nil
+}
+func (g *TestGlobalStatus) Random() (uint64, error) {
+	if g.setRandSeed {
+		return g.randSource.Uint64(), nil
+	}
+	g.randSource = helper.NewSource64(int64(g.seed))
+	g.setRandSeed = true
+	return g.randSource.Uint64(), nil
+}
+func (g *TestGlobalStatus) SnapshotBlock() *ledger.SnapshotBlock {
+	return g.snapshotBlock
+}
@@ -0,0 +1,68 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+func TestRunCode(t *testing.T) {
+	caller, _ := types.HexToAddress("vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a")
+	addr, _ := types.HexToAddress("vite_470328ad08903a431953bfdcaf7760c084233c475e5726a35c")
+	key := "0000000000000000000000000000000000000000000000000000000000000005"
+	cfg := &RunConfig{
+		Forks:          testForks,
+		SnapshotHeight: 1,
+		SnapshotTime:   time.Now(),
+		Caller:         caller,
+		Address:        addr,
+		// sload(5), sstore(0, it), return the 32 bytes of memory
+		Code:    []byte{byte(PUSH1), 5, byte(SLOAD), byte(PUSH1), 0, byte(SSTORE), byte(PUSH1), 32, byte(PUSH1), 0, byte(RETURN)},
+		Amount:  big.NewInt(5),
+		TokenId: ledger.ViteTokenId,
+		Quota:   100000,
+	}
+	db := newMemoryDatabase(addr, RunSnapshotBlock(cfg), testForks)
+	db.storage[key] = []byte{42}
+	db.originalStorage[key] = []byte{42}
+	db.SetBalance(&ledger.ViteTokenId, big.NewInt(100))
+	steps := 0
+	cfg.Tracer = func(step *TraceStep) {
+		steps++
+	}
+
+	result := RunCode(cfg, db)
+	if result.Err != nil {
+		t.Fatalf("run code failed, %v", result.Err)
+	}
+	if steps != 7 {
+		t.Fatalf("expected 7 trace steps, got %v", steps)
+	}
+	if result.QuotaUsed+result.QuotaLeft != cfg.Quota || result.QuotaUsed == 0 {
+		t.Fatalf("invalid quota used %v, quota left %v", result.QuotaUsed, result.QuotaLeft)
+	}
+	if len(result.ReturnData) != 32 {
+		t.Fatalf("invalid return data %v", result.ReturnData)
+	}
+	if v := db.storage["0000000000000000000000000000000000000000000000000000000000000000"]; len(v) != 1 || v[0] != 42 {
+		t.Fatalf("invalid poststate storage %v", db.storage)
+	}
+	if b, _ := db.GetBalance(&ledger.ViteTokenId); b.Cmp(big.NewInt(105)) != 0 {
+		t.Fatalf("invalid poststate balance %v", b)
+	}
+
+	// RANDOM is valid since the seed fork
+	cfg.Code = []byte{byte(RANDOM)}
+	cfg.Tracer = nil
+	if result := RunCode(cfg, db); result.Err != util.ErrInvalidOpCode {
+		t.Fatalf("expected invalid opcode before seed fork, got %v", result.Err)
+	}
+	cfg.SnapshotHeight = 100
+	if result := RunCode(cfg, db); result.Err != nil {
+		t.Fatalf("expected RANDOM after seed fork, got %v", result.Err)
+	}
+}
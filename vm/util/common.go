@@ -92,16 +92,16 @@ func GetSnapshotWithSeedCountCountFromCreateContractData(data []byte) uint8 {
 }
 
 // GetQuotaMultiplierFromCreateContractData decode quota multiplier from create contract request block data
-func GetQuotaMultiplierFromCreateContractData(data []byte, snapshotHeight uint64) uint8 {
-	if !fork.IsSeedFork(snapshotHeight) {
+func GetQuotaMultiplierFromCreateContractData(data []byte, forks *fork.Schedule, snapshotHeight uint64) uint8 {
+	if !forks.IsSeedFork(snapshotHeight) {
 		return uint8(data[types.GidSize+contractTypeSize+snapshotCountSize])
 	}
 	return uint8(data[types.GidSize+contractTypeSize+snapshotCountSize+snapshotWithSeedCountSize])
 }
 
 // GetCodeFromCreateContractData decode code and constructor params from create contract request block data
-func GetCodeFromCreateContractData(data []byte, snapshotHeight uint64) []byte {
-	if !fork.IsSeedFork(snapshotHeight) {
+func GetCodeFromCreateContractData(data []byte, forks *fork.Schedule, snapshotHeight uint64) []byte {
+	if !forks.IsSeedFork(snapshotHeight) {
 		return data[types.GidSize+contractTypeSize+snapshotCountSize+quotaMultiplierSize:]
 	}
 	return data[types.GidSize+contractTypeSize+snapshotCountSize+snapshotWithSeedCountSize+quotaMultiplierSize:]
@@ -138,10 +138,10 @@ func PrintMap(m map[string][]byte) string {
 }
 
 // CheckFork check whether current snapshot block height is over certain hard fork
-func CheckFork(db dbInterface, f func(uint64) bool) bool {
+func CheckFork(db dbInterface, f func(*fork.Schedule, uint64) bool) bool {
 	sb, err := db.LatestSnapshotBlock()
 	DealWithErr(err)
-	return f(sb.Height)
+	return f(db.ForkSchedule(), sb.Height)
 }
 
 // FirstToLower change first character for string to lower case
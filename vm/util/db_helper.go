@@ -1,6 +1,7 @@
 package util
 
 import (
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"math/big"
@@ -13,6 +14,7 @@ type dbInterface interface {
 	SetValue(key []byte, value []byte) error
 
 	LatestSnapshotBlock() (*ledger.SnapshotBlock, error)
+	ForkSchedule() *fork.Schedule
 
 	Address() *types.Address
 	IsContractAccount() (bool, error)
@@ -44,7 +44,8 @@ var (
 	ErrChainForked          = errors.New("chain forked")
 	ErrContractCreationFail = errors.New("contract creation failed")
 
-	ErrExecutionCanceled = errors.New("vm execution canceled")
+	ErrExecutionCanceled   = errors.New("vm execution canceled")
+	ErrOffChainReaderPanic = errors.New("offchain reader panic")
 )
 
 // DealWithErr panics if err is not nil.
@@ -241,12 +241,12 @@ type QuotaTable struct {
 }
 
 // QuotaTableByHeight returns different quota table by hard fork version
-func QuotaTableByHeight(sbHeight uint64) *QuotaTable {
-	if fork.IsEarthFork(sbHeight) {
+func QuotaTableByHeight(forks *fork.Schedule, sbHeight uint64) *QuotaTable {
+	if forks.IsEarthFork(sbHeight) {
 		return &earthQuotaTable
-	} else if fork.IsStemFork(sbHeight) {
+	} else if forks.IsStemFork(sbHeight) {
 		return &dexAgentQuotaTable
-	} else if fork.IsDexFork(sbHeight) {
+	} else if forks.IsDexFork(sbHeight) {
 		return &viteQuotaTable
 	}
 	return &initQuotaTable
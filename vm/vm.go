@@ -2,8 +2,8 @@
 package vm
 
 import (
+	"context"
 	"encoding/hex"
-	"errors"
 	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/vm/abi"
 	"runtime/debug"
@@ -158,7 +158,11 @@ type VM struct {
 	reader util.ConsensusReader
 	// latest snapshot block height, used for fork check
 	latestSnapshotHeight uint64
-	gasTable             *util.QuotaTable
+	// fork points of the chain, used for fork check
+	forks    *fork.Schedule
+	gasTable *util.QuotaTable
+	// tracer is called before every instruction if it is set, used by RunCode
+	tracer Tracer
 }
 
 // NewVM is a constructor of VM. This method is called before running an
@@ -222,7 +226,8 @@ func (vm *VM) RunV2(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger
 	sb, err := db.LatestSnapshotBlock()
 	util.DealWithErr(err)
 	vm.latestSnapshotHeight = sb.Height
-	vm.gasTable = util.QuotaTableByHeight(sb.Height)
+	vm.forks = db.ForkSchedule()
+	vm.gasTable = util.QuotaTableByHeight(vm.forks, sb.Height)
 	// In case vm will update some fields of block, make a copy of block.
 	blockCopy := block.Copy()
 	if blockCopy.IsSendBlock() {
@@ -254,7 +259,7 @@ func (vm *VM) RunV2(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger
 		}
 	} else {
 		// New interpreter instance according to latest snapshot block height.
-		vm.i = newInterpreter(sb.Height, false)
+		vm.i = newInterpreter(vm.forks, sb.Height, false)
 		vm.globalStatus = status
 		blockCopy.Data = nil
 		contractMeta := getContractMeta(db)
@@ -263,7 +268,7 @@ func (vm *VM) RunV2(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger
 		} else if sendBlock.BlockType == ledger.BlockTypeSendCall {
 			return vm.receiveCall(db, blockCopy, sendBlock, contractMeta)
 		} else if sendBlock.BlockType == ledger.BlockTypeSendReward {
-			if !fork.IsSeedFork(sb.Height) {
+			if !vm.forks.IsSeedFork(sb.Height) {
 				return vm.receiveCall(db, blockCopy, sendBlock, contractMeta)
 			}
 			return vm.receiveReward(db, blockCopy, sendBlock, contractMeta)
@@ -309,7 +314,7 @@ func (vm *VM) sendCreate(db vm_db.VmDb, block *ledger.AccountBlock, useQuota boo
 	}
 
 	// Check params.
-	isSeedFork := fork.IsSeedFork(vm.latestSnapshotHeight)
+	isSeedFork := vm.forks.IsSeedFork(vm.latestSnapshotHeight)
 	if !isSeedFork {
 		if len(block.Data) < util.CreateContractDataLengthMin {
 			return nil, util.ErrInvalidMethodParam
@@ -331,14 +336,14 @@ func (vm *VM) sendCreate(db vm_db.VmDb, block *ledger.AccountBlock, useQuota boo
 	if snapshotCount < snapshotCountMin || snapshotCount > snapshotCountMax {
 		return nil, util.ErrInvalidResponseLatency
 	}
-	quotaMultiplier := util.GetQuotaMultiplierFromCreateContractData(block.Data, vm.latestSnapshotHeight)
+	quotaMultiplier := util.GetQuotaMultiplierFromCreateContractData(block.Data, vm.forks, vm.latestSnapshotHeight)
 	if quotaMultiplier < 10 || quotaMultiplier > 100 {
 		return nil, util.ErrInvalidQuotaMultiplier
 	}
 
 	snapshotWithSeedCount := snapshotCount
 	if !isSeedFork {
-		if ContainsStatusCode(util.GetCodeFromCreateContractData(block.Data, vm.latestSnapshotHeight)) && snapshotCount <= 0 {
+		if ContainsStatusCode(util.GetCodeFromCreateContractData(block.Data, vm.forks, vm.latestSnapshotHeight)) && snapshotCount <= 0 {
 			return nil, util.ErrInvalidResponseLatency
 		}
 	} else {
@@ -346,7 +351,7 @@ func (vm *VM) sendCreate(db vm_db.VmDb, block *ledger.AccountBlock, useQuota boo
 		if snapshotWithSeedCount < snapshotWithSeedCountMin || snapshotWithSeedCount > snapshotWithSeedCountMax || snapshotCount < snapshotWithSeedCount {
 			return nil, util.ErrInvalidRandomDegree
 		}
-		code := util.GetCodeFromCreateContractData(block.Data, vm.latestSnapshotHeight)
+		code := util.GetCodeFromCreateContractData(block.Data, vm.forks, vm.latestSnapshotHeight)
 		requireSnapshot, requireSnapshotWithSeed := ContainsCertainStatusCode(code)
 		if requireSnapshot && snapshotCount <= 0 {
 			return nil, util.ErrInvalidResponseLatency
@@ -413,7 +418,7 @@ func (vm *VM) receiveCreate(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock
 	util.AddBalance(db, &sendBlock.TokenId, sendBlock.Amount)
 
 	// init contract state_bak and set contract code
-	initCode := util.GetCodeFromCreateContractData(sendBlock.Data, vm.latestSnapshotHeight)
+	initCode := util.GetCodeFromCreateContractData(sendBlock.Data, vm.forks, vm.latestSnapshotHeight)
 	c := newContract(block, db, sendBlock, initCode, quotaLeft)
 	c.setCallCode(block.AccountAddress, initCode)
 	code, err := c.run(vm)
@@ -431,7 +436,7 @@ func (vm *VM) receiveCreate(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock
 			}
 		}
 	}
-	if err == nil && len(code) > maxCodeSize && fork.IsEarthFork(vm.latestSnapshotHeight) {
+	if err == nil && len(code) > maxCodeSize && vm.forks.IsEarthFork(vm.latestSnapshotHeight) {
 		err = util.ErrInvalidCodeLength
 	}
 	vm.revert(db)
@@ -470,7 +475,7 @@ func (vm *VM) sendCall(db vm_db.VmDb, block *ledger.AccountBlock, useQuota bool,
 	defer monitor.LogTimerConsuming([]string{"vm", "sendCall"}, time.Now())
 	// check can make transaction
 	quotaLeft := quotaTotal
-	if p, ok, err := contracts.GetBuiltinContractMethod(block.ToAddress, block.Data, vm.latestSnapshotHeight); ok {
+	if p, ok, err := contracts.GetBuiltinContractMethod(block.ToAddress, block.Data, vm.forks, vm.latestSnapshotHeight); ok {
 		if err != nil {
 			return nil, err
 		}
@@ -540,7 +545,7 @@ func (vm *VM) receiveCall(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *
 		vm.updateBlock(db, block, util.ErrDepth, 0, 0)
 		return &vm_db.VmAccountBlock{block, db}, noRetry, util.ErrDepth
 	}
-	if p, ok, _ := contracts.GetBuiltinContractMethod(block.AccountAddress, sendBlock.Data, vm.latestSnapshotHeight); ok {
+	if p, ok, _ := contracts.GetBuiltinContractMethod(block.AccountAddress, sendBlock.Data, vm.forks, vm.latestSnapshotHeight); ok {
 		// check quota
 		quotaUsed := p.GetReceiveQuota(vm.gasTable)
 		if quotaUsed > 0 {
@@ -899,27 +904,53 @@ func checkDepth(db vm_db.VmDb, sendBlock *ledger.AccountBlock) bool {
 
 // OffChainReader read contract storage without tx
 func (vm *VM) OffChainReader(db vm_db.VmDb, code []byte, data []byte) (result []byte, err error) {
+	result, _, err = vm.OffChainReaderWithContext(context.Background(), db, code, data, OffChainReaderQuota)
+	return result, err
+}
+
+// OffChainReaderWithContext read contract storage without tx, using at most
+// quota. The execution is canceled by Cancel when ctx is done, in which case
+// util.ErrExecutionCanceled is returned.
+func (vm *VM) OffChainReaderWithContext(ctx context.Context, db vm_db.VmDb, code []byte, data []byte, quota uint64) (result []byte, quotaUsed uint64, err error) {
+	sb, err := db.LatestSnapshotBlock()
+	if err != nil {
+		return nil, 0, err
+	}
+	vm.forks = db.ForkSchedule()
+	vm.i = newInterpreter(vm.forks, sb.Height, true)
+	vm.gasTable = util.QuotaTableByHeight(vm.forks, sb.Height)
+	c := newContract(&ledger.AccountBlock{AccountAddress: *db.Address()}, db, &ledger.AccountBlock{ToAddress: *db.Address()}, data, quota)
+	c.setCallCode(*db.Address(), code)
+
+	if done := ctx.Done(); done != nil {
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-done:
+				vm.Cancel()
+			case <-finished:
+			}
+		}()
+	}
 	defer func() {
-		if err := recover(); err != nil {
-			debug.PrintStack()
+		if r := recover(); r != nil {
+			result, quotaUsed = nil, quota-c.quotaLeft
+			if r == util.ErrExecutionCanceled {
+				err = util.ErrExecutionCanceled
+				return
+			}
 			nodeConfig.log.Error("offchain reader panic",
-				"err", err,
+				"err", r,
 				"addr", db.Address(),
 				"code", hex.EncodeToString(code),
-				"data", hex.EncodeToString(data))
-			result = nil
-			err = errors.New("offchain reader panic")
+				"data", hex.EncodeToString(data),
+				"stack", string(debug.Stack()))
+			err = util.ErrOffChainReaderPanic
 		}
 	}()
-	sb, err := db.LatestSnapshotBlock()
-	if err != nil {
-		return nil, err
-	}
-	vm.i = newInterpreter(sb.Height, true)
-	vm.gasTable = util.QuotaTableByHeight(sb.Height)
-	c := newContract(&ledger.AccountBlock{AccountAddress: *db.Address()}, db, &ledger.AccountBlock{ToAddress: *db.Address()}, data, offChainReaderGas)
-	c.setCallCode(*db.Address(), code)
-	return c.run(vm)
+	result, err = c.run(vm)
+	return result, quota - c.quotaLeft, err
 }
 
 func getStakeBeneficialAmount(db vm_db.VmDb) *big.Int {
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"github.com/vitelabs/go-vite/common"
@@ -12,7 +13,7 @@ import (
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
 	"github.com/vitelabs/go-vite/vm/util"
-	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
 	"strconv"
@@ -22,11 +23,12 @@ import (
 
 func init() {
 	InitVMConfig(false, false, false, false, common.HomeDir())
-	initFork()
 }
 
-func initFork() {
-	fork.SetForkPoints(&config.ForkPoints{
+var testForks = newTestForkSchedule()
+
+func newTestForkSchedule() *fork.Schedule {
+	forks := fork.NewSchedule(&config.ForkPoints{
 		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
 		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
 		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
@@ -34,7 +36,8 @@ func initFork() {
 		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
 		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
 		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7}})
-	fork.SetActiveChecker(mockActiveChecker{})
+	forks.SetActiveChecker(mockActiveChecker{})
+	return forks
 }
 
 var (
@@ -281,7 +284,8 @@ func TestVmRun(t *testing.T) {
 
 	vm := NewVM(nil)
 	vm.globalStatus = &util.GlobalStatus{0, &ledger.SnapshotBlock{}}
-	vm.i = newInterpreter(1, false)
+	vm.forks = testForks
+	vm.i = newInterpreter(testForks, 1, false)
 	//vm.Debug = true
 	sendCallBlock := ledger.AccountBlock{
 		AccountAddress: addr1,
@@ -484,199 +488,6 @@ func TestVmForTest(t *testing.T) {
 	}
 }
 
-type TestCaseMap map[string]TestCase
-
-type TestCaseSendBlock struct {
-	BlockType byte
-	ToAddress types.Address
-	Amount    string
-	TokenID   types.TokenTypeId
-	Data      string
-}
-type TestLog struct {
-	Data   string
-	Topics []string
-}
-type TestCase struct {
-	SBHeight      uint64
-	SBTime        int64
-	FromAddress   types.Address
-	ToAddress     types.Address
-	InputData     string
-	Amount        string
-	TokenID       types.TokenTypeId
-	Code          string
-	ReturnData    string
-	QuotaTotal    uint64
-	QuotaLeft     uint64
-	Err           string
-	Storage       map[string]string
-	PreStorage    map[string]string
-	LogHash       string
-	LogList       []TestLog
-	SendBlockList []*TestCaseSendBlock
-	Seed          uint64
-}
-
-func TestVmInterpreter(t *testing.T) {
-	testDir := "./test/interpreter_test/"
-	testFiles, ok := ioutil.ReadDir(testDir)
-	if ok != nil {
-		t.Fatalf("read dir failed, %v", ok)
-	}
-	for _, testFile := range testFiles {
-		if testFile.IsDir() {
-			continue
-		}
-		file, ok := os.Open(testDir + testFile.Name())
-		if ok != nil {
-			t.Fatalf("open test file failed, %v", ok)
-		}
-		testCaseMap := new(TestCaseMap)
-		if ok := json.NewDecoder(file).Decode(testCaseMap); ok != nil {
-			t.Fatalf("decode test file failed, %v", ok)
-		}
-
-		for k, testCase := range *testCaseMap {
-			var sbTime time.Time
-			if testCase.SBTime > 0 {
-				sbTime = time.Unix(testCase.SBTime, 0)
-			} else {
-				sbTime = time.Now()
-			}
-			sb := ledger.SnapshotBlock{
-				Height:    testCase.SBHeight,
-				Timestamp: &sbTime,
-				Hash:      types.DataHash([]byte{1, 1}),
-			}
-			vm := NewVM(nil)
-			vm.i = newInterpreter(testCase.SBHeight, false)
-			vm.gasTable = util.QuotaTableByHeight(testCase.SBHeight)
-			vm.globalStatus = NewTestGlobalStatus(testCase.Seed, &sb)
-			vm.latestSnapshotHeight = testCase.SBHeight
-			//fmt.Printf("testcase %v: %v\n", testFile.Name(), k)
-			inputData, _ := hex.DecodeString(testCase.InputData)
-			amount, _ := hex.DecodeString(testCase.Amount)
-			sendCallBlock := ledger.AccountBlock{
-				AccountAddress: testCase.FromAddress,
-				ToAddress:      testCase.ToAddress,
-				BlockType:      ledger.BlockTypeSendCall,
-				Data:           inputData,
-				Amount:         new(big.Int).SetBytes(amount),
-				Fee:            big.NewInt(0),
-				TokenId:        testCase.TokenID,
-			}
-			receiveCallBlock := &ledger.AccountBlock{
-				AccountAddress: testCase.ToAddress,
-				BlockType:      ledger.BlockTypeReceive,
-			}
-			db := newMemoryDatabase(testCase.ToAddress, &sb)
-			if len(testCase.PreStorage) > 0 {
-				for k, v := range testCase.PreStorage {
-					vByte, _ := hex.DecodeString(v)
-					db.storage[k] = vByte
-					db.originalStorage[k] = vByte
-				}
-			}
-			c := newContract(
-				receiveCallBlock,
-				db,
-				&sendCallBlock,
-				sendCallBlock.Data,
-				testCase.QuotaTotal)
-			code, _ := hex.DecodeString(testCase.Code)
-			c.setCallCode(testCase.ToAddress, code)
-			util.AddBalance(db, &sendCallBlock.TokenId, sendCallBlock.Amount)
-			ret, err := c.run(vm)
-			returnData, _ := hex.DecodeString(testCase.ReturnData)
-			if (err == nil && testCase.Err != "") || (err != nil && testCase.Err != err.Error()) {
-				t.Fatalf("%v: %v failed, err not match, expected %v, got %v", testFile.Name(), k, testCase.Err, err)
-			}
-			if err == nil || err.Error() == "execution reverted" {
-				if bytes.Compare(returnData, ret) != 0 {
-					t.Fatalf("%v: %v failed, return Data error, expected %v, got %v", testFile.Name(), k, returnData, ret)
-				} else if c.quotaLeft != testCase.QuotaLeft {
-					t.Fatalf("%v: %v failed, quota left error, expected %v, got %v", testFile.Name(), k, testCase.QuotaLeft, c.quotaLeft)
-				} else if checkStorageResult := checkStorage(db, testCase.Storage); checkStorageResult != "" {
-					t.Fatalf("%v: %v failed, storage error, %v", testFile.Name(), k, checkStorageResult)
-				} else if len(testCase.LogHash) > 0 {
-					if logHash := db.GetLogListHash(); (logHash == nil && len(testCase.LogHash) != 0) || (logHash != nil && logHash.String() != testCase.LogHash) {
-						t.Fatalf("%v: %v failed, log hash error, expected\n%v,\ngot\n%v", testFile.Name(), k, testCase.LogHash, logHash)
-					}
-				} else if len(testCase.LogList) > 0 {
-					if checkLogListResult := checkLogList(testCase.LogList, db.logList); checkLogListResult != "" {
-						t.Fatalf("%v: %v failed, log list error, %v", testFile.Name(), k, checkLogListResult)
-					}
-				} else if checkSendBlockListResult := checkSendBlockList(testCase.SendBlockList, vm.sendBlockList); checkSendBlockListResult != "" {
-					t.Fatalf("%v: %v failed, send block list error, %v", testFile.Name(), k, checkSendBlockListResult)
-				}
-			}
-		}
-	}
-}
-func checkLogList(expected []TestLog, got []*ledger.VmLog) string {
-	if len(expected) != len(got) {
-		return "expected len " + strconv.Itoa(len(expected)) + ", got len" + strconv.Itoa(len(got))
-	}
-	for index, lGot := range got {
-		lexpected := expected[index]
-		if len(lexpected.Topics) != len(lGot.Topics) {
-			return strconv.Itoa(index) + "th log topic len not match, expected " + strconv.Itoa(len(lexpected.Topics)) + ", got " + strconv.Itoa(len(lGot.Topics))
-		}
-		if dataStr := hex.EncodeToString(lGot.Data); dataStr != lexpected.Data {
-			return "expected " + strconv.Itoa(index) + "th log data: " + lexpected.Data + ", got: " + dataStr
-		}
-		for topicIndex, t := range lGot.Topics {
-			if topicStr := t.String(); topicStr != lexpected.Topics[topicIndex] {
-				return "expected " + strconv.Itoa(index) + ":" + strconv.Itoa(topicIndex) + "th topic: " + lexpected.Topics[topicIndex] + ", got: " + topicStr
-			}
-		}
-	}
-	return ""
-}
-
-func checkStorage(got *memoryDatabase, expected map[string]string) string {
-	count := 0
-	for _, v := range got.storage {
-		if len(v) > 0 {
-			count = count + 1
-		}
-	}
-	if len(expected) != count {
-		return "expected len " + strconv.Itoa(len(expected)) + ", got len" + strconv.Itoa(len(got.storage))
-	}
-	for k, v := range got.storage {
-		if len(v) == 0 {
-			continue
-		}
-		if sv, ok := expected[k]; !ok || sv != hex.EncodeToString(v) {
-			return "expect " + k + ": " + sv + ", got " + k + ": " + hex.EncodeToString(v)
-		}
-	}
-	return ""
-}
-
-func checkSendBlockList(expected []*TestCaseSendBlock, got []*ledger.AccountBlock) string {
-	if len(got) != len(expected) {
-		return "expected len " + strconv.Itoa(len(expected)) + ", got len" + strconv.Itoa(len(got))
-	}
-	for i, expectedSendBlock := range expected {
-		gotSendBlock := got[i]
-		if (expectedSendBlock.BlockType > 0 && gotSendBlock.BlockType != expectedSendBlock.BlockType) || (expectedSendBlock.BlockType == 0 && gotSendBlock.BlockType != ledger.BlockTypeSendCall) {
-			return strconv.Itoa(i) + "th, expected blockType " + strconv.Itoa(int(expectedSendBlock.BlockType)) + ", got blockType " + strconv.Itoa(int(gotSendBlock.BlockType))
-		} else if gotSendBlock.ToAddress != expectedSendBlock.ToAddress {
-			return strconv.Itoa(i) + "th, expected toAddress " + expectedSendBlock.ToAddress.String() + ", got toAddress " + gotSendBlock.ToAddress.String()
-		} else if gotAmount := hex.EncodeToString(gotSendBlock.Amount.Bytes()); gotAmount != expectedSendBlock.Amount {
-			return strconv.Itoa(i) + "th, expected amount " + expectedSendBlock.Amount + ", got amount " + gotAmount
-		} else if gotSendBlock.TokenId != expectedSendBlock.TokenID {
-			return strconv.Itoa(i) + "th, expected tokenId " + expectedSendBlock.TokenID.String() + ", got tokenId " + gotSendBlock.TokenId.String()
-		} else if gotData := hex.EncodeToString(gotSendBlock.Data); gotData != expectedSendBlock.Data {
-			return strconv.Itoa(i) + "th, expected data " + expectedSendBlock.Data + ", got data " + gotData
-		}
-	}
-	return ""
-}
-
 type OffchainTestCaseMap map[string]OffchainTestCase
 type OffchainTestCase struct {
 	SBHeight   uint64
@@ -701,7 +512,8 @@ func TestOffChainReader(t *testing.T) {
 
 	for k, testCase := range *testCaseMap {
 		vm := NewVM(nil)
-		vm.i = newInterpreter(1, true)
+		vm.forks = testForks
+		vm.i = newInterpreter(testForks, 1, true)
 		var sbTime time.Time
 		if testCase.SBTime > 0 {
 			sbTime = time.Unix(testCase.SBTime, 0)
@@ -713,7 +525,7 @@ func TestOffChainReader(t *testing.T) {
 			Timestamp: &sbTime,
 			Hash:      types.DataHash([]byte{1, 1}),
 		}
-		db := newMemoryDatabase(testCase.ToAddress, &sb)
+		db := newMemoryDatabase(testCase.ToAddress, &sb, testForks)
 		if len(testCase.PreStorage) > 0 {
 			for k, v := range testCase.PreStorage {
 				vByte, _ := hex.DecodeString(v)
@@ -734,29 +546,25 @@ func TestOffChainReader(t *testing.T) {
 	}
 }
 
-type TestGlobalStatus struct {
-	seed          uint64
-	snapshotBlock *ledger.SnapshotBlock
-	randSource    helper.Source64
-	setRandSeed   bool
-}
+func TestOffChainReaderWithContext(t *testing.T) {
+	sbTime := time.Now()
+	sb := ledger.SnapshotBlock{Height: 1, Timestamp: &sbTime, Hash: types.DataHash([]byte{1, 1})}
+	addr, _ := types.HexToAddress("vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a")
+	// JUMPDEST PUSH1 0 JUMP
+	loop, _ := hex.DecodeString("5b600056")
 
-func NewTestGlobalStatus(seed uint64, snapshotBlock *ledger.SnapshotBlock) *TestGlobalStatus {
-	return &TestGlobalStatus{seed: seed, snapshotBlock: snapshotBlock}
-}
-func (g *TestGlobalStatus) Seed() (uint64, error) {
-	return g.seed, nil
-}
-func (g *TestGlobalStatus) Random() (uint64, error) {
-	if g.setRandSeed {
-		return g.randSource.Uint64(), nil
+	db := newMemoryDatabase(addr, &sb, testForks)
+	result, quotaUsed, err := NewVM(nil).OffChainReaderWithContext(context.Background(), db, loop, nil, 1000)
+	if err != util.ErrOutOfQuota || result != nil || quotaUsed != 1000 {
+		t.Fatalf("out of quota not match, %v, %v, %v", result, quotaUsed, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result, quotaUsed, err = NewVM(nil).OffChainReaderWithContext(ctx, db, loop, nil, math.MaxUint64)
+	if err != util.ErrExecutionCanceled || result != nil || quotaUsed == 0 {
+		t.Fatalf("cancel not match, %v, %v, %v", result, quotaUsed, err)
 	}
-	g.randSource = helper.NewSource64(int64(g.seed))
-	g.setRandSeed = true
-	return g.randSource.Uint64(), nil
-}
-func (g *TestGlobalStatus) SnapshotBlock() *ledger.SnapshotBlock {
-	return g.snapshotBlock
 }
 
 func BenchmarkSendCall(b *testing.B) {
@@ -776,7 +584,7 @@ func BenchmarkSendCall(b *testing.B) {
 	}
 	sendCallBlock.AccountAddress, _ = types.HexToAddress("vite_e41be57d38c796984952fad618a9bc91637329b5255cb18906")
 	sendCallBlock.ToAddress, _ = types.HexToAddress("vite_098dfae02679a4ca05a4c8bf5dd00a8757f0c622bfccce7d68")
-	db := newMemoryDatabase(sendCallBlock.AccountAddress, &sb)
+	db := newMemoryDatabase(sendCallBlock.AccountAddress, &sb, testForks)
 	db.SetBalance(&ledger.ViteTokenId, new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18)))
 	for i := 0; i < b.N; i++ {
 		vm := NewVM(nil)
@@ -810,7 +618,7 @@ func BenchmarkReceiveCall(b *testing.B) {
 		Difficulty: big.NewInt(67108863),
 	}
 
-	db := newMemoryDatabase(sendCallBlock.AccountAddress, &sb)
+	db := newMemoryDatabase(sendCallBlock.AccountAddress, &sb, testForks)
 	db.SetBalance(&ledger.ViteTokenId, new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18)))
 	for i := 0; i < b.N; i++ {
 		vm := NewVM(nil)
@@ -0,0 +1,63 @@
+package vmtest
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/vitelabs/go-vite/consensus/core"
+)
+
+// ConsensusDetail is the block producing detail of a sbp in a day of a fixture
+type ConsensusDetail struct {
+	BlockNum         uint64
+	ExpectedBlockNum uint64
+	VoteCount        *big.Int
+}
+
+type consensusReaderTest struct {
+	ti        timeIndex
+	detailMap map[uint64]map[string]*ConsensusDetail
+}
+
+func newConsensusReaderTest(genesisTime int64, interval int64, detailMap map[uint64]map[string]*ConsensusDetail) *consensusReaderTest {
+	return &consensusReaderTest{timeIndex{time.Unix(genesisTime, 0), time.Second * time.Duration(interval)}, detailMap}
+}
+
+func (r *consensusReaderTest) DayStats(startIndex uint64, endIndex uint64) ([]*core.DayStats, error) {
+	list := make([]*core.DayStats, 0)
+	for i := startIndex; i <= endIndex; i++ {
+		m, ok := r.detailMap[i]
+		if !ok {
+			continue
+		}
+		blockNum := uint64(0)
+		voteCount := big.NewInt(0)
+		statusMap := make(map[string]*core.SbpStats, len(m))
+		for name, detail := range m {
+			blockNum = blockNum + detail.BlockNum
+			voteCount.Add(voteCount, detail.VoteCount)
+			statusMap[name] = &core.SbpStats{Index: i, BlockNum: detail.BlockNum, ExceptedBlockNum: detail.ExpectedBlockNum, VoteCnt: &core.BigInt{Int: detail.VoteCount}, Name: name}
+		}
+		list = append(list, &core.DayStats{Index: i, Stats: statusMap, VoteSum: &core.BigInt{Int: voteCount}, BlockTotal: blockNum})
+	}
+	return list, nil
+}
+func (r *consensusReaderTest) GetDayTimeIndex() core.TimeIndex {
+	return r.ti
+}
+
+type timeIndex struct {
+	GenesisTime time.Time
+	Interval    time.Duration
+}
+
+func (ti timeIndex) Index2Time(index uint64) (time.Time, time.Time) {
+	sTime := ti.GenesisTime.Add(ti.Interval * time.Duration(index))
+	eTime := ti.GenesisTime.Add(ti.Interval * time.Duration(index+1))
+	return sTime, eTime
+}
+func (ti timeIndex) Time2Index(t time.Time) uint64 {
+	subSec := int64(t.Sub(ti.GenesisTime).Seconds())
+	i := uint64(subSec) / uint64(ti.Interval.Seconds())
+	return i
+}
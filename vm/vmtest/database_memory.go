@@ -0,0 +1,281 @@
+package vmtest
+
+import (
+	"encoding/hex"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"math/big"
+	"strings"
+)
+
+var (
+	balanceKey = "$BALANCE"
+	codeKey    = "$CODE"
+)
+
+func getBalanceKey(tokenID *types.TokenTypeId) string {
+	return balanceKey + tokenID.String()
+}
+
+func getCodeKey(addr types.Address) string {
+	return codeKey + addr.String()
+}
+
+// MemoryDatabase is a vm database of a single account for a single call,
+// it's used by the interpreter fixtures and vmrun.
+type MemoryDatabase struct {
+	addr            types.Address
+	storage         map[string][]byte
+	originalStorage map[string][]byte
+	logList         []*ledger.VmLog
+	sb              *ledger.SnapshotBlock
+	forks           *fork.Schedule
+}
+
+func NewMemoryDatabase(addr types.Address, sb *ledger.SnapshotBlock, forks *fork.Schedule) *MemoryDatabase {
+	return &MemoryDatabase{
+		addr:            addr,
+		storage:         make(map[string][]byte),
+		originalStorage: make(map[string][]byte),
+		logList:         make([]*ledger.VmLog, 0),
+		sb:              sb,
+		forks:           forks,
+	}
+}
+
+// SetStorage sets the prestate of a storage value, key is hex encoded
+func (db *MemoryDatabase) SetStorage(key string, value []byte) {
+	db.storage[key] = value
+	db.originalStorage[key] = value
+}
+
+// Storage returns the non empty storage values keyed by hex encoded keys, balances and code excluded
+func (db *MemoryDatabase) Storage() map[string][]byte {
+	storage := make(map[string][]byte)
+	for k, v := range db.storage {
+		if len(v) > 0 && !strings.HasPrefix(k, balanceKey) && !strings.HasPrefix(k, codeKey) {
+			storage[k] = v
+		}
+	}
+	return storage
+}
+
+// Balances returns the balances of the account
+func (db *MemoryDatabase) Balances() map[types.TokenTypeId]*big.Int {
+	balances := make(map[types.TokenTypeId]*big.Int)
+	for k, v := range db.storage {
+		if !strings.HasPrefix(k, balanceKey) {
+			continue
+		}
+		if tokenId, err := types.HexToTokenTypeId(k[len(balanceKey):]); err == nil {
+			balances[tokenId] = new(big.Int).SetBytes(v)
+		}
+	}
+	return balances
+}
+func (db *MemoryDatabase) GetBalance(tokenTypeID *types.TokenTypeId) (*big.Int, error) {
+	if balance, ok := db.storage[getBalanceKey(tokenTypeID)]; ok {
+		return new(big.Int).SetBytes(balance), nil
+	}
+	return big.NewInt(0), nil
+}
+func (db *MemoryDatabase) SetBalance(tokenTypeID *types.TokenTypeId, amount *big.Int) {
+	if amount == nil {
+		delete(db.storage, getBalanceKey(tokenTypeID))
+	} else {
+		db.storage[getBalanceKey(tokenTypeID)] = amount.Bytes()
+	}
+}
+func (db *MemoryDatabase) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	return nil, nil
+}
+
+func (db *MemoryDatabase) Reset()  {}
+func (db *MemoryDatabase) Finish() {}
+
+func (db *MemoryDatabase) SetContractCode(code []byte) {
+	db.storage[getCodeKey(db.addr)] = code
+}
+func (db *MemoryDatabase) GetContractCode() ([]byte, error) {
+	if code, ok := db.storage[getCodeKey(db.addr)]; ok {
+		return code, nil
+	}
+	return nil, nil
+}
+
+func (db *MemoryDatabase) GetContractCodeBySnapshotBlock(addr *types.Address, snapshotBlock *ledger.SnapshotBlock) ([]byte, error) {
+	if code, ok := db.storage[getCodeKey(*addr)]; ok {
+		return code, nil
+	}
+	return nil, nil
+}
+
+func (db *MemoryDatabase) GetOriginalValue(key []byte) ([]byte, error) {
+	if data, ok := db.originalStorage[hex.EncodeToString(key)]; ok {
+		return data, nil
+	}
+	return nil, nil
+}
+
+func (db *MemoryDatabase) GetValue(key []byte) ([]byte, error) {
+	if data, ok := db.storage[hex.EncodeToString(key)]; ok {
+		return data, nil
+	}
+	return nil, nil
+}
+func (db *MemoryDatabase) SetValue(key []byte, value []byte) error {
+	if len(value) == 0 {
+		delete(db.storage, hex.EncodeToString(key))
+	} else {
+		db.storage[hex.EncodeToString(key)] = value
+	}
+	return nil
+}
+func (db *MemoryDatabase) PrintStorage() string {
+	str := "["
+	for key, value := range db.storage {
+		str += key + "=>" + hex.EncodeToString(value) + ", "
+	}
+	str += "]"
+	return str
+}
+func (db *MemoryDatabase) GetReceiptHash() *types.Hash {
+	return &types.Hash{}
+}
+func (db *MemoryDatabase) AddLog(log *ledger.VmLog) {
+	db.logList = append(db.logList, log)
+}
+func (db *MemoryDatabase) GetLogListHash() *types.Hash {
+	if len(db.logList) == 0 {
+		return nil
+	}
+	var source []byte
+	for _, vmLog := range db.logList {
+		for _, topic := range vmLog.Topics {
+			source = append(source, topic.Bytes()...)
+		}
+		source = append(source, vmLog.Data...)
+	}
+
+	hash, _ := types.BytesToHash(crypto.Hash256(source))
+	return &hash
+}
+
+func (db *MemoryDatabase) GetLogList() ledger.VmLogList {
+	return db.logList
+}
+func (db *MemoryDatabase) GetHistoryLogList(logHash *types.Hash) (ledger.VmLogList, error) {
+	return nil, nil
+}
+
+func (db *MemoryDatabase) NewStorageIterator(prefix []byte) (interfaces.StorageIterator, error) {
+	return nil, nil
+}
+
+func (db *MemoryDatabase) ForkSchedule() *fork.Schedule {
+	return db.forks
+}
+
+func (db *MemoryDatabase) Address() *types.Address {
+	return &db.addr
+}
+func (db *MemoryDatabase) LatestSnapshotBlock() (*ledger.SnapshotBlock, error) {
+	return db.sb, nil
+}
+func (db *MemoryDatabase) PrevAccountBlock() (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (db *MemoryDatabase) GetGenesisSnapshotBlock() *ledger.SnapshotBlock {
+	sb, _ := db.LatestSnapshotBlock()
+	return sb
+}
+
+func (db *MemoryDatabase) GetUnsavedStorage() [][2][]byte {
+	return nil
+}
+
+func (db *MemoryDatabase) GetUnsavedBalanceMap() map[types.TokenTypeId]*big.Int {
+	return nil
+}
+func (db *MemoryDatabase) GetUnsavedContractMeta() map[types.Address]*ledger.ContractMeta {
+	return nil
+}
+func (db *MemoryDatabase) GetUnsavedContractCode() []byte {
+	return nil
+}
+
+func (db *MemoryDatabase) DebugGetStorage() (map[string][]byte, error) {
+	return db.storage, nil
+}
+
+func (db *MemoryDatabase) IsContractAccount() (bool, error) {
+	return len(db.storage[getCodeKey(db.addr)]) > 0, nil
+}
+
+func (db *MemoryDatabase) GetCallDepth(hash *types.Hash) (uint16, error) {
+	return 0, nil
+}
+func (db *MemoryDatabase) SetCallDepth(uint16) {
+}
+
+func (db *MemoryDatabase) GetUnsavedCallDepth() uint16 {
+	return 0
+}
+
+func (db *MemoryDatabase) DeleteValue(key []byte) {
+	delete(db.storage, hex.EncodeToString(key))
+}
+
+func (db *MemoryDatabase) GetUnconfirmedBlocks(address types.Address) []*ledger.AccountBlock {
+	return nil
+}
+func (db *MemoryDatabase) GetQuotaUsedList(addr types.Address) []types.QuotaInfo {
+	list := make([]types.QuotaInfo, 75)
+	for i := range list {
+		list[i] = types.QuotaInfo{BlockCount: 0, QuotaTotal: 0, QuotaUsedTotal: 0}
+	}
+	return list
+}
+
+func (db *MemoryDatabase) GetGlobalQuota() types.QuotaInfo {
+	return types.QuotaInfo{}
+}
+
+func (db *MemoryDatabase) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (db *MemoryDatabase) GetCompleteBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (db *MemoryDatabase) SetContractMeta(toAddr types.Address, meta *ledger.ContractMeta) {
+}
+
+func (db *MemoryDatabase) GetContractMeta() (*ledger.ContractMeta, error) {
+	return &ledger.ContractMeta{Gid: types.DELEGATE_GID, SendConfirmedTimes: 0, QuotaRatio: 10}, nil
+}
+
+func (db *MemoryDatabase) GetConfirmSnapshotHeader(blockHash types.Hash) (*ledger.SnapshotBlock, error) {
+	return db.LatestSnapshotBlock()
+}
+func (db *MemoryDatabase) GetContractMetaInSnapshot(contractAddress types.Address, snapshotBlock *ledger.SnapshotBlock) (*ledger.ContractMeta, error) {
+	return &ledger.ContractMeta{Gid: types.DELEGATE_GID, SendConfirmedTimes: 0, QuotaRatio: 10}, nil
+}
+
+func (db *MemoryDatabase) GetStakeBeneficialAmount(addr *types.Address) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (db *MemoryDatabase) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	return 0, nil
+}
+func (db *MemoryDatabase) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+func (db *MemoryDatabase) CanWrite() bool {
+	return false
+}
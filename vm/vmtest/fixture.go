@@ -0,0 +1,199 @@
+// Package vmtest runs the vm fixtures of vm/test without a chain,
+// it's used by the tests and the conformance suite of cmd/vmrun.
+package vmtest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+// fixtures are run with the fork schedule of testForks, all the forks are active
+var testForks = newTestForkSchedule()
+
+func newTestForkSchedule() *fork.Schedule {
+	forks := fork.NewSchedule(&config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7}})
+	forks.SetActiveChecker(mockActiveChecker{})
+	return forks
+}
+
+type mockActiveChecker struct {
+}
+
+func (m mockActiveChecker) IsForkActive(point fork.ForkPointItem) bool {
+	return true
+}
+
+// TestCaseMap is a fixture file of vm/test/interpreter_test, interpreter fixtures keyed by name
+type TestCaseMap map[string]TestCase
+
+type TestCaseSendBlock struct {
+	BlockType byte
+	ToAddress types.Address
+	Amount    string
+	TokenID   types.TokenTypeId
+	Data      string
+}
+type TestLog struct {
+	Data   string
+	Topics []string
+}
+type TestCase struct {
+	SBHeight      uint64
+	SBTime        int64
+	FromAddress   types.Address
+	ToAddress     types.Address
+	InputData     string
+	Amount        string
+	TokenID       types.TokenTypeId
+	Code          string
+	ReturnData    string
+	QuotaTotal    uint64
+	QuotaLeft     uint64
+	Err           string
+	Storage       map[string]string
+	PreStorage    map[string]string
+	LogHash       string
+	LogList       []TestLog
+	SendBlockList []*TestCaseSendBlock
+	Seed          uint64
+}
+
+// RunTestCase runs an interpreter fixture and returns an error describing the first mismatch of the result
+func RunTestCase(testCase *TestCase, tracer vm.Tracer) error {
+	var sbTime time.Time
+	if testCase.SBTime > 0 {
+		sbTime = time.Unix(testCase.SBTime, 0)
+	} else {
+		sbTime = time.Now()
+	}
+	inputData, _ := hex.DecodeString(testCase.InputData)
+	amount, _ := hex.DecodeString(testCase.Amount)
+	code, _ := hex.DecodeString(testCase.Code)
+	cfg := &vm.RunConfig{
+		Forks:          testForks,
+		SnapshotHeight: testCase.SBHeight,
+		SnapshotTime:   sbTime,
+		Seed:           testCase.Seed,
+		Caller:         testCase.FromAddress,
+		Address:        testCase.ToAddress,
+		Code:           code,
+		Data:           inputData,
+		Amount:         new(big.Int).SetBytes(amount),
+		TokenId:        testCase.TokenID,
+		Quota:          testCase.QuotaTotal,
+		Tracer:         tracer,
+	}
+	db := NewMemoryDatabase(testCase.ToAddress, vm.RunSnapshotBlock(cfg), testForks)
+	for k, v := range testCase.PreStorage {
+		vByte, _ := hex.DecodeString(v)
+		db.SetStorage(k, vByte)
+	}
+	result := vm.RunCode(cfg, db)
+
+	ret, err := result.ReturnData, result.Err
+	returnData, _ := hex.DecodeString(testCase.ReturnData)
+	if (err == nil && testCase.Err != "") || (err != nil && testCase.Err != err.Error()) {
+		return fmt.Errorf("err not match, expected %v, got %v", testCase.Err, err)
+	}
+	if err == nil || err == util.ErrExecutionReverted {
+		if !bytes.Equal(returnData, ret) {
+			return fmt.Errorf("return Data error, expected %v, got %v", returnData, ret)
+		} else if result.QuotaLeft != testCase.QuotaLeft {
+			return fmt.Errorf("quota left error, expected %v, got %v", testCase.QuotaLeft, result.QuotaLeft)
+		} else if checkStorageResult := checkStorage(db, testCase.Storage); checkStorageResult != "" {
+			return fmt.Errorf("storage error, %v", checkStorageResult)
+		} else if len(testCase.LogHash) > 0 {
+			if logHash := result.LogHash; (logHash == nil && len(testCase.LogHash) != 0) || (logHash != nil && logHash.String() != testCase.LogHash) {
+				return fmt.Errorf("log hash error, expected\n%v,\ngot\n%v", testCase.LogHash, logHash)
+			}
+		} else if len(testCase.LogList) > 0 {
+			if checkLogListResult := checkLogList(testCase.LogList, result.Logs); checkLogListResult != "" {
+				return fmt.Errorf("log list error, %v", checkLogListResult)
+			}
+		} else if checkSendBlockListResult := checkSendBlockList(testCase.SendBlockList, result.SendBlocks); checkSendBlockListResult != "" {
+			return fmt.Errorf("send block list error, %v", checkSendBlockListResult)
+		}
+	}
+	return nil
+}
+
+func checkLogList(expected []TestLog, got []*ledger.VmLog) string {
+	if len(expected) != len(got) {
+		return "expected len " + strconv.Itoa(len(expected)) + ", got len" + strconv.Itoa(len(got))
+	}
+	for index, lGot := range got {
+		lexpected := expected[index]
+		if len(lexpected.Topics) != len(lGot.Topics) {
+			return strconv.Itoa(index) + "th log topic len not match, expected " + strconv.Itoa(len(lexpected.Topics)) + ", got " + strconv.Itoa(len(lGot.Topics))
+		}
+		if dataStr := hex.EncodeToString(lGot.Data); dataStr != lexpected.Data {
+			return "expected " + strconv.Itoa(index) + "th log data: " + lexpected.Data + ", got: " + dataStr
+		}
+		for topicIndex, t := range lGot.Topics {
+			if topicStr := t.String(); topicStr != lexpected.Topics[topicIndex] {
+				return "expected " + strconv.Itoa(index) + ":" + strconv.Itoa(topicIndex) + "th topic: " + lexpected.Topics[topicIndex] + ", got: " + topicStr
+			}
+		}
+	}
+	return ""
+}
+
+func checkStorage(got *MemoryDatabase, expected map[string]string) string {
+	count := 0
+	for _, v := range got.storage {
+		if len(v) > 0 {
+			count = count + 1
+		}
+	}
+	if len(expected) != count {
+		return "expected len " + strconv.Itoa(len(expected)) + ", got len" + strconv.Itoa(len(got.storage))
+	}
+	for k, v := range got.storage {
+		if len(v) == 0 {
+			continue
+		}
+		if sv, ok := expected[k]; !ok || sv != hex.EncodeToString(v) {
+			return "expect " + k + ": " + sv + ", got " + k + ": " + hex.EncodeToString(v)
+		}
+	}
+	return ""
+}
+
+func checkSendBlockList(expected []*TestCaseSendBlock, got []*ledger.AccountBlock) string {
+	if len(got) != len(expected) {
+		return "expected len " + strconv.Itoa(len(expected)) + ", got len" + strconv.Itoa(len(got))
+	}
+	for i, expectedSendBlock := range expected {
+		gotSendBlock := got[i]
+		if (expectedSendBlock.BlockType > 0 && gotSendBlock.BlockType != expectedSendBlock.BlockType) || (expectedSendBlock.BlockType == 0 && gotSendBlock.BlockType != ledger.BlockTypeSendCall) {
+			return strconv.Itoa(i) + "th, expected blockType " + strconv.Itoa(int(expectedSendBlock.BlockType)) + ", got blockType " + strconv.Itoa(int(gotSendBlock.BlockType))
+		} else if gotSendBlock.ToAddress != expectedSendBlock.ToAddress {
+			return strconv.Itoa(i) + "th, expected toAddress " + expectedSendBlock.ToAddress.String() + ", got toAddress " + gotSendBlock.ToAddress.String()
+		} else if gotAmount := hex.EncodeToString(gotSendBlock.Amount.Bytes()); gotAmount != expectedSendBlock.Amount {
+			return strconv.Itoa(i) + "th, expected amount " + expectedSendBlock.Amount + ", got amount " + gotAmount
+		} else if gotSendBlock.TokenId != expectedSendBlock.TokenID {
+			return strconv.Itoa(i) + "th, expected tokenId " + expectedSendBlock.TokenID.String() + ", got tokenId " + gotSendBlock.TokenId.String()
+		} else if gotData := hex.EncodeToString(gotSendBlock.Data); gotData != expectedSendBlock.Data {
+			return strconv.Itoa(i) + "th, expected data " + expectedSendBlock.Data + ", got data " + gotData
+		}
+	}
+	return ""
+}
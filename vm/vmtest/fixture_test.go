@@ -0,0 +1,68 @@
+package vmtest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/vm"
+)
+
+func init() {
+	vm.InitVMConfig(false, false, false, false, common.HomeDir())
+}
+
+func TestVmInterpreter(t *testing.T) {
+	testDir := "../test/interpreter_test/"
+	testFiles, ok := ioutil.ReadDir(testDir)
+	if ok != nil {
+		t.Fatalf("read dir failed, %v", ok)
+	}
+	for _, testFile := range testFiles {
+		if testFile.IsDir() {
+			continue
+		}
+		file, ok := os.Open(testDir + testFile.Name())
+		if ok != nil {
+			t.Fatalf("open test file failed, %v", ok)
+		}
+		testCaseMap := new(TestCaseMap)
+		if ok := json.NewDecoder(file).Decode(testCaseMap); ok != nil {
+			t.Fatalf("decode test file failed, %v", ok)
+		}
+
+		for k, testCase := range *testCaseMap {
+			if err := RunTestCase(&testCase, nil); err != nil {
+				t.Fatalf("%v: %v failed, %v", testFile.Name(), k, err)
+			}
+		}
+	}
+}
+
+func TestVM_RunV2(t *testing.T) {
+	testDir := "../test/run_test/"
+	testFiles, ok := ioutil.ReadDir(testDir)
+	if ok != nil {
+		t.Fatalf("read dir failed, %v", ok)
+	}
+	for _, testFile := range testFiles {
+		if testFile.IsDir() {
+			continue
+		}
+		file, ok := os.Open(testDir + testFile.Name())
+		if ok != nil {
+			t.Fatalf("open test file failed, %v", ok)
+		}
+		testCaseMap := new(VMRunTestCaseMap)
+		if ok := json.NewDecoder(file).Decode(testCaseMap); ok != nil {
+			t.Fatalf("decode test file %v failed, %v", testFile.Name(), ok)
+		}
+		for k, testCase := range *testCaseMap {
+			if err := RunVMRunTestCase(&testCase); err != nil {
+				t.Fatalf("%v: %v failed, %v", testFile.Name(), k, err)
+			}
+		}
+	}
+}
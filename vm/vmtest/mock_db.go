@@ -1,9 +1,10 @@
-package vm
+package vmtest
 
 import (
 	"bytes"
 	"encoding/hex"
 	"github.com/vitelabs/go-vite/common/db/xleveldb/errors"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/crypto"
 	"github.com/vitelabs/go-vite/interfaces"
@@ -29,6 +30,7 @@ type mockDB struct {
 	logList                []*ledger.VmLog
 	code                   []byte
 	genesisBlock           *ledger.SnapshotBlock
+	forks                  *fork.Schedule
 }
 
 func NewMockDB(addr *types.Address,
@@ -41,7 +43,8 @@ func NewMockDB(addr *types.Address,
 	contractMetaMap map[types.Address]*ledger.ContractMeta,
 	code []byte,
 	genesisTimestamp int64,
-	snapshotBlockMap map[uint64]*ledger.SnapshotBlock) (*mockDB, error) {
+	snapshotBlockMap map[uint64]*ledger.SnapshotBlock,
+	forks *fork.Schedule) (*mockDB, error) {
 	db := &mockDB{currentAddr: addr,
 		latestSnapshotBlock:    latestSnapshotBlock,
 		prevAccountBlock:       prevAccountBlock,
@@ -53,6 +56,7 @@ func NewMockDB(addr *types.Address,
 		contractMetaMap:        make(map[types.Address]*ledger.ContractMeta),
 		code:                   code,
 		forkSnapshotBlockMap:   snapshotBlockMap,
+		forks:                  forks,
 	}
 	balanceMapCopy := make(map[types.TokenTypeId]*big.Int)
 	for tid, amount := range balanceMap {
@@ -83,6 +87,10 @@ func NewMockDB(addr *types.Address,
 	return db, nil
 }
 
+func (db *mockDB) ForkSchedule() *fork.Schedule {
+	return db.forks
+}
+
 func (db *mockDB) Address() *types.Address {
 	return db.currentAddr
 }
@@ -216,7 +224,6 @@ func (db *mockDB) NewStorageIterator(prefix []byte) (interfaces.StorageIterator,
 	}
 	sort.Sort(mockIteratorSorter(items))
 	return &mockIterator{-1, items}, nil
-	return nil, nil
 }
 
 type mockIteratorItem struct {
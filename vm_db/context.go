@@ -3,6 +3,7 @@ package vm_db
 import (
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 )
@@ -27,6 +28,13 @@ func (vdb *vmDb) LatestSnapshotBlock() (*ledger.SnapshotBlock, error) {
 	return vdb.latestSnapshotBlock, nil
 }
 
+func (vdb *vmDb) ForkSchedule() *fork.Schedule {
+	if vdb.forks == nil {
+		vdb.forks = vdb.chain.ForkSchedule()
+	}
+	return vdb.forks
+}
+
 func (vdb *vmDb) PrevAccountBlockHash() types.Hash {
 	if vdb.prevAccountBlockHash == nil {
 		return types.Hash{}
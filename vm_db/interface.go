@@ -1,6 +1,7 @@
 package vm_db
 
 import (
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/ledger"
@@ -58,6 +59,8 @@ type Chain interface {
 	GetSeedConfirmedSnapshotBlock(addr types.Address, fromHash types.Hash) (*ledger.SnapshotBlock, error)
 
 	GetSeed(limitSb *ledger.SnapshotBlock, fromHash types.Hash) (uint64, error)
+
+	ForkSchedule() *fork.Schedule
 }
 
 type VmDb interface {
@@ -68,6 +71,9 @@ type VmDb interface {
 
 	LatestSnapshotBlock() (*ledger.SnapshotBlock, error)
 
+	// ForkSchedule returns the fork points of the chain
+	ForkSchedule() *fork.Schedule
+
 	PrevAccountBlock() (*ledger.AccountBlock, error)
 
 	GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error)
@@ -4,6 +4,7 @@ import (
 	"github.com/vitelabs/go-vite/common/db/xleveldb/comparer"
 	"github.com/vitelabs/go-vite/common/db/xleveldb/memdb"
 	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/ledger"
@@ -134,8 +135,8 @@ func (unsaved *Unsaved) GetLogList() ledger.VmLogList {
 	return unsaved.logList
 }
 
-func (unsaved *Unsaved) GetLogListHash(snapshotBlockHeight uint64, address types.Address, prevHash types.Hash) *types.Hash {
-	return unsaved.logList.Hash(snapshotBlockHeight, address, prevHash)
+func (unsaved *Unsaved) GetLogListHash(forks *fork.Schedule, snapshotBlockHeight uint64, address types.Address, prevHash types.Hash) *types.Hash {
+	return unsaved.logList.Hash(forks, snapshotBlockHeight, address, prevHash)
 }
 
 func (unsaved *Unsaved) SetContractMeta(addr types.Address, contractMeta *ledger.ContractMeta) {
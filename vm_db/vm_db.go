@@ -2,6 +2,7 @@ package vm_db
 
 import (
 	"errors"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 )
@@ -22,6 +23,8 @@ type vmDb struct {
 	prevAccountBlock     *ledger.AccountBlock // for cache
 
 	callDepth *uint16 // for cache
+
+	forks *fork.Schedule // for cache
 }
 
 func NewVmDb(chain Chain, address *types.Address, latestSnapshotBlockHash *types.Hash, prevAccountBlockHash *types.Hash) (VmDb, error) {
@@ -69,9 +72,10 @@ func NewVmDbByAddr(chain Chain, address *types.Address) VmDb {
 	}
 }
 
-func NewGenesisVmDB(address *types.Address) VmDb {
+func NewGenesisVmDB(forks *fork.Schedule, address *types.Address) VmDb {
 	return &vmDb{
 		address:   address,
 		isGenesis: true,
+		forks:     forks,
 	}
 }
@@ -28,5 +28,5 @@ func (vdb *vmDb) GetLogListHash() *types.Hash {
 		sbHeight = latestSb.Height
 	}
 
-	return vdb.unsaved().GetLogListHash(sbHeight, *vdb.Address(), vdb.PrevAccountBlockHash())
+	return vdb.unsaved().GetLogListHash(vdb.ForkSchedule(), sbHeight, *vdb.Address(), vdb.PrevAccountBlockHash())
 }